@@ -0,0 +1,46 @@
+package main
+
+import (
+	"ltools/internal/crash"
+	"ltools/internal/plugins"
+	"ltools/internal/update"
+	"ltools/plugins/sysinfo"
+)
+
+// crashPlugin 崩溃包中的插件信息
+type crashPlugin struct {
+	ID      string              `json:"id"`
+	Version string              `json:"version"`
+	State   plugins.PluginState `json:"state"`
+}
+
+// registerCrashSections 添加崩溃包中的版本、系统和插件信息
+func registerCrashSections(
+	reporter *crash.Reporter,
+	updateService *update.Service,
+	sysInfoPlugin *sysinfo.SysInfoPlugin,
+	pluginManager *plugins.Manager,
+) {
+	reporter.AddSection("version", func() (any, error) {
+		return map[string]string{"version": updateService.GetCurrentVersion()}, nil
+	})
+
+	// 系统信息：不包含主机名和主机 ID，崩溃包可能被附加到公开的 issue
+	reporter.AddSection("system", func() (any, error) {
+		host := sysInfoPlugin.GetHostInfo()
+		delete(host, "hostname")
+		delete(host, "hostId")
+		return map[string]any{
+			"host":   host,
+			"memory": sysInfoPlugin.GetMemoryInfo(),
+		}, nil
+	})
+
+	reporter.AddSection("plugins", func() (any, error) {
+		var list []crashPlugin
+		for _, meta := range pluginManager.ListMetadata() {
+			list = append(list, crashPlugin{ID: meta.ID, Version: meta.Version, State: meta.State})
+		}
+		return list, nil
+	})
+}
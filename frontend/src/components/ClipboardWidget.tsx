@@ -1,7 +1,7 @@
-import { useState, useEffect } from 'react';
+import { useState, useEffect, useRef } from 'react';
 import { Events } from '@wailsio/runtime';
 import { ClipboardService } from '../../bindings/ltools/plugins/clipboard';
-import { ClipboardItem } from '../../bindings/ltools/plugins/clipboard/models';
+import { Item, Query, Retention } from '../../bindings/ltools/plugins/clipboard/history/models';
 import { Icon } from './Icon';
 import { useToast } from '../hooks/useToast';
 
@@ -47,27 +47,44 @@ function ImagePreviewModal({ imageSrc, onClose }: ImagePreviewModalProps): JSX.E
  * 剪贴板历史项组件
  */
 interface ClipboardItemProps {
-  item: ClipboardItem;
-  index: number;
+  item: Item;
   onCopy: (content: string) => void;
   onCopyImage: (base64Data: string) => void;
   onSaveImage: (base64Data: string) => void;
-  onDelete: (index: number) => void;
+  onDelete: (id: number) => void;
   onPreviewImage: (src: string) => void;
 }
 
-function ClipboardHistoryItem({ item, index, onCopy, onCopyImage, onSaveImage, onDelete, onPreviewImage }: ClipboardItemProps): JSX.Element {
+const PAGE_SIZE = 50;
+
+const formatSize = (bytes: number) => {
+  if (bytes < 1024) return `${bytes} B`;
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${(bytes / 1024 / 1024).toFixed(1)} MB`;
+};
+
+function ClipboardHistoryItem({ item, onCopy, onCopyImage, onSaveImage, onDelete, onPreviewImage }: ClipboardItemProps): JSX.Element {
   const [copied, setCopied] = useState(false);
   const [imageCopied, setImageCopied] = useState(false);
   const [isHovered, setIsHovered] = useState(false);
   const { success, error: showError } = useToast();
 
+  // 列表只带缩略图，完整图片按需读取
+  const loadImage = async (): Promise<string> => {
+    const full = await ClipboardService.GetItem(item.id);
+    if (!full?.content) {
+      throw new Error('image not found');
+    }
+    return full.content;
+  };
+
   const handleCopy = async () => {
     if (item.type === 'image') {
       try {
-        await ClipboardService.CopyImageToClipboard(item.content);
+        const content = await loadImage();
+        await ClipboardService.CopyImageToClipboard(content);
         setImageCopied(true);
-        onCopyImage(item.content);
+        onCopyImage(content);
         success('图片已复制到剪贴板');
         setTimeout(() => setImageCopied(false), 2000);
       } catch (err) {
@@ -92,9 +109,10 @@ function ClipboardHistoryItem({ item, index, onCopy, onCopyImage, onSaveImage, o
     try {
       const timestamp = new Date().getTime();
       const defaultFilename = `clipboard_image_${timestamp}.png`;
-      const filePath = await ClipboardService.SaveImageToFile(item.content, defaultFilename);
+      const content = await loadImage();
+      const filePath = await ClipboardService.SaveImageToFile(content, defaultFilename);
       success(`图片已保存到: ${filePath}`);
-      onSaveImage(item.content);
+      onSaveImage(content);
     } catch (err) {
       console.error('Failed to save image:', err);
       // User cancelled or error - don't show error for cancellation
@@ -105,7 +123,16 @@ function ClipboardHistoryItem({ item, index, onCopy, onCopyImage, onSaveImage, o
   };
 
   const handleDelete = () => {
-    onDelete(index);
+    onDelete(item.id);
+  };
+
+  const handlePreview = async () => {
+    try {
+      onPreviewImage(await loadImage());
+    } catch (err) {
+      console.error('Failed to load image:', err);
+      showError('读取图片失败');
+    }
   };
 
   const formatTime = (timestamp: any) => {
@@ -151,10 +178,10 @@ function ClipboardHistoryItem({ item, index, onCopy, onCopyImage, onSaveImage, o
         <div className="flex items-start gap-3">
           <div
             className="flex-shrink-0 cursor-pointer hover:opacity-80 transition-opacity"
-            onClick={() => onPreviewImage(item.content)}
+            onClick={handlePreview}
           >
             <img
-              src={item.content}
+              src={item.thumbnail}
               alt="剪贴板图片"
               className="max-h-24 max-w-32 rounded-lg object-contain bg-white/5"
             />
@@ -162,7 +189,7 @@ function ClipboardHistoryItem({ item, index, onCopy, onCopyImage, onSaveImage, o
           <div className="flex-1 min-w-0">
             <p className="text-xs text-white/40 mb-1">图片</p>
             <p className="text-sm text-white/60 font-mono">
-              {truncateContent(item.content.substring(0, 50))}...
+              {item.mime} · {formatSize(item.size)}
             </p>
           </div>
         </div>
@@ -197,6 +224,7 @@ function ClipboardHistoryItem({ item, index, onCopy, onCopyImage, onSaveImage, o
             </div>
             <span className="text-xs text-white/30 whitespace-nowrap">
               {formatTime(item.timestamp)}
+              {item.copyCount > 1 && ` · ${item.copyCount} 次`}
             </span>
           </div>
 
@@ -255,27 +283,32 @@ function EmptyState(): JSX.Element {
  */
 export function ClipboardWidget(): JSX.Element {
   const { success, error: showError } = useToast();
-  const [history, setHistory] = useState<ClipboardItem[]>([]);
-  const [filteredHistory, setFilteredHistory] = useState<ClipboardItem[]>([]);
+  const [items, setItems] = useState<Item[]>([]);
+  const [matchCount, setMatchCount] = useState(0);
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [searchQuery, setSearchQuery] = useState('');
-  const [maxHistory, setMaxHistory] = useState(100);
+  const [typeFilter, setTypeFilter] = useState('');
+  const [retention, setRetention] = useState<Retention | null>(null);
   const [totalCount, setTotalCount] = useState(0);
   const [addingClipboard, setAddingClipboard] = useState(false);
   const [previewImage, setPreviewImage] = useState<string | null>(null);
 
-  // 加载剪贴板历史
-  const loadHistory = async () => {
+  const fetchPage = (offset: number, search: string, type: string) =>
+    ClipboardService.GetHistoryPage(new Query({ search, type, offset, limit: PAGE_SIZE }));
+
+  // 加载剪贴板历史的第一页，更多记录由 loadMore 追加
+  const loadHistory = async (search = searchQuery, type = typeFilter) => {
     try {
-      setLoading(true);
-      const [historyData, maxData] = await Promise.all([
-        ClipboardService.GetHistory(),
-        ClipboardService.GetMaxHistory()
+      const [page, count, retentionData] = await Promise.all([
+        fetchPage(0, search, type),
+        ClipboardService.GetHistoryCount(),
+        ClipboardService.GetRetention()
       ]);
-      setHistory(historyData);
-      setFilteredHistory(historyData);
-      setMaxHistory(maxData);
-      setTotalCount(historyData.length);
+      setItems(page.items);
+      setMatchCount(page.total);
+      setTotalCount(count);
+      setRetention(retentionData);
     } catch (err) {
       console.error('Failed to load clipboard history:', err);
     } finally {
@@ -283,24 +316,33 @@ export function ClipboardWidget(): JSX.Element {
     }
   };
 
-  // 初始化时加载历史
-  useEffect(() => {
-    loadHistory();
-  }, []);
+  const loadMore = async () => {
+    try {
+      setLoadingMore(true);
+      const page = await fetchPage(items.length, searchQuery, typeFilter);
+      setItems(prev => [...prev, ...page.items]);
+      setMatchCount(page.total);
+    } catch (err) {
+      console.error('Failed to load more clipboard history:', err);
+    } finally {
+      setLoadingMore(false);
+    }
+  };
+
+  // 监听剪贴板事件；处理函数读取最新的搜索条件
+  const reloadRef = useRef(loadHistory);
+  reloadRef.current = loadHistory;
 
-  // 监听剪贴板事件
   useEffect(() => {
-    const unsubNew = Events.On('clipboard:new', () => {
-      loadHistory();
-    });
+    const reload = () => reloadRef.current();
 
-    const unsubImageNew = Events.On('clipboard:image:new', () => {
-      loadHistory();
-    });
+    const unsubNew = Events.On('clipboard:new', reload);
+
+    const unsubImageNew = Events.On('clipboard:image:new', reload);
 
     const unsubCleared = Events.On('clipboard:cleared', () => {
-      setHistory([]);
-      setFilteredHistory([]);
+      setItems([]);
+      setMatchCount(0);
       setTotalCount(0);
     });
 
@@ -309,13 +351,9 @@ export function ClipboardWidget(): JSX.Element {
       setTotalCount(count);
     });
 
-    const unsubDeleted = Events.On('clipboard:deleted', () => {
-      loadHistory();
-    });
+    const unsubDeleted = Events.On('clipboard:deleted', reload);
 
-    const unsubImageSaved = Events.On('clipboard:image:saved', () => {
-      loadHistory();
-    });
+    const unsubImageSaved = Events.On('clipboard:image:saved', reload);
 
     return () => {
       unsubNew?.();
@@ -327,23 +365,11 @@ export function ClipboardWidget(): JSX.Element {
     };
   }, []);
 
-  // 搜索功能
+  // 首次加载历史；之后输入时自动搜索（全文索引，在后端完成）
   useEffect(() => {
-    if (!searchQuery.trim()) {
-      setFilteredHistory(history);
-    } else {
-      const query = searchQuery.toLowerCase();
-      const filtered = history.filter(item => {
-        // For text items, search in content
-        if (item.type !== 'image') {
-          return item.content.toLowerCase().includes(query);
-        }
-        // For images, only match if searching for "image" or "图片"
-        return query === 'image' || query === '图片' || query === 'img' || query === '图';
-      });
-      setFilteredHistory(filtered);
-    }
-  }, [searchQuery, history]);
+    const timer = setTimeout(() => loadHistory(searchQuery, typeFilter), loading ? 0 : 200);
+    return () => clearTimeout(timer);
+  }, [searchQuery, typeFilter]);
 
   // 复制处理
   const handleCopy = (content: string) => {
@@ -351,9 +377,9 @@ export function ClipboardWidget(): JSX.Element {
   };
 
   // 删除处理
-  const handleDelete = async (index: number) => {
+  const handleDelete = async (id: number) => {
     try {
-      await ClipboardService.DeleteItem(index);
+      await ClipboardService.DeleteItem(id);
       await loadHistory();
     } catch (err) {
       console.error('Failed to delete item:', err);
@@ -364,8 +390,8 @@ export function ClipboardWidget(): JSX.Element {
   const handleClear = async () => {
     try {
       await ClipboardService.ClearHistory();
-      setHistory([]);
-      setFilteredHistory([]);
+      setItems([]);
+      setMatchCount(0);
       setTotalCount(0);
     } catch (err) {
       console.error('Failed to clear history:', err);
@@ -373,18 +399,8 @@ export function ClipboardWidget(): JSX.Element {
   };
 
   // 搜索处理
-  const handleSearch = async () => {
-    if (!searchQuery.trim()) {
-      setFilteredHistory(history);
-      return;
-    }
-
-    try {
-      const results = await ClipboardService.SearchHistory(searchQuery);
-      setFilteredHistory(results);
-    } catch (err) {
-      console.error('Failed to search history:', err);
-    }
+  const handleSearch = () => {
+    loadHistory();
   };
 
   // 添加当前剪贴板内容
@@ -432,7 +448,10 @@ export function ClipboardWidget(): JSX.Element {
         <div>
           <h2 className="text-xl font-semibold text-white">剪贴板历史</h2>
           <p className="text-sm text-white/40">
-            {totalCount} 条记录 · 最大 {maxHistory} 条
+            {totalCount} 条记录
+            {retention && retention.maxItems > 0 && ` · 最多 ${retention.maxItems} 条`}
+            {retention && retention.maxAgeDays > 0 && ` · 保留 ${retention.maxAgeDays} 天`}
+            {retention && retention.maxBytes > 0 && ` · 上限 ${formatSize(retention.maxBytes)}`}
           </p>
         </div>
         <div className="flex items-center gap-3">
@@ -474,14 +493,20 @@ export function ClipboardWidget(): JSX.Element {
           {searchQuery && (
             <button
               className="p-2 rounded-lg text-white/40 hover:text-white/80 hover:bg-white/5 transition-all duration-200 clickable"
-              onClick={() => {
-                setSearchQuery('');
-                setFilteredHistory(history);
-              }}
+              onClick={() => setSearchQuery('')}
             >
               <Icon name="x-mark" size={18} />
             </button>
           )}
+          <select
+            className="bg-white/5 text-white/80 text-sm rounded-lg px-3 border border-white/10 focus:outline-none"
+            value={typeFilter}
+            onChange={(e) => setTypeFilter(e.target.value)}
+          >
+            <option value="">全部</option>
+            <option value="text">文本</option>
+            <option value="image">图片</option>
+          </select>
           <button
             className="px-5 py-2 rounded-lg bg-[#7C3AED] text-white hover:bg-[#6D28D9] transition-all duration-200 text-sm font-medium clickable"
             onClick={handleSearch}
@@ -492,15 +517,14 @@ export function ClipboardWidget(): JSX.Element {
       </div>
 
       {/* 历史列表 */}
-      {filteredHistory.length === 0 ? (
+      {items.length === 0 ? (
         <EmptyState />
       ) : (
         <div className="space-y-3">
-          {filteredHistory.map((item, index) => (
+          {items.map((item) => (
             <ClipboardHistoryItem
-              key={`${item.timestamp}-${index}`}
+              key={item.id}
               item={item}
-              index={index}
               onCopy={handleCopy}
               onCopyImage={() => {}}
               onSaveImage={() => {}}
@@ -511,11 +535,24 @@ export function ClipboardWidget(): JSX.Element {
         </div>
       )}
 
+      {/* 分页 */}
+      {items.length < matchCount && (
+        <div className="text-center">
+          <button
+            className="px-4 py-2 rounded-lg bg-white/5 text-white/60 hover:bg-white/10 border border-white/10 transition-all duration-200 text-sm clickable disabled:opacity-50"
+            onClick={loadMore}
+            disabled={loadingMore}
+          >
+            {loadingMore ? '加载中...' : `加载更多（${items.length} / ${matchCount}）`}
+          </button>
+        </div>
+      )}
+
       {/* 统计信息 */}
-      {searchQuery && filteredHistory.length > 0 && (
+      {(searchQuery || typeFilter) && matchCount > 0 && (
         <div className="text-center">
           <p className="text-sm text-white/40">
-            找到 {filteredHistory.length} 条匹配结果
+            找到 {matchCount} 条匹配结果
           </p>
         </div>
       )}
@@ -1,31 +1,48 @@
 import { useEffect, useState } from 'react';
 import { Events } from '@wailsio/runtime';
-import { DateTimeService } from '../../bindings/ltools/plugins/datetime';
+import { DateTimeService, TimeToolkitService } from '../../bindings/ltools/plugins/datetime';
 import { Icon } from './Icon';
 
+/**
+ * 世界时钟中一个时区的当前时间（datetime:clocks 事件）
+ */
+interface ZoneTime {
+  zone: string;
+  label: string;
+  dateTime: string;
+  weekday: string;
+  abbrev: string;
+  offset: string;
+  dst: boolean;
+  dayDiff: number;
+}
+
 /**
  * 日期时间小部件组件
- * 显示当前日期和时间
+ * 显示当前日期和时间，以及用户配置的世界时钟
  */
 export function DateTimeWidget(): JSX.Element {
   const [currentTime, setCurrentTime] = useState<string>('');
   const [currentDate, setCurrentDate] = useState<string>('');
   const [weekday, setWeekday] = useState<string>('');
   const [isWeekend, setIsWeekend] = useState<boolean>(false);
+  const [clocks, setClocks] = useState<ZoneTime[]>([]);
 
   // 初始化和监听实时更新事件
   useEffect(() => {
     // 初始化时获取当前时间
     const initializeDateTime = async () => {
       try {
-        const [time, date, day] = await Promise.all([
+        const [time, date, day, zones] = await Promise.all([
           DateTimeService.GetCurrentTime(),
           DateTimeService.GetCurrentDate(),
           DateTimeService.GetWeekday(),
+          DateTimeService.GetWorldTimes(),
         ]);
         setCurrentTime(time || '');
         setCurrentDate(date || '');
         setWeekday(day || '');
+        setClocks((zones as ZoneTime[]) || []);
 
         // 检查是否是周末
         const weekendDays = ['星期六', '星期日', 'Saturday', 'Sunday', '周六', '周日'];
@@ -53,10 +70,16 @@ export function DateTimeWidget(): JSX.Element {
       setIsWeekend(weekendDays.some(wd => ev.data?.includes(wd)));
     });
 
+    // 监听世界时钟更新
+    const unsubscribeClocks = Events.On('datetime:clocks', (ev: { data: ZoneTime[] }) => {
+      setClocks(ev.data || []);
+    });
+
     return () => {
       unsubscribeTime?.();
       unsubscribeDate?.();
       unsubscribeWeekday?.();
+      unsubscribeClocks?.();
     };
   }, []);
 
@@ -87,6 +110,27 @@ export function DateTimeWidget(): JSX.Element {
           </span>
         )}
       </div>
+
+      {/* 世界时钟 */}
+      {clocks.length > 0 && (
+        <div className="grid grid-cols-2 gap-3 mt-6 text-left">
+          {clocks.map((clock, index) => (
+            <div key={`${clock.zone}-${index}`} className="rounded-xl bg-white/5 border border-white/10 px-4 py-3">
+              <div className="text-sm text-white/60 truncate" title={clock.zone}>
+                {clock.label}
+              </div>
+              <div className="text-2xl font-semibold text-white/90 tabular-nums">
+                {clock.dateTime.slice(11, 16)}
+              </div>
+              <div className="text-xs text-white/40">
+                {clock.weekday} · {clock.abbrev} {clock.offset}
+                {clock.dayDiff > 0 && ' · 次日'}
+                {clock.dayDiff < 0 && ' · 前一日'}
+              </div>
+            </div>
+          ))}
+        </div>
+      )}
     </div>
   );
 }
@@ -142,6 +186,42 @@ export function ClockWidget(): JSX.Element {
 
 export default DateTimeWidget;
 
+/**
+ * 时间戳解码结果（epoch.Decoded）
+ */
+interface DecodedTimestamp {
+  format: string;
+  time: string;
+  detail?: string;
+}
+
+/**
+ * 某种格式的时间戳（epoch.Value）
+ */
+interface TimestampValue {
+  format: string;
+  value: string;
+}
+
+const timestampFormatLabels: Record<string, string> = {
+  unix: 'Unix 秒',
+  unix_ms: 'Unix 毫秒',
+  unix_us: 'Unix 微秒',
+  unix_ns: 'Unix 纳秒',
+  snowflake: 'Snowflake',
+  ulid: 'ULID',
+  uuid_v1: 'UUID v1',
+  uuid_v6: 'UUID v6',
+  uuid_v7: 'UUID v7',
+  filetime: 'FILETIME',
+  excel: 'Excel',
+};
+
+// Snowflake 默认使用 Twitter 纪元，Excel 使用 1900 日期系统
+const timestampOptions = { snowflakeEpoch: 0, excel1904: false };
+
+const errorMessage = (err: unknown): string => (err instanceof Error ? err.message : String(err));
+
 /**
  * 时间戳转换工具组件
  */
@@ -162,99 +242,65 @@ export function TimestampConverter(): JSX.Element {
     return () => clearInterval(timer);
   }, []);
 
-  // 时间戳转日期时间
-  const timestampToDatetime = (ts: string): string => {
-    const timestamp = parseInt(ts, 10);
-    if (isNaN(timestamp)) return '无效的时间戳';
-
-    // 判断是秒还是毫秒
-    const date = timestamp > 9999999999 ? new Date(timestamp) : new Date(timestamp * 1000);
-
-    if (isNaN(date.getTime())) return '无效的日期';
-
-    // 格式化输出多种格式
-    const formats = [
-      date.toLocaleString('zh-CN', { year: 'numeric', month: '2-digit', day: '2-digit', hour: '2-digit', minute: '2-digit', second: '2-digit', hour12: false }),
-      date.toISOString(),
-      date.toLocaleString('en-US', { year: 'numeric', month: 'short', day: 'numeric', hour: '2-digit', minute: '2-digit', second: '2-digit', hour12: true }),
-    ];
-
-    return formats.join('\n');
+  // 时间戳转日期时间：后端识别 Unix 时间戳、Snowflake、ULID、UUID、FILETIME 和 Excel 序列日期
+  const timestampToDatetime = async (ts: string): Promise<string> => {
+    if (!ts.trim()) return '请输入时间戳';
+    try {
+      const results = (await TimeToolkitService.DecodeTimestamp(ts, '', '', timestampOptions)) as DecodedTimestamp[];
+      return (results || [])
+        .map((d) => {
+          const date = new Date(d.time);
+          const text = date.toLocaleString('zh-CN', { year: 'numeric', month: '2-digit', day: '2-digit', hour: '2-digit', minute: '2-digit', second: '2-digit', hour12: false });
+          return `${timestampFormatLabels[d.format] || d.format}: ${text} (${date.toISOString()})${d.detail ? ` · ${d.detail}` : ''}`;
+        })
+        .join('\n');
+    } catch (err) {
+      return errorMessage(err);
+    }
   };
 
-  // 日期时间转时间戳
-  const datetimeToTimestamp = (dt: string): string => {
+  // 日期时间转各种时间戳
+  const datetimeToTimestamp = async (dt: string): Promise<string> => {
     if (!dt.trim()) return '请输入日期时间';
-
-    // 尝试多种日期格式解析
-    let date: Date;
-
-    // 尝试直接解析
-    date = new Date(dt);
-    if (!isNaN(date.getTime())) {
-      return `秒级: ${Math.floor(date.getTime() / 1000)}\n毫秒级: ${date.getTime()}`;
-    }
-
-    // 尝试常见格式
-    const formats = [
-      /^(\d{4})-(\d{2})-(\d{2}) (\d{2}):(\d{2}):(\d{2})$/,
-      /^(\d{4})\/(\d{2})\/(\d{2}) (\d{2}):(\d{2}):(\d{2})$/,
-      /^(\d{4})-(\d{2})-(\d{2})$/,
-      /^(\d{4})\/(\d{2})\/(\d{2})$/,
-    ];
-
-    for (const fmt of formats) {
-      const match = dt.match(fmt);
-      if (match) {
-        const [, year, month, day, hour = '0', minute = '0', second = '0'] = match;
-        date = new Date(
-          parseInt(year),
-          parseInt(month) - 1,
-          parseInt(day),
-          parseInt(hour),
-          parseInt(minute),
-          parseInt(second)
-        );
-        if (!isNaN(date.getTime())) {
-          return `秒级: ${Math.floor(date.getTime() / 1000)}\n毫秒级: ${date.getTime()}`;
-        }
-      }
+    try {
+      const values = (await TimeToolkitService.EncodeTimestamp(dt, '', timestampOptions)) as TimestampValue[];
+      return (values || []).map((v) => `${timestampFormatLabels[v.format] || v.format}: ${v.value}`).join('\n');
+    } catch (err) {
+      return errorMessage(err);
     }
-
-    return '无法解析日期格式，请使用格式如：2024-01-01 12:00:00';
   };
 
   // 处理转换
-  const handleConvert = () => {
+  const handleConvert = async () => {
     if (mode === 'toDatetime') {
-      setResult(timestampToDatetime(timestamp));
+      setResult(await timestampToDatetime(timestamp));
     } else {
-      setResult(datetimeToTimestamp(datetime));
+      setResult(await datetimeToTimestamp(datetime));
     }
   };
 
   // 使用当前时间戳
-  const useCurrentTimestamp = () => {
+  const useCurrentTimestamp = async () => {
     setTimestamp(currentTime);
     if (mode === 'toDatetime') {
-      setResult(timestampToDatetime(currentTime));
+      setResult(await timestampToDatetime(currentTime));
     }
   };
 
   // 使用当前日期时间
-  const useCurrentDatetime = () => {
-    const now = new Date();
-    const formatted = now.toISOString().slice(0, 19).replace('T', ' ');
-    setDatetime(formatted);
+  const useCurrentDatetime = async () => {
+    setDatetime('now');
     if (mode === 'toTimestamp') {
-      setResult(datetimeToTimestamp(formatted));
+      setResult(await datetimeToTimestamp('now'));
     }
   };
 
   // 复制结果
   const copyResult = async () => {
     try {
-      await navigator.clipboard.writeText(result.split('\n')[0]);
+      // 复制第一行的值，去掉格式名
+      const first = result.split('\n')[0];
+      await navigator.clipboard.writeText(first.slice(first.indexOf(': ') + 2));
     } catch (err) {
       console.error('Failed to copy:', err);
     }
@@ -306,7 +352,7 @@ export function TimestampConverter(): JSX.Element {
               <input
                 type="text"
                 className="flex-1 px-4 py-3 bg-[#0D0F1A]/50 border border-white/10 rounded-lg text-white placeholder-white/30 focus:outline-none focus:ring-2 focus:ring-[#7C3AED]/50 focus:border-[#7C3AED]/50 transition-all duration-200 font-mono"
-                placeholder="例如: 1704067200 或 1704067200000"
+                placeholder="例如: 1704067200、Snowflake ID、ULID 或 UUID"
                 value={timestamp}
                 onChange={(e) => setTimestamp(e.target.value)}
                 onKeyPress={(e) => {
@@ -359,7 +405,7 @@ export function TimestampConverter(): JSX.Element {
               <input
                 type="text"
                 className="flex-1 px-4 py-3 bg-[#0D0F1A]/50 border border-white/10 rounded-lg text-white placeholder-white/30 focus:outline-none focus:ring-2 focus:ring-[#7C3AED]/50 focus:border-[#7C3AED]/50 transition-all duration-200 font-mono"
-                placeholder="例如: 2024-01-01 12:00:00 或 2024/01/01"
+                placeholder="例如: 2024-01-01 12:00:00、明天 9am 或 now"
                 value={datetime}
                 onChange={(e) => setDatetime(e.target.value)}
                 onKeyPress={(e) => {
@@ -381,7 +427,7 @@ export function TimestampConverter(): JSX.Element {
               </button>
             </div>
             <p className="text-xs text-white/30 mt-2">
-              支持格式: YYYY-MM-DD HH:MM:SS, YYYY/MM/DD 等
+              转换为 Unix 时间戳、Snowflake、ULID、UUID、FILETIME 和 Excel 序列日期
             </p>
           </div>
 
@@ -416,11 +462,11 @@ export function TimestampConverter(): JSX.Element {
             <button
               key={item.label}
               className="px-3 py-1.5 rounded-lg bg-white/5 hover:bg-white/10 text-white/60 hover:text-white/80 transition-all duration-200 text-xs clickable border border-white/10"
-              onClick={() => {
+              onClick={async () => {
                 const ts = Math.floor((Date.now() / 1000) + item.seconds);
                 setTimestamp(ts.toString());
-                setResult(timestampToDatetime(ts.toString()));
                 setMode('toDatetime');
+                setResult(await timestampToDatetime(ts.toString()));
               }}
             >
               {item.label}
@@ -431,3 +477,103 @@ export function TimestampConverter(): JSX.Element {
     </div>
   );
 }
+
+/**
+ * cron 表达式的说明（cron.Explanation）
+ */
+interface CronExplanation {
+  dialect: string;
+  description: string;
+  fields?: { name: string; value: string; meaning: string }[];
+  zone?: string;
+}
+
+/**
+ * cron 表达式工具组件
+ * 校验并解释 5/6 字段、Quartz 和 @every 表达式，列出接下来的执行时间
+ */
+export function CronExplainer(): JSX.Element {
+  const [spec, setSpec] = useState<string>('0 9 * * mon-fri');
+  const [explanation, setExplanation] = useState<CronExplanation | null>(null);
+  const [times, setTimes] = useState<ZoneTime[]>([]);
+  const [error, setError] = useState<string>('');
+
+  const handleExplain = async () => {
+    try {
+      const [exp, next] = await Promise.all([
+        TimeToolkitService.ExplainCron(spec, ''),
+        TimeToolkitService.NextCronTimes(spec, '', '', '', 5),
+      ]);
+      setExplanation(exp as CronExplanation);
+      setTimes((next as ZoneTime[]) || []);
+      setError('');
+    } catch (err) {
+      setExplanation(null);
+      setTimes([]);
+      setError(errorMessage(err));
+    }
+  };
+
+  return (
+    <div className="glass-light rounded-xl p-6">
+      <h3 className="text-lg font-semibold text-white mb-4 flex items-center gap-2">
+        <Icon name="clock" size={18} color="#A78BFA" />
+        Cron 表达式
+      </h3>
+
+      <div className="flex gap-2">
+        <input
+          type="text"
+          className="flex-1 px-4 py-3 bg-[#0D0F1A]/50 border border-white/10 rounded-lg text-white placeholder-white/30 focus:outline-none focus:ring-2 focus:ring-[#7C3AED]/50 focus:border-[#7C3AED]/50 transition-all duration-200 font-mono"
+          placeholder="例如: */15 * * * *、0 15 10 ? * 6#3 或 @every 1h"
+          value={spec}
+          onChange={(e) => setSpec(e.target.value)}
+          onKeyPress={(e) => {
+            if (e.key === 'Enter') handleExplain();
+          }}
+        />
+        <button
+          className="px-4 py-3 rounded-lg bg-[#7C3AED] hover:bg-[#6D28D9] text-white transition-all duration-200 text-sm font-medium clickable"
+          onClick={handleExplain}
+        >
+          解释
+        </button>
+      </div>
+
+      {error && <p className="text-sm text-red-400 mt-3">{error}</p>}
+
+      {explanation && (
+        <div className="glass-heavy rounded-lg p-4 mt-4 space-y-3">
+          <div className="text-white">
+            {explanation.description}
+            <span className="text-xs text-white/40 ml-2">
+              {explanation.dialect === 'quartz' ? 'Quartz' : 'Unix'}
+              {explanation.zone && ` · ${explanation.zone}`}
+            </span>
+          </div>
+          {explanation.fields && explanation.fields.length > 0 && (
+            <div className="grid grid-cols-3 gap-x-4 gap-y-1 text-xs">
+              {explanation.fields.map((field) => (
+                <div key={field.name} className="contents">
+                  <span className="text-white/40">{field.name}</span>
+                  <span className="font-mono text-[#A78BFA]">{field.value}</span>
+                  <span className="text-white/60">{field.meaning}</span>
+                </div>
+              ))}
+            </div>
+          )}
+          {times.length > 0 && (
+            <div className="pt-3 border-t border-white/10">
+              <p className="text-xs text-white/40 mb-1">接下来的执行时间</p>
+              {times.map((t) => (
+                <div key={t.dateTime} className="text-sm text-white/80 font-mono">
+                  {t.dateTime} {t.weekday} {t.abbrev}
+                </div>
+              ))}
+            </div>
+          )}
+        </div>
+      )}
+    </div>
+  );
+}
@@ -43,6 +43,12 @@ export function GeneralSettings() {
 
     loadLaunchAtLogin();
 
+    SettingsService.GetGeneral()
+      .then((general) => {
+        if (alive && general) setShowInMenu(general.showInMenu);
+      })
+      .catch((err) => console.error('[GeneralSettings] Failed to load general settings:', err));
+
     return () => {
       alive = false;
     };
@@ -72,6 +78,18 @@ export function GeneralSettings() {
     }
   };
 
+  const handleShowInMenuChange = async (checked: boolean) => {
+    const previous = showInMenu;
+    setShowInMenu(checked);
+    try {
+      await SettingsService.SetShowInMenu(checked);
+    } catch (err: any) {
+      console.error('[GeneralSettings] Failed to set show-in-menu:', err);
+      setShowInMenu(previous);
+      showError(err?.message || '菜单栏设置失败');
+    }
+  };
+
   return (
     <div className="space-y-8">
       {/* 页面标题 */}
@@ -158,7 +176,7 @@ export function GeneralSettings() {
             <input
               type="checkbox"
               checked={showInMenu}
-              onChange={(e) => setShowInMenu(e.target.checked)}
+              onChange={(e) => handleShowInMenuChange(e.target.checked)}
               className="sr-only peer"
             />
             <div className="w-11 h-6 bg-white/10 peer-focus:outline-none rounded-full peer peer-checked:after:translate-x-full peer-checked:after:border-white after:content-[''] after:absolute after:top-[2px] after:left-[2px] after:bg-white after:rounded-full after:h-5 after:w-5 after:transition-all peer-checked:bg-[#7C3AED]"></div>
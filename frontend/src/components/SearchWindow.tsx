@@ -2,7 +2,6 @@ import { useState, useEffect, useCallback, useRef } from 'react';
 import { Icon } from './Icon';
 import { Events } from '@wailsio/runtime';
 import * as SearchWindowService from '../../bindings/ltools/internal/plugins/searchwindowservice';
-import * as AppLauncherService from '../../bindings/ltools/plugins/applauncher/applauncherservice';
 import { usePlugins } from '../plugins/usePlugins';
 import { getPluginIcon, getPluginIconName } from '../utils/pluginHelpers';
 import { PluginState, PluginMetadata } from '../../bindings/ltools/internal/plugins';
@@ -63,9 +62,10 @@ interface SearchResult {
   description: string;
   icon: string;
   matchedFields?: string[];
-  type: string; // "plugin", "app", or "file"
+  type: string; // "plugin", "app", "file", "calculation" or "command"
   path?: string;          // 文件/目录路径
   isDirectory?: boolean;  // 是否为目录
+  command?: string;       // 命令（如 "timer 25m"）
 }
 
 /**
@@ -208,17 +208,13 @@ export function SearchWindow() {
     try {
       console.log('[SearchWindow] Searching for:', searchQuery);
 
-      // 同时调用插件搜索和应用搜索
-      const [pluginResults, appResults] = await Promise.all([
-        SearchWindowService.Search(searchQuery),
-        AppLauncherService.Search(searchQuery).catch(() => [])
-      ]);
+      // 插件、应用等结果都由 SearchWindowService 返回（应用结果可在设置中关闭）
+      const items = await SearchWindowService.Search(searchQuery);
 
-      console.log('[SearchWindow] Plugin results:', pluginResults);
-      console.log('[SearchWindow] App results:', appResults);
+      console.log('[SearchWindow] Results:', items);
 
-      // 转换插件结果格式，并解码 Unicode 转义字符
-      const searchResults: SearchResult[] = pluginResults.map((item: any) => ({
+      // 转换结果格式，并解码 Unicode 转义字符
+      const allResults: SearchResult[] = items.map((item: any) => ({
         pluginId: item.pluginId,
         appId: item.appId,
         name: decodeUnicode(item.name || ''),
@@ -230,23 +226,6 @@ export function SearchWindow() {
         isDirectory: item.isDirectory,  // 是否为目录
       }));
 
-      // 转换应用结果格式，并解码 Unicode 转义字符
-      const appResultsFormatted: SearchResult[] = appResults.map((item: any) => {
-        // 使用 iconData，如果没有则使用默认图标
-        const icon = item.iconData || '🚀';
-        console.log('[SearchWindow] App icon for', item.name, ':', icon.substring(0, 50) + '...');
-        return {
-          appId: item.id,
-          name: decodeUnicode(item.name || ''),
-          description: decodeUnicode(item.description || ''),
-          icon: icon,
-          type: 'app',
-        };
-      });
-
-      // 合并结果
-      const allResults = [...searchResults, ...appResultsFormatted];
-
       console.log('[SearchWindow] Total results:', allResults.length);
       setResults(allResults);
       setSelectedIndex(Math.min(selectedIndex, Math.max(0, allResults.length - 1)));
@@ -362,7 +341,27 @@ export function SearchWindow() {
     }
   };
 
-  // 打开结果项（插件、应用或文件路径）
+  // 复制计算结果
+  const copyResult = async (text: string) => {
+    try {
+      await SearchWindowService.CopyResult(text);
+      // 窗口会在 CopyResult 后自动隐藏
+    } catch (error) {
+      console.error('[SearchWindow] Failed to copy result:', error);
+    }
+  };
+
+  // 执行插件命令
+  const runCommand = async (command: string) => {
+    try {
+      await SearchWindowService.RunCommand(command);
+      // 窗口会在 RunCommand 后自动隐藏
+    } catch (error) {
+      console.error('[SearchWindow] Failed to run command:', error);
+    }
+  };
+
+  // 打开结果项（插件、应用或文件路径），计算结果则复制，命令则执行
   const openItem = async (result: SearchResult) => {
     if (result.type === 'app' && result.appId) {
       await openApp(result.appId);
@@ -370,6 +369,10 @@ export function SearchWindow() {
       await openPlugin(result.pluginId);
     } else if (result.type === 'file' && result.path) {
       await openPath(result.path);
+    } else if (result.type === 'calculation') {
+      await copyResult(result.name);
+    } else if (result.type === 'command' && result.command) {
+      await runCommand(result.command);
     }
   };
 
@@ -522,7 +525,7 @@ export function SearchWindow() {
                     </div>
                   )}
                   <div className="result-type-badge">
-                    {result.type === 'app' ? '应用' : result.type === 'file' ? (result.isDirectory ? '文件夹' : '文件') : '插件'}
+                    {result.type === 'app' ? '应用' : result.type === 'file' ? (result.isDirectory ? '文件夹' : '文件') : result.type === 'calculation' ? '计算' : result.type === 'command' ? '命令' : '插件'}
                   </div>
                 </div>
                 {index === selectedIndex && (
@@ -49,12 +49,12 @@ export function SyncSettings() {
     return () => clearInterval(interval);
   }, [loadData]);
 
-  // 检查 Git 是否安装
-  const [gitInstalled, setGitInstalled] = useState(true);
+  // 内置 Git 引擎无需安装 Git，仅在选择系统 Git 时检查
+  const [syncAvailable, setSyncAvailable] = useState(true);
   const [sshAvailable, setSshAvailable] = useState(false);
 
   useEffect(() => {
-    SyncService.IsGitInstalled().then(setGitInstalled);
+    SyncService.IsSyncAvailable().then(setSyncAvailable);
     SyncService.CheckSSHCredential().then(setSshAvailable);
   }, []);
 
@@ -63,6 +63,7 @@ export function SyncSettings() {
     try {
       await SyncService.SetConfig(newConfig);
       setConfig(newConfig);
+      SyncService.IsSyncAvailable().then(setSyncAvailable);
       success('配置已保存');
     } catch (err: any) {
       error(`保存失败: ${err.message || err}`);
@@ -144,22 +145,14 @@ export function SyncSettings() {
     );
   }
 
-  if (!gitInstalled) {
-    return (
-      <div className="glass-light rounded-xl p-6">
-        <div className="flex items-center gap-3 text-[#F59E0B]">
-          <Icon name="exclamation-circle" size={24} />
-          <div>
-            <h3 className="font-semibold">Git 未安装</h3>
-            <p className="text-sm text-white/50">请先安装 Git 以使用同步功能</p>
-          </div>
-        </div>
-      </div>
-    );
-  }
-
   return (
     <div className="space-y-6">
+      {!syncAvailable && (
+        <div className="glass-light rounded-xl p-4 flex items-center gap-3 text-[#F59E0B]">
+          <Icon name="exclamation-circle" size={20} />
+          <p className="text-sm">已选择系统 Git 引擎，但未找到 Git。请安装 Git 或改用内置引擎。</p>
+        </div>
+      )}
       {/* 状态卡片 */}
       <div className="glass-light rounded-xl p-6">
         <h3 className="text-lg font-semibold text-white mb-4 flex items-center gap-2">
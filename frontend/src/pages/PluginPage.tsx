@@ -5,7 +5,7 @@ import { usePlugins } from '../plugins/usePlugins'
 import { getPluginIcon } from '../utils/pluginHelpers'
 
 // 导入所有插件组件
-import { DateTimeWidget, TimestampConverter } from '../components/DateTimeWidget'
+import { DateTimeWidget, TimestampConverter, CronExplainer } from '../components/DateTimeWidget'
 import { ClipboardWidget } from '../components/ClipboardWidget'
 import { SystemInfoWidget } from '../components/SystemInfoWidget'
 import { CalculatorWidget } from '../components/CalculatorWidget'
@@ -278,6 +278,7 @@ function PluginContent({ pluginId, plugin, pluginIcon, onBack, isActive }: Plugi
               <div className="space-y-8">
                 <DateTimeWidget />
                 <TimestampConverter />
+                <CronExplainer />
               </div>
             </div>
           </div>
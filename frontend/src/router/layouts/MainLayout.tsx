@@ -39,6 +39,30 @@ export function MainLayout() {
     }
   }, [navigate])
 
+  // 监听后端通知事件（"标题|内容"），以系统通知显示，例如计时结束和提醒
+  useEffect(() => {
+    const unsubscribe = Events.On('notification:show', (ev: any) => {
+      const [title, ...rest] = String(ev.data ?? '').split('|')
+      const body = rest.join('|')
+      const show = () => new Notification(title, { body })
+      if (!('Notification' in window)) {
+        console.log('[MainLayout] Notification:', title, body)
+      } else if (Notification.permission === 'granted') {
+        show()
+      } else if (Notification.permission !== 'denied') {
+        Notification.requestPermission().then((permission) => {
+          if (permission === 'granted') show()
+        })
+      }
+    })
+
+    return () => {
+      if (unsubscribe && typeof unsubscribe === 'function') {
+        unsubscribe()
+      }
+    }
+  }, [])
+
   // 监听文件打开事件（文件关联）
   useEffect(() => {
     const unsubscribe = Events.On('file:open', async (ev: any) => {
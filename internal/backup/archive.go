@@ -0,0 +1,254 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// manifestName is the last entry of every archive, listing the files before it.
+const manifestName = "BACKUP-MANIFEST.json"
+
+// Paths that are not backed up: caches and downloads that can be fetched again,
+// and device state such as the sync repository or the install ID. The device
+// state in localDirs and localFiles is kept in place when a backup is restored.
+var (
+	localDirs  = []string{"cache", "updates", "rollback", ".sync", "localtranslate/models", "lx-music-service", "logs", "crashes"}
+	localFiles = []string{"install-id", configFile, "logging.json"}
+	// excludeNames are file name patterns skipped in every directory
+	excludeNames = []string{"*.tmp", "*.partial", "*.gguf", ".DS_Store", "Thumbs.db"}
+)
+
+// ErrCorrupt is returned when an archive does not match its manifest.
+var ErrCorrupt = errors.New("备份文件已损坏")
+
+// manifest describes the content of an archive.
+type manifest struct {
+	Version   int                  `json:"version"`
+	CreatedAt time.Time            `json:"createdAt"`
+	Files     map[string]fileEntry `json:"files"`
+}
+
+// fileEntry is a regular file in the archive.
+type fileEntry struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// excluded reports whether rel (slash separated, relative to the data dir) is skipped.
+func excluded(rel string, isDir bool) bool {
+	if isDir {
+		return slices.Contains(localDirs, rel)
+	}
+	if slices.Contains(localFiles, rel) {
+		return true
+	}
+	for _, pattern := range excludeNames {
+		if matched, _ := path.Match(pattern, path.Base(rel)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// writeArchive writes srcDir as a gzip-compressed tar stream to w, followed by the manifest.
+// It returns the number of files written.
+func writeArchive(w io.Writer, srcDir string, createdAt time.Time) (int, error) {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	m := &manifest{Version: 1, CreatedAt: createdAt, Files: make(map[string]fileEntry)}
+
+	err := filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if excluded(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: rel + "/", Mode: int64(info.Mode().Perm()), ModTime: info.ModTime()})
+		case info.Mode().IsRegular():
+			entry, err := writeFile(tw, p, rel, info)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rel, err)
+			}
+			m.Files[rel] = entry
+			return nil
+		default:
+			// 符号链接、套接字等不属于应用数据
+			return nil
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: manifestName, Mode: 0644, Size: int64(len(data)), ModTime: createdAt}); err != nil {
+		return 0, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return len(m.Files), gz.Close()
+}
+
+// writeFile adds one regular file to the archive and returns its manifest entry.
+// The size in the header is taken before reading, so a file that changes while it
+// is archived makes the copy fail instead of producing a silently truncated entry.
+func writeFile(tw *tar.Writer, p, rel string, info fs.FileInfo) (fileEntry, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return fileEntry{}, err
+	}
+	defer f.Close()
+
+	hdr := &tar.Header{Typeflag: tar.TypeReg, Name: rel, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fileEntry{}, err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(tw, h), f, info.Size()); err != nil {
+		return fileEntry{}, err
+	}
+	return fileEntry{Size: info.Size(), SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// readArchive reads an archive written by writeArchive and checks every file
+// against the manifest. If destDir is not empty the files are extracted there.
+func readArchive(r io.Reader, destDir string) (*manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, corrupt(err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	seen := make(map[string]fileEntry)
+	var m *manifest
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, corrupt(err)
+		}
+		if m != nil {
+			return nil, fmt.Errorf("%w: unexpected entry after manifest: %s", ErrCorrupt, hdr.Name)
+		}
+
+		if hdr.Name == manifestName {
+			m = &manifest{}
+			if err := json.NewDecoder(tr).Decode(m); err != nil {
+				return nil, fmt.Errorf("%w: invalid manifest: %v", ErrCorrupt, err)
+			}
+			continue
+		}
+
+		// 拒绝绝对路径和 .. 逃逸
+		name := path.Clean(strings.TrimSuffix(hdr.Name, "/"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("%w: invalid path %s", ErrCorrupt, hdr.Name)
+		}
+		target := ""
+		if destDir != "" {
+			target = filepath.Join(destDir, filepath.FromSlash(name))
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if target != "" {
+				if err := os.MkdirAll(target, os.FileMode(hdr.Mode).Perm()|0700); err != nil {
+					return nil, err
+				}
+			}
+		case tar.TypeReg:
+			entry, err := readFile(tr, target, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return nil, err
+			}
+			seen[name] = entry
+		default:
+			return nil, fmt.Errorf("%w: unsupported entry %s", ErrCorrupt, hdr.Name)
+		}
+	}
+
+	if m == nil {
+		return nil, fmt.Errorf("%w: manifest missing", ErrCorrupt)
+	}
+	if len(seen) != len(m.Files) {
+		return nil, fmt.Errorf("%w: expected %d files, found %d", ErrCorrupt, len(m.Files), len(seen))
+	}
+	for name, entry := range m.Files {
+		if seen[name] != entry {
+			return nil, fmt.Errorf("%w: checksum mismatch for %s", ErrCorrupt, name)
+		}
+	}
+	return m, nil
+}
+
+// readFile hashes the current archive entry, writing it to target if set.
+func readFile(r io.Reader, target string, perm os.FileMode) (fileEntry, error) {
+	w := io.Discard
+	if target != "" {
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fileEntry{}, err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+		if err != nil {
+			return fileEntry{}, err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return fileEntry{}, corrupt(err)
+	}
+	return fileEntry{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// corrupt wraps a read error in ErrCorrupt, keeping ErrWrongPassphrase from the
+// decryption layer so that callers can ask for the passphrase again.
+func corrupt(err error) error {
+	if errors.Is(err, ErrWrongPassphrase) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrCorrupt, err)
+}
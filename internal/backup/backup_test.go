@@ -0,0 +1,235 @@
+package backup
+
+import (
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// fakePlugins records the calls made by the backup service.
+type fakePlugins struct {
+	quiesced, resumed int
+}
+
+func (f *fakePlugins) Quiesce() (func(), error) {
+	f.quiesced++
+	return func() { f.resumed++ }, nil
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return "<missing>"
+	}
+	return string(data)
+}
+
+func newTestService(t *testing.T, plugins PluginController) (*BackupService, string) {
+	t.Helper()
+	keyring.MockInit()
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "ltools")
+	s := NewBackupService(dataDir, plugins)
+	t.Cleanup(func() { s.ServiceShutdown() })
+	return s, dataDir
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	plugins := &fakePlugins{}
+	s, dataDir := newTestService(t, plugins)
+	restarted := 0
+	s.SetRestarter(func() error { restarted++; return nil })
+	writeFiles(t, dataDir, map[string]string{
+		"settings.json":          `{"theme":"dark"}`,
+		"kanban/boards.json":     "boards v1",
+		"install-id":             "device-a",
+		"cache/proxy/blob":       "cached",
+		"kanban/boards.json.tmp": "partial write",
+	})
+
+	backup, err := s.CreateBackup("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup.Files != 2 {
+		t.Fatalf("backup has %d files, want 2", backup.Files)
+	}
+	if plugins.quiesced != 1 || plugins.resumed != 1 {
+		t.Fatalf("quiesced %d, resumed %d", plugins.quiesced, plugins.resumed)
+	}
+	if err := s.VerifyBackup(backup.ID, ""); err != nil {
+		t.Fatalf("VerifyBackup: %v", err)
+	}
+
+	// 备份之后的改动和新文件在恢复后消失，本机状态保留
+	writeFiles(t, dataDir, map[string]string{
+		"kanban/boards.json": "boards v2",
+		"sticky.json":        "new notes",
+		"install-id":         "device-b",
+	})
+	if err := s.RestoreBackup(backup.ID, ""); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if restarted != 1 {
+		t.Fatalf("app restarted %d times", restarted)
+	}
+
+	// 运行中的服务写回的旧状态被重启时的恢复覆盖
+	writeFiles(t, dataDir, map[string]string{"kanban/boards.json": "boards v3"})
+	if applied, err := ApplyPendingRestore(dataDir); err != nil || !applied {
+		t.Fatalf("ApplyPendingRestore = %v, %v", applied, err)
+	}
+	if applied, err := ApplyPendingRestore(dataDir); err != nil || applied {
+		t.Fatalf("second ApplyPendingRestore = %v, %v", applied, err)
+	}
+	for name, want := range map[string]string{
+		"kanban/boards.json": "boards v1",
+		"settings.json":      `{"theme":"dark"}`,
+		"sticky.json":        "<missing>",
+		"install-id":         "device-b",
+		"cache/proxy/blob":   "cached",
+	} {
+		if got := readTestFile(t, dataDir, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(dataDir + ".old"); !os.IsNotExist(err) {
+		t.Error("previous data dir left behind")
+	}
+}
+
+func TestEncryptedBackup(t *testing.T) {
+	s, dataDir := newTestService(t, nil)
+	writeFiles(t, dataDir, map[string]string{"vault/vault.json": "secret data"})
+
+	backup, err := s.CreateBackup("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backup.Encrypted {
+		t.Fatal("backup not marked as encrypted")
+	}
+
+	if err := s.VerifyBackup(backup.ID, ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("VerifyBackup without passphrase = %v", err)
+	}
+	if err := s.VerifyBackup(backup.ID, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("VerifyBackup with wrong passphrase = %v", err)
+	}
+	if err := s.RestoreBackup(backup.ID, "wrong"); err == nil {
+		t.Fatal("RestoreBackup succeeded with wrong passphrase")
+	}
+	if _, err := os.Stat(dataDir + pendingSuffix); !os.IsNotExist(err) {
+		t.Fatal("failed restore was staged")
+	}
+	if err := s.RestoreBackup(backup.ID, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if applied, err := ApplyPendingRestore(dataDir); err != nil || !applied {
+		t.Fatalf("ApplyPendingRestore = %v, %v", applied, err)
+	}
+	if got := readTestFile(t, dataDir, "vault/vault.json"); got != "secret data" {
+		t.Fatalf("vault/vault.json = %q", got)
+	}
+}
+
+func TestEncryptionDetectsTruncation(t *testing.T) {
+	s, dataDir := newTestService(t, nil)
+	// 压缩后仍超过一个分块的数据
+	big := make([]byte, 3*chunkSize)
+	rand.Read(big)
+	writeFiles(t, dataDir, map[string]string{"blob": string(big)})
+
+	backup, err := s.CreateBackup("pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _, _ := s.archivePath(backup.ID)
+	data, _ := os.ReadFile(path)
+
+	// 截断在块边界上，剩余的块都能单独解密
+	header := len(encMagic) + saltLength + noncePrefixSize
+	if err := os.WriteFile(path, data[:header+chunkSize+16], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VerifyBackup(backup.ID, "pw"); err == nil {
+		t.Fatal("truncated archive verified")
+	}
+}
+
+func TestCorruptBackupDetected(t *testing.T) {
+	s, dataDir := newTestService(t, nil)
+	writeFiles(t, dataDir, map[string]string{"a.json": "aaaa", "b.json": "bbbb"})
+
+	backup, err := s.CreateBackup("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _, _ := s.archivePath(backup.ID)
+	data, _ := os.ReadFile(path)
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(path, data, 0600)
+
+	if err := s.VerifyBackup(backup.ID, ""); err == nil {
+		t.Fatal("corrupt archive verified")
+	}
+}
+
+func TestRetentionAndSchedule(t *testing.T) {
+	s, dataDir := newTestService(t, nil)
+	writeFiles(t, dataDir, map[string]string{"a.json": "a"})
+
+	cfg := s.GetConfig()
+	cfg.Keep = 2
+	cfg.IntervalHours = 24
+	if err := s.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 1, 1, 3, 0, 0, 0, time.Local)
+	s.now = func() time.Time { return now }
+	if !s.due() {
+		t.Fatal("first scheduled backup not due")
+	}
+
+	for range 4 {
+		s.runScheduled()
+		if s.due() {
+			t.Fatal("backup due right after running")
+		}
+		now = now.Add(25 * time.Hour)
+	}
+
+	backups, err := s.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || !backups[0].CreatedAt.After(backups[1].CreatedAt) {
+		t.Fatalf("backups after retention = %+v", backups)
+	}
+
+	// 备份目录不能位于数据目录中
+	cfg.Dir = filepath.Join(dataDir, "backups")
+	if err := s.SetConfig(cfg); err == nil {
+		t.Fatal("SetConfig accepted a backup dir inside the data dir")
+	}
+}
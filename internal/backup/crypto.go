@@ -0,0 +1,204 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// 加密备份格式：
+//
+//	magic(8) | salt(32) | nonce prefix(7) | chunk...
+//
+// 数据按 64KB 分块，每块单独用 AES-256-GCM 加密，nonce 为前缀 + 4 字节块序号 +
+// 1 字节结束标记，因此块被截断、删除或调换顺序都会导致解密失败。文件头作为附加数据参与认证。
+const (
+	encMagic         = "LTBKENC1"
+	saltLength       = 32
+	noncePrefixSize  = 7
+	chunkSize        = 64 << 10
+	pbkdf2Iterations = 100000
+	keyLength        = 32
+)
+
+var (
+	// ErrWrongPassphrase is returned when an encrypted archive cannot be authenticated.
+	ErrWrongPassphrase = errors.New("密码错误或备份文件已损坏")
+	// ErrPassphraseRequired is returned when an encrypted archive is opened without a passphrase.
+	ErrPassphraseRequired = errors.New("备份已加密，需要输入密码")
+)
+
+// deriveKey derives the archive key from the passphrase.
+func deriveKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, keyLength, sha256.New)
+}
+
+// newGCM creates the cipher for a passphrase and header.
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(passphrase, salt))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce builds the nonce of chunk n.
+func chunkNonce(prefix []byte, n uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], n)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptWriter encrypts everything written to it in chunks.
+// Close must be called to write the final chunk.
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	buf    []byte
+	n      uint32
+}
+
+// newEncryptWriter writes the header to w and returns a writer for the plaintext.
+func newEncryptWriter(w io.Writer, passphrase string) (*encryptWriter, error) {
+	header := make([]byte, len(encMagic)+saltLength+noncePrefixSize)
+	copy(header, encMagic)
+	if _, err := rand.Read(header[len(encMagic):]); err != nil {
+		return nil, err
+	}
+	salt := header[len(encMagic) : len(encMagic)+saltLength]
+
+	aead, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		prefix: header[len(encMagic)+saltLength:],
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// 缓冲区满且还有数据时才写出，保证最后一块在 Close 时带结束标记
+		if len(e.buf) == chunkSize {
+			if err := e.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close writes the final chunk.
+func (e *encryptWriter) Close() error {
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(last bool) error {
+	sealed := e.aead.Seal(nil, chunkNonce(e.prefix, e.n, last), e.buf, e.header)
+	e.n++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+// decryptReader decrypts a stream written by encryptWriter.
+type decryptReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	header []byte
+	prefix []byte
+	chunk  []byte // next sealed chunk, read ahead to detect the last one
+	plain  []byte
+	n      uint32
+	done   bool
+}
+
+// newDecryptReader reads the header from r and returns a reader for the plaintext.
+func newDecryptReader(r io.Reader, passphrase string) (*decryptReader, error) {
+	header := make([]byte, len(encMagic)+saltLength+noncePrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(encMagic)]) != encMagic {
+		return nil, fmt.Errorf("%w: invalid header", ErrCorrupt)
+	}
+	aead, err := newGCM(passphrase, header[len(encMagic):len(encMagic)+saltLength])
+	if err != nil {
+		return nil, err
+	}
+	d := &decryptReader{
+		r:      r,
+		aead:   aead,
+		header: header,
+		prefix: header[len(encMagic)+saltLength:],
+	}
+	if d.chunk, err = d.readChunk(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open decrypts the buffered chunk, using the read-ahead to tell whether it is the last.
+func (d *decryptReader) open() error {
+	next, err := d.readChunk()
+	if err != nil {
+		return err
+	}
+	last := len(next) == 0
+
+	plain, err := d.aead.Open(nil, chunkNonce(d.prefix, d.n, last), d.chunk, d.header)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	d.n++
+	d.plain = plain
+	d.chunk = next
+	d.done = last
+	return nil
+}
+
+// readChunk reads the next sealed chunk; an empty result means end of stream.
+func (d *decryptReader) readChunk() ([]byte, error) {
+	buf := make([]byte, chunkSize+d.aead.Overhead())
+	n, err := io.ReadFull(d.r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return buf[:n], err
+}
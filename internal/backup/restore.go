@@ -0,0 +1,151 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pendingSuffix is appended to the data directory for a verified restore that
+// is swapped in on the next start.
+const pendingSuffix = ".restore"
+
+// RestoreBackup replaces the data directory with the content of a backup.
+//
+// The archive is extracted and verified next to the data directory first. The
+// running services keep their state in memory and would write it back over the
+// restored files, so the directories are not swapped while the app runs: the
+// restore is staged and the app is restarted, and ApplyPendingRestore swaps the
+// directories before anything reads the data directory.
+func (s *BackupService) RestoreBackup(id, passphrase string) error {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+
+	path, encrypted, err := s.archivePath(id)
+	if err != nil {
+		return err
+	}
+
+	pending := s.dataDir + pendingSuffix
+	staging := pending + ".tmp"
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if _, err := s.readArchiveFile(path, encrypted, passphrase, staging); err != nil {
+		return err
+	}
+
+	// 只有完整解压并校验过的目录才会出现在 pending 路径上
+	if err := os.RemoveAll(pending); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, pending); err != nil {
+		return fmt.Errorf("failed to stage restore: %w", err)
+	}
+
+	log.Printf("[BackupService] Staged %s, restoring on restart", id)
+	s.emit("backup:restored", id)
+
+	s.mu.Lock()
+	restart := s.restart
+	s.mu.Unlock()
+	if restart == nil {
+		return nil
+	}
+	if err := restart(); err != nil {
+		return fmt.Errorf("备份将在下次启动时恢复，自动重启失败: %w", err)
+	}
+	return nil
+}
+
+// ApplyPendingRestore swaps in a restore staged by RestoreBackup. It must be
+// called at startup before anything opens the data directory, and reports
+// whether a restore was applied.
+func ApplyPendingRestore(dataDir string) (bool, error) {
+	pending := dataDir + pendingSuffix
+	if _, err := os.Stat(pending); err != nil {
+		return false, nil
+	}
+
+	// 重启时上一个进程可能还没退出，Windows 上它打开的文件会阻止重命名
+	var err error
+	for attempt := 0; attempt < restoreAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(restoreRetryDelay)
+		}
+		if err = swapDataDir(dataDir, pending); err == nil {
+			return true, nil
+		}
+	}
+
+	// 放弃这次恢复，避免每次启动都重试
+	os.RemoveAll(pending)
+	return false, fmt.Errorf("恢复备份失败: %w", err)
+}
+
+// Retry policy of ApplyPendingRestore.
+var (
+	restoreAttempts   = 10
+	restoreRetryDelay = 500 * time.Millisecond
+)
+
+// swapDataDir replaces dataDir with staging, moving the device state across first.
+// On failure everything is moved back.
+func swapDataDir(dataDir, staging string) error {
+	var moved []string
+	moveBack := func() {
+		for _, rel := range moved {
+			if err := os.Rename(filepath.Join(staging, rel), filepath.Join(dataDir, rel)); err != nil {
+				log.Printf("[BackupService] Failed to move back %s: %v", rel, err)
+			}
+		}
+	}
+
+	for _, rel := range append(append([]string(nil), localDirs...), localFiles...) {
+		rel = filepath.FromSlash(rel)
+		src := filepath.Join(dataDir, rel)
+		if _, err := os.Lstat(src); err != nil {
+			continue
+		}
+		dst := filepath.Join(staging, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			moveBack()
+			return err
+		}
+		os.RemoveAll(dst)
+		if err := os.Rename(src, dst); err != nil {
+			moveBack()
+			return fmt.Errorf("failed to keep %s: %w", rel, err)
+		}
+		moved = append(moved, rel)
+	}
+
+	old := dataDir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		moveBack()
+		return err
+	}
+	if err := os.Rename(dataDir, old); err != nil {
+		moveBack()
+		return fmt.Errorf("failed to move data dir aside: %w", err)
+	}
+	if err := os.Rename(staging, dataDir); err != nil {
+		if restoreErr := os.Rename(old, dataDir); restoreErr != nil {
+			return fmt.Errorf("failed to restore (%v) and to put back the data dir: %w", err, restoreErr)
+		}
+		moveBack()
+		return fmt.Errorf("failed to move restored data into place: %w", err)
+	}
+
+	if err := os.RemoveAll(old); err != nil {
+		log.Printf("[BackupService] Warning: failed to remove previous data dir: %v", err)
+	}
+	return nil
+}
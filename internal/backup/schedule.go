@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// checkInterval is how often the schedule checks whether a backup is due.
+var checkInterval = time.Minute
+
+// schedule runs scheduled backups until ServiceShutdown.
+// Checking periodically instead of sleeping until the due time keeps the
+// schedule correct across config changes and system sleep.
+func (s *BackupService) schedule() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if s.due() {
+				s.runScheduled()
+			}
+		}
+	}
+}
+
+// due reports whether a scheduled backup should run now.
+func (s *BackupService) due() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.config.IntervalHours <= 0 || s.now().Before(s.retryAfter) {
+		return false
+	}
+	return !s.now().Before(s.config.LastBackup.Add(time.Duration(s.config.IntervalHours) * time.Hour))
+}
+
+// runScheduled creates a scheduled backup and records a failure in the config.
+func (s *BackupService) runScheduled() {
+	passphrase := ""
+	if s.GetConfig().Encrypt {
+		p, err := keyring.Get(keychainService, keychainKey)
+		if err != nil {
+			s.scheduledFailed(fmt.Errorf("未设置定时备份密码: %w", err))
+			return
+		}
+		passphrase = p
+	}
+
+	if _, err := s.CreateBackup(passphrase); err != nil {
+		s.scheduledFailed(err)
+	}
+}
+
+// scheduledFailed records the error and postpones the next attempt by an hour,
+// so that a persistent failure does not retry every minute.
+func (s *BackupService) scheduledFailed(err error) {
+	log.Printf("[BackupService] Scheduled backup failed: %v", err)
+
+	s.mu.Lock()
+	s.config.LastError = err.Error()
+	s.retryAfter = s.now().Add(time.Hour)
+	if saveErr := s.saveConfigLocked(s.config); saveErr != nil {
+		log.Printf("[BackupService] Failed to save config: %v", saveErr)
+	}
+	s.mu.Unlock()
+
+	s.emit("backup:failed", err.Error())
+}
+
+// prune deletes archives beyond the retention. The newest archive is always kept.
+func (s *BackupService) prune() error {
+	cfg := s.GetConfig()
+	backups, err := s.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Time{}
+	if cfg.MaxAgeDays > 0 {
+		cutoff = s.now().AddDate(0, 0, -cfg.MaxAgeDays)
+	}
+
+	for i, backup := range backups {
+		if i == 0 || (i < cfg.Keep && !backup.CreatedAt.Before(cutoff)) {
+			continue
+		}
+		ext := archiveExt
+		if backup.Encrypted {
+			ext = encryptedExt
+		}
+		if err := os.Remove(filepath.Join(cfg.Dir, backup.ID+ext)); err != nil {
+			return err
+		}
+		log.Printf("[BackupService] Removed old backup %s", backup.ID)
+	}
+	return nil
+}
@@ -0,0 +1,457 @@
+// Package backup creates and restores compressed, optionally encrypted archives
+// of the whole data directory.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	configFile = "backup.json"
+
+	// archivePrefix and the extensions identify backup files in the backup directory.
+	archivePrefix = "ltools-"
+	archiveExt    = ".tar.gz"
+	encryptedExt  = ".tar.gz.enc"
+	idTimeFormat  = "20060102-150405"
+
+	// keychainService and keychainKey store the passphrase for scheduled encrypted backups.
+	keychainService = "ltools-backup"
+	keychainKey     = "schedule-passphrase"
+)
+
+// Config is the backup configuration, saved as backup.json in the data directory.
+type Config struct {
+	// Dir is where archives are written. It must be outside the data directory,
+	// which is replaced on restore. Defaults to "ltools-backups" next to it.
+	Dir string `json:"dir"`
+
+	// IntervalHours is the time between scheduled backups; 0 disables the schedule.
+	IntervalHours int `json:"intervalHours"`
+
+	// Encrypt encrypts scheduled backups with the passphrase set by SetPassphrase.
+	Encrypt bool `json:"encrypt"`
+
+	// Keep is the number of archives kept; older ones are deleted after each backup.
+	Keep int `json:"keep"`
+
+	// MaxAgeDays deletes archives older than this many days; 0 keeps them regardless of age.
+	// The newest archive is never deleted.
+	MaxAgeDays int `json:"maxAgeDays"`
+
+	// LastBackup is the time of the last successful backup.
+	LastBackup time.Time `json:"lastBackup"`
+
+	// LastError is the error of the last scheduled backup, if it failed.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Backup describes an archive in the backup directory.
+type Backup struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int64     `json:"size"`
+	Encrypted bool      `json:"encrypted"`
+	Files     int       `json:"files,omitempty"` // only set for backups created in this session
+}
+
+// PluginController pauses plugins while a backup is created.
+// It is implemented by plugins.Manager.
+type PluginController interface {
+	// Quiesce flushes pending writes and pauses background writes until resume is called.
+	Quiesce() (resume func(), err error)
+}
+
+// BackupService exposes backup and restore of the data directory to the frontend.
+type BackupService struct {
+	dataDir    string
+	configPath string
+	plugins    PluginController
+	restart    func() error
+	now        func() time.Time
+
+	mu         sync.Mutex // protects config, retryAfter, restart and onEvent
+	config     *Config
+	retryAfter time.Time  // next scheduled attempt after a failure
+	opMu       sync.Mutex // serializes backups and restores
+	onEvent    []func(name string, data any)
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewBackupService creates a BackupService for dataDir and starts the schedule.
+// plugins may be nil, in which case no plugin is paused.
+func NewBackupService(dataDir string, plugins PluginController) *BackupService {
+	s := &BackupService{
+		dataDir:    dataDir,
+		configPath: filepath.Join(dataDir, configFile),
+		plugins:    plugins,
+		now:        time.Now,
+		config:     defaultConfig(dataDir),
+		stop:       make(chan struct{}),
+	}
+	if err := s.loadConfig(); err != nil {
+		log.Printf("[BackupService] Failed to load config, using defaults: %v", err)
+	}
+
+	s.wg.Add(1)
+	go s.schedule()
+	return s
+}
+
+func defaultConfig(dataDir string) *Config {
+	return &Config{
+		Dir:  filepath.Join(filepath.Dir(dataDir), "ltools-backups"),
+		Keep: 10,
+	}
+}
+
+// SetRestarter sets the function that restarts the app after a restore has
+// been staged. Without it the restore is applied on the next start.
+func (s *BackupService) SetRestarter(restart func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restart = restart
+}
+
+// OnEvent registers fn to receive backup events: backup:created, backup:restored
+// and backup:failed.
+func (s *BackupService) OnEvent(fn func(name string, data any)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEvent = append(s.onEvent, fn)
+}
+
+// ServiceShutdown stops the schedule.
+func (s *BackupService) ServiceShutdown() error {
+	close(s.stop)
+	s.wg.Wait()
+	return nil
+}
+
+// GetConfig returns the backup configuration.
+func (s *BackupService) GetConfig() *Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg := *s.config
+	return &cfg
+}
+
+// SetConfig validates and saves the backup configuration.
+func (s *BackupService) SetConfig(cfg *Config) error {
+	if cfg.Dir == "" {
+		return fmt.Errorf("备份目录不能为空")
+	}
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(s.dataDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("备份目录不能位于数据目录中")
+	}
+	if cfg.IntervalHours < 0 || cfg.Keep < 1 || cfg.MaxAgeDays < 0 {
+		return fmt.Errorf("无效的备份计划")
+	}
+	if cfg.Encrypt && cfg.IntervalHours > 0 && !s.HasPassphrase() {
+		return fmt.Errorf("加密的定时备份需要先设置密码")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := *cfg
+	next.Dir = dir
+	// 运行状态由服务维护
+	next.LastBackup = s.config.LastBackup
+	next.LastError = s.config.LastError
+	return s.saveConfigLocked(&next)
+}
+
+// SetPassphrase stores the passphrase used by scheduled encrypted backups in the
+// OS keychain. An empty passphrase removes it.
+func (s *BackupService) SetPassphrase(passphrase string) error {
+	if passphrase == "" {
+		err := keyring.Delete(keychainService, keychainKey)
+		if errors.Is(err, keyring.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return keyring.Set(keychainService, keychainKey, passphrase)
+}
+
+// HasPassphrase reports whether a passphrase for scheduled backups is stored.
+func (s *BackupService) HasPassphrase() bool {
+	_, err := keyring.Get(keychainService, keychainKey)
+	return err == nil
+}
+
+// CreateBackup archives the data directory now. The archive is encrypted when
+// passphrase is not empty. Old archives are removed according to the retention.
+func (s *BackupService) CreateBackup(passphrase string) (*Backup, error) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+
+	backup, err := s.create(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.config.LastBackup = backup.CreatedAt
+	s.config.LastError = ""
+	if err := s.saveConfigLocked(s.config); err != nil {
+		log.Printf("[BackupService] Failed to save config: %v", err)
+	}
+	s.mu.Unlock()
+
+	if err := s.prune(); err != nil {
+		log.Printf("[BackupService] Failed to remove old backups: %v", err)
+	}
+	s.emit("backup:created", backup)
+	return backup, nil
+}
+
+// ListBackups returns the archives in the backup directory, newest first.
+func (s *BackupService) ListBackups() ([]Backup, error) {
+	dir := s.GetConfig().Dir
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		backup, ok := parseArchiveName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+	slices.SortFunc(backups, func(a, b Backup) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return backups, nil
+}
+
+// VerifyBackup reads the whole archive and checks every file against its manifest.
+func (s *BackupService) VerifyBackup(id, passphrase string) error {
+	path, encrypted, err := s.archivePath(id)
+	if err != nil {
+		return err
+	}
+	_, err = s.readArchiveFile(path, encrypted, passphrase, "")
+	return err
+}
+
+// DeleteBackup removes an archive.
+func (s *BackupService) DeleteBackup(id string) error {
+	path, _, err := s.archivePath(id)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// create writes a new archive. The caller must hold opMu.
+func (s *BackupService) create(passphrase string) (*Backup, error) {
+	dir := s.GetConfig().Dir
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	createdAt := s.now()
+	backup := &Backup{
+		ID:        archivePrefix + createdAt.Format(idTimeFormat),
+		CreatedAt: createdAt.Truncate(time.Second),
+		Encrypted: passphrase != "",
+	}
+	name := backup.ID + archiveExt
+	if backup.Encrypted {
+		name = backup.ID + encryptedExt
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("备份 %s 已存在", backup.ID)
+	}
+
+	// 写入前让插件落盘并暂停写入，保证快照一致
+	if s.plugins != nil {
+		resume, err := s.plugins.Quiesce()
+		if err != nil {
+			return nil, err
+		}
+		defer resume()
+	}
+
+	tmp := path + ".tmp"
+	files, err := s.writeArchiveFile(tmp, passphrase, createdAt)
+	if err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	// 写完后完整读一遍，确认归档可用再保留
+	if _, err := s.readArchiveFile(tmp, backup.Encrypted, passphrase, ""); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("backup verification failed: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	backup.Size = info.Size()
+	backup.Files = files
+
+	log.Printf("[BackupService] Created %s (%d files, %d bytes, encrypted: %v)", name, files, backup.Size, backup.Encrypted)
+	return backup, nil
+}
+
+func (s *BackupService) writeArchiveFile(path, passphrase string, createdAt time.Time) (int, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var enc *encryptWriter
+	if passphrase != "" {
+		if enc, err = newEncryptWriter(f, passphrase); err != nil {
+			return 0, err
+		}
+		w = enc
+	}
+
+	files, err := writeArchive(w, s.dataDir, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return 0, err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return 0, err
+	}
+	return files, f.Close()
+}
+
+// readArchiveFile verifies an archive, extracting it to destDir if not empty.
+func (s *BackupService) readArchiveFile(path string, encrypted bool, passphrase, destDir string) (*manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if encrypted {
+		if passphrase == "" {
+			return nil, ErrPassphraseRequired
+		}
+		if r, err = newDecryptReader(f, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	return readArchive(r, destDir)
+}
+
+// archivePath finds the archive file of a backup ID.
+func (s *BackupService) archivePath(id string) (string, bool, error) {
+	if _, ok := parseArchiveName(id + archiveExt); !ok {
+		return "", false, fmt.Errorf("无效的备份 ID: %s", id)
+	}
+	dir := s.GetConfig().Dir
+	for _, ext := range []string{archiveExt, encryptedExt} {
+		path := filepath.Join(dir, id+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, ext == encryptedExt, nil
+		}
+	}
+	return "", false, fmt.Errorf("备份不存在: %s", id)
+}
+
+// parseArchiveName parses "ltools-<time>.tar.gz[.enc]".
+func parseArchiveName(name string) (Backup, bool) {
+	encrypted := strings.HasSuffix(name, encryptedExt)
+	id, ok := strings.CutSuffix(name, archiveExt)
+	if encrypted {
+		id, ok = strings.CutSuffix(name, encryptedExt)
+	}
+	if !ok || !strings.HasPrefix(id, archivePrefix) {
+		return Backup{}, false
+	}
+	createdAt, err := time.ParseInLocation(idTimeFormat, strings.TrimPrefix(id, archivePrefix), time.Local)
+	if err != nil {
+		return Backup{}, false
+	}
+	return Backup{ID: id, CreatedAt: createdAt, Encrypted: encrypted}, true
+}
+
+func (s *BackupService) emit(name string, data any) {
+	s.mu.Lock()
+	listeners := slices.Clone(s.onEvent)
+	s.mu.Unlock()
+	for _, fn := range listeners {
+		fn(name, data)
+	}
+}
+
+func (s *BackupService) loadConfig() error {
+	data, err := os.ReadFile(s.configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cfg := defaultConfig(s.dataDir)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return err
+	}
+	s.config = cfg
+	return nil
+}
+
+// saveConfigLocked saves cfg as the current config. The caller must hold mu.
+func (s *BackupService) saveConfigLocked(cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		return err
+	}
+	tmpFile := s.configPath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, s.configPath); err != nil {
+		return err
+	}
+	s.config = cfg
+	return nil
+}
@@ -0,0 +1,98 @@
+package crash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeLogs(n int) []string {
+	return []string{"INFO [Main] starting", "ERROR [Kanban] something failed"}
+}
+
+func readBundleFile(t *testing.T, report *Report, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(report.Path, name))
+	if err != nil {
+		t.Fatalf("bundle is missing %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestRecoverWritesBundle(t *testing.T) {
+	r := NewReporter(t.TempDir(), fakeLogs)
+	r.AddSection("plugins", func() (any, error) {
+		return []map[string]string{{"id": "kanban.builtin", "state": "enabled"}}, nil
+	})
+	r.AddSection("system", func() (any, error) { return nil, errors.New("not available") })
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Fatalf("Recover did not panic again, got %v", v)
+			}
+		}()
+		defer r.Recover()
+		panic("boom")
+	}()
+
+	s := NewCrashService(r, nil)
+	reports, err := s.GetUnseenCrashes()
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("GetUnseenCrashes = %+v, %v", reports, err)
+	}
+	report := &reports[0]
+	if report.Reason != "boom" || report.Fatal {
+		t.Fatalf("report = %+v", report)
+	}
+
+	if crash := readBundleFile(t, report, "crash.txt"); !strings.Contains(crash, "TestRecoverWritesBundle") {
+		t.Errorf("crash.txt has no stack of the panicking goroutine:\n%s", crash)
+	}
+	if logs := readBundleFile(t, report, "logs.txt"); !strings.Contains(logs, "something failed") {
+		t.Errorf("logs.txt = %q", logs)
+	}
+	if plugins := readBundleFile(t, report, "plugins.json"); !strings.Contains(plugins, "kanban.builtin") {
+		t.Errorf("plugins.json = %q", plugins)
+	}
+	if system := readBundleFile(t, report, "system.json"); !strings.Contains(system, "not available") {
+		t.Errorf("system.json = %q", system)
+	}
+
+	if err := s.MarkSeen(report.ID); err != nil {
+		t.Fatal(err)
+	}
+	if reports, _ := s.GetUnseenCrashes(); len(reports) != 0 {
+		t.Fatalf("bundle still unseen: %+v", reports)
+	}
+	if err := s.DeleteCrash("../" + report.ID); err == nil {
+		t.Fatal("DeleteCrash accepted a path")
+	}
+}
+
+func TestFatalCrashFromPreviousRun(t *testing.T) {
+	dataDir := t.TempDir()
+	dir := filepath.Join(dataDir, crashDirName)
+	os.MkdirAll(dir, 0755)
+	output := "fatal error: concurrent map writes\n\ngoroutine 12 [running]:\nmain.worker()\n"
+	os.WriteFile(filepath.Join(dir, fatalFile), []byte(output), 0644)
+
+	r := NewReporter(dataDir, fakeLogs)
+	report, err := r.ProcessPending()
+	if err != nil || report == nil {
+		t.Fatalf("ProcessPending = %+v, %v", report, err)
+	}
+	if report.Reason != "fatal error: concurrent map writes" || !report.Fatal {
+		t.Fatalf("report = %+v", report)
+	}
+	if crash := readBundleFile(t, report, "crash.txt"); !strings.Contains(crash, "main.worker()") {
+		t.Errorf("crash.txt = %q", crash)
+	}
+
+	// 崩溃输出文件已清空，下次启动不会重复生成
+	if again := NewReporter(dataDir, fakeLogs); again.pending != nil {
+		t.Fatal("crash output not reset")
+	}
+}
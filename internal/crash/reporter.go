@@ -0,0 +1,277 @@
+// Package crash writes local crash bundles when the app panics or dies and
+// lists them on the next launch, so that they can be attached to an issue.
+// Nothing is uploaded.
+package crash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	crashDirName = "crashes"
+
+	// fatalFile receives the runtime's output for crashes that cannot be
+	// recovered (panics in other goroutines, fatal errors). It is turned into
+	// a bundle on the next launch.
+	fatalFile = "fatal.log"
+
+	bundlePrefix = "crash-"
+	idTimeFormat = "20060102-150405"
+	reportFile   = "report.json"
+	seenFile     = ".seen"
+
+	// LogLines is the number of log lines included in a bundle.
+	LogLines = 300
+
+	// sectionTimeout limits how long a section may take.
+	sectionTimeout = 2 * time.Second
+)
+
+// Section adds a file <name>.json to each bundle. It is called while the app
+// is crashing, so it must not block on locks that the crashed code may hold.
+type Section func() (any, error)
+
+// Report describes a crash bundle.
+type Report struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Reason    string    `json:"reason"`
+	Fatal     bool      `json:"fatal"` // found on the next launch rather than recovered
+	Path      string    `json:"path"`
+	Seen      bool      `json:"seen"`
+}
+
+// Reporter writes crash bundles to the crashes directory in the data directory.
+type Reporter struct {
+	dir     string
+	logTail func(n int) []string
+
+	mu       sync.Mutex
+	names    []string
+	sections map[string]Section
+
+	// pending is the runtime output of a crash in the previous run, with the
+	// log lines of that run read before this run logs anything.
+	pending     []byte
+	pendingLogs []string
+
+	writing atomic.Bool
+	now     func() time.Time
+}
+
+// NewReporter creates a reporter and makes the runtime write the output of
+// unrecoverable crashes to the crashes directory. logTail returns the last
+// log lines; it may be nil.
+func NewReporter(dataDir string, logTail func(n int) []string) *Reporter {
+	r := &Reporter{
+		dir:      filepath.Join(dataDir, crashDirName),
+		logTail:  logTail,
+		sections: map[string]Section{},
+		now:      time.Now,
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		log.Printf("[Crash] Failed to create crash dir: %v", err)
+		return r
+	}
+
+	// 上次运行的崩溃输出，在本次写入日志之前读取上次的日志
+	if data, err := os.ReadFile(filepath.Join(r.dir, fatalFile)); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		r.pending = data
+		r.pendingLogs = r.tailLogs()
+	}
+
+	// 崩溃时输出所有 goroutine 的堆栈
+	debug.SetTraceback("all")
+	f, err := os.Create(filepath.Join(r.dir, fatalFile))
+	if err != nil {
+		log.Printf("[Crash] Failed to create %s: %v", fatalFile, err)
+		return r
+	}
+	if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+		log.Printf("[Crash] Failed to set crash output: %v", err)
+	}
+	f.Close() // SetCrashOutput 持有自己的副本
+	return r
+}
+
+// AddSection adds a section to the bundles written from now on.
+func (r *Reporter) AddSection(name string, section Section) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sections[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.sections[name] = section
+}
+
+// ProcessPending writes the bundle of a crash in the previous run, if there was
+// one. Call it after the sections have been added.
+func (r *Reporter) ProcessPending() (*Report, error) {
+	if r.pending == nil {
+		return nil, nil
+	}
+	output := r.pending
+	r.pending = nil
+
+	report, err := r.writeBundle(fatalReason(output), true, output, r.pendingLogs)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Crash] Previous run crashed, bundle written to %s", report.Path)
+	return report, nil
+}
+
+// Recover writes a bundle for a panic in the calling goroutine and panics
+// again. It must be deferred directly:
+//
+//	defer reporter.Recover()
+func (r *Reporter) Recover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	r.ReportPanic(v)
+
+	// 已写入崩溃包，不再让运行时把同一次崩溃写入 fatal.log
+	debug.SetCrashOutput(nil, debug.CrashOptions{})
+	panic(v)
+}
+
+// ReportPanic writes a bundle for a recovered panic value, with the stacks of
+// all goroutines. Only the first crash is reported; panics while writing the
+// bundle are ignored.
+func (r *Reporter) ReportPanic(v any) *Report {
+	if !r.writing.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer func() { recover() }()
+
+	report, err := r.writeBundle(fmt.Sprint(v), false, allStacks(), r.tailLogs())
+	if err != nil {
+		log.Printf("[Crash] Failed to write crash bundle: %v", err)
+		return nil
+	}
+	log.Printf("[Crash] Crash bundle written to %s", report.Path)
+	return report
+}
+
+func (r *Reporter) tailLogs() []string {
+	if r.logTail == nil {
+		return nil
+	}
+	return r.logTail(LogLines)
+}
+
+// writeBundle creates the bundle directory with the stacks, logs and sections.
+func (r *Reporter) writeBundle(reason string, fatal bool, stacks []byte, logs []string) (*Report, error) {
+	now := r.now()
+	id := bundlePrefix + now.Format(idTimeFormat)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(r.dir, id)); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s%s-%d", bundlePrefix, now.Format(idTimeFormat), i)
+	}
+	path := filepath.Join(r.dir, id)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	report := &Report{ID: id, CreatedAt: now, Reason: reason, Fatal: fatal, Path: path}
+
+	var crash bytes.Buffer
+	fmt.Fprintf(&crash, "Reason: %s\nTime: %s\nGo: %s %s/%s\n\n", reason, now.Format(time.RFC3339), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	crash.Write(stacks)
+	if err := os.WriteFile(filepath.Join(path, "crash.txt"), crash.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	if len(logs) > 0 {
+		if err := os.WriteFile(filepath.Join(path, "logs.txt"), []byte(strings.Join(logs, "\n")+"\n"), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	names := append([]string(nil), r.names...)
+	sections := make(map[string]Section, len(r.sections))
+	for name, section := range r.sections {
+		sections[name] = section
+	}
+	r.mu.Unlock()
+
+	for _, name := range names {
+		writeJSON(filepath.Join(path, name+".json"), runSection(sections[name]))
+	}
+	if err := writeJSON(filepath.Join(path, reportFile), report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// runSection calls a section, turning errors and panics into the section content.
+// A section that blocks, e.g. on a lock held by the crashed code, is abandoned.
+func runSection(section Section) any {
+	result := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				result <- map[string]string{"error": fmt.Sprintf("panic: %v", p)}
+			}
+		}()
+		v, err := section()
+		if err != nil {
+			result <- map[string]string{"error": err.Error()}
+			return
+		}
+		result <- v
+	}()
+
+	select {
+	case v := <-result:
+		return v
+	case <-time.After(sectionTimeout):
+		return map[string]string{"error": "timed out"}
+	}
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// allStacks returns the stacks of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// fatalReason extracts the "panic: ..." or "fatal error: ..." line from runtime output.
+func fatalReason(output []byte) string {
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "panic: ") || strings.HasPrefix(line, "fatal error: ") {
+			return line
+		}
+	}
+	return "unknown crash"
+}
@@ -0,0 +1,122 @@
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CrashService lets the UI list crash bundles, offer them after a crash and
+// open their folder.
+type CrashService struct {
+	reporter *Reporter
+	openPath func(path string) error
+}
+
+// NewCrashService creates the service for the bundles of reporter.
+// openPath opens a folder in the file manager.
+func NewCrashService(reporter *Reporter, openPath func(path string) error) *CrashService {
+	return &CrashService{reporter: reporter, openPath: openPath}
+}
+
+// ListCrashes returns the crash bundles, newest first.
+func (s *CrashService) ListCrashes() ([]Report, error) {
+	entries, err := os.ReadDir(s.reporter.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Report{}, nil
+		}
+		return nil, err
+	}
+
+	reports := []Report{}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), bundlePrefix) {
+			continue
+		}
+		report, err := s.readReport(entry.Name())
+		if err != nil {
+			continue
+		}
+		reports = append(reports, *report)
+	}
+	slices.SortFunc(reports, func(a, b Report) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return reports, nil
+}
+
+// GetUnseenCrashes returns the bundles that have not been shown to the user.
+// The UI checks it on launch and offers to open the bundle folder.
+func (s *CrashService) GetUnseenCrashes() ([]Report, error) {
+	reports, err := s.ListCrashes()
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(reports, func(r Report) bool { return r.Seen }), nil
+}
+
+// MarkSeen marks a bundle as shown, so that it is not offered again.
+func (s *CrashService) MarkSeen(id string) error {
+	dir, err := s.bundleDir(id)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, seenFile), nil, 0644)
+}
+
+// OpenCrashFolder opens a bundle in the file manager and marks it as seen.
+// An empty id opens the folder with all bundles.
+func (s *CrashService) OpenCrashFolder(id string) error {
+	dir := s.reporter.dir
+	if id != "" {
+		var err error
+		if dir, err = s.bundleDir(id); err != nil {
+			return err
+		}
+		s.MarkSeen(id)
+	}
+	if s.openPath == nil {
+		return fmt.Errorf("不支持打开文件夹")
+	}
+	return s.openPath(dir)
+}
+
+// DeleteCrash deletes a bundle.
+func (s *CrashService) DeleteCrash(id string) error {
+	dir, err := s.bundleDir(id)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (s *CrashService) readReport(id string) (*Report, error) {
+	dir := filepath.Join(s.reporter.dir, id)
+	data, err := os.ReadFile(filepath.Join(dir, reportFile))
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	report.ID, report.Path = id, dir
+	if _, err := os.Stat(filepath.Join(dir, seenFile)); err == nil {
+		report.Seen = true
+	}
+	return &report, nil
+}
+
+// bundleDir validates id and returns the bundle directory.
+func (s *CrashService) bundleDir(id string) (string, error) {
+	if !strings.HasPrefix(id, bundlePrefix) || id != filepath.Base(id) {
+		return "", fmt.Errorf("无效的崩溃报告: %s", id)
+	}
+	dir := filepath.Join(s.reporter.dir, id)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("崩溃报告不存在: %s", id)
+	}
+	return dir, nil
+}
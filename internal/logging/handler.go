@@ -0,0 +1,208 @@
+// Package logging provides the application-wide structured logger.
+//
+// All records go to a rotating JSON log file in the data directory, to an
+// in-memory ring buffer that the log viewer reads, and to stderr. Plugins log
+// through a child logger from For, so that their records can be filtered and
+// their level changed independently at runtime.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginKey is the attribute that marks records of a plugin.
+const PluginKey = "plugin"
+
+// Entry is a single log record as shown in the log viewer and written to the log file.
+type Entry struct {
+	ID        int64             `json:"id,omitempty"`
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Plugin    string            `json:"plugin,omitempty"`
+	Component string            `json:"component,omitempty"` // "[Name]" prefix of legacy messages
+	Message   string            `json:"message"`
+	Attrs     map[string]string `json:"attrs,omitempty"`
+}
+
+// For returns the logger of a plugin. It is a child of the default logger, so
+// it must be called after the log service is set up; plugins should call it
+// when logging rather than keep the result in a package variable.
+func For(pluginID string) *slog.Logger {
+	return slog.Default().With(PluginKey, pluginID)
+}
+
+// levels holds the default level and the per-plugin overrides.
+type levels struct {
+	mu      sync.RWMutex
+	def     slog.Level
+	plugins map[string]slog.Level
+}
+
+func (l *levels) enabled(plugin string, level slog.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if lvl, ok := l.plugins[plugin]; ok && plugin != "" {
+		return level >= lvl
+	}
+	return level >= l.def
+}
+
+// sink receives the records that pass the level check.
+type sink struct {
+	mu      sync.Mutex
+	file    io.Writer // may be nil if the log directory is not writable
+	console io.Writer // may be nil
+	ring    *ring
+}
+
+func (s *sink) write(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e = s.ring.add(e)
+	if s.file != nil {
+		line, err := marshalLine(e)
+		if err == nil {
+			s.file.Write(line)
+		}
+	}
+	if s.console != nil {
+		fmt.Fprintln(s.console, formatText(e))
+	}
+}
+
+// handler is the slog.Handler behind the default logger.
+type handler struct {
+	levels *levels
+	sink   *sink
+	plugin string
+	attrs  []slog.Attr // resolved, with group prefixes applied
+	prefix string      // current group prefix, "a.b."
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.levels.enabled(h.plugin, level)
+}
+
+func (h *handler) Handle(_ context.Context, r slog.Record) error {
+	e := Entry{
+		Time:    r.Time,
+		Level:   r.Level.String(),
+		Plugin:  h.plugin,
+		Message: strings.TrimRight(r.Message, "\n"),
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	attrs := make(map[string]string, len(h.attrs)+r.NumAttrs())
+	for _, a := range h.attrs {
+		attrs[a.Key] = a.Value.String()
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if h.prefix == "" && a.Key == PluginKey {
+			e.Plugin = a.Value.String()
+			return true
+		}
+		addAttr(attrs, h.prefix, a)
+		return true
+	})
+	if len(attrs) > 0 {
+		e.Attrs = attrs
+	}
+
+	// 兼容旧代码的 "[Name] message" 格式
+	if e.Plugin == "" {
+		e.Component, e.Message = splitPrefix(e.Message)
+	}
+
+	// 插件级别只能在 Handle 中确定的情况（调用时传入 plugin 属性）
+	if e.Plugin != h.plugin && !h.levels.enabled(e.Plugin, r.Level) {
+		return nil
+	}
+
+	h.sink.write(e)
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if h.prefix == "" && a.Key == PluginKey {
+			c.plugin = a.Value.String()
+			continue
+		}
+		m := map[string]string{}
+		addAttr(m, h.prefix, a)
+		for k, v := range m {
+			c.attrs = append(c.attrs, slog.String(k, v))
+		}
+	}
+	return &c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// addAttr flattens a into m, joining group names with dots.
+func addAttr(m map[string]string, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		p := prefix
+		if a.Key != "" {
+			p += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(m, p, ga)
+		}
+		return
+	}
+	m[prefix+a.Key] = a.Value.String()
+}
+
+// splitPrefix splits "[Name] message" into its parts.
+func splitPrefix(msg string) (component, rest string) {
+	if !strings.HasPrefix(msg, "[") {
+		return "", msg
+	}
+	end := strings.Index(msg, "]")
+	if end < 2 || end > 40 {
+		return "", msg
+	}
+	return msg[1:end], strings.TrimSpace(msg[end+1:])
+}
+
+// formatText formats an entry for the console.
+func formatText(e Entry) string {
+	var b strings.Builder
+	b.WriteString(e.Time.Format("2006/01/02 15:04:05 "))
+	b.WriteString(e.Level)
+	switch {
+	case e.Plugin != "":
+		fmt.Fprintf(&b, " [%s]", e.Plugin)
+	case e.Component != "":
+		fmt.Fprintf(&b, " [%s]", e.Component)
+	}
+	b.WriteByte(' ')
+	b.WriteString(e.Message)
+	for _, k := range sortedKeys(e.Attrs) {
+		fmt.Fprintf(&b, " %s=%q", k, e.Attrs[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"archive/zip"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestService(t *testing.T) *LogService {
+	t.Helper()
+	s := NewLogService(t.TempDir(), "1.2.3")
+	s.sink.console = nil
+	t.Cleanup(func() { s.ServiceShutdown() })
+	return s
+}
+
+func TestPluginLevelsAndFilter(t *testing.T) {
+	s := newTestService(t)
+
+	For("clipboard.builtin").Debug("hidden")
+	if err := s.SetLevel("clipboard.builtin", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	For("clipboard.builtin").Debug("changed", "length", 3)
+	For("kanban.builtin").Debug("other plugin stays at info")
+	log.Printf("[Main] legacy message\n")
+
+	entries := s.Tail(0, 0)
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if e := entries[0]; e.Plugin != "clipboard.builtin" || e.Message != "changed" || e.Attrs["length"] != "3" {
+		t.Fatalf("plugin entry = %+v", e)
+	}
+	if e := entries[1]; e.Component != "Main" || e.Message != "legacy message" {
+		t.Fatalf("legacy entry = %+v", e)
+	}
+
+	got, err := s.Query(Filter{Plugin: "Main"})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Query by component = %+v, %v", got, err)
+	}
+	if got, _ := s.Query(Filter{Level: "info"}); len(got) != 1 {
+		t.Fatalf("Query by level = %+v", got)
+	}
+	if tail := s.Tail(entries[0].ID, 0); len(tail) != 1 || tail[0].ID != entries[1].ID {
+		t.Fatalf("Tail after first = %+v", tail)
+	}
+	if err := s.SetLevel("", "verbose"); err == nil {
+		t.Fatal("SetLevel accepted an unknown level")
+	}
+
+	// 级别在重启后保留
+	s2 := NewLogService(s.dataDir, "")
+	s2.sink.console = nil
+	defer s2.ServiceShutdown()
+	if levels := s2.GetLevels(); levels.Plugins["clipboard.builtin"] != "DEBUG" {
+		t.Fatalf("levels after reload = %+v", levels)
+	}
+}
+
+func TestRotationAndExport(t *testing.T) {
+	s := newTestService(t)
+
+	big := strings.Repeat("x", 64<<10)
+	for range maxFileSize/len(big) + 10 {
+		For("test").Info(big)
+	}
+	if _, err := os.Stat(s.file.backupPath(1)); err != nil {
+		t.Fatalf("log file not rotated: %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "logs.zip")
+	if err := s.Export(out); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	want := "info.json logs/ltools.1.log logs/ltools.log"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("export contains %q, want %q", got, want)
+	}
+}
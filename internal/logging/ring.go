@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+)
+
+// ring keeps the most recent entries in memory for the log viewer.
+// It is not safe for concurrent use; the sink serializes access.
+type ring struct {
+	entries []Entry
+	next    int   // index of the next write
+	full    bool  // whether the buffer has wrapped
+	lastID  int64 // ID of the newest entry
+}
+
+func newRing(size int) *ring {
+	return &ring{entries: make([]Entry, size)}
+}
+
+// add stores e with the next ID and returns it.
+func (r *ring) add(e Entry) Entry {
+	r.lastID++
+	e.ID = r.lastID
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	return e
+}
+
+// snapshot returns the entries, oldest first.
+func (r *ring) snapshot() []Entry {
+	if !r.full {
+		return slices.Clone(r.entries[:r.next])
+	}
+	return append(slices.Clone(r.entries[r.next:]), r.entries[:r.next]...)
+}
+
+// marshalLine encodes e as a line of the log file. IDs are only meaningful
+// within a session and are left out.
+func marshalLine(e Entry) ([]byte, error) {
+	e.ID = 0
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	return slices.Sorted(maps.Keys(m))
+}
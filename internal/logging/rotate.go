@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	logFileName = "ltools.log"
+
+	// maxFileSize is the size at which the log file is rotated,
+	// maxBackups the number of rotated files kept (ltools.1.log is the newest).
+	maxFileSize = 5 << 20
+	maxBackups  = 5
+)
+
+// rotatingFile is an append-only log file that is rotated by size.
+// It is not safe for concurrent use; the sink serializes access.
+type rotatingFile struct {
+	dir  string
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(dir string) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log dir: %w", err)
+	}
+	r := &rotatingFile{dir: dir}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(filepath.Join(r.dir, logFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > maxFileSize {
+		if err := r.rotate(); err != nil && r.f == nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts ltools.N.log to ltools.N+1.log, dropping the oldest, and starts a new file.
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+
+	os.Remove(r.backupPath(maxBackups))
+	for i := maxBackups - 1; i >= 1; i-- {
+		os.Rename(r.backupPath(i), r.backupPath(i+1))
+	}
+	if err := os.Rename(filepath.Join(r.dir, logFileName), r.backupPath(1)); err != nil {
+		// 继续写入当前文件，下次写入时再尝试
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) backupPath(n int) string {
+	base := strings.TrimSuffix(logFileName, filepath.Ext(logFileName))
+	return filepath.Join(r.dir, fmt.Sprintf("%s.%d%s", base, n, filepath.Ext(logFileName)))
+}
+
+// files returns the existing log files, oldest first.
+func (r *rotatingFile) files() []string {
+	var files []string
+	for i := maxBackups; i >= 1; i-- {
+		if _, err := os.Stat(r.backupPath(i)); err == nil {
+			files = append(files, r.backupPath(i))
+		}
+	}
+	return append(files, filepath.Join(r.dir, logFileName))
+}
+
+func (r *rotatingFile) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
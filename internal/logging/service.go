@@ -0,0 +1,421 @@
+package logging
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	configFile = "logging.json"
+	logDirName = "logs"
+
+	// ringSize is the number of entries kept in memory for the log viewer.
+	ringSize = 5000
+
+	// defaultTailLimit caps Tail when no limit is given.
+	defaultTailLimit = 500
+)
+
+// Levels is the logging configuration, saved as logging.json in the data directory.
+type Levels struct {
+	// Default is the minimum level of records without an override.
+	Default string `json:"default"`
+
+	// Plugins overrides the level of individual plugins, by plugin ID.
+	Plugins map[string]string `json:"plugins,omitempty"`
+}
+
+// Filter selects entries in Query.
+type Filter struct {
+	Plugin string `json:"plugin"` // plugin ID or component name, empty for all
+	Level  string `json:"level"`  // minimum level, empty for all
+	Text   string `json:"text"`   // case-insensitive substring of the message or attributes
+	Limit  int    `json:"limit"`  // maximum number of newest entries, 0 for all
+}
+
+// LogService installs the structured logger as the process default and lets
+// the UI read, filter and export the logs and change levels at runtime.
+type LogService struct {
+	dataDir string
+	version string
+	levels  *levels
+	sink    *sink
+	file    *rotatingFile // nil if the log file could not be opened
+
+	mu sync.Mutex // serializes SetLevel
+}
+
+// NewLogService creates the log service and makes its logger the default for
+// slog and the standard log package. Logging keeps working in memory and on
+// stderr if the log directory is not writable.
+func NewLogService(dataDir, version string) *LogService {
+	s := &LogService{
+		dataDir: dataDir,
+		version: version,
+		levels:  &levels{def: slog.LevelInfo, plugins: map[string]slog.Level{}},
+		sink:    &sink{console: os.Stderr, ring: newRing(ringSize)},
+	}
+
+	if err := s.loadLevels(); err != nil {
+		fmt.Fprintf(os.Stderr, "[LogService] Failed to load %s: %v\n", configFile, err)
+	}
+
+	file, err := openRotatingFile(filepath.Join(dataDir, logDirName))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[LogService] Log file disabled: %v\n", err)
+	} else {
+		s.file = file
+		s.sink.file = file
+	}
+
+	slog.SetDefault(slog.New(s.handler()))
+	return s
+}
+
+func (s *LogService) handler() *handler {
+	return &handler{levels: s.levels, sink: s.sink}
+}
+
+// ServiceShutdown closes the log file. Later records only go to memory and stderr.
+func (s *LogService) ServiceShutdown() error {
+	s.sink.mu.Lock()
+	defer s.sink.mu.Unlock()
+	s.sink.file = nil
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
+
+// Tail returns the entries logged after afterID, at most limit of the newest.
+// The viewer polls it with the ID of the last entry it has.
+func (s *LogService) Tail(afterID int64, limit int) []Entry {
+	if limit <= 0 {
+		limit = defaultTailLimit
+	}
+	entries := s.snapshot()
+	i, _ := slices.BinarySearchFunc(entries, afterID+1, func(e Entry, id int64) int {
+		return int(e.ID - id)
+	})
+	entries = entries[i:]
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// Query returns the entries in memory that match the filter, oldest first.
+func (s *LogService) Query(filter Filter) ([]Entry, error) {
+	var minLevel slog.Level
+	if filter.Level != "" {
+		l, err := parseLevel(filter.Level)
+		if err != nil {
+			return nil, err
+		}
+		minLevel = l
+	}
+	text := strings.ToLower(filter.Text)
+
+	var result []Entry
+	for _, e := range s.snapshot() {
+		if filter.Plugin != "" && e.Plugin != filter.Plugin && e.Component != filter.Plugin {
+			continue
+		}
+		if filter.Level != "" {
+			if l, err := parseLevel(e.Level); err == nil && l < minLevel {
+				continue
+			}
+		}
+		if text != "" && !entryContains(e, text) {
+			continue
+		}
+		result = append(result, e)
+	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result, nil
+}
+
+// Sources returns the plugin IDs and component names of the entries in memory,
+// for the viewer's filter list.
+func (s *LogService) Sources() []string {
+	seen := map[string]bool{}
+	for _, e := range s.snapshot() {
+		switch {
+		case e.Plugin != "":
+			seen[e.Plugin] = true
+		case e.Component != "":
+			seen[e.Component] = true
+		}
+	}
+	sources := make([]string, 0, len(seen))
+	for name := range seen {
+		sources = append(sources, name)
+	}
+	slices.Sort(sources)
+	return sources
+}
+
+// RecentLines returns the last n log lines as text, oldest first. They are read
+// from the log file, so right after startup they are those of the previous run.
+func (s *LogService) RecentLines(n int) []string {
+	var entries []Entry
+	if s.file != nil {
+		s.sink.mu.Lock()
+		entries = readTail(filepath.Join(s.file.dir, logFileName), n)
+		s.sink.mu.Unlock()
+	} else {
+		entries = s.Tail(0, n)
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = formatText(e)
+	}
+	return lines
+}
+
+// GetLevels returns the current log levels.
+func (s *LogService) GetLevels() Levels {
+	s.levels.mu.RLock()
+	defer s.levels.mu.RUnlock()
+
+	levels := Levels{Default: s.levels.def.String(), Plugins: map[string]string{}}
+	for id, l := range s.levels.plugins {
+		levels.Plugins[id] = l.String()
+	}
+	return levels
+}
+
+// SetLevel changes the level of a plugin, or the default level if pluginID is
+// empty. An empty level removes the plugin's override. The change takes effect
+// immediately and is saved.
+func (s *LogService) SetLevel(pluginID, level string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if level == "" {
+		if pluginID == "" {
+			return fmt.Errorf("默认日志级别不能为空")
+		}
+		s.levels.mu.Lock()
+		delete(s.levels.plugins, pluginID)
+		s.levels.mu.Unlock()
+		return s.saveLevels()
+	}
+
+	l, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	s.levels.mu.Lock()
+	if pluginID == "" {
+		s.levels.def = l
+	} else {
+		s.levels.plugins[pluginID] = l
+	}
+	s.levels.mu.Unlock()
+	return s.saveLevels()
+}
+
+// Export writes a zip archive with the log files and some information about
+// the system to path, for attaching to bug reports.
+func (s *LogService) Export(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	zw := zip.NewWriter(f)
+
+	err = s.writeExport(zw)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("导出日志失败: %w", err)
+	}
+	return nil
+}
+
+func (s *LogService) writeExport(zw *zip.Writer) error {
+	info := map[string]any{
+		"version":    s.version,
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"goVersion":  runtime.Version(),
+		"exportedAt": time.Now().Format(time.RFC3339),
+		"levels":     s.GetLevels(),
+	}
+	w, err := zw.Create("info.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(info); err != nil {
+		return err
+	}
+
+	if s.file == nil {
+		// 没有日志文件时导出内存中的记录
+		w, err := zw.Create(filepath.ToSlash(filepath.Join(logDirName, logFileName)))
+		if err != nil {
+			return err
+		}
+		for _, e := range s.snapshot() {
+			line, err := marshalLine(e)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 持有 sink 锁，避免导出写到一半的行或轮转中的文件
+	s.sink.mu.Lock()
+	defer s.sink.mu.Unlock()
+	for _, path := range s.file.files() {
+		if err := addFile(zw, path, logDirName+"/"+filepath.Base(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addFile(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// readTail parses the last n entries of a log file. Only the end of the file is
+// read; lines that are not valid entries are skipped.
+func readTail(path string, n int) []Entry {
+	const maxRead = 1 << 20
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil
+	}
+	offset := max(info.Size()-maxRead, 0)
+	data := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if offset > 0 && len(lines) > 0 {
+		lines = lines[1:] // 第一行可能不完整
+	}
+	var entries []Entry
+	for _, line := range lines[max(len(lines)-n, 0):] {
+		var e Entry
+		if json.Unmarshal([]byte(line), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (s *LogService) snapshot() []Entry {
+	s.sink.mu.Lock()
+	defer s.sink.mu.Unlock()
+	return s.sink.ring.snapshot()
+}
+
+func (s *LogService) loadLevels() error {
+	data, err := os.ReadFile(filepath.Join(s.dataDir, configFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var cfg Levels
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	if cfg.Default != "" {
+		if l, err := parseLevel(cfg.Default); err == nil {
+			s.levels.def = l
+		}
+	}
+	for id, level := range cfg.Plugins {
+		if l, err := parseLevel(level); err == nil {
+			s.levels.plugins[id] = l
+		}
+	}
+	return nil
+}
+
+func (s *LogService) saveLevels() error {
+	data, err := json.MarshalIndent(s.GetLevels(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(s.dataDir, configFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to save %s: %w", configFile, err)
+	}
+	return nil
+}
+
+// parseLevel accepts the slog level names (debug, info, warn, error) in any case.
+func parseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("无效的日志级别: %s", s)
+	}
+	return l, nil
+}
+
+func entryContains(e Entry, text string) bool {
+	if strings.Contains(strings.ToLower(e.Message), text) {
+		return true
+	}
+	for k, v := range e.Attrs {
+		if strings.Contains(strings.ToLower(k+"="+v), text) {
+			return true
+		}
+	}
+	return false
+}
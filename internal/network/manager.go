@@ -0,0 +1,246 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Manager owns the network configuration and the shared HTTP transport.
+// Clients created by NewClient route through the manager's current transport,
+// so configuration changes apply to them without being recreated.
+type Manager struct {
+	mu        sync.RWMutex
+	filePath  string
+	config    *Config
+	transport atomic.Pointer[http.Transport]
+}
+
+// defaultManager is used by NewClient.
+var defaultManager = newManager()
+
+// Default returns the process-wide network manager.
+func Default() *Manager {
+	return defaultManager
+}
+
+// newManager creates a manager with the default configuration.
+func newManager() *Manager {
+	m := &Manager{config: DefaultConfig()}
+	transport, _ := m.buildTransport(m.config)
+	m.transport.Store(transport)
+	return m
+}
+
+// NewClient creates an HTTP client that uses the app-wide network configuration.
+// timeout limits the whole request including reading the body; 0 means no limit.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: defaultManager,
+		Timeout:   timeout,
+	}
+}
+
+// Load reads the configuration from dataDir/network.json and applies it.
+// A missing file keeps the defaults.
+func (m *Manager) Load(dataDir string) error {
+	m.mu.Lock()
+	m.filePath = filepath.Join(dataDir, "network.json")
+	m.mu.Unlock()
+
+	data, err := os.ReadFile(m.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	config := DefaultConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		return err
+	}
+	return m.apply(config)
+}
+
+// Config returns a copy of the current configuration.
+func (m *Manager) Config() *Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config.Clone()
+}
+
+// SetConfig validates, applies and saves a new configuration.
+func (m *Manager) SetConfig(config *Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := m.apply(config.Clone()); err != nil {
+		return err
+	}
+	return m.save()
+}
+
+// RoundTrip implements http.RoundTripper using the current transport.
+func (m *Manager) RoundTrip(req *http.Request) (*http.Response, error) {
+	return m.transport.Load().RoundTrip(req)
+}
+
+// CloseIdleConnections closes idle connections of the current transport.
+func (m *Manager) CloseIdleConnections() {
+	m.transport.Load().CloseIdleConnections()
+}
+
+// Route returns the proxy used for targetURL, or "direct".
+func (m *Manager) Route(targetURL string) (string, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return "", err
+	}
+
+	proxyURL, err := m.proxyFor(m.Config())(&http.Request{URL: u})
+	if err != nil {
+		return "", err
+	}
+	if proxyURL == nil {
+		return RouteDirect, nil
+	}
+	safe := *proxyURL
+	safe.User = nil // Don't expose credentials
+	return safe.String(), nil
+}
+
+// apply builds a transport for config and makes it current.
+func (m *Manager) apply(config *Config) error {
+	transport, err := m.buildTransport(config)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.config = config
+	m.mu.Unlock()
+
+	old := m.transport.Swap(transport)
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+// save writes the configuration to disk.
+func (m *Manager) save() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.filePath == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.filePath), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m.config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.filePath, data, 0644)
+}
+
+// buildTransport creates an HTTP transport for config.
+func (m *Manager) buildTransport(config *Config) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(config.ConnectTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 m.proxyFor(config),
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   time.Duration(config.TLSHandshakeTimeout) * time.Second,
+		ResponseHeaderTimeout: time.Duration(config.ResponseHeaderTimeout) * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if config.CABundle != "" {
+		pool, err := loadCertPool(config.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return transport, nil
+}
+
+// proxyFor returns the transport proxy function for config.
+func (m *Manager) proxyFor(config *Config) func(*http.Request) (*url.URL, error) {
+	switch config.Mode {
+	case ProxyModeDirect:
+		return func(*http.Request) (*url.URL, error) { return nil, nil }
+	case ProxyModeSystem:
+		return http.ProxyFromEnvironment
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		host := req.URL.Hostname()
+
+		// Loopback traffic never goes through a proxy
+		if host == "localhost" {
+			return nil, nil
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+			return nil, nil
+		}
+
+		route := RouteProxy
+		for _, rule := range config.Rules {
+			if rule.match(host) {
+				route = rule.Route
+				break
+			}
+		}
+
+		switch route {
+		case RouteDirect:
+			return nil, nil
+		case RouteProxy:
+			if config.ProxyURL == "" {
+				return nil, nil
+			}
+			return parseProxyURL(config.ProxyURL)
+		default:
+			return parseProxyURL(route)
+		}
+	}
+}
+
+// loadCertPool returns the system roots plus the certificates in path.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取 CA 证书文件: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA 证书文件中没有有效的 PEM 证书: %s", path)
+	}
+	return pool, nil
+}
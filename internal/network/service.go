@@ -0,0 +1,82 @@
+package network
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// TestResult is the outcome of a connection test.
+type TestResult struct {
+	Success    bool   `json:"success"`
+	Route      string `json:"route"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	LatencyMs  int64  `json:"latencyMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Service exposes the network configuration to the frontend.
+type Service struct {
+	manager *Manager
+}
+
+// NewService loads the network configuration from dataDir and creates a Service.
+// If the saved configuration cannot be applied, the defaults stay in effect.
+func NewService(dataDir string) *Service {
+	manager := Default()
+	if err := manager.Load(dataDir); err != nil {
+		log.Printf("[NetworkService] Failed to load network config, using defaults: %v", err)
+	}
+	return &Service{manager: manager}
+}
+
+// GetConfig returns the current network configuration.
+func (s *Service) GetConfig() *Config {
+	return s.manager.Config()
+}
+
+// SetConfig applies and saves a new network configuration.
+// All HTTP clients pick up the change on their next request.
+func (s *Service) SetConfig(config *Config) error {
+	return s.manager.SetConfig(config)
+}
+
+// GetRoute returns the proxy that would be used for targetURL, or "direct".
+func (s *Service) GetRoute(targetURL string) (string, error) {
+	return s.manager.Route(targetURL)
+}
+
+// TestConnection requests targetURL with the current configuration.
+func (s *Service) TestConnection(targetURL string) *TestResult {
+	result := &TestResult{}
+
+	route, err := s.manager.Route(targetURL)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Route = route
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, targetURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	resp, err := NewClient(0).Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Success = true
+	return result
+}
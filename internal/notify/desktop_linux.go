@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// notificationTimeout lets the notification server choose how long a
+// notification is shown.
+const notificationTimeout = int32(-1)
+
+// freedesktop sends notifications to the org.freedesktop.Notifications
+// service on the session bus, which every Linux desktop provides.
+type freedesktop struct {
+	mu  sync.Mutex
+	ids map[string]uint32 // Notification.ID -> 服务器分配的 ID，用于替换
+}
+
+func desktopNotifier() Notifier {
+	return &freedesktop{ids: make(map[string]uint32)}
+}
+
+func (f *freedesktop) Notify(n Notification) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	replaces := f.ids[n.ID]
+	obj := conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+	call := obj.Call("org.freedesktop.Notifications.Notify", 0,
+		"LTools", replaces, "", n.Title, n.Body, []string{}, map[string]dbus.Variant{}, notificationTimeout)
+	if call.Err != nil {
+		return call.Err
+	}
+	var id uint32
+	if err := call.Store(&id); err == nil && n.ID != "" {
+		f.ids[n.ID] = id
+	}
+	return nil
+}
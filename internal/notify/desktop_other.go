@@ -0,0 +1,9 @@
+//go:build !linux
+
+package notify
+
+// desktopNotifier returns nil: on Windows and macOS notifications are shown
+// by the webview, which has the notification permission.
+func desktopNotifier() Notifier {
+	return nil
+}
@@ -0,0 +1,76 @@
+// Package notify shows notifications to the user. Features send through the
+// Notifier interface, so they do not depend on how a platform delivers
+// notifications and can be tested without a desktop.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// Notification is a message for the user.
+type Notification struct {
+	// ID identifies the source, e.g. a timer; where supported a notification
+	// replaces the previous one with the same ID.
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// Func adapts a function to Notifier.
+type Func func(n Notification) error
+
+// Notify calls f.
+func (f Func) Notify(n Notification) error {
+	return f(n)
+}
+
+// Event emits the notification:show event with "title|body", which the
+// frontend shows as a system notification with the web Notification API.
+// It works on every platform, but only while the app is running a window.
+func Event(app *application.App) Notifier {
+	return Func(func(n Notification) error {
+		// 标题中的 | 会被当作分隔符
+		app.Event.Emit("notification:show", fmt.Sprintf("%s|%s", strings.ReplaceAll(n.Title, "|", "/"), n.Body))
+		return nil
+	})
+}
+
+// Chain tries the notifiers in order until one delivers the notification.
+// Nil notifiers are skipped.
+func Chain(notifiers ...Notifier) Notifier {
+	return Func(func(n Notification) error {
+		var errs []error
+		for _, notifier := range notifiers {
+			if notifier == nil {
+				continue
+			}
+			err := notifier.Notify(n)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return errors.New("no notifier available")
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// New returns the notifier for this platform: the desktop's notification
+// service where one is supported, falling back to Event.
+func New(app *application.App) Notifier {
+	if desktop := desktopNotifier(); desktop != nil {
+		return Chain(desktop, Event(app))
+	}
+	return Event(app)
+}
@@ -110,6 +110,14 @@ const void* GetImageFromClipboard() {
     }
 }
 
+// GetChangeCount returns the pasteboard change count, which increases on
+// every change of ownership of the pasteboard
+long GetChangeCount() {
+    @autoreleasepool {
+        return [[NSPasteboard generalPasteboard] changeCount];
+    }
+}
+
 // GetImageDataLength returns the length of the image data
 size_t GetImageDataLength() {
     if (g_imageData == nil) {
@@ -193,3 +201,10 @@ func (c *ImageClipboard) GetImage() ([]byte, error) {
 	//log.Printf("[Clipboard] ✓ Retrieved image from clipboard (%d bytes)", len(imgData))
 	return imgData, nil
 }
+
+// ChangeCount returns the change count of the general pasteboard. It changes
+// whenever something is copied, so comparing it is a cheap way to detect a
+// change without reading the clipboard.
+func ChangeCount() int64 {
+	return int64(C.GetChangeCount())
+}
@@ -3,6 +3,7 @@ package plugins
 import (
 	"errors"
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
@@ -60,7 +61,7 @@ func (m *Manager) Register(plugin Plugin) error {
 	currentlyEnabled := plugin.Enabled()
 
 	if shouldBeEnabled != currentlyEnabled {
-		fmt.Printf("[Manager] Syncing plugin %s enabled state: metadata.State=%s, plugin.Enabled()=%v, setting to %v\n",
+		log.Printf("[Manager] Syncing plugin %s enabled state: metadata.State=%s, plugin.Enabled()=%v, setting to %v",
 			metadata.ID, metadata.State, currentlyEnabled, shouldBeEnabled)
 		plugin.SetEnabled(shouldBeEnabled)
 	}
@@ -133,7 +134,7 @@ func (m *Manager) Enable(id string) error {
 	}
 
 	// DEBUG: Log the state change
-	fmt.Printf("[Plugin Manager] Enabled plugin %s, state = %s\n", id, metadata.State)
+	log.Printf("[Plugin Manager] Enabled plugin %s, state = %s", id, metadata.State)
 
 	return nil
 }
@@ -356,3 +357,37 @@ func (m *Manager) ShutdownAll() error {
 
 	return nil
 }
+
+// Quiesce flushes the registry and asks plugins implementing Quiescer to pause
+// their writes. The returned function resumes them.
+func (m *Manager) Quiesce() (resume func(), err error) {
+	if err := m.registry.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush registry: %w", err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var paused []Quiescer
+	resume = func() {
+		for _, q := range paused {
+			if err := q.Resume(); err != nil {
+				log.Printf("[Manager] Failed to resume plugin %s: %v", q.Metadata().ID, err)
+			}
+		}
+	}
+
+	for _, plugin := range m.plugins {
+		q, ok := plugin.(Quiescer)
+		if !ok || !plugin.Enabled() {
+			continue
+		}
+		if err := q.Quiesce(); err != nil {
+			resume()
+			return nil, fmt.Errorf("failed to quiesce plugin %s: %w", plugin.Metadata().ID, err)
+		}
+		paused = append(paused, q)
+	}
+
+	return resume, nil
+}
@@ -1,7 +1,7 @@
 package plugins
 
 import (
-	"fmt"
+	"log"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
@@ -71,6 +71,12 @@ type PluginMetadata struct {
 	Pinned      *bool  `json:"pinned,omitempty"`      // 是否固定到顶部
 	PinnedAt    string `json:"pinnedAt,omitempty"`    // 固定时间（RFC3339）
 	Score       int    `json:"score,omitempty"`       // 加权使用分数（衰减算法）
+	// DataPaths 插件在数据目录中拥有的文件或目录（相对路径，目录以 / 结尾），用于按插件选择同步
+	DataPaths []string `json:"dataPaths,omitempty"`
+	// LocalDataFields 仅保留在本机、不参与同步的 JSON 字段，格式为 "文件:字段路径"，如 "sticky.json:notes.*.x"
+	LocalDataFields []string `json:"localDataFields,omitempty"`
+	// AllowedDomains 声明网络权限时允许通过代理访问的域名（包含子域名），为空表示不限制
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
 }
 
 // Plugin defines the interface that all plugins must implement
@@ -109,6 +115,19 @@ type ViewLifecycle interface {
 	OnViewLeave(app *application.App) error
 }
 
+// Quiescer defines optional methods for plugins that write to the data directory
+// in the background. The backup service calls Quiesce before archiving the data
+// directory and Resume afterwards, so that the archive is a consistent snapshot.
+type Quiescer interface {
+	Plugin
+
+	// Quiesce flushes pending writes and pauses further writes until Resume
+	Quiesce() error
+
+	// Resume continues the writes paused by Quiesce
+	Resume() error
+}
+
 // BasePlugin provides a default implementation for common plugin functionality
 // Other plugins can embed this struct to get default behavior
 type BasePlugin struct {
@@ -119,7 +138,7 @@ type BasePlugin struct {
 // NewBasePlugin creates a new BasePlugin with the given metadata
 func NewBasePlugin(metadata *PluginMetadata) *BasePlugin {
 	// Debug: print initial value
-	fmt.Printf("[BasePlugin] Plugin %s: ShowInMenu initial value: %v (nil=%v)\n",
+	log.Printf("[BasePlugin] Plugin %s: ShowInMenu initial value: %v (nil=%v)",
 		metadata.ID, metadata.ShowInMenu, metadata.ShowInMenu == nil)
 
 	// Set default values for optional pointer fields
@@ -127,20 +146,20 @@ func NewBasePlugin(metadata *PluginMetadata) *BasePlugin {
 	if metadata.ShowInMenu == nil {
 		trueValue := true
 		metadata.ShowInMenu = &trueValue
-		fmt.Printf("[BasePlugin] Plugin %s: ShowInMenu defaulted to true\n", metadata.ID)
+		log.Printf("[BasePlugin] Plugin %s: ShowInMenu defaulted to true", metadata.ID)
 	} else {
 		// 保留显式设置的值（可能是 true 或 false）
-		fmt.Printf("[BasePlugin] Plugin %s: ShowInMenu explicitly set to %v\n", metadata.ID, *metadata.ShowInMenu)
+		log.Printf("[BasePlugin] Plugin %s: ShowInMenu explicitly set to %v", metadata.ID, *metadata.ShowInMenu)
 	}
 
 	// 如果 HasPage 为 nil（未设置），默认为 true
 	if metadata.HasPage == nil {
 		trueValue := true
 		metadata.HasPage = &trueValue
-		fmt.Printf("[BasePlugin] Plugin %s: HasPage defaulted to true\n", metadata.ID)
+		log.Printf("[BasePlugin] Plugin %s: HasPage defaulted to true", metadata.ID)
 	} else {
 		// 保留显式设置的值（可能是 true 或 false）
-		fmt.Printf("[BasePlugin] Plugin %s: HasPage explicitly set to %v\n", metadata.ID, *metadata.HasPage)
+		log.Printf("[BasePlugin] Plugin %s: HasPage explicitly set to %v", metadata.ID, *metadata.HasPage)
 	}
 
 	return &BasePlugin{
@@ -3,7 +3,7 @@ package plugins
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
+	"log"
 	"math"
 	"os"
 	"path/filepath"
@@ -82,6 +82,13 @@ func (r *Registry) save() error {
 	return nil
 }
 
+// Flush writes changes held back by the debounced save
+func (r *Registry) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save()
+}
+
 // Register registers a plugin in the registry
 func (r *Registry) Register(metadata *PluginMetadata) error {
 	r.mu.Lock()
@@ -90,12 +97,12 @@ func (r *Registry) Register(metadata *PluginMetadata) error {
 	// If plugin already exists in registry, preserve its state
 	if existing, ok := r.plugins[metadata.ID]; ok {
 		// Preserve the saved state and enabled status
-		fmt.Printf("[Registry] Plugin %s already exists with state %s, preserving state\n", metadata.ID, existing.State)
+		log.Printf("[Registry] Plugin %s already exists with state %s, preserving state", metadata.ID, existing.State)
 		metadata.State = existing.State
 		// Copy all other fields from existing metadata that should be preserved
 		// (Currently only state needs to be preserved)
 	} else {
-		fmt.Printf("[Registry] Registering new plugin %s with state %s\n", metadata.ID, metadata.State)
+		log.Printf("[Registry] Registering new plugin %s with state %s", metadata.ID, metadata.State)
 	}
 
 	r.plugins[metadata.ID] = metadata
@@ -177,18 +184,18 @@ func (r *Registry) Update(metadata *PluginMetadata) error {
 	}
 
 	// DEBUG: Log before update
-	fmt.Printf("[Registry] Updating plugin %s, state = %s (pointer: %p)\n", metadata.ID, metadata.State, metadata)
+	log.Printf("[Registry] Updating plugin %s, state = %s (pointer: %p)", metadata.ID, metadata.State, metadata)
 
 	r.plugins[metadata.ID] = metadata
 	r.dirty = true
 
 	if err := r.save(); err != nil {
-		fmt.Printf("[Registry] Failed to save: %v\n", err)
+		log.Printf("[Registry] Failed to save: %v", err)
 		return err
 	}
 
 	// DEBUG: Verify save worked
-	fmt.Printf("[Registry] Successfully saved plugin %s\n", metadata.ID)
+	log.Printf("[Registry] Successfully saved plugin %s", metadata.ID)
 
 	return nil
 }
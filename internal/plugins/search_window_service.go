@@ -16,17 +16,40 @@ type AppLauncherService interface {
 	LaunchApp(appID string) error
 }
 
+// Calculator 接口定义，搜索框中输入的算式和换算由计算器插件给出结果
+type Calculator interface {
+	Answer(query string) (string, bool)
+}
+
+// CommandProvider 接口定义，插件可以把搜索框中的输入当作命令执行，例如 "timer 25m"
+type CommandProvider interface {
+	// Command 返回命令的说明；ok 为 false 表示输入不是该插件的命令
+	Command(query string) (title, description string, ok bool)
+	RunCommand(query string) error
+}
+
+// commandProvider 记录命令提供者所属的插件，用于显示图标
+type commandProvider struct {
+	pluginID string
+	CommandProvider
+}
+
 // SearchWindowService manages the global search window (Spotlight/Alfred-like)
 type SearchWindowService struct {
 	app                   *application.App
 	pluginService         *PluginService
 	shortcutService       *ShortcutService
 	appLauncherService    AppLauncherService         `json:"-"` // Exclude from JSON serialization
+	calculator            Calculator                 `json:"-"`
+	commandProviders      []commandProvider          `json:"-"`
 	searchWindow          *application.WebviewWindow
 	mainWindow            *application.WebviewWindow // 主窗口引用
 	isVisible             bool
 	lastPosition          *windowPosition
 	searchHotkeyPluginID  string // Plugin ID for the search hotkey
+	maxResults            int    // 目录列表的最大条数
+	includeApps           bool   // 是否在结果中包含已安装的应用
+	includePaths          bool   // 是否将路径形式的查询作为文件搜索
 	mu                    sync.RWMutex
 }
 
@@ -43,10 +66,11 @@ type SearchResult struct {
 	Description   string   `json:"description"`
 	Icon          string   `json:"icon"`
 	MatchedFields []string `json:"matchedFields"` // Fields that matched the search query
-	Type          string   `json:"type"`          // "plugin", "app", or "file"
+	Type          string   `json:"type"`          // "plugin", "app", "file", "calculation" or "command"
 	AppID         string   `json:"appId,omitempty"`   // For apps
 	Path          string   `json:"path,omitempty"`    // For file/directory paths
 	IsDirectory   bool     `json:"isDirectory,omitempty"` // Whether the path is a directory
+	Command       string   `json:"command,omitempty"`     // For commands: the query to run
 }
 
 // NewSearchWindowService creates a new search window service
@@ -56,6 +80,9 @@ func NewSearchWindowService(app *application.App, pluginService *PluginService,
 		pluginService:   pluginService,
 		shortcutService: shortcutService,
 		isVisible:       false,
+		maxResults:      50,
+		includeApps:     true,
+		includePaths:    true,
 	}
 }
 
@@ -66,6 +93,29 @@ func (s *SearchWindowService) SetAppLauncherService(service AppLauncherService)
 	s.appLauncherService = service
 }
 
+// SetCalculator 设置计算器，用于在搜索结果中显示计算结果
+func (s *SearchWindowService) SetCalculator(calculator Calculator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calculator = calculator
+}
+
+// AddCommandProvider 添加插件的命令提供者，其命令显示在插件搜索结果之前
+func (s *SearchWindowService) AddCommandProvider(pluginID string, provider CommandProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commandProviders = append(s.commandProviders, commandProvider{pluginID: pluginID, CommandProvider: provider})
+}
+
+// SetSearchOptions 设置搜索选项（由设置服务同步）
+func (s *SearchWindowService) SetSearchOptions(maxResults int, includeApps, includePaths bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxResults = maxResults
+	s.includeApps = includeApps
+	s.includePaths = includePaths
+}
+
 // SetMainWindow 设置主窗口引用
 func (s *SearchWindowService) SetMainWindow(window *application.WebviewWindow) {
 	s.mu.Lock()
@@ -215,9 +265,17 @@ func (s *SearchWindowService) Search(query string) ([]*SearchResult, error) {
 
 	results := make([]*SearchResult, 0)
 
+	s.mu.RLock()
+	includePaths := s.includePaths
+	includeApps := s.includeApps
+	appLauncher := s.appLauncherService
+	calculator := s.calculator
+	commandProviders := s.commandProviders
+	s.mu.RUnlock()
+
 	// 1. 先检测文件/目录路径（优先级最高）
 	pathInfo := DetectPath(query)
-	if pathInfo.IsValid && pathInfo.Exists {
+	if includePaths && pathInfo.IsValid && pathInfo.Exists {
 		if pathInfo.IsDirectory {
 			// 如果是目录，首先添加目录本身作为第一个结果
 			pathResult, err := s.SearchPath(query)
@@ -246,7 +304,33 @@ func (s *SearchWindowService) Search(query string) ([]*SearchResult, error) {
 		}
 	}
 
-	// 2. 搜索插件（仅当没有路径匹配时）
+	// 2. 计算结果排在插件前面，例如 "2*(3+4)"、"5 km in miles"
+	if calculator != nil {
+		if answer, ok := calculator.Answer(query); ok {
+			results = append(results, &SearchResult{
+				Name:        answer,
+				Description: fmt.Sprintf("%s = %s，回车复制结果", query, answer),
+				Icon:        s.getPluginIcon("calculator.builtin"),
+				Type:        "calculation",
+			})
+		}
+	}
+
+	// 3. 插件命令，例如 "timer 25m"、"remind 10m 喝水"
+	for _, provider := range commandProviders {
+		if title, description, ok := provider.Command(query); ok {
+			results = append(results, &SearchResult{
+				PluginID:    provider.pluginID,
+				Name:        title,
+				Description: description,
+				Icon:        s.getPluginIcon(provider.pluginID),
+				Type:        "command",
+				Command:     query,
+			})
+		}
+	}
+
+	// 4. 搜索插件（仅当没有路径匹配时）
 	plugins := s.pluginService.List()
 	for _, plugin := range plugins {
 		// Skip disabled plugins
@@ -268,10 +352,28 @@ func (s *SearchWindowService) Search(query string) ([]*SearchResult, error) {
 		}
 	}
 
-	// 注意：应用搜索由前端直接调用 AppLauncherService.Search() 处理
-	// 这样可以避免 Wails 绑定警告，并保持更清晰的职责分离
+	// 5. 已安装的应用（设置中可关闭）
+	if includeApps && appLauncher != nil {
+		apps, err := appLauncher.Search(query)
+		if err != nil {
+			s.app.Logger.Warn(fmt.Sprintf("[SearchWindowService] App search failed: %v", err))
+		}
+		for _, app := range apps {
+			icon := app.IconData
+			if icon == "" {
+				icon = "🚀"
+			}
+			results = append(results, &SearchResult{
+				AppID:       app.ID,
+				Name:        app.Name,
+				Description: app.Description,
+				Icon:        icon,
+				Type:        "app",
+			})
+		}
+	}
 
-	s.app.Logger.Info(fmt.Sprintf("[SearchWindowService] Found %d plugin results", len(results)))
+	s.app.Logger.Info(fmt.Sprintf("[SearchWindowService] Found %d results", len(results)))
 	return results, nil
 }
 
@@ -316,7 +418,32 @@ func (s *SearchWindowService) OpenApp(appID string) error {
 	return s.Hide()
 }
 
-// OpenItem 根据类型打开插件、应用或文件路径（统一接口）
+// CopyResult 复制计算结果并隐藏搜索窗口
+func (s *SearchWindowService) CopyResult(text string) error {
+	if !s.app.Clipboard.SetText(text) {
+		return fmt.Errorf("failed to copy result to clipboard")
+	}
+	return s.Hide()
+}
+
+// RunCommand 执行插件命令并隐藏搜索窗口
+func (s *SearchWindowService) RunCommand(query string) error {
+	s.mu.RLock()
+	commandProviders := s.commandProviders
+	s.mu.RUnlock()
+
+	for _, provider := range commandProviders {
+		if _, _, ok := provider.Command(query); ok {
+			if err := provider.RunCommand(query); err != nil {
+				return err
+			}
+			return s.Hide()
+		}
+	}
+	return fmt.Errorf("unknown command: %s", query)
+}
+
+// OpenItem 根据类型打开插件、应用或文件路径，复制计算结果或执行命令（统一接口）
 func (s *SearchWindowService) OpenItem(resultType, id string) error {
 	switch resultType {
 	case "plugin":
@@ -325,6 +452,10 @@ func (s *SearchWindowService) OpenItem(resultType, id string) error {
 		return s.OpenApp(id)
 	case "file":
 		return s.OpenPath(id)
+	case "calculation":
+		return s.CopyResult(id)
+	case "command":
+		return s.RunCommand(id)
 	default:
 		return fmt.Errorf("unknown result type: %s", resultType)
 	}
@@ -613,7 +744,9 @@ func (s *SearchWindowService) ListDirectory(dirPath string) ([]*SearchResult, er
 	}
 
 	// 限制返回数量，避免结果过多
-	const maxResults = 50
+	s.mu.RLock()
+	maxResults := s.maxResults
+	s.mu.RUnlock()
 	if len(results) > maxResults {
 		results = results[:maxResults]
 	}
@@ -1,34 +1,47 @@
 package proxy
 
+import "log"
+
+// musicPlayerPluginID 音乐播放器插件 ID，与插件元数据一致，以便应用插件策略和统计
+const musicPlayerPluginID = "musicplayer.builtin"
+
 // MusicPlayerAdapter 适配器，让 ProxyManager 兼容 musicplayer 的 ProxyHandler 接口
 type MusicPlayerAdapter struct {
-	manager  *ProxyManager
-	pluginName string
+	manager *ProxyManager
+	service *ProxyService
 }
 
 // NewMusicPlayerAdapter 创建适配器
 func NewMusicPlayerAdapter(manager *ProxyManager) *MusicPlayerAdapter {
 	return &MusicPlayerAdapter{
-		manager:    manager,
-		pluginName: "musicplayer",
+		manager: manager,
+		service: NewProxyService(manager, musicPlayerPluginID),
 	}
 }
 
 // RegisterAudioURL 注册音频 URL（实现 musicplayer.ProxyHandler 接口）
-// 返回代理 URL
+// 返回代理 URL，被插件策略拒绝时返回空字符串
 func (a *MusicPlayerAdapter) RegisterAudioURL(resourceID, remoteURL string) string {
-	return a.manager.RegisterAudio(a.pluginName, resourceID, remoteURL)
+	proxyURL, err := a.service.RegisterAudio(resourceID, remoteURL)
+	if err != nil {
+		log.Printf("[MusicPlayerAdapter] Failed to register audio: %v", err)
+	}
+	return proxyURL
 }
 
 // RegisterImageURL 注册图片 URL（实现 musicplayer.ProxyHandler 接口）
-// 返回代理 URL
+// 返回代理 URL，被插件策略拒绝时返回空字符串
 func (a *MusicPlayerAdapter) RegisterImageURL(resourceID, remoteURL string) string {
-	return a.manager.RegisterImage(a.pluginName, resourceID, remoteURL)
+	proxyURL, err := a.service.RegisterImage(resourceID, remoteURL)
+	if err != nil {
+		log.Printf("[MusicPlayerAdapter] Failed to register image: %v", err)
+	}
+	return proxyURL
 }
 
 // GetAudioURL 获取音频的真实 URL（实现 musicplayer.ProxyHandler 接口）
 func (a *MusicPlayerAdapter) GetAudioURL(resourceID string) string {
-	return a.manager.GetAudioURL(a.pluginName, resourceID)
+	return a.service.GetResourceURL(ResourceTypeAudio, resourceID)
 }
 
 // GetURLByFullID 通过完整的资源 ID（哈希值）直接获取 URL（实现 musicplayer.ProxyHandler 接口）
@@ -0,0 +1,528 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// diskCacheIndexFile 缓存索引文件名
+	diskCacheIndexFile = "index.json"
+	// diskCacheCheckpoint 下载过程中每写入这么多字节保存一次索引，用于断点续传
+	diskCacheCheckpoint = 4 << 20
+)
+
+// cachedHeaders 需要随缓存一起保存的响应头
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Content-Disposition"}
+
+// byteRange 字节区间 [Start, End)
+type byteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// rangeSet 有序且互不重叠的已缓存区间
+type rangeSet []byteRange
+
+// add 添加区间并合并相邻/重叠的区间
+func (s rangeSet) add(start, end int64) rangeSet {
+	if end <= start {
+		return s
+	}
+
+	merged := make(rangeSet, 0, len(s)+1)
+	inserted := false
+	for _, r := range s {
+		switch {
+		case r.End < start:
+			merged = append(merged, r)
+		case r.Start > end:
+			if !inserted {
+				merged = append(merged, byteRange{start, end})
+				inserted = true
+			}
+			merged = append(merged, r)
+		default:
+			// 重叠或相邻，扩展待插入区间
+			start = min(start, r.Start)
+			end = max(end, r.End)
+		}
+	}
+	if !inserted {
+		merged = append(merged, byteRange{start, end})
+	}
+	return merged
+}
+
+// nextGap 返回 [start, end) 中第一个未缓存的偏移量，全部已缓存时返回 end
+func (s rangeSet) nextGap(start, end int64) int64 {
+	for _, r := range s {
+		if r.Start <= start && start < r.End {
+			start = r.End
+		}
+	}
+	return min(start, end)
+}
+
+// covers 判断 [start, end) 是否已全部缓存
+func (s rangeSet) covers(start, end int64) bool {
+	return s.nextGap(start, end) >= end
+}
+
+// total 已缓存的字节数
+func (s rangeSet) total() int64 {
+	var n int64
+	for _, r := range s {
+		n += r.End - r.Start
+	}
+	return n
+}
+
+// diskEntry 磁盘缓存条目
+// 下载中的内容保存在 partial/<Key>，下载完成后按内容哈希移动到 blobs/ 下，
+// 相同内容的不同 URL 共享同一个文件
+type diskEntry struct {
+	Key        string      `json:"key"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header,omitempty"`
+	Size       int64       `json:"size"` // 资源总大小，-1 表示未知
+	Segments   rangeSet    `json:"segments,omitempty"`
+	Hash       string      `json:"hash,omitempty"` // 完整内容的 SHA-256
+	LastAccess time.Time   `json:"lastAccess"`
+
+	active int // 正在使用该条目的请求数
+}
+
+// complete 判断内容是否已完整缓存
+func (e *diskEntry) complete() bool {
+	return e.Hash != "" || (e.Size >= 0 && e.Segments.covers(0, e.Size))
+}
+
+// diskEntryState 条目状态快照
+type diskEntryState struct {
+	Header   http.Header
+	Size     int64
+	Segments rangeSet
+	Complete bool
+}
+
+// DiskCache 磁盘缓存（按内容寻址，LRU 淘汰）
+type DiskCache struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	entries map[string]*diskEntry
+	unsaved int64 // 自上次保存索引以来写入的字节数
+}
+
+// OpenDiskCache 打开（或创建）磁盘缓存目录，并恢复上次的缓存索引
+func OpenDiskCache(dir string, maxSize int64) (*DiskCache, error) {
+	for _, sub := range []string{"partial", "blobs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+
+	c := &DiskCache{
+		dir:     dir,
+		maxSize: maxSize,
+		entries: make(map[string]*diskEntry),
+	}
+
+	if err := c.load(); err != nil {
+		log.Printf("[DiskCache] Failed to load index, starting empty: %v", err)
+		c.entries = make(map[string]*diskEntry)
+	}
+	c.removeOrphans()
+
+	c.mu.Lock()
+	c.evictLocked()
+	c.saveLocked()
+	c.mu.Unlock()
+
+	return c, nil
+}
+
+// cacheKey 根据远程 URL 生成条目键
+func cacheKey(remoteURL string) string {
+	sum := sha256.Sum256([]byte(remoteURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// acquire 获取（必要时创建）URL 对应的条目，使用完毕后必须调用 release
+func (c *DiskCache) acquire(remoteURL string) *diskEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(remoteURL)
+	e, ok := c.entries[key]
+	if !ok {
+		e = &diskEntry{Key: key, URL: remoteURL, Size: -1}
+		c.entries[key] = e
+	}
+	e.active++
+	e.LastAccess = time.Now()
+	return e
+}
+
+// release 释放条目，完整下载的内容会被移动到内容寻址存储
+func (c *DiskCache) release(e *diskEntry) {
+	c.mu.Lock()
+	e.active--
+	finalize := e.active == 0 && e.Hash == "" && e.complete()
+	c.mu.Unlock()
+
+	if finalize {
+		if err := c.finalize(e); err != nil {
+			log.Printf("[DiskCache] Failed to finalize %s: %v", e.Key, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e.active == 0 && e.Hash == "" && len(e.Segments) == 0 {
+		// 没有缓存任何内容的条目不需要保留
+		if c.entries[e.Key] == e {
+			delete(c.entries, e.Key)
+		}
+		os.Remove(c.partialPath(e.Key))
+	}
+	c.evictLocked()
+	c.saveLocked()
+}
+
+// state 返回条目的状态快照
+func (c *DiskCache) state(e *diskEntry) diskEntryState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return diskEntryState{
+		Header:   e.Header.Clone(),
+		Size:     e.Size,
+		Segments: append(rangeSet(nil), e.Segments...),
+		Complete: e.complete(),
+	}
+}
+
+// update 记录上游响应的元数据。若校验信息（ETag、Last-Modified、大小）
+// 与已缓存内容不一致，说明远程资源已变化，丢弃已缓存的部分
+func (c *DiskCache) update(e *diskEntry, header http.Header, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := false
+	for _, name := range []string{"ETag", "Last-Modified"} {
+		if old, cur := e.Header.Get(name), header.Get(name); old != "" && cur != "" && old != cur {
+			changed = true
+		}
+	}
+	if e.Size >= 0 && size >= 0 && e.Size != size {
+		changed = true
+	}
+	if changed && e.Hash == "" {
+		log.Printf("[DiskCache] Remote content changed, discarding cached data: %s", e.URL)
+		e.Segments = nil
+		os.Truncate(c.partialPath(e.Key), 0)
+	}
+
+	stored := make(http.Header)
+	for _, name := range cachedHeaders {
+		if v := header.Get(name); v != "" {
+			stored.Set(name, v)
+		}
+	}
+	e.Header = stored
+	if size >= 0 {
+		e.Size = size
+	}
+}
+
+// openRead 打开条目的内容文件用于读取
+func (c *DiskCache) openRead(e *diskEntry) (*os.File, error) {
+	c.mu.Lock()
+	path := c.partialPath(e.Key)
+	if e.Hash != "" {
+		path = c.blobPath(e.Hash)
+	}
+	c.mu.Unlock()
+	return os.Open(path)
+}
+
+// openWrite 打开条目的下载文件用于写入
+func (c *DiskCache) openWrite(e *diskEntry) (*os.File, error) {
+	return os.OpenFile(c.partialPath(e.Key), os.O_RDWR|os.O_CREATE, 0644)
+}
+
+// written 记录已写入磁盘的区间
+func (c *DiskCache) written(e *diskEntry, offset int64, n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.Segments = e.Segments.add(offset, offset+int64(n))
+	c.unsaved += int64(n)
+	if c.unsaved >= diskCacheCheckpoint {
+		c.saveLocked()
+	}
+}
+
+// accepts 判断指定大小的资源是否可以放入缓存
+func (c *DiskCache) accepts(size int64) bool {
+	return c.maxSize <= 0 || size <= c.maxSize
+}
+
+// finalize 计算完整内容的哈希并移动到内容寻址存储
+func (c *DiskCache) finalize(e *diskEntry) error {
+	c.mu.Lock()
+	size := e.Size
+	c.mu.Unlock()
+
+	partial := c.partialPath(e.Key)
+	f, err := os.Open(partial)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, io.NewSectionReader(f, 0, size))
+	f.Close()
+	if err != nil {
+		return err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 计算哈希期间条目可能被再次使用或重置
+	if e.active > 0 || e.Hash != "" || !e.complete() {
+		return nil
+	}
+
+	blob := c.blobPath(hash)
+	if _, err := os.Stat(blob); err == nil {
+		// 相同内容已存在，复用
+		os.Remove(partial)
+	} else {
+		if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return err
+		}
+		if err := os.Truncate(partial, size); err != nil {
+			return err
+		}
+		if err := os.Rename(partial, blob); err != nil {
+			return err
+		}
+	}
+
+	e.Hash = hash
+	e.Segments = rangeSet{{0, size}}
+	return nil
+}
+
+// usageLocked 计算缓存占用的磁盘空间（共享的内容文件只计算一次）
+func (c *DiskCache) usageLocked() int64 {
+	var total int64
+	blobs := make(map[string]bool)
+	for _, e := range c.entries {
+		if e.Hash == "" {
+			total += e.Segments.total()
+		} else if !blobs[e.Hash] {
+			blobs[e.Hash] = true
+			total += e.Size
+		}
+	}
+	return total
+}
+
+// evictLocked 按最近访问时间淘汰条目，直到占用不超过上限
+func (c *DiskCache) evictLocked() {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	usage := c.usageLocked()
+	if usage <= c.maxSize {
+		return
+	}
+
+	candidates := make([]*diskEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		if e.active == 0 {
+			candidates = append(candidates, e)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastAccess.Before(candidates[j].LastAccess)
+	})
+
+	for _, e := range candidates {
+		if usage <= c.maxSize {
+			break
+		}
+		c.removeLocked(e)
+		usage = c.usageLocked()
+	}
+}
+
+// removeLocked 删除条目及不再被引用的文件
+func (c *DiskCache) removeLocked(e *diskEntry) {
+	delete(c.entries, e.Key)
+
+	if e.Hash == "" {
+		os.Remove(c.partialPath(e.Key))
+		return
+	}
+	for _, other := range c.entries {
+		if other.Hash == e.Hash {
+			return
+		}
+	}
+	os.Remove(c.blobPath(e.Hash))
+}
+
+// Clear 清空磁盘缓存（正在使用的条目除外）
+func (c *DiskCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range c.entries {
+		if e.active == 0 {
+			c.removeLocked(e)
+		}
+	}
+	c.saveLocked()
+}
+
+// Stats 返回条目数、已用空间和空间上限
+func (c *DiskCache) Stats() (entries int, used, limit int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries), c.usageLocked(), c.maxSize
+}
+
+// load 读取缓存索引，丢弃文件已丢失或损坏的条目
+func (c *DiskCache) load() error {
+	data, err := os.ReadFile(filepath.Join(c.dir, diskCacheIndexFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []*diskEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		// 索引可能被改坏，哈希会拼进文件路径，必须是 SHA-256 的十六进制形式
+		if e == nil || e.Key != cacheKey(e.URL) || (e.Hash != "" && !validHash(e.Hash)) {
+			continue
+		}
+
+		path := c.partialPath(e.Key)
+		if e.Hash != "" {
+			path = c.blobPath(e.Hash)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		// 文件比记录的区间短（例如写入中途崩溃），无法信任已记录的内容
+		if len(e.Segments) > 0 && info.Size() < e.Segments[len(e.Segments)-1].End {
+			continue
+		}
+		if e.Hash != "" && info.Size() != e.Size {
+			continue
+		}
+
+		c.entries[e.Key] = e
+	}
+	return nil
+}
+
+// removeOrphans 删除没有被索引引用的文件
+func (c *DiskCache) removeOrphans() {
+	c.mu.Lock()
+	referenced := make(map[string]bool)
+	for _, e := range c.entries {
+		if e.Hash != "" {
+			referenced[c.blobPath(e.Hash)] = true
+		} else {
+			referenced[c.partialPath(e.Key)] = true
+		}
+	}
+	c.mu.Unlock()
+
+	for _, sub := range []string{"partial", "blobs"} {
+		filepath.Walk(filepath.Join(c.dir, sub), func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && !referenced[path] {
+				os.Remove(path)
+			}
+			return nil
+		})
+	}
+}
+
+// saveLocked 原子地写入缓存索引
+func (c *DiskCache) saveLocked() {
+	c.unsaved = 0
+
+	entries := make([]*diskEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		if len(e.Segments) > 0 || e.Hash != "" {
+			entries = append(entries, e)
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		log.Printf("[DiskCache] Failed to encode index: %v", err)
+		return
+	}
+
+	path := filepath.Join(c.dir, diskCacheIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("[DiskCache] Failed to write index: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("[DiskCache] Failed to replace index: %v", err)
+	}
+}
+
+// partialPath 下载中内容的文件路径
+func (c *DiskCache) partialPath(key string) string {
+	return filepath.Join(c.dir, "partial", key)
+}
+
+// validHash 判断是否为小写十六进制的 SHA-256
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, ch := range hash {
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// blobPath 完整内容的文件路径，hash 必须通过 validHash 校验
+func (c *DiskCache) blobPath(hash string) string {
+	return filepath.Join(c.dir, "blobs", hash[:2], hash)
+}
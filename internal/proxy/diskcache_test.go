@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rangeOrigin serves content with Range support and records the Range headers it receives.
+type rangeOrigin struct {
+	content []byte
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (o *rangeOrigin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	o.ranges = append(o.ranges, r.Header.Get("Range"))
+	o.mu.Unlock()
+
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Header().Set("ETag", `"v1"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(o.content))
+}
+
+func (o *rangeOrigin) requests() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.ranges...)
+}
+
+func newCachedProxy(t *testing.T, dir string) *ProxyManager {
+	t.Helper()
+
+	config := DefaultProxyConfig()
+	config.EnableLogging = false
+	config.MaxCacheEntries = 0
+	pm := NewProxyManager(config)
+	if err := pm.SetCacheDir(dir); err != nil {
+		t.Fatalf("SetCacheDir: %v", err)
+	}
+	return pm
+}
+
+func get(t *testing.T, pm *ProxyManager, path, rangeHeader string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	rec := httptest.NewRecorder()
+	pm.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDiskCacheRangesAndPersistence(t *testing.T) {
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	origin := &rangeOrigin{content: content}
+	srv := httptest.NewServer(origin)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	pm := newCachedProxy(t, dir)
+	path := pm.RegisterAudio("test", "song", srv.URL+"/song.mp3")
+
+	check := func(rec *httptest.ResponseRecorder, status int, start, end int, xcache string) {
+		t.Helper()
+		if rec.Code != status {
+			t.Fatalf("status = %d, want %d", rec.Code, status)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), content[start:end]) {
+			t.Fatalf("body mismatch for bytes %d-%d (got %d bytes)", start, end-1, rec.Body.Len())
+		}
+		if got := rec.Header().Get("X-Cache"); got != xcache {
+			t.Fatalf("X-Cache = %q, want %q", got, xcache)
+		}
+	}
+
+	// Cold range request is forwarded as is
+	check(get(t, pm, path, "bytes=100-199"), http.StatusPartialContent, 100, 200, "MISS")
+
+	// The same range is now served from disk
+	check(get(t, pm, path, "bytes=100-199"), http.StatusPartialContent, 100, 200, "HIT")
+
+	// A longer range reuses the cached prefix and only fetches the rest
+	check(get(t, pm, path, "bytes=100-499"), http.StatusPartialContent, 100, 500, "PARTIAL")
+
+	// A full request with nothing cached at the start is fetched in one go
+	rec := get(t, pm, path, "")
+	check(rec, http.StatusOK, 0, len(content), "MISS")
+
+	want := []string{"bytes=100-199", "bytes=200-499", ""}
+	if got := origin.requests(); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Fatalf("origin ranges = %q, want %q", got, want)
+	}
+
+	// After a restart the cache is still complete and no request reaches the origin
+	pm = newCachedProxy(t, dir)
+	path = pm.RegisterAudio("test", "song", srv.URL+"/song.mp3")
+	before := len(origin.requests())
+
+	check(get(t, pm, path, ""), http.StatusOK, 0, len(content), "HIT")
+	check(get(t, pm, path, "bytes=900-"), http.StatusPartialContent, 900, 1000, "HIT")
+	check(get(t, pm, path, "bytes=-10"), http.StatusPartialContent, 990, 1000, "HIT")
+
+	if after := len(origin.requests()); after != before {
+		t.Fatalf("origin received %d requests after restart, want 0", after-before)
+	}
+
+	if rec := get(t, pm, path, "bytes=5000-"); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("out of range status = %d, want 416", rec.Code)
+	}
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenDiskCache(dir, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fill := func(url string, size int) {
+		e := cache.acquire(url)
+		cache.update(e, http.Header{}, int64(size))
+		f, err := cache.openWrite(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteAt(bytes.Repeat([]byte(url[:1]), size), 0); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		cache.written(e, 0, size)
+		cache.release(e)
+	}
+
+	fill("a", 100)
+	fill("b", 100) // same size, different content
+	fill("c", 40)
+
+	entries, used, _ := cache.Stats()
+	if entries != 2 || used != 140 {
+		t.Fatalf("Stats() = %d entries, %d bytes; want 2, 140", entries, used)
+	}
+	if _, ok := cache.entries[cacheKey("a")]; ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+}
+
+func TestDiskCacheIgnoresInvalidIndex(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenDiskCache(dir, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	key := cacheKey("a")
+	index := fmt.Sprintf(`[null,
+		{"key":%[1]q,"url":"a","size":1,"hash":"a"},
+		{"key":%[1]q,"url":"a","size":1,"hash":"../../../../etc/passwd"},
+		{"key":%[1]q,"url":"a","size":1,"hash":%[2]q}]`, key, strings.ToUpper(key))
+	if err := os.WriteFile(filepath.Join(dir, diskCacheIndexFile), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := OpenDiskCache(dir, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, _, _ := cache.Stats(); entries != 0 {
+		t.Fatalf("Stats() = %d entries, want 0", entries)
+	}
+}
+
+func TestRangeSet(t *testing.T) {
+	var s rangeSet
+	s = s.add(10, 20)
+	s = s.add(30, 40)
+	s = s.add(20, 25)
+
+	if len(s) != 2 || s[0] != (byteRange{10, 25}) {
+		t.Fatalf("add merged = %v", s)
+	}
+	if gap := s.nextGap(10, 40); gap != 25 {
+		t.Fatalf("nextGap = %d, want 25", gap)
+	}
+	if !s.covers(12, 25) || s.covers(12, 31) {
+		t.Fatalf("covers gave wrong result for %v", s)
+	}
+	if s.total() != 25 {
+		t.Fatalf("total = %d, want 25", s.total())
+	}
+}
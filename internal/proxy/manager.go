@@ -3,6 +3,7 @@ package proxy
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"ltools/internal/network"
 )
 
 // ResourceType 资源类型
@@ -35,6 +38,8 @@ type ProxyConfig struct {
 	CacheTTL time.Duration
 	// 最大缓存条目数
 	MaxCacheEntries int
+	// 磁盘缓存最大字节数（设置缓存目录后生效）
+	MaxCacheSize int64
 	// 是否启用日志
 	EnableLogging bool
 	// 用户代理
@@ -49,6 +54,7 @@ func DefaultProxyConfig() *ProxyConfig {
 		EnableCache:     true,
 		CacheTTL:        30 * time.Minute,
 		MaxCacheEntries: 1000,
+		MaxCacheSize:    1 << 30,
 		EnableLogging:   true,
 		UserAgent:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36",
 	}
@@ -68,6 +74,8 @@ type ProxyManager struct {
 	urlMapping   map[string]string // 资源ID -> 远程URL
 	metadataMap  map[string]*ResourceMetadata
 	cache        map[string]*CacheEntry
+	diskCache    *DiskCache // 磁盘缓存，未设置缓存目录时为 nil
+	policies     map[string]*pluginPolicyState // 插件名 -> 代理策略
 	stats        *ProxyStats
 	mutex        sync.RWMutex
 	cacheMutex   sync.RWMutex
@@ -92,6 +100,7 @@ type ProxyStats struct {
 	FailedRequests  int64
 	TotalBytes      int64
 	AverageLatency  time.Duration
+	Plugins         map[string]*PluginStats // 按插件统计
 	mutex           sync.RWMutex
 }
 
@@ -106,9 +115,11 @@ func NewProxyManager(config *ProxyConfig) *ProxyManager {
 		urlMapping:  make(map[string]string),
 		metadataMap: make(map[string]*ResourceMetadata),
 		cache:       make(map[string]*CacheEntry),
+		policies:    make(map[string]*pluginPolicyState),
 		stats:       &ProxyStats{},
 		httpClient: &http.Client{
-			Timeout: config.RequestTimeout,
+			Transport: network.Default(), // 使用全局网络配置（代理、证书等）
+			Timeout:   config.RequestTimeout,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return http.ErrUseLastResponse // 手动处理重定向
 			},
@@ -123,6 +134,35 @@ func NewProxyManager(config *ProxyConfig) *ProxyManager {
 	return pm
 }
 
+// SetCacheDir 设置磁盘缓存目录
+// 设置后所有 GET 请求的资源都会缓存到磁盘（支持 Range 请求和断点续传），
+// 缓存在重启后仍然有效
+func (pm *ProxyManager) SetCacheDir(dir string) error {
+	if !pm.config.EnableCache {
+		return nil
+	}
+
+	maxSize := pm.config.MaxCacheSize
+	if maxSize <= 0 {
+		maxSize = DefaultProxyConfig().MaxCacheSize
+	}
+
+	diskCache, err := OpenDiskCache(dir, maxSize)
+	if err != nil {
+		return err
+	}
+
+	pm.mutex.Lock()
+	pm.diskCache = diskCache
+	pm.mutex.Unlock()
+
+	if pm.config.EnableLogging {
+		entries, used, _ := diskCache.Stats()
+		log.Printf("[ProxyManager] Disk cache opened: %s (%d entries, %d bytes)", dir, entries, used)
+	}
+	return nil
+}
+
 // RegisterResource 注册资源（通用方法）
 func (pm *ProxyManager) RegisterResource(resourceType ResourceType, pluginName, resourceID, remoteURL string) string {
 	pm.mutex.Lock()
@@ -292,6 +332,7 @@ func (pm *ProxyManager) handleProxy(w http.ResponseWriter, r *http.Request, path
 	pm.mutex.RLock()
 	remoteURL, ok := pm.urlMapping[resourceID]
 	metadata := pm.metadataMap[resourceID]
+	diskCache := pm.diskCache
 	pm.mutex.RUnlock()
 
 	if !ok {
@@ -311,6 +352,22 @@ func (pm *ProxyManager) handleProxy(w http.ResponseWriter, r *http.Request, path
 		pm.mutex.Lock()
 		metadata.HitCount++
 		pm.mutex.Unlock()
+	} else {
+		metadata = &ResourceMetadata{ID: resourceID, Type: resourceType, RemoteURL: remoteURL}
+	}
+	pluginName := metadata.PluginName
+	pm.recordRequest(pluginName)
+
+	// 检查插件策略（网络权限可能在注册后被撤销）
+	if err := pm.checkAccess(pluginName, remoteURL); err != nil {
+		pm.writeFetchError(w, pluginName, err)
+		return
+	}
+
+	// 磁盘缓存（支持 Range 请求）
+	if diskCache != nil && pm.serveWithDiskCache(w, r, diskCache, metadata) {
+		pm.recordLatency(startTime)
+		return
 	}
 
 	// 检查缓存
@@ -323,10 +380,7 @@ func (pm *ProxyManager) handleProxy(w http.ResponseWriter, r *http.Request, path
 			pm.serveFromCache(w, r, cached)
 
 			// 更新统计
-			pm.stats.mutex.Lock()
-			pm.stats.CacheHits++
-			pm.stats.TotalBytes += int64(len(cached.Data))
-			pm.stats.mutex.Unlock()
+			pm.recordCacheHit(pluginName, int64(len(cached.Data)))
 
 			if pm.config.EnableLogging {
 				log.Printf("[ProxyManager] Cache hit: %s (%d bytes)", resourceID, len(cached.Data))
@@ -336,51 +390,77 @@ func (pm *ProxyManager) handleProxy(w http.ResponseWriter, r *http.Request, path
 	}
 
 	// 缓存未命中，代理请求
+	pm.recordCacheMiss(pluginName)
+
+	// 代理请求（带重试）
+	resp, proxyErr := pm.proxyRequestWithRetry(r, remoteURL, resourceID, pluginName)
+	if proxyErr != nil {
+		pm.writeFetchError(w, pluginName, proxyErr)
+		return
+	}
+	defer resp.Body.Close()
+
+	// 处理响应
+	pm.serveResponse(w, r, resp, resourceID, resourceType, remoteURL, pluginName)
+
+	// 更新延迟统计
+	pm.recordLatency(startTime)
+}
+
+// recordLatency 更新延迟统计
+func (pm *ProxyManager) recordLatency(startTime time.Time) {
+	latency := time.Since(startTime)
 	pm.stats.mutex.Lock()
-	pm.stats.CacheMisses++
+	// 简单的移动平均
+	pm.stats.AverageLatency = (pm.stats.AverageLatency + latency) / 2
 	pm.stats.mutex.Unlock()
+}
+
+// proxyRequestWithRetry 执行代理请求，失败时按配置重试
+func (pm *ProxyManager) proxyRequestWithRetry(r *http.Request, remoteURL, resourceID, pluginName string) (*http.Response, error) {
+	if !pm.allowRequest(pluginName) {
+		return nil, ErrRateLimited
+	}
 
-	// 代理请求（带重试）
 	var resp *http.Response
-	var proxyErr error
+	var err error
 
 	for retry := 0; retry <= pm.config.MaxRetries; retry++ {
-		resp, proxyErr = pm.proxyRequest(r, remoteURL)
-		if proxyErr == nil {
+		resp, err = pm.proxyRequest(r, remoteURL, pluginName)
+		if err == nil || errors.Is(err, ErrNetworkNotAllowed) || errors.Is(err, ErrDomainNotAllowed) {
 			break
 		}
 
 		if retry < pm.config.MaxRetries && pm.config.EnableLogging {
-			log.Printf("[ProxyManager] Retry %d/%d for %s: %v", retry+1, pm.config.MaxRetries, resourceID, proxyErr)
+			log.Printf("[ProxyManager] Retry %d/%d for %s: %v", retry+1, pm.config.MaxRetries, resourceID, err)
 		}
 	}
 
-	if proxyErr != nil {
-		pm.stats.mutex.Lock()
-		pm.stats.FailedRequests++
-		pm.stats.mutex.Unlock()
+	return resp, err
+}
 
+// writeFetchError 返回代理请求失败的响应
+func (pm *ProxyManager) writeFetchError(w http.ResponseWriter, pluginName string, err error) {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		pm.recordRejected(pluginName, true)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	case errors.Is(err, ErrNetworkNotAllowed), errors.Is(err, ErrDomainNotAllowed):
+		pm.recordRejected(pluginName, false)
+		http.Error(w, "Forbidden by plugin policy", http.StatusForbidden)
+	default:
+		pm.recordFailure(pluginName)
 		http.Error(w, "Failed to fetch resource", http.StatusBadGateway)
-		if pm.config.EnableLogging {
-			log.Printf("[ProxyManager] Failed to proxy: %v", proxyErr)
-		}
-		return
 	}
-	defer resp.Body.Close()
 
-	// 处理响应
-	pm.serveResponse(w, r, resp, resourceID, resourceType, remoteURL)
-
-	// 更新延迟统计
-	latency := time.Since(startTime)
-	pm.stats.mutex.Lock()
-	// 简单的移动平均
-	pm.stats.AverageLatency = (pm.stats.AverageLatency + latency) / 2
-	pm.stats.mutex.Unlock()
+	if pm.config.EnableLogging {
+		log.Printf("[ProxyManager] Failed to proxy for %s: %v", pluginName, err)
+	}
 }
 
 // proxyRequest 执行代理请求
-func (pm *ProxyManager) proxyRequest(r *http.Request, remoteURL string) (*http.Response, error) {
+func (pm *ProxyManager) proxyRequest(r *http.Request, remoteURL, pluginName string) (*http.Response, error) {
 	// 创建代理请求
 	proxyReq, err := http.NewRequest(r.Method, remoteURL, nil)
 	if err != nil {
@@ -397,6 +477,11 @@ func (pm *ProxyManager) proxyRequest(r *http.Request, remoteURL string) (*http.R
 	// 设置 User-Agent
 	proxyReq.Header.Set("User-Agent", pm.config.UserAgent)
 
+	// 插件附加的请求头（如 Referer、Cookie、Authorization）
+	for key, value := range pm.pluginHeaders(pluginName) {
+		proxyReq.Header.Set(key, value)
+	}
+
 	// 发送请求
 	resp, err := pm.httpClient.Do(proxyReq)
 	if err != nil {
@@ -408,7 +493,14 @@ func (pm *ProxyManager) proxyRequest(r *http.Request, remoteURL string) (*http.R
 		location := resp.Header.Get("Location")
 		if location != "" {
 			resp.Body.Close()
-			return pm.proxyRequest(r, location)
+			// 重定向目标同样需要符合插件的域名白名单
+			if target, err := resp.Request.URL.Parse(location); err == nil {
+				location = target.String()
+			}
+			if err := pm.checkAccess(pluginName, location); err != nil {
+				return nil, err
+			}
+			return pm.proxyRequest(r, location, pluginName)
 		}
 	}
 
@@ -416,7 +508,7 @@ func (pm *ProxyManager) proxyRequest(r *http.Request, remoteURL string) (*http.R
 }
 
 // serveResponse 处理并缓存响应
-func (pm *ProxyManager) serveResponse(w http.ResponseWriter, r *http.Request, resp *http.Response, resourceID string, resourceType ResourceType, remoteURL string) {
+func (pm *ProxyManager) serveResponse(w http.ResponseWriter, r *http.Request, resp *http.Response, resourceID string, resourceType ResourceType, remoteURL, pluginName string) {
 	// 检查状态码
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		http.Error(w, fmt.Sprintf("Server returned status %d", resp.StatusCode), resp.StatusCode)
@@ -451,27 +543,52 @@ func (pm *ProxyManager) serveResponse(w http.ResponseWriter, r *http.Request, re
 		pm.cacheMutex.Unlock()
 
 		// 更新统计
-		pm.stats.mutex.Lock()
-		pm.stats.TotalBytes += int64(len(bodyData))
-		pm.stats.mutex.Unlock()
+		pm.recordBytes(pluginName, int64(len(bodyData)))
 	}
 
 	// 复制响应头
-	for key, values := range resp.Header {
+	pm.writeProxyHeaders(w.Header(), resp.Header, resourceType, remoteURL)
+
+	// 写入响应状态码
+	w.WriteHeader(resp.StatusCode)
+
+	// 写入响应体
+	if bodyData != nil {
+		w.Write(bodyData)
+	} else {
+		// 流式传输（不缓存）
+		written, err := io.Copy(w, resp.Body)
+		if err != nil && !isBrokenPipeError(err) {
+			log.Printf("[ProxyManager] Failed to write response: %v", err)
+			return
+		}
+
+		// 更新统计
+		pm.recordBytes(pluginName, written)
+	}
+
+	if pm.config.EnableLogging {
+		log.Printf("[ProxyManager] Proxied: %s (status: %d)", resourceID, resp.StatusCode)
+	}
+}
+
+// writeProxyHeaders 复制上游响应头，并补充媒体类型修正和 CORS 头
+func (pm *ProxyManager) writeProxyHeaders(h, upstream http.Header, resourceType ResourceType, remoteURL string) {
+	for key, values := range upstream {
 		for _, value := range values {
-			w.Header().Add(key, value)
+			h.Add(key, value)
 		}
 	}
 
 	// 🔧 关键修复：对于音频和视频，添加 Accept-Ranges 头
 	// 某些浏览器需要这个头来确认服务器支持 Range 请求
 	if resourceType == ResourceTypeAudio || resourceType == ResourceTypeVideo {
-		if w.Header().Get("Accept-Ranges") == "" {
-			w.Header().Set("Accept-Ranges", "bytes")
+		if h.Get("Accept-Ranges") == "" {
+			h.Set("Accept-Ranges", "bytes")
 		}
 
 		// 🔧 规范化音频 MIME 类型：Safari 可能不认识某些非标准 MIME 类型
-		contentType := w.Header().Get("Content-Type")
+		contentType := h.Get("Content-Type")
 		normalized := false
 
 		// 如果是通用二进制流，根据 URL 推断 MIME 类型
@@ -480,74 +597,54 @@ func (pm *ProxyManager) serveResponse(w http.ResponseWriter, r *http.Request, re
 			urlLower := strings.ToLower(remoteURL)
 			switch {
 			case strings.Contains(urlLower, ".flac"):
-				w.Header().Set("Content-Type", "audio/flac")
+				h.Set("Content-Type", "audio/flac")
 				normalized = true
 			case strings.Contains(urlLower, ".mp3"):
-				w.Header().Set("Content-Type", "audio/mpeg")
+				h.Set("Content-Type", "audio/mpeg")
 				normalized = true
 			case strings.Contains(urlLower, ".m4a"):
-				w.Header().Set("Content-Type", "audio/mp4")
+				h.Set("Content-Type", "audio/mp4")
 				normalized = true
 			case strings.Contains(urlLower, ".aac"):
-				w.Header().Set("Content-Type", "audio/aac")
+				h.Set("Content-Type", "audio/aac")
 				normalized = true
 			case strings.Contains(urlLower, ".ogg"):
-				w.Header().Set("Content-Type", "audio/ogg")
+				h.Set("Content-Type", "audio/ogg")
 				normalized = true
 			case strings.Contains(urlLower, ".wav"):
-				w.Header().Set("Content-Type", "audio/wav")
+				h.Set("Content-Type", "audio/wav")
 				normalized = true
 			}
 		} else {
 			// 规范化已知的非标准 MIME 类型
 			switch contentType {
 			case "audio/x-flac":
-				w.Header().Set("Content-Type", "audio/flac")
+				h.Set("Content-Type", "audio/flac")
 				normalized = true
 			case "audio/x-ogg", "application/ogg":
-				w.Header().Set("Content-Type", "audio/ogg")
+				h.Set("Content-Type", "audio/ogg")
 				normalized = true
 			case "audio/x-vorbis":
-				w.Header().Set("Content-Type", "audio/vorbis")
+				h.Set("Content-Type", "audio/vorbis")
 				normalized = true
 			}
 		}
 
 		if normalized && pm.config.EnableLogging {
-			log.Printf("[ProxyManager] Normalized MIME type: %s -> %s", contentType, w.Header().Get("Content-Type"))
+			log.Printf("[ProxyManager] Normalized MIME type: %s -> %s", contentType, h.Get("Content-Type"))
 		}
 	}
 
-	// 添加 CORS 头（宽松配置，支持通用场景）
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "*")                                                                                         // 允许所有请求头
-	w.Header().Set("Access-Control-Expose-Headers", "*")                                                                                       // 暴露所有响应头
-	w.Header().Set("Access-Control-Max-Age", "86400")                                                                                          // 24小时缓存
-
-	// 写入响应状态码
-	w.WriteHeader(resp.StatusCode)
-
-	// 写入响应体
-	if bodyData != nil {
-		w.Write(bodyData)
-	} else {
-		// 流式传输（不缓存）
-		written, err := io.Copy(w, resp.Body)
-		if err != nil && !isBrokenPipeError(err) {
-			log.Printf("[ProxyManager] Failed to write response: %v", err)
-			return
-		}
-
-		// 更新统计
-		pm.stats.mutex.Lock()
-		pm.stats.TotalBytes += written
-		pm.stats.mutex.Unlock()
-	}
+	setCORSHeaders(h)
+}
 
-	if pm.config.EnableLogging {
-		log.Printf("[ProxyManager] Proxied: %s (status: %d)", resourceID, resp.StatusCode)
-	}
+// setCORSHeaders 添加 CORS 头（宽松配置，支持通用场景）
+func setCORSHeaders(h http.Header) {
+	h.Set("Access-Control-Allow-Origin", "*")
+	h.Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+	h.Set("Access-Control-Allow-Headers", "*")  // 允许所有请求头
+	h.Set("Access-Control-Expose-Headers", "*") // 暴露所有响应头
+	h.Set("Access-Control-Max-Age", "86400")    // 24小时缓存
 }
 
 // serveFromCache 从缓存提供响应
@@ -559,12 +656,7 @@ func (pm *ProxyManager) serveFromCache(w http.ResponseWriter, r *http.Request, c
 		}
 	}
 
-	// 添加 CORS 头（宽松配置，支持通用场景）
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
-	w.Header().Set("Access-Control-Expose-Headers", "*")
-	w.Header().Set("Access-Control-Max-Age", "86400")
+	setCORSHeaders(w.Header())
 	w.Header().Set("X-Cache", "HIT")
 
 	w.WriteHeader(cached.StatusCode)
@@ -635,7 +727,7 @@ func (pm *ProxyManager) GetStats() map[string]interface{} {
 	pm.cacheMutex.RLock()
 	defer pm.cacheMutex.RUnlock()
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"total_requests":   pm.stats.TotalRequests,
 		"cache_hits":       pm.stats.CacheHits,
 		"cache_misses":     pm.stats.CacheMisses,
@@ -646,10 +738,33 @@ func (pm *ProxyManager) GetStats() map[string]interface{} {
 		"cache_entries":    len(pm.cache),
 		"hit_rate":         float64(pm.stats.CacheHits) / float64(pm.stats.TotalRequests) * 100,
 	}
+
+	// 按插件统计
+	pluginStats := make(map[string]PluginStats, len(pm.stats.Plugins))
+	for name, s := range pm.stats.Plugins {
+		pluginStats[name] = *s
+	}
+	stats["plugins"] = pluginStats
+
+	if pm.diskCache != nil {
+		entries, used, limit := pm.diskCache.Stats()
+		stats["disk_cache_entries"] = entries
+		stats["disk_cache_bytes"] = used
+		stats["disk_cache_limit"] = limit
+	}
+
+	return stats
 }
 
 // ClearCache 清空缓存
 func (pm *ProxyManager) ClearCache() {
+	pm.mutex.RLock()
+	diskCache := pm.diskCache
+	pm.mutex.RUnlock()
+	if diskCache != nil {
+		diskCache.Clear()
+	}
+
 	pm.cacheMutex.Lock()
 	defer pm.cacheMutex.Unlock()
 
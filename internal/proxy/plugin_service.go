@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"ltools/internal/network"
+)
+
+// ProxyService 面向单个插件的代理服务
+// 插件通过它注册远程资源并获得可在前端直接使用的代理 URL，
+// 请求会应用该插件的请求头、域名白名单和频率限制，并单独统计
+type ProxyService struct {
+	manager    *ProxyManager
+	pluginName string
+}
+
+// NewProxyService 创建插件代理服务，pluginName 应为插件 ID
+func NewProxyService(manager *ProxyManager, pluginName string) *ProxyService {
+	return &ProxyService{
+		manager:    manager,
+		pluginName: pluginName,
+	}
+}
+
+// Register 注册资源，返回代理 URL
+// 插件未声明网络权限或地址不在域名白名单中时返回错误
+func (s *ProxyService) Register(resourceType ResourceType, resourceID, remoteURL string) (string, error) {
+	if err := s.manager.checkAccess(s.pluginName, remoteURL); err != nil {
+		return "", fmt.Errorf("%w: %s", err, remoteURL)
+	}
+	return s.manager.RegisterResource(resourceType, s.pluginName, resourceID, remoteURL), nil
+}
+
+// RegisterAudio 注册音频资源
+func (s *ProxyService) RegisterAudio(resourceID, remoteURL string) (string, error) {
+	return s.Register(ResourceTypeAudio, resourceID, remoteURL)
+}
+
+// RegisterImage 注册图片资源
+func (s *ProxyService) RegisterImage(resourceID, remoteURL string) (string, error) {
+	return s.Register(ResourceTypeImage, resourceID, remoteURL)
+}
+
+// RegisterVideo 注册视频资源
+func (s *ProxyService) RegisterVideo(resourceID, remoteURL string) (string, error) {
+	return s.Register(ResourceTypeVideo, resourceID, remoteURL)
+}
+
+// RegisterFile 注册文件资源
+func (s *ProxyService) RegisterFile(resourceID, remoteURL string) (string, error) {
+	return s.Register(ResourceTypeFile, resourceID, remoteURL)
+}
+
+// GetResourceURL 获取已注册资源的远程 URL
+func (s *ProxyService) GetResourceURL(resourceType ResourceType, resourceID string) string {
+	return s.manager.GetResourceURL(resourceType, s.pluginName, resourceID)
+}
+
+// Unregister 注销资源
+func (s *ProxyService) Unregister(resourceType ResourceType, resourceID string) {
+	s.manager.UnregisterResource(resourceType, s.pluginName, resourceID)
+}
+
+// UnregisterAll 注销该插件的所有资源
+func (s *ProxyService) UnregisterAll() {
+	s.manager.UnregisterPlugin(s.pluginName)
+}
+
+// SetHeaders 设置转发请求时附加的请求头（如 Referer、Cookie、Authorization）
+func (s *ProxyService) SetHeaders(headers map[string]string) {
+	s.manager.SetPluginHeaders(s.pluginName, headers)
+}
+
+// SetRateLimit 设置每秒允许的远程请求数，rate 为 0 表示不限制
+func (s *ProxyService) SetRateLimit(rate float64, burst int) {
+	s.manager.SetPluginRateLimit(s.pluginName, rate, burst)
+}
+
+// Client 返回直接访问远程接口用的 HTTP 客户端
+// 请求（包括重定向）同样受插件的网络权限、域名白名单、请求头和频率限制约束，并计入插件统计
+func (s *ProxyService) Client(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &policyTransport{manager: s.manager, pluginName: s.pluginName, base: network.Default()},
+		Timeout:   timeout,
+	}
+}
+
+// Policy 获取当前生效的代理策略
+func (s *ProxyService) Policy() PluginPolicy {
+	return s.manager.GetPluginPolicy(s.pluginName)
+}
+
+// Stats 获取该插件的代理统计
+func (s *ProxyService) Stats() PluginStats {
+	return s.manager.GetPluginStats(s.pluginName)
+}
+
+// policyTransport 在转发前应用插件策略的 RoundTripper
+type policyTransport struct {
+	manager    *ProxyManager
+	pluginName string
+	base       http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	pm := t.manager
+	pm.stats.mutex.Lock()
+	pm.stats.TotalRequests++
+	pm.stats.mutex.Unlock()
+	pm.recordRequest(t.pluginName)
+
+	if err := pm.checkAccess(t.pluginName, req.URL.String()); err != nil {
+		pm.recordRejected(t.pluginName, false)
+		return nil, fmt.Errorf("%w: %s", err, req.URL.Redacted())
+	}
+	if !pm.allowRequest(t.pluginName) {
+		pm.recordRejected(t.pluginName, true)
+		return nil, ErrRateLimited
+	}
+
+	// RoundTripper 不能修改调用方的请求，附加请求头前先复制
+	if headers := pm.pluginHeaders(t.pluginName); len(headers) > 0 {
+		req = req.Clone(req.Context())
+		for key, value := range headers {
+			if req.Header.Get(key) == "" {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		pm.recordFailure(t.pluginName)
+		return nil, err
+	}
+	if resp.ContentLength > 0 {
+		pm.recordBytes(t.pluginName, resp.ContentLength)
+	}
+	return resp, nil
+}
@@ -0,0 +1,206 @@
+package proxy
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNetworkNotAllowed 插件未声明网络权限
+	ErrNetworkNotAllowed = errors.New("plugin has no network permission")
+	// ErrDomainNotAllowed 远程地址不在插件的域名白名单中
+	ErrDomainNotAllowed = errors.New("domain not allowed for plugin")
+	// ErrRateLimited 插件请求过于频繁
+	ErrRateLimited = errors.New("plugin rate limit exceeded")
+)
+
+// PluginPolicy 插件的代理策略
+type PluginPolicy struct {
+	// NetworkAllowed 插件是否声明了网络权限（PermissionNetwork）
+	NetworkAllowed bool `json:"networkAllowed"`
+	// AllowedDomains 允许访问的域名（包含子域名），为空表示不限制
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+	// Headers 转发请求时附加的请求头（如 Referer、Cookie、Authorization）
+	Headers map[string]string `json:"headers,omitempty"`
+	// RateLimit 每秒允许的远程请求数，0 表示不限制（缓存命中不计入）
+	RateLimit float64 `json:"rateLimit,omitempty"`
+	// RateBurst 允许的突发请求数
+	RateBurst int `json:"rateBurst,omitempty"`
+}
+
+// pluginPolicyState 插件策略及其运行时状态
+type pluginPolicyState struct {
+	policy   PluginPolicy
+	declared bool // 是否已根据插件元数据设置了网络权限
+	limiter  *rateLimiter
+}
+
+// rateLimiter 令牌桶限流器
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter 创建限流器，burst 小于 1 时按 1 处理
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	b := float64(max(burst, 1))
+	return &rateLimiter{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// allow 尝试消耗一个令牌
+func (l *rateLimiter) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// SetNetworkAccess 根据插件元数据设置网络权限和域名白名单
+// 设置后未声明网络权限的插件无法注册或访问远程资源；从未设置过的插件不受限制
+func (pm *ProxyManager) SetNetworkAccess(pluginName string, allowed bool, domains []string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	state := pm.policyStateLocked(pluginName)
+	state.declared = true
+	state.policy.NetworkAllowed = allowed
+	state.policy.AllowedDomains = append([]string(nil), domains...)
+}
+
+// SetPluginHeaders 设置插件转发请求时附加的请求头
+func (pm *ProxyManager) SetPluginHeaders(pluginName string, headers map[string]string) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	copied := make(map[string]string, len(headers))
+	for key, value := range headers {
+		copied[key] = value
+	}
+	pm.policyStateLocked(pluginName).policy.Headers = copied
+}
+
+// SetPluginRateLimit 设置插件每秒允许的远程请求数，rate 为 0 表示不限制
+func (pm *ProxyManager) SetPluginRateLimit(pluginName string, rate float64, burst int) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	state := pm.policyStateLocked(pluginName)
+	state.policy.RateLimit = rate
+	state.policy.RateBurst = burst
+	state.limiter = nil
+	if rate > 0 {
+		state.limiter = newRateLimiter(rate, burst)
+	}
+}
+
+// GetPluginPolicy 获取插件的代理策略
+func (pm *ProxyManager) GetPluginPolicy(pluginName string) PluginPolicy {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	state, ok := pm.policies[pluginName]
+	if !ok {
+		return PluginPolicy{NetworkAllowed: true}
+	}
+
+	policy := state.policy
+	policy.AllowedDomains = append([]string(nil), policy.AllowedDomains...)
+	policy.Headers = make(map[string]string, len(state.policy.Headers))
+	for key, value := range state.policy.Headers {
+		policy.Headers[key] = value
+	}
+	if !state.declared {
+		policy.NetworkAllowed = true
+	}
+	return policy
+}
+
+// policyStateLocked 获取（必要时创建）插件策略，调用方需持有 pm.mutex 写锁
+func (pm *ProxyManager) policyStateLocked(pluginName string) *pluginPolicyState {
+	state, ok := pm.policies[pluginName]
+	if !ok {
+		state = &pluginPolicyState{}
+		pm.policies[pluginName] = state
+	}
+	return state
+}
+
+// checkAccess 检查插件是否允许访问远程地址
+func (pm *ProxyManager) checkAccess(pluginName, remoteURL string) error {
+	pm.mutex.RLock()
+	state, ok := pm.policies[pluginName]
+	var policy PluginPolicy
+	declared := false
+	if ok {
+		policy, declared = state.policy, state.declared
+	}
+	pm.mutex.RUnlock()
+
+	if !declared {
+		return nil
+	}
+	if !policy.NetworkAllowed {
+		return ErrNetworkNotAllowed
+	}
+	if len(policy.AllowedDomains) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return err
+	}
+	if !domainAllowed(u.Hostname(), policy.AllowedDomains) {
+		return ErrDomainNotAllowed
+	}
+	return nil
+}
+
+// allowRequest 检查插件是否超出请求频率限制
+func (pm *ProxyManager) allowRequest(pluginName string) bool {
+	pm.mutex.RLock()
+	var limiter *rateLimiter
+	if state, ok := pm.policies[pluginName]; ok {
+		limiter = state.limiter
+	}
+	pm.mutex.RUnlock()
+
+	return limiter == nil || limiter.allow()
+}
+
+// pluginHeaders 获取插件附加的请求头
+func (pm *ProxyManager) pluginHeaders(pluginName string) map[string]string {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	if state, ok := pm.policies[pluginName]; ok {
+		return state.policy.Headers
+	}
+	return nil
+}
+
+// domainAllowed 判断主机名是否匹配白名单（域名本身或其子域名，支持 *. 前缀写法）
+func domainAllowed(host string, domains []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "*."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProxyServicePolicies(t *testing.T) {
+	var gotReferer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReferer = r.Header.Get("Referer")
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	config := DefaultProxyConfig()
+	config.EnableLogging = false
+	config.EnableCache = false
+	pm := NewProxyManager(config)
+
+	// Plugins without the network permission cannot register anything
+	pm.SetNetworkAccess("offline", false, nil)
+	if _, err := NewProxyService(pm, "offline").RegisterImage("a", srv.URL); !errors.Is(err, ErrNetworkNotAllowed) {
+		t.Fatalf("Register() error = %v, want ErrNetworkNotAllowed", err)
+	}
+
+	// Allowlisted domains include subdomains only
+	pm.SetNetworkAccess("images", true, []string{"example.com"})
+	svc := NewProxyService(pm, "images")
+	if _, err := svc.RegisterImage("a", "https://cdn.example.com/a.png"); err != nil {
+		t.Fatalf("Register() allowlisted subdomain: %v", err)
+	}
+	if _, err := svc.RegisterImage("b", "https://example.com.evil.net/b.png"); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Fatalf("Register() error = %v, want ErrDomainNotAllowed", err)
+	}
+
+	// Headers are injected and requests beyond the burst are rejected
+	pm.SetNetworkAccess("web", true, nil)
+	web := NewProxyService(pm, "web")
+	web.SetHeaders(map[string]string{"Referer": "https://music.example/"})
+	web.SetRateLimit(0.001, 1)
+
+	path, err := web.RegisterFile("page", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		pm.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Fatalf("request %d status = %d, want %d", i, rec.Code, want)
+		}
+	}
+	if gotReferer != "https://music.example/" {
+		t.Fatalf("Referer = %q", gotReferer)
+	}
+
+	stats := web.Stats()
+	if stats.Requests != 2 || stats.RateLimited != 1 || stats.TotalBytes != 2 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+	if _, ok := pm.GetStats()["plugins"].(map[string]PluginStats)["web"]; !ok {
+		t.Fatal("GetStats() has no per-plugin entry")
+	}
+}
+
+func TestProxyServiceClient(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	config := DefaultProxyConfig()
+	config.EnableLogging = false
+	pm := NewProxyManager(config)
+
+	pm.SetNetworkAccess("api", true, []string{"127.0.0.1"})
+	svc := NewProxyService(pm, "api")
+	svc.SetHeaders(map[string]string{"Authorization": "token plugin"})
+	client := svc.Client(time.Second)
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if gotAuth != "token plugin" {
+		t.Fatalf("Authorization = %q", gotAuth)
+	}
+
+	// Headers set by the caller win over the plugin defaults
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Authorization", "token caller")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if gotAuth != "token caller" {
+		t.Fatalf("Authorization = %q", gotAuth)
+	}
+
+	if _, err := client.Get("http://localhost.evil.net/"); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Fatalf("Get() error = %v, want ErrDomainNotAllowed", err)
+	}
+
+	pm.SetNetworkAccess("offline", false, nil)
+	if _, err := NewProxyService(pm, "offline").Client(time.Second).Get(srv.URL); !errors.Is(err, ErrNetworkNotAllowed) {
+		t.Fatalf("Get() error = %v, want ErrNetworkNotAllowed", err)
+	}
+
+	stats := svc.Stats()
+	if stats.Requests != 3 || stats.Blocked != 1 || stats.TotalBytes != 4 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+}
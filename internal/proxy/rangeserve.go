@@ -0,0 +1,416 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpRange 客户端请求的单个字节区间（Range 头）
+// Start 为 -1 时表示最后 End 个字节（bytes=-N）；End 为 -1 表示直到末尾（bytes=N-）
+type httpRange struct {
+	Start int64
+	End   int64
+}
+
+// errMultiRange 不支持的多区间请求
+var errMultiRange = errors.New("multiple ranges not supported")
+
+// parseRange 解析 Range 头，没有 Range 头时返回 nil
+func parseRange(header string) (*httpRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, fmt.Errorf("invalid range: %s", header)
+	}
+	if strings.Contains(spec, ",") {
+		return nil, errMultiRange
+	}
+
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid range: %s", header)
+	}
+
+	rng := &httpRange{Start: -1, End: -1}
+	if startStr != "" {
+		start, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("invalid range: %s", header)
+		}
+		rng.Start = start
+	}
+	if endStr != "" {
+		end, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < 0 {
+			return nil, fmt.Errorf("invalid range: %s", header)
+		}
+		rng.End = end
+	}
+	if rng.Start < 0 && rng.End < 0 || rng.Start >= 0 && rng.End >= 0 && rng.End < rng.Start {
+		return nil, fmt.Errorf("invalid range: %s", header)
+	}
+	return rng, nil
+}
+
+// resolve 根据资源大小计算实际区间 [start, end)，区间无法满足时 ok 为 false
+func (r *httpRange) resolve(size int64) (start, end int64, ok bool) {
+	if r == nil {
+		return 0, size, true
+	}
+
+	if r.Start < 0 {
+		// 最后 N 个字节
+		start = max(size-r.End, 0)
+		return start, size, r.End > 0
+	}
+
+	end = size
+	if r.End >= 0 && r.End+1 < size {
+		end = r.End + 1
+	}
+	return r.Start, end, r.Start < size
+}
+
+// parseContentRange 解析 206 响应的 Content-Range 头，返回起始偏移和资源总大小（未知时为 -1）
+func parseContentRange(header string) (offset, total int64, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid content range: %s", header)
+	}
+
+	rangePart, totalPart, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid content range: %s", header)
+	}
+	startStr, _, ok := strings.Cut(rangePart, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid content range: %s", header)
+	}
+
+	if offset, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid content range: %s", header)
+	}
+
+	total = -1
+	if totalPart != "*" {
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid content range: %s", header)
+		}
+	}
+	return offset, total, nil
+}
+
+// countingWriter 统计写入客户端的字节数
+type countingWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// serveWithDiskCache 通过磁盘缓存处理请求
+// 已缓存的区间直接从磁盘返回，缺失的部分从远程下载并同时写入缓存。
+// 返回 false 表示请求不适合走磁盘缓存（如多区间请求），由原有代理流程处理
+func (pm *ProxyManager) serveWithDiskCache(w http.ResponseWriter, r *http.Request, cache *DiskCache, res *ResourceMetadata) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	rng, err := parseRange(r.Header.Get("Range"))
+	if err != nil {
+		return false
+	}
+
+	entry := cache.acquire(res.RemoteURL)
+	defer cache.release(entry)
+
+	state := cache.state(entry)
+
+	// 完整缓存：交给 http.ServeContent 处理 Range、HEAD 和条件请求
+	if state.Complete {
+		pm.serveCompleteFromDisk(w, r, cache, entry, state, res)
+		return true
+	}
+
+	if r.Method != http.MethodGet {
+		return false
+	}
+
+	if state.Size >= 0 {
+		start, end, ok := rng.resolve(state.Size)
+		if !ok {
+			pm.writeRangeNotSatisfiable(w, state.Size)
+			return true
+		}
+
+		// 请求的区间已全部缓存
+		if state.Segments.covers(start, end) {
+			pm.servePartialFromDisk(w, cache, entry, state, start, end, res)
+			return true
+		}
+	}
+
+	pm.fetchIntoDiskCache(w, r, cache, entry, state, rng, res)
+	return true
+}
+
+// serveCompleteFromDisk 从完整的磁盘缓存提供响应
+func (pm *ProxyManager) serveCompleteFromDisk(w http.ResponseWriter, r *http.Request, cache *DiskCache, entry *diskEntry, state diskEntryState, res *ResourceMetadata) {
+	f, err := cache.openRead(entry)
+	if err != nil {
+		http.Error(w, "Failed to read cache", http.StatusInternalServerError)
+		log.Printf("[ProxyManager] Failed to open cached file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	pm.writeProxyHeaders(w.Header(), state.Header, res.Type, res.RemoteURL)
+	if w.Header().Get("Content-Type") == "" {
+		// 避免 ServeContent 读取文件内容嗅探类型
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	w.Header().Set("X-Cache", "HIT")
+
+	cw := &countingWriter{ResponseWriter: w}
+	http.ServeContent(cw, r, "", time.Time{}, io.NewSectionReader(f, 0, state.Size))
+
+	pm.recordCacheHit(res.PluginName, cw.written)
+
+	if pm.config.EnableLogging {
+		log.Printf("[ProxyManager] Disk cache hit: %s (%d bytes)", entry.Key, cw.written)
+	}
+}
+
+// servePartialFromDisk 从部分缓存中返回已完整缓存的区间 [start, end)
+func (pm *ProxyManager) servePartialFromDisk(w http.ResponseWriter, cache *DiskCache, entry *diskEntry, state diskEntryState, start, end int64, res *ResourceMetadata) {
+	f, err := cache.openRead(entry)
+	if err != nil {
+		http.Error(w, "Failed to read cache", http.StatusInternalServerError)
+		log.Printf("[ProxyManager] Failed to open cached file: %v", err)
+		return
+	}
+	defer f.Close()
+
+	pm.writeProxyHeaders(w.Header(), state.Header, res.Type, res.RemoteURL)
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, state.Size))
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start, 10))
+	w.Header().Set("X-Cache", "HIT")
+	w.WriteHeader(http.StatusPartialContent)
+
+	written, err := io.Copy(w, io.NewSectionReader(f, start, end-start))
+	if err != nil && !isBrokenPipeError(err) {
+		log.Printf("[ProxyManager] Failed to write response: %v", err)
+	}
+
+	pm.recordCacheHit(res.PluginName, written)
+
+	if pm.config.EnableLogging {
+		log.Printf("[ProxyManager] Disk cache hit: %s bytes %d-%d", entry.Key, start, end-1)
+	}
+}
+
+// fetchIntoDiskCache 下载缺失的部分并写入缓存，同时返回给客户端
+// 请求区间开头已缓存的部分直接从磁盘读取，只向远程请求剩余的字节（断点续传）
+func (pm *ProxyManager) fetchIntoDiskCache(w http.ResponseWriter, r *http.Request, cache *DiskCache, entry *diskEntry, state diskEntryState, rng *httpRange, res *ResourceMetadata) {
+	pm.recordCacheMiss(res.PluginName)
+
+	upstream := r.Clone(r.Context())
+	// 需要原始字节才能按偏移量写入缓存
+	upstream.Header.Set("Accept-Encoding", "identity")
+	upstream.Header.Del("If-None-Match")
+	upstream.Header.Del("If-Modified-Since")
+	upstream.Header.Del("If-Range")
+
+	if state.Size >= 0 {
+		start, end, _ := rng.resolve(state.Size)
+		gap := state.Segments.nextGap(start, end)
+		if gap == 0 && end == state.Size {
+			upstream.Header.Del("Range")
+		} else {
+			upstream.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", gap, end-1))
+		}
+	}
+
+	// 远程内容发生变化时服务器会返回完整内容，而不是与旧缓存拼接
+	if len(state.Segments) > 0 {
+		if etag := state.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			upstream.Header.Set("If-Range", etag)
+		} else if lastModified := state.Header.Get("Last-Modified"); lastModified != "" {
+			upstream.Header.Set("If-Range", lastModified)
+		}
+	}
+
+	resp, err := pm.proxyRequestWithRetry(upstream, res.RemoteURL, res.ID, res.PluginName)
+	if err != nil {
+		pm.writeFetchError(w, res.PluginName, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		http.Error(w, fmt.Sprintf("Server returned status %d", resp.StatusCode), resp.StatusCode)
+		return
+	}
+
+	// 确定上游响应对应的偏移量和资源总大小
+	offset, total := int64(0), resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		if offset, total, err = parseContentRange(resp.Header.Get("Content-Range")); err != nil {
+			http.Error(w, "Invalid upstream response", http.StatusBadGateway)
+			log.Printf("[ProxyManager] %v", err)
+			return
+		}
+	}
+
+	cache.update(entry, resp.Header, total)
+	state = cache.state(entry)
+	caching := total < 0 || cache.accepts(total)
+
+	pm.writeProxyHeaders(w.Header(), resp.Header, res.Type, res.RemoteURL)
+
+	status := resp.StatusCode
+	start, end, prefixEnd := offset, int64(-1), offset
+	if total >= 0 {
+		var ok bool
+		if start, end, ok = rng.resolve(total); !ok {
+			w.Header().Del("Content-Length")
+			pm.writeRangeNotSatisfiable(w, total)
+			return
+		}
+
+		prefixEnd = state.Segments.nextGap(start, end)
+		if offset > prefixEnd {
+			http.Error(w, "Unexpected upstream range", http.StatusBadGateway)
+			log.Printf("[ProxyManager] Upstream returned offset %d, need %d", offset, prefixEnd)
+			return
+		}
+
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.FormatInt(end-start, 10))
+		if rng != nil {
+			status = http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, total))
+		} else {
+			status = http.StatusOK
+			w.Header().Del("Content-Range")
+		}
+	}
+	// 总大小未知时按上游响应原样转发
+
+	if prefixEnd > start {
+		w.Header().Set("X-Cache", "PARTIAL")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	w.WriteHeader(status)
+
+	var written int64
+
+	// 先返回已缓存的前缀
+	if prefixEnd > start {
+		f, err := cache.openRead(entry)
+		if err != nil {
+			log.Printf("[ProxyManager] Failed to open cached file: %v", err)
+			return
+		}
+		n, err := io.Copy(w, io.NewSectionReader(f, start, prefixEnd-start))
+		f.Close()
+		written += n
+		if err != nil {
+			pm.recordBytes(res.PluginName, written)
+			return
+		}
+	}
+
+	// 再边下载边写入缓存
+	var file *os.File
+	if caching {
+		if file, err = cache.openWrite(entry); err != nil {
+			log.Printf("[ProxyManager] Failed to open cache file: %v", err)
+			caching = false
+		} else {
+			defer file.Close()
+		}
+	}
+
+	buf := make([]byte, 32*1024)
+	pos := offset
+	for end < 0 || pos < end {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+
+			if caching && total < 0 && !cache.accepts(pos+int64(n)) {
+				caching = false
+			}
+			if caching {
+				if _, err := file.WriteAt(chunk, pos); err != nil {
+					log.Printf("[ProxyManager] Failed to write cache: %v", err)
+					caching = false
+				} else {
+					cache.written(entry, pos, n)
+				}
+			}
+
+			// 只返回客户端请求区间内、且未从缓存返回过的部分
+			lo, hi := max(pos, prefixEnd), pos+int64(n)
+			if end >= 0 {
+				hi = min(hi, end)
+			}
+			if lo < hi {
+				m, err := w.Write(chunk[lo-pos : hi-pos])
+				written += int64(m)
+				if err != nil {
+					// 客户端断开，已下载的部分保留在缓存中，下次继续
+					if !isBrokenPipeError(err) {
+						log.Printf("[ProxyManager] Failed to write response: %v", err)
+					}
+					break
+				}
+			}
+			pos += int64(n)
+		}
+
+		if readErr == io.EOF {
+			if total < 0 && offset == 0 && caching {
+				// 下载完成后才知道总大小
+				cache.update(entry, resp.Header, pos)
+			}
+			break
+		}
+		if readErr != nil {
+			if !isBrokenPipeError(readErr) {
+				log.Printf("[ProxyManager] Download interrupted at %d: %v", pos, readErr)
+			}
+			break
+		}
+	}
+
+	pm.recordBytes(res.PluginName, written)
+
+	if pm.config.EnableLogging {
+		log.Printf("[ProxyManager] Proxied via disk cache: %s (status: %d, %d bytes)", res.ID, status, written)
+	}
+}
+
+// writeRangeNotSatisfiable 返回 416 响应
+func (pm *ProxyManager) writeRangeNotSatisfiable(w http.ResponseWriter, size int64) {
+	setCORSHeaders(w.Header())
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	http.Error(w, "Requested range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+}
@@ -24,7 +24,16 @@ type Service interface {
 	// UnregisterPlugin 注销插件的所有资源
 	UnregisterPlugin(pluginName string)
 
-	// GetStats 获取统计信息
+	// SetNetworkAccess 设置插件的网络权限和域名白名单
+	SetNetworkAccess(pluginName string, allowed bool, domains []string)
+
+	// SetPluginHeaders 设置插件转发请求时附加的请求头
+	SetPluginHeaders(pluginName string, headers map[string]string)
+
+	// SetPluginRateLimit 设置插件的请求频率限制
+	SetPluginRateLimit(pluginName string, rate float64, burst int)
+
+	// GetStats 获取统计信息（包含按插件的统计）
 	GetStats() map[string]interface{}
 
 	// ClearCache 清空缓存
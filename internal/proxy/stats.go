@@ -0,0 +1,97 @@
+package proxy
+
+// PluginStats 单个插件的代理统计
+type PluginStats struct {
+	Requests       int64 `json:"requests"`
+	CacheHits      int64 `json:"cacheHits"`
+	CacheMisses    int64 `json:"cacheMisses"`
+	FailedRequests int64 `json:"failedRequests"`
+	RateLimited    int64 `json:"rateLimited"`
+	Blocked        int64 `json:"blocked"`
+	TotalBytes     int64 `json:"totalBytes"`
+}
+
+// pluginStatsLocked 获取（必要时创建）插件统计，调用方需持有统计锁
+func (s *ProxyStats) pluginStatsLocked(pluginName string) *PluginStats {
+	if s.Plugins == nil {
+		s.Plugins = make(map[string]*PluginStats)
+	}
+	stats, ok := s.Plugins[pluginName]
+	if !ok {
+		stats = &PluginStats{}
+		s.Plugins[pluginName] = stats
+	}
+	return stats
+}
+
+// recordRequest 记录插件的请求数（全局请求数在解析资源前已经计入）
+func (pm *ProxyManager) recordRequest(pluginName string) {
+	pm.stats.mutex.Lock()
+	defer pm.stats.mutex.Unlock()
+	pm.stats.pluginStatsLocked(pluginName).Requests++
+}
+
+// recordCacheHit 记录缓存命中及返回的字节数
+func (pm *ProxyManager) recordCacheHit(pluginName string, bytes int64) {
+	pm.stats.mutex.Lock()
+	defer pm.stats.mutex.Unlock()
+
+	pm.stats.CacheHits++
+	pm.stats.TotalBytes += bytes
+	plugin := pm.stats.pluginStatsLocked(pluginName)
+	plugin.CacheHits++
+	plugin.TotalBytes += bytes
+}
+
+// recordCacheMiss 记录缓存未命中
+func (pm *ProxyManager) recordCacheMiss(pluginName string) {
+	pm.stats.mutex.Lock()
+	defer pm.stats.mutex.Unlock()
+
+	pm.stats.CacheMisses++
+	pm.stats.pluginStatsLocked(pluginName).CacheMisses++
+}
+
+// recordFailure 记录失败的请求
+func (pm *ProxyManager) recordFailure(pluginName string) {
+	pm.stats.mutex.Lock()
+	defer pm.stats.mutex.Unlock()
+
+	pm.stats.FailedRequests++
+	pm.stats.pluginStatsLocked(pluginName).FailedRequests++
+}
+
+// recordRejected 记录被策略拒绝的请求（限流或白名单）
+func (pm *ProxyManager) recordRejected(pluginName string, rateLimited bool) {
+	pm.stats.mutex.Lock()
+	defer pm.stats.mutex.Unlock()
+
+	pm.stats.FailedRequests++
+	plugin := pm.stats.pluginStatsLocked(pluginName)
+	plugin.FailedRequests++
+	if rateLimited {
+		plugin.RateLimited++
+	} else {
+		plugin.Blocked++
+	}
+}
+
+// recordBytes 记录传输的字节数
+func (pm *ProxyManager) recordBytes(pluginName string, bytes int64) {
+	pm.stats.mutex.Lock()
+	defer pm.stats.mutex.Unlock()
+
+	pm.stats.TotalBytes += bytes
+	pm.stats.pluginStatsLocked(pluginName).TotalBytes += bytes
+}
+
+// GetPluginStats 获取单个插件的代理统计
+func (pm *ProxyManager) GetPluginStats(pluginName string) PluginStats {
+	pm.stats.mutex.RLock()
+	defer pm.stats.mutex.RUnlock()
+
+	if stats, ok := pm.stats.Plugins[pluginName]; ok {
+		return *stats
+	}
+	return PluginStats{}
+}
@@ -0,0 +1,178 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"time"
+)
+
+// bundleFormat identifies files written by ExportAll.
+const bundleFormat = "ltools-settings"
+
+// Section is configuration owned by another service that is included
+// in exported bundles, e.g. shortcuts or the sync configuration.
+type Section interface {
+	// Export returns the section's data. Tokens, passwords and other
+	// credentials must be left out unless includeSecrets is set.
+	Export(includeSecrets bool) (any, error)
+
+	// Import applies data previously returned by Export.
+	Import(data json.RawMessage) error
+}
+
+// SectionFuncs adapts a pair of functions to the Section interface.
+type SectionFuncs struct {
+	ExportFunc func(includeSecrets bool) (any, error)
+	ImportFunc func(data json.RawMessage) error
+}
+
+// Export calls f.ExportFunc.
+func (f SectionFuncs) Export(includeSecrets bool) (any, error) {
+	return f.ExportFunc(includeSecrets)
+}
+
+// Import calls f.ImportFunc.
+func (f SectionFuncs) Import(data json.RawMessage) error {
+	return f.ImportFunc(data)
+}
+
+// Bundle is the portable settings file written by ExportAll.
+type Bundle struct {
+	Format          string    `json:"format"`
+	ExportedAt      time.Time `json:"exportedAt"`
+	IncludesSecrets bool      `json:"includesSecrets"`
+
+	// Settings is kept raw so that bundles from older versions are migrated on import.
+	Settings json.RawMessage `json:"settings"`
+
+	// Sections holds the data of registered sections by name.
+	Sections map[string]json.RawMessage `json:"sections,omitempty"`
+}
+
+// ImportResult reports which parts of a bundle were applied.
+type ImportResult struct {
+	Imported []string          `json:"imported"`
+	Skipped  []string          `json:"skipped,omitempty"` // sections unknown to this build
+	Errors   map[string]string `json:"errors,omitempty"`  // section -> error message
+}
+
+// RegisterSection includes section in exported and imported bundles under name.
+func (s *Service) RegisterSection(name string, section Section) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sections[name] = section
+}
+
+// ExportAll writes all settings and registered sections to a single bundle at path.
+// Secrets are only included when includeSecrets is set; such bundles are
+// written readable by the current user only.
+func (s *Service) ExportAll(path string, includeSecrets bool) error {
+	settings, err := json.Marshal(s.GetSettings())
+	if err != nil {
+		return err
+	}
+
+	bundle := &Bundle{
+		Format:          bundleFormat,
+		ExportedAt:      time.Now(),
+		IncludesSecrets: includeSecrets,
+		Settings:        settings,
+		Sections:        make(map[string]json.RawMessage),
+	}
+
+	for _, name := range s.sectionNames() {
+		data, err := s.section(name).Export(includeSecrets)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", name, err)
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", name, err)
+		}
+		bundle.Sections[name] = raw
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if includeSecrets {
+		perm = 0600
+	}
+	if err := writeFileAtomic(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	log.Printf("[SettingsService] Exported settings to %s (secrets: %v)", path, includeSecrets)
+	return nil
+}
+
+// ImportAll applies a bundle written by ExportAll.
+// The bundle's own settings are validated before anything is changed; a
+// failing section does not stop the others and is reported in the result.
+func (s *Service) ImportAll(path string) (*ImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if bundle.Format != bundleFormat {
+		return nil, fmt.Errorf("不是有效的 LTools 配置文件")
+	}
+
+	settings := s.GetSettings()
+	if len(bundle.Settings) > 0 {
+		if settings, err = migrate(bundle.Settings); err != nil {
+			return nil, err
+		}
+		if err := settings.Validate(); err != nil {
+			return nil, fmt.Errorf("配置文件无效: %w", err)
+		}
+	}
+	if err := s.replace(settings); err != nil {
+		return nil, fmt.Errorf("failed to save settings: %w", err)
+	}
+
+	result := &ImportResult{Imported: []string{SectionGeneral, SectionSearch, SectionUpdate}}
+	for _, name := range slices.Sorted(maps.Keys(bundle.Sections)) {
+		section := s.section(name)
+		if section == nil {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if err := section.Import(bundle.Sections[name]); err != nil {
+			log.Printf("[SettingsService] Failed to import %s: %v", name, err)
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[name] = err.Error()
+			continue
+		}
+		result.Imported = append(result.Imported, name)
+	}
+
+	log.Printf("[SettingsService] Imported settings from %s: %v", path, result.Imported)
+	return result, nil
+}
+
+// sectionNames returns the registered section names in order.
+func (s *Service) sectionNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Sorted(maps.Keys(s.sections))
+}
+
+func (s *Service) section(name string) Section {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sections[name]
+}
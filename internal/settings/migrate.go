@@ -0,0 +1,60 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSchemaVersion is the settings layout written by this build.
+// When a field is renamed or moved, bump it and add a migration below.
+const CurrentSchemaVersion = 1
+
+// migrations upgrade a decoded settings document in place;
+// migrations[n] turns version n into version n+1.
+var migrations = map[int]func(doc map[string]any) error{
+	// Version 0 is a document without schemaVersion. Missing fields
+	// are filled from the defaults when decoding, so nothing moves.
+	0: func(doc map[string]any) error { return nil },
+}
+
+// migrate upgrades raw settings JSON to CurrentSchemaVersion and decodes it
+// over the defaults, so fields added after the document was written get their
+// default values.
+func migrate(data []byte) (*Settings, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse settings: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	version := 0
+	if v, ok := doc["schemaVersion"].(float64); ok {
+		version = int(v)
+	}
+	if version > CurrentSchemaVersion {
+		return nil, fmt.Errorf("配置由更新版本的 LTools 生成 (schema %d)，请先升级应用", version)
+	}
+
+	for ; version < CurrentSchemaVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from settings schema %d", version)
+		}
+		if err := m(doc); err != nil {
+			return nil, fmt.Errorf("failed to migrate settings from schema %d: %w", version, err)
+		}
+	}
+	doc["schemaVersion"] = CurrentSchemaVersion
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	settings := DefaultSettings()
+	if err := json.Unmarshal(upgraded, settings); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	return settings, nil
+}
@@ -1,16 +1,129 @@
 package settings
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sync"
 )
 
+// settingsFile is the name of the settings file inside the data directory.
+const settingsFile = "settings.json"
+
+// Change describes an update to one settings section.
+// It is passed to OnChange listeners and emitted to the frontend as settings:changed.
+type Change struct {
+	Section  string    `json:"section"`
+	Settings *Settings `json:"settings"`
+}
+
 // Service provides general settings functionality to the frontend.
-type Service struct{}
+type Service struct {
+	filePath string
+
+	mu        sync.RWMutex
+	settings  *Settings
+	listeners []func(Change)
+	sections  map[string]Section
+}
+
+// NewService loads settings.json from dataDir and creates a settings service.
+// If the file cannot be loaded, the defaults stay in effect.
+func NewService(dataDir string) *Service {
+	s := &Service{
+		filePath: filepath.Join(dataDir, settingsFile),
+		settings: DefaultSettings(),
+		sections: make(map[string]Section),
+	}
+	if err := s.load(); err != nil {
+		log.Printf("[SettingsService] Failed to load settings, using defaults: %v", err)
+	}
+	return s
+}
+
+// OnChange registers fn to be called after a settings section changes.
+func (s *Service) OnChange(fn func(Change)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// GetSettings returns a copy of all settings.
+func (s *Service) GetSettings() *Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	settings := *s.settings
+	return &settings
+}
+
+// GetGeneral returns the general settings.
+func (s *Service) GetGeneral() GeneralSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings.General
+}
+
+// SetGeneral validates and saves the general settings.
+func (s *Service) SetGeneral(general GeneralSettings) error {
+	if err := general.Validate(); err != nil {
+		return err
+	}
+	return s.update(SectionGeneral, func(settings *Settings) { settings.General = general })
+}
+
+// SetTheme changes the theme ("dark", "light" or "system").
+func (s *Service) SetTheme(theme string) error {
+	general := s.GetGeneral()
+	general.Theme = theme
+	return s.SetGeneral(general)
+}
+
+// SetLanguage changes the UI language.
+func (s *Service) SetLanguage(language string) error {
+	general := s.GetGeneral()
+	general.Language = language
+	return s.SetGeneral(general)
+}
+
+// SetShowInMenu shows or hides the menu bar / system tray icon.
+func (s *Service) SetShowInMenu(show bool) error {
+	general := s.GetGeneral()
+	general.ShowInMenu = show
+	return s.SetGeneral(general)
+}
 
-// NewService creates a new settings service.
-func NewService() *Service {
-	return &Service{}
+// GetSearch returns the global search settings.
+func (s *Service) GetSearch() SearchSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings.Search
+}
+
+// SetSearch validates and saves the global search settings.
+func (s *Service) SetSearch(search SearchSettings) error {
+	if err := search.Validate(); err != nil {
+		return err
+	}
+	return s.update(SectionSearch, func(settings *Settings) { settings.Search = search })
+}
+
+// GetUpdate returns the auto-update settings.
+func (s *Service) GetUpdate() UpdateSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings.Update
+}
+
+// SetUpdate validates and saves the auto-update settings.
+func (s *Service) SetUpdate(update UpdateSettings) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
+	return s.update(SectionUpdate, func(settings *Settings) { settings.Update = update })
 }
 
 // IsLaunchAtLoginSupported reports whether the current platform supports login startup.
@@ -38,3 +151,107 @@ func (s *Service) SetLaunchAtLogin(enabled bool) error {
 
 	return disableLaunchAtLogin()
 }
+
+// update applies fn to the settings, saves them and notifies listeners.
+func (s *Service) update(section string, fn func(*Settings)) error {
+	s.mu.Lock()
+	previous := *s.settings
+	fn(s.settings)
+	if err := s.save(); err != nil {
+		*s.settings = previous
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+
+	s.notify(section)
+	return nil
+}
+
+// replace swaps in a complete set of settings, e.g. from an imported bundle,
+// and notifies listeners of every section.
+func (s *Service) replace(settings *Settings) error {
+	s.mu.Lock()
+	previous := s.settings
+	s.settings = settings
+	if err := s.save(); err != nil {
+		s.settings = previous
+		s.mu.Unlock()
+		return err
+	}
+	s.mu.Unlock()
+
+	for _, section := range []string{SectionGeneral, SectionSearch, SectionUpdate} {
+		s.notify(section)
+	}
+	return nil
+}
+
+// notify calls the change listeners outside the lock so they may read settings.
+func (s *Service) notify(section string) {
+	s.mu.RLock()
+	listeners := slices.Clone(s.listeners)
+	s.mu.RUnlock()
+
+	change := Change{Section: section, Settings: s.GetSettings()}
+	for _, fn := range listeners {
+		fn(change)
+	}
+}
+
+// load reads settings.json, migrating older layouts.
+// A migrated file is written back so the upgrade only happens once.
+func (s *Service) load() error {
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var header struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	json.Unmarshal(data, &header)
+
+	settings, err := migrate(data)
+	if err != nil {
+		return err
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	s.settings = settings
+
+	if header.SchemaVersion != CurrentSchemaVersion {
+		log.Printf("[SettingsService] Migrated settings from schema %d to %d", header.SchemaVersion, CurrentSchemaVersion)
+		return s.save()
+	}
+	return nil
+}
+
+// save writes settings.json atomically. The caller must hold s.mu.
+func (s *Service) save() error {
+	data, err := json.MarshalIndent(s.settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.filePath, data, 0644)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
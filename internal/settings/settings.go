@@ -0,0 +1,139 @@
+package settings
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Section names used in change notifications.
+const (
+	SectionGeneral = "general"
+	SectionSearch  = "search"
+	SectionUpdate  = "update"
+)
+
+// Supported values for GeneralSettings.
+var (
+	themes    = []string{"dark", "light", "system"}
+	languages = []string{"zh-CN", "en-US"}
+	channels  = []string{"stable", "beta", "nightly"}
+)
+
+// Settings is the content of settings.json.
+type Settings struct {
+	// SchemaVersion is the layout version of the file, see migrate.go.
+	SchemaVersion int `json:"schemaVersion"`
+
+	General GeneralSettings `json:"general"`
+	Search  SearchSettings  `json:"search"`
+	Update  UpdateSettings  `json:"update"`
+}
+
+// GeneralSettings holds appearance and window preferences.
+type GeneralSettings struct {
+	// Theme is "dark", "light" or "system".
+	Theme string `json:"theme"`
+
+	// Language is the UI language, e.g. "zh-CN".
+	Language string `json:"language"`
+
+	// ShowInMenu shows the app in the menu bar / system tray.
+	ShowInMenu bool `json:"showInMenu"`
+}
+
+// SearchSettings holds global search preferences.
+type SearchSettings struct {
+	// MaxResults limits the number of items listed for a directory.
+	MaxResults int `json:"maxResults"`
+
+	// IncludeApps adds installed applications to the results.
+	IncludeApps bool `json:"includeApps"`
+
+	// IncludePaths treats queries that look like paths as file searches.
+	IncludePaths bool `json:"includePaths"`
+}
+
+// UpdateSettings holds auto-update preferences.
+type UpdateSettings struct {
+	// Enabled turns on the automatic update check at startup.
+	Enabled bool `json:"enabled"`
+
+	// Channel is "stable", "beta" or "nightly".
+	Channel string `json:"channel"`
+
+	// Background downloads updates silently and installs them on quit.
+	Background bool `json:"background"`
+
+	// BandwidthLimit caps download speed in bytes per second; 0 means unlimited.
+	BandwidthLimit int64 `json:"bandwidthLimit"`
+
+	// MaxLaunchFailures is how many failed launches of a new version trigger
+	// a rollback to the previous one. It takes effect on the next start.
+	MaxLaunchFailures int `json:"maxLaunchFailures"`
+}
+
+// DefaultSettings returns the settings used on first launch.
+func DefaultSettings() *Settings {
+	return &Settings{
+		SchemaVersion: CurrentSchemaVersion,
+		General: GeneralSettings{
+			Theme:      "dark",
+			Language:   "zh-CN",
+			ShowInMenu: true,
+		},
+		Search: SearchSettings{
+			MaxResults:   50,
+			IncludeApps:  true,
+			IncludePaths: true,
+		},
+		Update: UpdateSettings{
+			Enabled:           true,
+			Channel:           "stable",
+			MaxLaunchFailures: 3,
+		},
+	}
+}
+
+// Validate checks the settings for unsupported values.
+func (s *Settings) Validate() error {
+	if err := s.General.Validate(); err != nil {
+		return err
+	}
+	if err := s.Search.Validate(); err != nil {
+		return err
+	}
+	return s.Update.Validate()
+}
+
+// Validate checks the general settings for unsupported values.
+func (g GeneralSettings) Validate() error {
+	if !slices.Contains(themes, g.Theme) {
+		return fmt.Errorf("不支持的主题: %s", g.Theme)
+	}
+	if !slices.Contains(languages, g.Language) {
+		return fmt.Errorf("不支持的语言: %s", g.Language)
+	}
+	return nil
+}
+
+// Validate checks the search settings for unsupported values.
+func (s SearchSettings) Validate() error {
+	if s.MaxResults < 1 || s.MaxResults > 500 {
+		return fmt.Errorf("搜索结果数量必须在 1-500 之间: %d", s.MaxResults)
+	}
+	return nil
+}
+
+// Validate checks the update settings for unsupported values.
+func (u UpdateSettings) Validate() error {
+	if !slices.Contains(channels, u.Channel) {
+		return fmt.Errorf("未知的更新通道: %s", u.Channel)
+	}
+	if u.BandwidthLimit < 0 {
+		return fmt.Errorf("下载限速不能为负数: %d", u.BandwidthLimit)
+	}
+	if u.MaxLaunchFailures < 1 || u.MaxLaunchFailures > 10 {
+		return fmt.Errorf("启动失败回滚次数必须在 1-10 之间: %d", u.MaxLaunchFailures)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSettingsPersistAndNotify(t *testing.T) {
+	dir := t.TempDir()
+	s := NewService(dir)
+
+	var changes []string
+	s.OnChange(func(c Change) { changes = append(changes, c.Section+":"+c.Settings.General.Theme) })
+
+	if err := s.SetTheme("light"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetTheme("purple"); err == nil {
+		t.Fatal("SetTheme accepted an unknown theme")
+	}
+	if err := s.SetSearch(SearchSettings{MaxResults: 0}); err == nil {
+		t.Fatal("SetSearch accepted MaxResults 0")
+	}
+	if err := s.SetUpdate(UpdateSettings{Channel: "stable"}); err == nil {
+		t.Fatal("SetUpdate accepted MaxLaunchFailures 0")
+	}
+	if len(changes) != 1 || changes[0] != "general:light" {
+		t.Fatalf("changes = %v", changes)
+	}
+
+	reloaded := NewService(dir)
+	if got := reloaded.GetGeneral().Theme; got != "light" {
+		t.Fatalf("theme after reload = %q", got)
+	}
+	if got := reloaded.GetSearch().MaxResults; got != 50 {
+		t.Fatalf("default MaxResults = %d", got)
+	}
+	if got := reloaded.GetUpdate().MaxLaunchFailures; got != 3 {
+		t.Fatalf("default MaxLaunchFailures = %d", got)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	// 没有 schemaVersion 的旧文件：缺失的字段使用默认值
+	dir := t.TempDir()
+	path := filepath.Join(dir, settingsFile)
+	os.WriteFile(path, []byte(`{"general":{"theme":"system"}}`), 0644)
+
+	s := NewService(dir)
+	if g := s.GetGeneral(); g.Theme != "system" || g.Language != "zh-CN" || !g.ShowInMenu {
+		t.Fatalf("general = %+v", g)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"schemaVersion": 1`) {
+		t.Fatalf("migrated file not written back:\n%s", data)
+	}
+
+	// 更新版本写入的文件不能被降级读取
+	if _, err := migrate([]byte(`{"schemaVersion": 99}`)); err == nil {
+		t.Fatal("migrate accepted a newer schema")
+	}
+}
+
+// fakeSection records imports and exports a token only with secrets.
+type fakeSection struct {
+	imported json.RawMessage
+	fail     bool
+}
+
+func (f *fakeSection) Export(includeSecrets bool) (any, error) {
+	data := map[string]string{"name": "team"}
+	if includeSecrets {
+		data["token"] = "s3cret"
+	}
+	return data, nil
+}
+
+func (f *fakeSection) Import(data json.RawMessage) error {
+	if f.fail {
+		return errors.New("boom")
+	}
+	f.imported = data
+	return nil
+}
+
+func TestExportImportBundle(t *testing.T) {
+	src := NewService(t.TempDir())
+	src.RegisterSection("team", &fakeSection{})
+	src.RegisterSection("extra", &fakeSection{})
+	if err := src.SetLanguage("en-US"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	public := filepath.Join(dir, "public.json")
+	private := filepath.Join(dir, "private.json")
+	if err := src.ExportAll(public, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.ExportAll(private, true); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(public)
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatal("bundle without secrets contains the token")
+	}
+	data, _ = os.ReadFile(private)
+	if !strings.Contains(string(data), "s3cret") {
+		t.Fatal("bundle with secrets is missing the token")
+	}
+	if info, _ := os.Stat(private); info.Mode().Perm() != 0600 {
+		t.Fatalf("bundle with secrets has mode %v", info.Mode().Perm())
+	}
+
+	// 新机器：team 成功导入，extra 失败，未注册的 sections 跳过
+	dst := NewService(t.TempDir())
+	team := &fakeSection{}
+	dst.RegisterSection("team", team)
+	dst.RegisterSection("extra", &fakeSection{fail: true})
+
+	var notified []string
+	dst.OnChange(func(c Change) { notified = append(notified, c.Section) })
+
+	result, err := dst.ImportAll(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dst.GetGeneral().Language; got != "en-US" {
+		t.Fatalf("language after import = %q", got)
+	}
+	if len(notified) != 3 {
+		t.Fatalf("notified sections = %v", notified)
+	}
+	var imported map[string]string
+	json.Unmarshal(team.imported, &imported)
+	if len(imported) != 1 || imported["name"] != "team" {
+		t.Fatalf("team section imported %s", team.imported)
+	}
+	if result.Errors["extra"] != "boom" {
+		t.Fatalf("errors = %v", result.Errors)
+	}
+
+	// 无效的配置包不会改动任何设置
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"format":"ltools-settings","settings":{"general":{"theme":"neon"}}}`), 0644)
+	if _, err := dst.ImportAll(bad); err == nil {
+		t.Fatal("ImportAll accepted an invalid theme")
+	}
+	if got := dst.GetGeneral().Theme; got != "dark" {
+		t.Fatalf("theme after rejected import = %q", got)
+	}
+}
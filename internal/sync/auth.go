@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// httpsTokenUser is the user name sent with an access token over HTTPS.
+// GitHub, GitLab and Gitea accept any non-empty user name for personal tokens.
+const httpsTokenUser = "x-access-token"
+
+// defaultSSHKeys are tried in order when no key file is configured and no agent is running.
+var defaultSSHKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// gitAuth returns an AuthProvider for the embedded engine.
+// HTTPS remotes use the token stored in the keychain; SSH remotes use the
+// configured key file, the SSH agent, or the default keys in ~/.ssh.
+func (m *SyncManager) gitAuth() AuthProvider {
+	return func(url string) (transport.AuthMethod, error) {
+		switch {
+		case IsSSHURL(url):
+			return m.sshAuth(url)
+		case IsHTTPSURL(url):
+			token, err := m.GetToken()
+			if err != nil || token == "" {
+				// Public repositories don't need credentials
+				return nil, nil
+			}
+			return &http.BasicAuth{Username: httpsTokenUser, Password: token}, nil
+		default:
+			return nil, nil
+		}
+	}
+}
+
+// sshAuth returns public key credentials for an SSH remote.
+func (m *SyncManager) sshAuth(url string) (transport.AuthMethod, error) {
+	user := "git"
+	if ep, err := transport.NewEndpoint(url); err == nil && ep.User != "" {
+		user = ep.User
+	}
+
+	if keyPath := m.config.Get().SSHKeyPath; keyPath != "" {
+		auth, err := ssh.NewPublicKeysFromFile(user, expandHome(keyPath), "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SSH key %s: %w", keyPath, err)
+		}
+		return auth, nil
+	}
+
+	if os.Getenv("SSH_AUTH_SOCK") != "" {
+		if auth, err := ssh.NewSSHAgentAuth(user); err == nil {
+			return auth, nil
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range defaultSSHKeys {
+		keyPath := filepath.Join(homeDir, ".ssh", name)
+		if _, err := os.Stat(keyPath); err != nil {
+			continue
+		}
+		auth, err := ssh.NewPublicKeysFromFile(user, keyPath, "")
+		if err != nil {
+			// Passphrase-protected keys need the agent
+			log.Printf("[SyncManager] Skipping SSH key %s: %v", name, err)
+			continue
+		}
+		return auth, nil
+	}
+
+	return nil, fmt.Errorf("no usable SSH key found, start ssh-agent or configure a key file")
+}
+
+// expandHome expands a leading ~ to the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
@@ -88,6 +88,15 @@ func (cm *ConfigManager) Get() *SyncConfig {
 		config.IgnorePatterns = make([]string, len(cm.config.IgnorePatterns))
 		copy(config.IgnorePatterns, cm.config.IgnorePatterns)
 	}
+	if cm.config.Profiles != nil {
+		config.Profiles = make([]SyncProfile, len(cm.config.Profiles))
+		for i, p := range cm.config.Profiles {
+			config.Profiles[i] = SyncProfile{
+				Name:    p.Name,
+				Plugins: append([]string{}, p.Plugins...),
+			}
+		}
+	}
 	return &config
 }
 
@@ -3,6 +3,7 @@ package sync
 import (
 	"bytes"
 	"fmt"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,6 +11,33 @@ import (
 	"time"
 )
 
+// GitBackend is the set of Git operations used by the sync manager.
+// GitClient implements it with the system git binary and GoGitClient in pure Go.
+type GitBackend interface {
+	IsRepo() bool
+	Init() error
+	Clone(url string) error
+	SetRemote(url string) error
+	GetRemoteURL() (string, error)
+	AddAll() error
+	Commit(message string) error
+	Push(force bool) error
+	Pull() error
+	Fetch() error
+	HasChanges() (bool, error)
+	GetCommitHash() (string, error)
+	GetShortHash() (string, error)
+	GetBehindCount() (int, error)
+	TestConnection(url string) (*ConnectionTestResult, error)
+	WriteGitignore(content string) error
+}
+
+// isGitInstalled checks if the git binary is available on the system.
+func isGitInstalled() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
 // GitClient wraps Git command-line operations.
 type GitClient struct {
 	repoPath string
@@ -61,8 +89,7 @@ func (g *GitClient) runGitWithInput(input string, args ...string) (string, error
 
 // IsGitInstalled checks if git is available on the system.
 func (g *GitClient) IsGitInstalled() bool {
-	_, err := exec.LookPath("git")
-	return err == nil
+	return isGitInstalled()
 }
 
 // IsRepo checks if the path is a Git repository.
@@ -95,7 +122,7 @@ func (g *GitClient) Init() error {
 	// Set default branch name to main
 	if _, err := g.runGit("branch", "-M", "main"); err != nil {
 		// Non-fatal, might already be on main
-		fmt.Printf("[GitClient] Warning: could not rename branch to main: %v\n", err)
+		log.Printf("[GitClient] Warning: could not rename branch to main: %v", err)
 	}
 
 	return nil
@@ -181,7 +208,7 @@ func (g *GitClient) Push(force bool) error {
 
 	// If normal push fails and force is requested, try force-with-lease
 	if force {
-		fmt.Printf("[GitClient] Normal push failed, trying force-with-lease: %v\n", err)
+		log.Printf("[GitClient] Normal push failed, trying force-with-lease: %v", err)
 		args = []string{"push", "--force-with-lease", "-u", "origin", "main"}
 		_, err = g.runGit(args...)
 		return err
@@ -200,12 +227,12 @@ func (g *GitClient) Pull() error {
 	}
 
 	// If main fails, try master (for older repositories)
-	fmt.Printf("[GitClient] Pull from main failed, trying master: %v\n", err)
+	log.Printf("[GitClient] Pull from main failed, trying master: %v", err)
 	_, err = g.runGit("pull", "origin", "master", "--rebase", "-X", "ours")
 
 	// If rebase still fails, try to abort and fall back to merge
 	if err != nil {
-		fmt.Printf("[GitClient] Rebase pull failed, aborting and trying merge: %v\n", err)
+		log.Printf("[GitClient] Rebase pull failed, aborting and trying merge: %v", err)
 		g.runGit("rebase", "--abort")
 		_, err = g.runGit("pull", "origin", "main", "--no-rebase")
 		if err != nil {
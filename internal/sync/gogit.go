@@ -0,0 +1,633 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ltools/internal/network"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// transportMu serializes remote operations that swap the go-git transports.
+var transportMu sync.Mutex
+
+// withTransports runs fn with the sync transports installed and restores the
+// previous go-git protocol table afterwards, so other go-git users in the
+// process are not affected.
+func withTransports(fn func() error) error {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+
+	// HTTP remotes use the app-wide network configuration (proxy, CA bundle)
+	httpTransport := githttp.NewClient(network.NewClient(0))
+	installed := map[string]transport.Transport{
+		// Serve local remotes in-process; the default file transport spawns git-upload-pack
+		"file":  &localTransport{Transport: server.DefaultServer, loader: server.DefaultLoader},
+		"https": httpTransport,
+		"http":  httpTransport,
+	}
+
+	previous := make(map[string]transport.Transport, len(installed))
+	for scheme, t := range installed {
+		if old, ok := client.Protocols[scheme]; ok {
+			previous[scheme] = old
+		}
+		client.InstallProtocol(scheme, t)
+	}
+	defer func() {
+		for scheme := range installed {
+			// InstallProtocol with nil removes the entry
+			client.InstallProtocol(scheme, previous[scheme])
+		}
+	}()
+
+	return fn()
+}
+
+// localTransport serves local repositories with the in-process server.
+type localTransport struct {
+	transport.Transport
+	loader server.Loader
+}
+
+// NewUploadPackSession wraps the session so unknown "have" commits are dropped.
+// git-upload-pack ignores commits it doesn't know, but the in-process server
+// fails with "object not found" when the client has unpushed commits.
+func (t *localTransport) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	sess, err := t.Transport.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	st, err := t.loader.Load(ep)
+	if err != nil {
+		sess.Close()
+		return nil, err
+	}
+	return &knownHavesSession{UploadPackSession: sess, storer: st}, nil
+}
+
+// knownHavesSession filters the client's haves down to objects the remote has.
+type knownHavesSession struct {
+	transport.UploadPackSession
+	storer storer.Storer
+}
+
+// UploadPack implements transport.UploadPackSession.
+func (s *knownHavesSession) UploadPack(ctx context.Context, req *packp.UploadPackRequest) (*packp.UploadPackResponse, error) {
+	haves := req.Haves[:0]
+	for _, h := range req.Haves {
+		if s.storer.HasEncodedObject(h) == nil {
+			haves = append(haves, h)
+		}
+	}
+	req.Haves = haves
+	return s.UploadPackSession.UploadPack(ctx, req)
+}
+
+// Commit identity used for sync commits.
+const (
+	syncAuthorName  = "LTools Sync"
+	syncAuthorEmail = "sync@ltools.local"
+)
+
+// AuthProvider returns the transport credentials to use for a remote URL.
+// A nil AuthMethod means no credentials (e.g. local paths).
+type AuthProvider func(url string) (transport.AuthMethod, error)
+
+// GoGitClient implements GitBackend in pure Go, so sync works without a git binary.
+type GoGitClient struct {
+	repoPath string
+	auth     AuthProvider
+}
+
+// NewGoGitClient creates a new GoGitClient for the specified repository path.
+func NewGoGitClient(repoPath string, auth AuthProvider) *GoGitClient {
+	return &GoGitClient{repoPath: repoPath, auth: auth}
+}
+
+// open opens the repository.
+func (g *GoGitClient) open() (*git.Repository, error) {
+	return git.PlainOpen(g.repoPath)
+}
+
+// authFor returns the credentials for url.
+func (g *GoGitClient) authFor(url string) (transport.AuthMethod, error) {
+	if g.auth == nil {
+		return nil, nil
+	}
+	return g.auth(url)
+}
+
+// remoteAuth returns the credentials for the origin remote.
+func (g *GoGitClient) remoteAuth() (transport.AuthMethod, error) {
+	url, err := g.GetRemoteURL()
+	if err != nil {
+		return nil, err
+	}
+	return g.authFor(url)
+}
+
+// IsRepo checks if the path is a Git repository.
+func (g *GoGitClient) IsRepo() bool {
+	_, err := os.Stat(filepath.Join(g.repoPath, ".git"))
+	return err == nil
+}
+
+// Init initializes a new Git repository on the main branch.
+func (g *GoGitClient) Init() error {
+	if err := os.MkdirAll(g.repoPath, 0755); err != nil {
+		return fmt.Errorf("failed to create repo directory: %w", err)
+	}
+
+	repo, err := git.PlainInit(g.repoPath, false)
+	if err != nil {
+		return fmt.Errorf("git init failed: %w", err)
+	}
+
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))
+	if err := repo.Storer.SetReference(head); err != nil {
+		return fmt.Errorf("failed to set default branch: %w", err)
+	}
+
+	return g.configureUser(repo)
+}
+
+// Clone clones a repository to the client's path.
+func (g *GoGitClient) Clone(url string) error {
+	if err := os.MkdirAll(filepath.Dir(g.repoPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	auth, err := g.authFor(url)
+	if err != nil {
+		return err
+	}
+
+	var repo *git.Repository
+	err = withTransports(func() (err error) {
+		repo, err = git.PlainClone(g.repoPath, false, &git.CloneOptions{
+			URL:  url,
+			Auth: auth,
+		})
+		return err
+	})
+	if err != nil {
+		// Don't leave a half-initialized repository behind
+		os.RemoveAll(g.repoPath)
+		return fmt.Errorf("git clone failed: %w", err)
+	}
+
+	return g.configureUser(repo)
+}
+
+// configureUser sets the commit identity in the repository config.
+func (g *GoGitClient) configureUser(repo *git.Repository) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.User.Name = syncAuthorName
+	cfg.User.Email = syncAuthorEmail
+	return repo.SetConfig(cfg)
+}
+
+// SetRemote sets the remote URL for the repository.
+func (g *GoGitClient) SetRemote(url string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+
+	if _, err := repo.Remote("origin"); err == nil {
+		if err := repo.DeleteRemote("origin"); err != nil {
+			return err
+		}
+	}
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	return err
+}
+
+// GetRemoteURL returns the current remote URL.
+func (g *GoGitClient) GetRemoteURL() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", err
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote origin has no URL")
+	}
+	return urls[0], nil
+}
+
+// AddAll stages all changes, including deletions.
+func (g *GoGitClient) AddAll() error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return wt.AddWithOptions(&git.AddOptions{All: true})
+}
+
+// Commit creates a commit with the given message.
+func (g *GoGitClient) Commit(message string) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	_, err = wt.Commit(message, &git.CommitOptions{Author: syncSignature()})
+	return err
+}
+
+// Push pushes main to the remote repository.
+// If force is true, a force-with-lease push is used.
+func (g *GoGitClient) Push(force bool) error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	auth, err := g.remoteAuth()
+	if err != nil {
+		return err
+	}
+
+	opts := &git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []config.RefSpec{"refs/heads/main:refs/heads/main"},
+		Auth:       auth,
+	}
+	if force {
+		opts.ForceWithLease = &git.ForceWithLease{}
+	}
+
+	err = withTransports(func() error { return repo.Push(opts) })
+	if errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return err
+}
+
+// Fetch fetches from the remote without merging.
+func (g *GoGitClient) Fetch() error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	return g.fetch(repo)
+}
+
+// fetch fetches origin into the remote-tracking branches.
+func (g *GoGitClient) fetch(repo *git.Repository) error {
+	auth, err := g.remoteAuth()
+	if err != nil {
+		return err
+	}
+
+	err = withTransports(func() error {
+		return repo.Fetch(&git.FetchOptions{RemoteName: "origin", Auth: auth})
+	})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) || errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil
+	}
+	return err
+}
+
+// remoteBranch returns the remote-tracking reference for main, falling back to master.
+func (g *GoGitClient) remoteBranch(repo *git.Repository) (*plumbing.Reference, error) {
+	for _, branch := range []string{"main", "master"} {
+		ref, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+		if err == nil {
+			return ref, nil
+		}
+	}
+	return nil, plumbing.ErrReferenceNotFound
+}
+
+// Pull fetches and integrates remote changes.
+// Fast-forwards when possible; otherwise remote changes to files that were
+// not modified locally are applied and a merge commit is created, with the
+// local version winning on conflicts (the equivalent of "-X ours").
+func (g *GoGitClient) Pull() error {
+	repo, err := g.open()
+	if err != nil {
+		return err
+	}
+	if err := g.fetch(repo); err != nil {
+		return err
+	}
+
+	remoteRef, err := g.remoteBranch(repo)
+	if err != nil {
+		// Nothing on the remote yet
+		return nil
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	if head.Hash() == remoteRef.Hash() {
+		return nil
+	}
+
+	local, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	remote, err := repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return err
+	}
+
+	// Already contains the remote commit
+	if isAncestor, err := remote.IsAncestor(local); err != nil {
+		return err
+	} else if isAncestor {
+		return nil
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	// Fast-forward
+	if isAncestor, err := local.IsAncestor(remote); err != nil {
+		return err
+	} else if isAncestor {
+		return wt.Reset(&git.ResetOptions{Commit: remote.Hash, Mode: git.MergeReset})
+	}
+
+	return g.mergeLocalFirst(wt, local, remote)
+}
+
+// mergeLocalFirst merges diverged histories, keeping local changes on conflict.
+func (g *GoGitClient) mergeLocalFirst(wt *git.Worktree, local, remote *object.Commit) error {
+	bases, err := local.MergeBase(remote)
+	if err != nil {
+		return err
+	}
+	if len(bases) == 0 {
+		return fmt.Errorf("local and remote histories are unrelated")
+	}
+
+	baseTree, err := bases[0].Tree()
+	if err != nil {
+		return err
+	}
+	localTree, err := local.Tree()
+	if err != nil {
+		return err
+	}
+	remoteTree, err := remote.Tree()
+	if err != nil {
+		return err
+	}
+
+	localChanges, err := object.DiffTree(baseTree, localTree)
+	if err != nil {
+		return err
+	}
+	localChanged := make(map[string]bool, len(localChanges))
+	for _, change := range localChanges {
+		localChanged[changePath(change)] = true
+	}
+
+	remoteChanges, err := object.DiffTree(baseTree, remoteTree)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range remoteChanges {
+		name := changePath(change)
+		if localChanged[name] {
+			continue
+		}
+		if err := g.applyRemoteChange(remoteTree, change, name); err != nil {
+			return fmt.Errorf("failed to apply remote change to %s: %w", name, err)
+		}
+	}
+
+	if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		return err
+	}
+
+	_, err = wt.Commit("sync: merge remote changes", &git.CommitOptions{
+		Author:            syncSignature(),
+		Parents:           []plumbing.Hash{local.Hash, remote.Hash},
+		AllowEmptyCommits: true,
+	})
+	return err
+}
+
+// applyRemoteChange writes a file from the remote tree into the worktree,
+// or removes it if the remote deleted it.
+func (g *GoGitClient) applyRemoteChange(remoteTree *object.Tree, change *object.Change, name string) error {
+	path := filepath.Join(g.repoPath, filepath.FromSlash(name))
+
+	if change.To.Name == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	file, err := remoteTree.File(name)
+	if err != nil {
+		return err
+	}
+	reader, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	mode, err := file.Mode.ToOSFileMode()
+	if err != nil {
+		mode = 0644
+	}
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, reader)
+	return err
+}
+
+// HasChanges checks if there are any changes to commit.
+func (g *GoGitClient) HasChanges() (bool, error) {
+	repo, err := g.open()
+	if err != nil {
+		return false, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return false, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return false, err
+	}
+	return !status.IsClean(), nil
+}
+
+// GetCommitHash returns the current commit hash.
+func (g *GoGitClient) GetCommitHash() (string, error) {
+	repo, err := g.open()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// GetShortHash returns the short commit hash.
+func (g *GoGitClient) GetShortHash() (string, error) {
+	hash, err := g.GetCommitHash()
+	if err != nil {
+		return "", err
+	}
+	return hash[:7], nil
+}
+
+// GetBehindCount returns the number of commits behind the remote.
+func (g *GoGitClient) GetBehindCount() (int, error) {
+	repo, err := g.open()
+	if err != nil {
+		return 0, err
+	}
+	if err := g.fetch(repo); err != nil {
+		return 0, err
+	}
+
+	remoteRef, err := g.remoteBranch(repo)
+	if err != nil {
+		return 0, nil
+	}
+
+	local := make(map[plumbing.Hash]bool)
+	if head, err := repo.Head(); err == nil {
+		iter, err := repo.Log(&git.LogOptions{From: head.Hash()})
+		if err != nil {
+			return 0, err
+		}
+		iter.ForEach(func(c *object.Commit) error {
+			local[c.Hash] = true
+			return nil
+		})
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: remoteRef.Hash()})
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if !local[c.Hash] {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// TestConnection tests if we can connect to the remote repository.
+func (g *GoGitClient) TestConnection(url string) (*ConnectionTestResult, error) {
+	result := &ConnectionTestResult{
+		AuthMethod: AuthMethodAuto,
+	}
+	if IsSSHURL(url) {
+		result.AuthMethod = AuthMethodSSH
+	} else if IsHTTPSURL(url) {
+		result.AuthMethod = AuthMethodHTTPS
+	}
+
+	auth, err := g.authFor(url)
+	if err != nil {
+		result.Success = false
+		result.Message = fmt.Sprintf("连接失败: %v", err)
+		return result, fmt.Errorf("connection test failed: %w", err)
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	err = withTransports(func() error {
+		_, err := remote.List(&git.ListOptions{Auth: auth})
+		return err
+	})
+	if err != nil && !errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		result.Success = false
+		result.Message = fmt.Sprintf("连接失败: %v", err)
+		return result, fmt.Errorf("connection test failed: %w", err)
+	}
+
+	result.Success = true
+	result.Message = "连接成功"
+	return result, nil
+}
+
+// WriteGitignore writes a .gitignore file to the repository.
+func (g *GoGitClient) WriteGitignore(content string) error {
+	return os.WriteFile(filepath.Join(g.repoPath, ".gitignore"), []byte(content), 0644)
+}
+
+// changePath returns the path affected by a tree change.
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+// syncSignature returns the author signature for sync commits.
+func syncSignature() *object.Signature {
+	return &object.Signature{
+		Name:  syncAuthorName,
+		Email: syncAuthorEmail,
+		When:  time.Now(),
+	}
+}
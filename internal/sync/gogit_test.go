@@ -0,0 +1,354 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+)
+
+// newBareRepo creates an empty bare repository whose HEAD points to main.
+func newBareRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "remote.git")
+	repo, err := git.PlainInit(dir, true)
+	if err != nil {
+		t.Fatalf("init bare repo: %v", err)
+	}
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))
+	if err := repo.Storer.SetReference(head); err != nil {
+		t.Fatalf("set HEAD: %v", err)
+	}
+	return dir
+}
+
+// writeRepoFile writes a file into a client's working tree.
+func writeRepoFile(t *testing.T, g *GoGitClient, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(g.repoPath, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRepoFile reads a file from a client's working tree.
+func readRepoFile(t *testing.T, g *GoGitClient, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(g.repoPath, name))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+// commitAndPush stages everything, commits and pushes.
+func commitAndPush(t *testing.T, g *GoGitClient, message string) {
+	t.Helper()
+
+	if err := g.AddAll(); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := g.Commit(message); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := g.Push(false); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+}
+
+func TestGoGitClientLocalBareRepo(t *testing.T) {
+	remote := newBareRepo(t)
+
+	// First device: the remote is empty, so clone fails and we init instead
+	a := NewGoGitClient(filepath.Join(t.TempDir(), "a"), nil)
+	if err := a.Clone(remote); err == nil {
+		t.Fatal("expected clone of empty repository to fail")
+	}
+	if a.IsRepo() {
+		t.Fatal("failed clone left a repository behind")
+	}
+	if err := a.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if err := a.SetRemote(remote); err != nil {
+		t.Fatalf("set remote: %v", err)
+	}
+	if url, err := a.GetRemoteURL(); err != nil || url != remote {
+		t.Fatalf("GetRemoteURL() = %q, %v", url, err)
+	}
+
+	writeRepoFile(t, a, "kanban/boards.json", `{"boards":[]}`)
+	if changed, err := a.HasChanges(); err != nil || !changed {
+		t.Fatalf("HasChanges() = %v, %v; want true", changed, err)
+	}
+	commitAndPush(t, a, "sync: a1")
+	if changed, err := a.HasChanges(); err != nil || changed {
+		t.Fatalf("HasChanges() after commit = %v, %v; want false", changed, err)
+	}
+
+	// Second device clones the pushed history
+	b := NewGoGitClient(filepath.Join(t.TempDir(), "b"), nil)
+	if err := b.Clone(remote); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if got := readRepoFile(t, b, "kanban/boards.json"); got != `{"boards":[]}` {
+		t.Fatalf("cloned content = %q", got)
+	}
+
+	// Fast-forward pull
+	writeRepoFile(t, b, "vault.json", "v1")
+	commitAndPush(t, b, "sync: b1")
+
+	if behind, err := a.GetBehindCount(); err != nil || behind != 1 {
+		t.Fatalf("GetBehindCount() = %d, %v; want 1", behind, err)
+	}
+	if err := a.Pull(); err != nil {
+		t.Fatalf("fast-forward pull: %v", err)
+	}
+	if got := readRepoFile(t, a, "vault.json"); got != "v1" {
+		t.Fatalf("pulled content = %q", got)
+	}
+	aHash, _ := a.GetCommitHash()
+	bHash, _ := b.GetCommitHash()
+	if aHash != bHash {
+		t.Fatalf("after fast-forward HEAD = %s, want %s", aHash, bHash)
+	}
+
+	// Diverged histories: b changes a shared file and adds one, a changes the same file
+	writeRepoFile(t, b, "vault.json", "remote")
+	writeRepoFile(t, b, "sticky.json", "notes")
+	commitAndPush(t, b, "sync: b2")
+
+	writeRepoFile(t, a, "vault.json", "local")
+	if err := a.AddAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Commit("sync: a2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Push(false); err == nil {
+		t.Fatal("expected non-fast-forward push to fail")
+	}
+	if err := a.Pull(); err != nil {
+		t.Fatalf("merge pull: %v", err)
+	}
+	if got := readRepoFile(t, a, "vault.json"); got != "local" {
+		t.Fatalf("conflicting file = %q, want local version", got)
+	}
+	if got := readRepoFile(t, a, "sticky.json"); got != "notes" {
+		t.Fatalf("remote-only file = %q, want remote version", got)
+	}
+	if err := a.Push(false); err != nil {
+		t.Fatalf("push after merge: %v", err)
+	}
+
+	if err := b.Pull(); err != nil {
+		t.Fatalf("pull merge commit: %v", err)
+	}
+	if got := readRepoFile(t, b, "vault.json"); got != "local" {
+		t.Fatalf("b vault.json = %q, want merged local version", got)
+	}
+}
+
+func TestGoGitClientSmartHTTP(t *testing.T) {
+	const token = "secret-token"
+	remote := newBareRepo(t)
+
+	srv := httptest.NewServer(newSmartHTTPHandler(t, filepath.Dir(remote), token))
+	defer srv.Close()
+	url := srv.URL + "/remote.git"
+
+	config, err := NewConfigManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &SyncManager{config: config, keychain: NewMemoryKeychain()}
+
+	// Without a token the server rejects us
+	a := NewGoGitClient(filepath.Join(t.TempDir(), "a"), m.gitAuth())
+	if _, err := a.TestConnection(url); err == nil {
+		t.Fatal("expected connection without token to fail")
+	}
+
+	if err := m.StoreToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := a.TestConnection(url); err != nil || !result.Success {
+		t.Fatalf("TestConnection() = %+v, %v", result, err)
+	}
+
+	if err := a.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetRemote(url); err != nil {
+		t.Fatal(err)
+	}
+	writeRepoFile(t, a, "hosts.json", "{}")
+	commitAndPush(t, a, "sync: over http")
+
+	b := NewGoGitClient(filepath.Join(t.TempDir(), "b"), m.gitAuth())
+	if err := b.Clone(url); err != nil {
+		t.Fatalf("clone over http: %v", err)
+	}
+	if got := readRepoFile(t, b, "hosts.json"); got != "{}" {
+		t.Fatalf("cloned content = %q", got)
+	}
+	if behind, err := b.GetBehindCount(); err != nil || behind != 0 {
+		t.Fatalf("GetBehindCount() = %d, %v; want 0", behind, err)
+	}
+}
+
+// newSmartHTTPHandler serves the repositories below root over the Git
+// smart-HTTP protocol, requiring token as the basic auth password.
+func newSmartHTTPHandler(t *testing.T, root, token string) http.Handler {
+	srv := server.NewServer(server.NewFilesystemLoader(osfs.New(root)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, ok := r.BasicAuth(); !ok || password != token {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var repoPath, service string
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/info/refs"):
+			repoPath = strings.TrimSuffix(r.URL.Path, "/info/refs")
+			service = r.URL.Query().Get("service")
+		case strings.HasSuffix(r.URL.Path, "/"+transport.UploadPackServiceName):
+			repoPath = strings.TrimSuffix(r.URL.Path, "/"+transport.UploadPackServiceName)
+			service = transport.UploadPackServiceName
+		case strings.HasSuffix(r.URL.Path, "/"+transport.ReceivePackServiceName):
+			repoPath = strings.TrimSuffix(r.URL.Path, "/"+transport.ReceivePackServiceName)
+			service = transport.ReceivePackServiceName
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		ep, err := transport.NewEndpoint(repoPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := serveGitService(w, r, srv, ep, service); err != nil {
+			t.Logf("smart-http %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// serveGitService handles a single smart-HTTP request.
+func serveGitService(w http.ResponseWriter, r *http.Request, srv transport.Transport, ep *transport.Endpoint, service string) error {
+	ctx := r.Context()
+
+	switch service {
+	case transport.UploadPackServiceName:
+		sess, err := srv.NewUploadPackSession(ep, nil)
+		if err != nil {
+			return err
+		}
+		defer sess.Close()
+
+		if r.Method == http.MethodGet {
+			ar, err := sess.AdvertisedReferencesContext(ctx)
+			if err != nil {
+				return err
+			}
+			return writeAdvertisement(w, service, ar)
+		}
+
+		req := packp.NewUploadPackRequest()
+		if err := req.UploadRequest.Decode(r.Body); err != nil {
+			return err
+		}
+		if err := decodeHaves(r.Body, req); err != nil {
+			return err
+		}
+
+		resp, err := sess.UploadPack(ctx, req)
+		if err != nil {
+			return err
+		}
+		defer resp.Close()
+
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+		return resp.Encode(w)
+
+	case transport.ReceivePackServiceName:
+		sess, err := srv.NewReceivePackSession(ep, nil)
+		if err != nil {
+			return err
+		}
+		defer sess.Close()
+
+		if r.Method == http.MethodGet {
+			ar, err := sess.AdvertisedReferencesContext(ctx)
+			if err != nil {
+				return err
+			}
+			return writeAdvertisement(w, service, ar)
+		}
+
+		req := packp.NewReferenceUpdateRequest()
+		if err := req.Decode(r.Body); err != nil {
+			return err
+		}
+
+		status, err := sess.ReceivePack(ctx, req)
+		if status == nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", "application/x-git-receive-pack-result")
+		return status.Encode(w)
+	}
+
+	return fmt.Errorf("unsupported service %q", service)
+}
+
+// writeAdvertisement writes the reference advertisement for info/refs.
+func writeAdvertisement(w http.ResponseWriter, service string, ar *packp.AdvRefs) error {
+	ar.Prefix = [][]byte{
+		[]byte("# service=" + service),
+		pktline.Flush,
+	}
+
+	w.Header().Set("Content-Type", "application/x-"+service+"-advertisement")
+	return ar.Encode(w)
+}
+
+// decodeHaves reads the "have" lines that follow the upload request.
+func decodeHaves(body io.Reader, req *packp.UploadPackRequest) error {
+	scanner := pktline.NewScanner(body)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		switch {
+		case bytes.Equal(line, []byte("done")):
+			return nil
+		case bytes.HasPrefix(line, []byte("have ")):
+			req.Haves = append(req.Haves, plumbing.NewHash(string(line[len("have "):])))
+		}
+	}
+	return scanner.Err()
+}
@@ -19,10 +19,15 @@ var DefaultIgnorePatterns = []string{
 
 	// Update files
 	"updates",
+	"rollback/",
+	"install-id",
 
 	// Music Server files
 	"lx-music-service",
 
+	// Caches (resource proxy, exchange rates)
+	"cache/",
+
 	// Temporary files
 	"*.tmp",
 	"*.log",
@@ -33,6 +38,30 @@ var DefaultIgnorePatterns = []string{
 	// Sync configuration itself (avoid recursive sync)
 	"sync.json",
 
+	// Device-specific network settings
+	"network.json",
+
+	// Device-specific backup location and schedule
+	"backup.json",
+
+	// Log files and device-specific log levels
+	"logs/",
+	"logging.json",
+
+	// Local crash bundles
+	"crashes/",
+
+	// Clipboard history: may hold copied passwords and tokens, and the
+	// sqlite database changes on every copy
+	"clipboard/",
+
+	// Calculator history changes on every calculation; user functions
+	// (calculator/functions.json) are synced
+	"calculator/history.json",
+
+	// Running timers and stopwatches belong to this device
+	"datetime/timers.json",
+
 	// Git directory
 	".sync/",
 }
@@ -61,7 +90,7 @@ func (r *IgnoreRules) ShouldIgnore(relPath string) bool {
 	relPath = filepath.ToSlash(relPath)
 
 	for _, pattern := range r.patterns {
-		if r.matchPattern(relPath, pattern) {
+		if matchPattern(relPath, pattern) {
 			return true
 		}
 	}
@@ -69,7 +98,7 @@ func (r *IgnoreRules) ShouldIgnore(relPath string) bool {
 }
 
 // matchPattern checks if a path matches a single ignore pattern.
-func (r *IgnoreRules) matchPattern(path, pattern string) bool {
+func matchPattern(path, pattern string) bool {
 	// Normalize pattern
 	pattern = filepath.ToSlash(pattern)
 
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"net/url"
+	"time"
+)
+
+// PortableConfig is the synchronization setup carried by settings bundles
+// to provision other machines.
+type PortableConfig struct {
+	// Config is the sync configuration without device state such as the last sync.
+	Config *SyncConfig `json:"config"`
+
+	// Token is the HTTPS access token, only present when secrets are exported.
+	Token string `json:"token,omitempty"`
+}
+
+// ExportPortable returns the configuration of s for a settings bundle.
+// Credentials embedded in the repository URL and the stored token are
+// only included when includeSecrets is set.
+//
+// This is a function rather than a SyncService method so that the token is
+// never exposed to the frontend bindings.
+func ExportPortable(s *SyncService, includeSecrets bool) (*PortableConfig, error) {
+	cfg := *s.manager.GetConfig()
+	cfg.LastSyncTime = time.Time{}
+	cfg.LastSyncHash = ""
+
+	portable := &PortableConfig{Config: &cfg}
+	if !includeSecrets {
+		cfg.RepoURL = stripCredentials(cfg.RepoURL)
+		return portable, nil
+	}
+
+	if s.manager.HasToken() {
+		token, err := s.manager.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		portable.Token = token
+	}
+	return portable, nil
+}
+
+// ImportPortable applies a configuration exported by ExportPortable.
+// The last sync state is kept when the repository stays the same, and the
+// stored token is only replaced when the bundle carries one.
+func ImportPortable(s *SyncService, portable *PortableConfig) error {
+	if portable.Config == nil {
+		return nil
+	}
+
+	cfg := *portable.Config
+	current := s.manager.GetConfig()
+	cfg.LastSyncTime = time.Time{}
+	cfg.LastSyncHash = ""
+	if stripCredentials(cfg.RepoURL) == stripCredentials(current.RepoURL) {
+		cfg.LastSyncTime = current.LastSyncTime
+		cfg.LastSyncHash = current.LastSyncHash
+		// Keep locally embedded credentials when the bundle has none
+		if cfg.RepoURL == stripCredentials(current.RepoURL) {
+			cfg.RepoURL = current.RepoURL
+		}
+	}
+
+	if portable.Token != "" {
+		if err := s.manager.StoreToken(portable.Token); err != nil {
+			return err
+		}
+	}
+	return s.manager.SetConfig(&cfg)
+}
+
+// stripCredentials removes the user info from an HTTPS repository URL.
+// SSH URLs keep their user name, which is not a secret.
+func stripCredentials(repoURL string) string {
+	if !IsHTTPSURL(repoURL) {
+		return repoURL
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil || u.User == nil {
+		return repoURL
+	}
+	u.User = nil
+	return u.String()
+}
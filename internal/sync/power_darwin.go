@@ -0,0 +1,22 @@
+package sync
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// onBatteryPower reports whether the machine is running on battery.
+func onBatteryPower() bool {
+	output, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), "'Battery Power'")
+}
+
+// onMeteredNetwork reports whether the current network is metered.
+// macOS exposes Low Data Mode only through the Network framework, so this
+// always returns false.
+func onMeteredNetwork() bool {
+	return false
+}
@@ -0,0 +1,68 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// NetworkManager metered states that mean the connection is metered.
+const (
+	nmMeteredYes      = 1
+	nmMeteredGuessYes = 3
+)
+
+// onBatteryPower reports whether the machine is running on battery.
+func onBatteryPower() bool {
+	const powerSupplyDir = "/sys/class/power_supply"
+
+	entries, err := os.ReadDir(powerSupplyDir)
+	if err != nil {
+		return false
+	}
+
+	hasBattery := false
+	for _, entry := range entries {
+		dir := filepath.Join(powerSupplyDir, entry.Name())
+		switch readSysValue(filepath.Join(dir, "type")) {
+		case "Mains", "USB":
+			if readSysValue(filepath.Join(dir, "online")) == "1" {
+				return false
+			}
+		case "Battery":
+			hasBattery = true
+		}
+	}
+
+	return hasBattery
+}
+
+// onMeteredNetwork reports whether NetworkManager considers the primary
+// connection metered.
+func onMeteredNetwork() bool {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	obj := conn.Object("org.freedesktop.NetworkManager", "/org/freedesktop/NetworkManager")
+	value, err := obj.GetProperty("org.freedesktop.NetworkManager.Metered")
+	if err != nil {
+		return false
+	}
+
+	metered, ok := value.Value().(uint32)
+	return ok && (metered == nmMeteredYes || metered == nmMeteredGuessYes)
+}
+
+// readSysValue reads a single-line sysfs attribute.
+func readSysValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
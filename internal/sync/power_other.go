@@ -0,0 +1,13 @@
+//go:build !darwin && !windows && !linux
+
+package sync
+
+// onBatteryPower reports whether the machine is running on battery.
+func onBatteryPower() bool {
+	return false
+}
+
+// onMeteredNetwork reports whether the current network is metered.
+func onMeteredNetwork() bool {
+	return false
+}
@@ -0,0 +1,39 @@
+package sync
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procGetSystemPowerStatus = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetSystemPowerStatus")
+
+// systemPowerStatus mirrors the Win32 SYSTEM_POWER_STATUS structure.
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// onBatteryPower reports whether the machine is running on battery.
+func onBatteryPower() bool {
+	var status systemPowerStatus
+	ret, _, _ := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return false
+	}
+
+	// ACLineStatus: 0 = offline, 1 = online, 255 = unknown
+	// BatteryFlag 128 means there is no system battery
+	return status.ACLineStatus == 0 && status.BatteryFlag != 128
+}
+
+// onMeteredNetwork reports whether the current network is metered.
+// The connection cost is only available through WinRT, so this always
+// returns false.
+func onMeteredNetwork() bool {
+	return false
+}
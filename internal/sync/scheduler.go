@@ -0,0 +1,203 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultDebounce is used when the configuration has no debounce delay.
+const defaultDebounce = 10 * time.Second
+
+// StartAutoSync starts automatic synchronization.
+// Local changes are detected by watching the data directory and pushed once
+// writes settle; the remote is checked for new commits every SyncInterval.
+func (m *SyncManager) StartAutoSync() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return nil
+	}
+
+	cfg := m.config.Get()
+	log.Printf("[SyncManager] StartAutoSync called: Enabled=%v, AutoSync=%v, SyncInterval=%d, Debounce=%ds",
+		cfg.Enabled, cfg.AutoSync, cfg.SyncInterval, cfg.DebounceSeconds)
+
+	if !cfg.Enabled {
+		log.Println("[SyncManager] Sync is disabled, auto-sync will not start")
+		return nil
+	}
+
+	if !cfg.AutoSync {
+		log.Println("[SyncManager] Auto-sync is disabled in config")
+		return nil
+	}
+
+	if cfg.SyncInterval <= 0 {
+		log.Printf("[SyncManager] Invalid sync interval: %d", cfg.SyncInterval)
+		return nil
+	}
+
+	watcher, err := NewDataWatcher(m.dataDir, m.shouldWatch)
+	if err != nil {
+		return fmt.Errorf("failed to watch data directory: %w", err)
+	}
+
+	debounce := time.Duration(cfg.DebounceSeconds) * time.Second
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+	interval := time.Duration(cfg.SyncInterval) * time.Minute
+
+	m.watcher = watcher
+	m.running = true
+	m.nextAction = ScheduledActionFetch
+	m.nextActionTime = time.Now().Add(interval)
+
+	go m.autoSyncLoop(watcher, debounce, interval, m.stopChan)
+
+	log.Printf("[SyncManager] Auto-sync started (debounce %v, remote check every %v)", debounce, interval)
+	return nil
+}
+
+// StopAutoSync stops automatic synchronization.
+func (m *SyncManager) StopAutoSync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return
+	}
+
+	if m.watcher != nil {
+		m.watcher.Close()
+		m.watcher = nil
+	}
+
+	close(m.stopChan)
+	m.stopChan = make(chan struct{})
+	m.running = false
+	m.nextAction = ""
+	m.nextActionTime = time.Time{}
+	m.pausedReason = ""
+
+	log.Println("[SyncManager] Auto-sync stopped")
+}
+
+// autoSyncLoop reacts to file changes and periodic remote checks until stopped.
+func (m *SyncManager) autoSyncLoop(watcher *DataWatcher, debounce, interval time.Duration, stop <-chan struct{}) {
+	fetchTicker := time.NewTicker(interval)
+	defer fetchTicker.Stop()
+
+	debounceTimer := time.NewTimer(debounce)
+	debounceTimer.Stop()
+	defer debounceTimer.Stop()
+
+	nextFetch := time.Now().Add(interval)
+	pendingPush := false
+
+	for {
+		select {
+		case relPath := <-watcher.Changes():
+			log.Printf("[SyncManager] Change detected: %s", relPath)
+			pendingPush = true
+			debounceTimer.Reset(debounce)
+			m.schedule(ScheduledActionPush, time.Now().Add(debounce))
+
+		case <-debounceTimer.C:
+			if m.runScheduled("push") {
+				pendingPush = false
+			}
+			m.schedule(ScheduledActionFetch, nextFetch)
+
+		case <-fetchTicker.C:
+			nextFetch = time.Now().Add(interval)
+			if pendingPush {
+				// A deferred push also pulls remote changes
+				if m.runScheduled("push") {
+					pendingPush = false
+				}
+			} else if m.remoteHasChanges() {
+				m.runScheduled("pull")
+			}
+			m.schedule(ScheduledActionFetch, nextFetch)
+
+		case <-stop:
+			log.Println("[SyncManager] Auto-sync goroutine stopped")
+			return
+		}
+	}
+}
+
+// runScheduled performs an automatic sync unless it is currently paused.
+// Returns true if the sync ran successfully.
+func (m *SyncManager) runScheduled(reason string) bool {
+	if paused := m.pauseReason(); paused != "" {
+		log.Printf("[SyncManager] Auto-sync (%s) deferred: %s", reason, paused)
+		return false
+	}
+
+	result := m.Sync()
+	if !result.Success {
+		log.Printf("[SyncManager] Auto-sync (%s) failed: %s", reason, result.Error)
+		m.mu.Lock()
+		m.lastError = fmt.Errorf("%s", result.Error)
+		m.mu.Unlock()
+		return false
+	}
+
+	if result.Message != "" {
+		log.Printf("[SyncManager] Auto-sync (%s) succeeded: %s", reason, result.Message)
+	}
+	return true
+}
+
+// remoteHasChanges fetches from the remote and reports whether it is ahead.
+func (m *SyncManager) remoteHasChanges() bool {
+	git := m.gitClient()
+	if m.pauseReason() != "" || !git.IsRepo() {
+		return false
+	}
+
+	behind, err := git.GetBehindCount()
+	if err != nil {
+		log.Printf("[SyncManager] Remote check failed: %v", err)
+		return false
+	}
+	if behind > 0 {
+		log.Printf("[SyncManager] Remote is %d commit(s) ahead", behind)
+	}
+	return behind > 0
+}
+
+// pauseReason returns why automatic sync should be deferred, or "" if it may run.
+func (m *SyncManager) pauseReason() string {
+	cfg := m.config.Get()
+
+	reason := ""
+	switch {
+	case cfg.PauseOnBattery && onBatteryPower():
+		reason = "正在使用电池供电，自动同步已暂停"
+	case cfg.PauseOnMetered && onMeteredNetwork():
+		reason = "当前为按流量计费的网络，自动同步已暂停"
+	}
+
+	m.mu.Lock()
+	m.pausedReason = reason
+	m.mu.Unlock()
+	return reason
+}
+
+// schedule records the next automatic action for GetStatus.
+func (m *SyncManager) schedule(action ScheduledAction, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextAction = action
+	m.nextActionTime = at
+}
+
+// shouldWatch reports whether changes to relPath should trigger a sync.
+func (m *SyncManager) shouldWatch(relPath string) bool {
+	return !m.ignore.ShouldIgnore(relPath) && m.dataSelection().Includes(relPath)
+}
@@ -0,0 +1,122 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// DataSelection decides which parts of the data directory take part in a sync,
+// based on the data paths declared by plugins and the active device profile.
+type DataSelection struct {
+	specs    []PluginDataSpec
+	selected map[string]bool // nil means every plugin is selected
+}
+
+// NewDataSelection creates a DataSelection for the given plugin specs.
+// If profile is nil, all plugins are selected.
+func NewDataSelection(specs []PluginDataSpec, profile *SyncProfile) *DataSelection {
+	s := &DataSelection{specs: specs}
+	if profile != nil {
+		s.selected = make(map[string]bool, len(profile.Plugins))
+		for _, id := range profile.Plugins {
+			s.selected[id] = true
+		}
+	}
+	return s
+}
+
+// Owner returns the spec of the plugin that owns relPath, if any.
+func (s *DataSelection) Owner(relPath string) (*PluginDataSpec, bool) {
+	relPath = filepath.ToSlash(relPath)
+	for i := range s.specs {
+		for _, p := range s.specs[i].Paths {
+			if matchPattern(relPath, p) {
+				return &s.specs[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Includes reports whether relPath should be synchronized.
+// Paths not owned by any plugin (shared app settings) are always included.
+func (s *DataSelection) Includes(relPath string) bool {
+	if s.selected == nil {
+		return true
+	}
+	owner, ok := s.Owner(relPath)
+	if !ok {
+		return true
+	}
+	return s.selected[owner.PluginID]
+}
+
+// LocalFields returns the machine-specific JSON field paths declared for relPath.
+func (s *DataSelection) LocalFields(relPath string) []string {
+	owner, ok := s.Owner(relPath)
+	if !ok {
+		return nil
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	var fields []string
+	for _, lf := range owner.LocalFields {
+		file, field, found := strings.Cut(lf, ":")
+		if found && file == relPath {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// stripLocalFields removes the given fields from a JSON document.
+// Field paths are dot-separated; "*" matches every element of an array or object.
+func stripLocalFields(data []byte, fields []string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		deleteField(doc, strings.Split(field, "."))
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// deleteField removes the field addressed by parts from node.
+func deleteField(node interface{}, parts []string) {
+	if len(parts) == 0 {
+		return
+	}
+
+	key, rest := parts[0], parts[1:]
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if key == "*" {
+			for _, child := range v {
+				deleteField(child, rest)
+			}
+			return
+		}
+		if len(rest) == 0 {
+			delete(v, key)
+			return
+		}
+		if child, ok := v[key]; ok {
+			deleteField(child, rest)
+		}
+	case []interface{}:
+		if key != "*" {
+			return
+		}
+		for _, child := range v {
+			deleteField(child, rest)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func testSpecs() []PluginDataSpec {
+	return []PluginDataSpec{
+		{PluginID: "kanban.builtin", Paths: []string{"kanban/"}},
+		{PluginID: "bookmark.builtin", Paths: []string{"bookmarks.json", "bookmark-icons/"},
+			LocalFields: []string{"bookmarks.json:browsers.*.profilePath", "bookmarks.json:lastImport"}},
+	}
+}
+
+func TestDataSelectionIncludes(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *SyncProfile
+		path    string
+		want    bool
+	}{
+		{"no profile", nil, "kanban/boards.json", true},
+		{"selected plugin", &SyncProfile{Plugins: []string{"kanban.builtin"}}, "kanban/boards.json", true},
+		{"unselected plugin", &SyncProfile{Plugins: []string{"kanban.builtin"}}, "bookmarks.json", false},
+		{"unselected plugin dir", &SyncProfile{Plugins: []string{"kanban.builtin"}}, "bookmark-icons/a.png", false},
+		{"shared settings", &SyncProfile{Plugins: []string{}}, "settings.json", true},
+		{"directory prefix only", &SyncProfile{Plugins: []string{}}, "kanban-old/boards.json", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewDataSelection(testSpecs(), tt.profile)
+			if got := s.Includes(tt.path); got != tt.want {
+				t.Errorf("Includes(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataSelectionLocalFields(t *testing.T) {
+	s := NewDataSelection(testSpecs(), nil)
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"bookmarks.json", []string{"browsers.*.profilePath", "lastImport"}},
+		{"bookmark-icons/a.png", nil},
+		{"kanban/boards.json", nil},
+		{"settings.json", nil},
+	}
+	for _, tt := range tests {
+		if got := s.LocalFields(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LocalFields(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestStripLocalFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		fields []string
+		want   string
+	}{
+		{"top-level field", `{"a":1,"b":2}`, []string{"b"}, `{"a":1}`},
+		{"nested field", `{"a":{"b":1,"c":2}}`, []string{"a.c"}, `{"a":{"b":1}}`},
+		{"array elements", `{"list":[{"id":1,"path":"/x"},{"id":2,"path":"/y"}]}`, []string{"list.*.path"},
+			`{"list":[{"id":1},{"id":2}]}`},
+		{"object values", `{"m":{"k1":{"p":1,"q":2},"k2":{"p":3}}}`, []string{"m.*.p"}, `{"m":{"k1":{"q":2},"k2":{}}}`},
+		{"missing field", `{"a":1}`, []string{"b.c", "a.x"}, `{"a":1}`},
+		{"index is not a wildcard", `{"list":[{"p":1}]}`, []string{"list.0.p"}, `{"list":[{"p":1}]}`},
+		{"large numbers kept", `{"id":12345678901234567890,"x":1}`, []string{"x"}, `{"id":12345678901234567890}`},
+		{"no fields", `[1,2]`, nil, `[1,2]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stripLocalFields([]byte(tt.input), tt.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("stripLocalFields(%s, %q) = %s, want %s", tt.input, tt.fields, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := stripLocalFields([]byte(`{"a":`), []string{"a"}); err == nil {
+		t.Error("stripLocalFields accepted invalid JSON")
+	}
+}
+
+// jsonEqual compares two JSON documents, keeping numbers as written.
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	return compactJSON(t, a) == compactJSON(t, b)
+}
+
+func compactJSON(t *testing.T, data []byte) string {
+	t.Helper()
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
@@ -2,7 +2,7 @@ package sync
 
 import (
 	"context"
-	"fmt"
+	"log"
 	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
@@ -51,9 +51,9 @@ func (s *SyncService) ServiceShutdown(app *application.App) error {
 		go func() {
 			result := s.manager.Sync()
 			if !result.Success {
-				fmt.Printf("[SyncService] Shutdown sync failed: %s\n", result.Error)
+				log.Printf("[SyncService] Shutdown sync failed: %s", result.Error)
 			} else {
-				fmt.Printf("[SyncService] Shutdown sync completed: %s\n", result.Message)
+				log.Printf("[SyncService] Shutdown sync completed: %s", result.Message)
 			}
 			close(done)
 		}()
@@ -63,7 +63,7 @@ func (s *SyncService) ServiceShutdown(app *application.App) error {
 		case <-done:
 			// Sync completed successfully
 		case <-ctx.Done():
-			fmt.Println("[SyncService] Shutdown sync timeout after 10 seconds, continuing...")
+			log.Println("[SyncService] Shutdown sync timeout after 10 seconds, continuing...")
 		}
 	}
 
@@ -145,6 +145,11 @@ func (s *SyncService) IsGitInstalled() bool {
 	return s.manager.IsGitInstalled()
 }
 
+// IsSyncAvailable reports whether sync can run with the configured Git engine.
+func (s *SyncService) IsSyncAvailable() bool {
+	return s.manager.IsSyncAvailable()
+}
+
 // CheckSSHCredential checks if SSH credentials are available.
 func (s *SyncService) CheckSSHCredential() bool {
 	return s.manager.CheckSSHCredential()
@@ -173,3 +178,33 @@ func (s *SyncService) SetSyncInterval(minutes int) error {
 	cfg.SyncInterval = minutes
 	return s.manager.SetConfig(cfg)
 }
+
+// RegisterPluginData registers the data paths owned by plugins.
+func (s *SyncService) RegisterPluginData(specs ...PluginDataSpec) {
+	s.manager.RegisterPluginData(specs...)
+}
+
+// GetPluginData returns the data paths registered by plugins.
+func (s *SyncService) GetPluginData() []PluginDataSpec {
+	return s.manager.GetPluginData()
+}
+
+// GetProfiles returns all device profiles.
+func (s *SyncService) GetProfiles() []SyncProfile {
+	return s.manager.GetConfig().Profiles
+}
+
+// SaveProfile creates or replaces a device profile.
+func (s *SyncService) SaveProfile(profile SyncProfile) error {
+	return s.manager.SaveProfile(profile)
+}
+
+// DeleteProfile removes a device profile.
+func (s *SyncService) DeleteProfile(name string) error {
+	return s.manager.DeleteProfile(name)
+}
+
+// SetActiveProfile selects the device profile used on this machine.
+func (s *SyncService) SetActiveProfile(name string) error {
+	return s.manager.SetActiveProfile(name)
+}
@@ -3,6 +3,7 @@ package sync
 import (
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,16 +15,23 @@ import (
 type SyncManager struct {
 	mu         sync.RWMutex
 	config     *ConfigManager
-	git        *GitClient
+	git        GitBackend
 	keychain   Keychain
 	dataDir    string
 	syncDir    string // .sync subdirectory for Git repo
 	ignore     *IgnoreRules
-	ticker     *time.Ticker
+	dataMu     sync.RWMutex // guards pluginData, independent of mu
+	pluginData []PluginDataSpec
+	watcher    *DataWatcher
 	stopChan   chan struct{}
 	running    bool
 	syncing    bool
 	lastError  error
+
+	// Scheduler state exposed through GetStatus
+	nextAction     ScheduledAction
+	nextActionTime time.Time
+	pausedReason   string
 }
 
 // NewSyncManager creates a new SyncManager.
@@ -38,15 +46,42 @@ func NewSyncManager(dataDir string) (*SyncManager, error) {
 	// Create OS keychain for credential storage
 	keychain := NewOSKeychain(KeychainServiceName)
 
-	return &SyncManager{
+	m := &SyncManager{
 		config:   config,
-		git:      NewGitClient(syncDir),
 		keychain: keychain,
 		dataDir:  dataDir,
 		syncDir:  syncDir,
 		ignore:   NewIgnoreRules(),
 		stopChan: make(chan struct{}),
-	}, nil
+	}
+	m.git = m.newGitBackend(config.Get())
+
+	return m, nil
+}
+
+// newGitBackend creates the Git backend selected by the configuration.
+// In auto mode the system git is preferred and the embedded engine is used
+// when no git binary is installed.
+func (m *SyncManager) newGitBackend(cfg *SyncConfig) GitBackend {
+	switch cfg.GitEngine {
+	case GitEngineSystem:
+		return NewGitClient(m.syncDir)
+	case GitEngineEmbedded:
+		return NewGoGitClient(m.syncDir, m.gitAuth())
+	}
+
+	if isGitInstalled() {
+		return NewGitClient(m.syncDir)
+	}
+	log.Println("[SyncManager] git not found, using embedded Git engine")
+	return NewGoGitClient(m.syncDir, m.gitAuth())
+}
+
+// gitClient returns the current Git backend.
+func (m *SyncManager) gitClient() GitBackend {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.git
 }
 
 // Sync performs a full synchronization.
@@ -60,6 +95,7 @@ func (m *SyncManager) Sync() *SyncResult {
 		}
 	}
 	m.syncing = true
+	git := m.git
 	m.mu.Unlock()
 
 	defer func() {
@@ -78,15 +114,15 @@ func (m *SyncManager) Sync() *SyncResult {
 		return result
 	}
 
-	// Check if git is installed
-	if !m.git.IsGitInstalled() {
+	// The system engine needs a git binary, auto mode falls back to the embedded engine
+	if cfg.GitEngine == GitEngineSystem && !isGitInstalled() {
 		result.Success = false
 		result.Error = "未安装 Git"
 		return result
 	}
 
 	// Ensure repository is set up
-	if err := m.ensureRepo(cfg); err != nil {
+	if err := m.ensureRepo(git, cfg); err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("设置仓库失败: %v", err)
 		m.lastError = err
@@ -94,13 +130,13 @@ func (m *SyncManager) Sync() *SyncResult {
 	}
 
 	// Pull remote changes first (if repo exists and has commits)
-	if m.git.IsRepo() {
-		if _, err := m.git.GetCommitHash(); err == nil {
+	if git.IsRepo() {
+		if _, err := git.GetCommitHash(); err == nil {
 			// Repo has commits, try to pull
-			fmt.Printf("[SyncManager] Pulling remote changes...\n")
-			if err := m.git.Pull(); err != nil {
+			log.Printf("[SyncManager] Pulling remote changes...")
+			if err := git.Pull(); err != nil {
 				// Non-fatal: might be no remote commits yet
-				fmt.Printf("[SyncManager] Pull warning (non-fatal): %v\n", err)
+				log.Printf("[SyncManager] Pull warning (non-fatal): %v", err)
 			}
 		}
 	}
@@ -116,7 +152,7 @@ func (m *SyncManager) Sync() *SyncResult {
 	result.FilesChanged = filesChanged
 
 	// Check if there are changes to commit
-	hasChanges, err := m.git.HasChanges()
+	hasChanges, err := git.HasChanges()
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("检查变更失败: %v", err)
@@ -130,7 +166,7 @@ func (m *SyncManager) Sync() *SyncResult {
 	}
 
 	// Stage all changes
-	if err := m.git.AddAll(); err != nil {
+	if err := git.AddAll(); err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("暂存变更失败: %v", err)
 		return result
@@ -138,33 +174,33 @@ func (m *SyncManager) Sync() *SyncResult {
 
 	// Create commit
 	commitMsg := fmt.Sprintf("sync: %s", time.Now().Format("2006-01-02 15:04:05"))
-	if err := m.git.Commit(commitMsg); err != nil {
+	if err := git.Commit(commitMsg); err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("提交失败: %v", err)
 		return result
 	}
 
 	// Try to push (without force first)
-	if err := m.git.Push(false); err != nil {
-		fmt.Printf("[SyncManager] Normal push failed, pulling and retrying: %v\n", err)
+	if err := git.Push(false); err != nil {
+		log.Printf("[SyncManager] Normal push failed, pulling and retrying: %v", err)
 
 		// Normal push failed, might be remote has new commits
 		// Pull again to get latest changes
-		if pullErr := m.git.Pull(); pullErr != nil {
-			fmt.Printf("[SyncManager] Pull failed: %v\n", pullErr)
+		if pullErr := git.Pull(); pullErr != nil {
+			log.Printf("[SyncManager] Pull failed: %v", pullErr)
 			// If pull also fails, try force-with-lease as last resort
-			if forceErr := m.git.Push(true); forceErr != nil {
+			if forceErr := git.Push(true); forceErr != nil {
 				result.Success = false
 				result.Error = fmt.Sprintf("推送失败（尝试强制推送也失败）: %v", forceErr)
 				return result
 			}
-			fmt.Printf("[SyncManager] Force push succeeded\n")
+			log.Printf("[SyncManager] Force push succeeded")
 		} else {
 			// Pull succeeded, try normal push again
-			if retryErr := m.git.Push(false); retryErr != nil {
+			if retryErr := git.Push(false); retryErr != nil {
 				// Still failed, use force-with-lease as last resort
-				fmt.Printf("[SyncManager] Retry push failed, using force-with-lease: %v\n", retryErr)
-				if forceErr := m.git.Push(true); forceErr != nil {
+				log.Printf("[SyncManager] Retry push failed, using force-with-lease: %v", retryErr)
+				if forceErr := git.Push(true); forceErr != nil {
 					result.Success = false
 					result.Error = fmt.Sprintf("推送失败: %v", forceErr)
 					return result
@@ -174,7 +210,7 @@ func (m *SyncManager) Sync() *SyncResult {
 	}
 
 	// Get commit hash
-	hash, _ := m.git.GetShortHash()
+	hash, _ := git.GetShortHash()
 	result.CommitHash = hash
 
 	// Update last sync info
@@ -187,13 +223,13 @@ func (m *SyncManager) Sync() *SyncResult {
 }
 
 // ensureRepo ensures the Git repository is properly set up.
-func (m *SyncManager) ensureRepo(cfg *SyncConfig) error {
+func (m *SyncManager) ensureRepo(git GitBackend, cfg *SyncConfig) error {
 	// Check if sync directory exists as a Git repo
-	if m.git.IsRepo() {
+	if git.IsRepo() {
 		// Update remote URL if changed
-		currentURL, err := m.git.GetRemoteURL()
+		currentURL, err := git.GetRemoteURL()
 		if err != nil || currentURL != cfg.RepoURL {
-			if err := m.git.SetRemote(cfg.RepoURL); err != nil {
+			if err := git.SetRemote(cfg.RepoURL); err != nil {
 				return err
 			}
 		}
@@ -208,19 +244,19 @@ func (m *SyncManager) ensureRepo(cfg *SyncConfig) error {
 	}
 
 	// Try to clone the repository
-	if err := m.git.Clone(cfg.RepoURL); err != nil {
+	if err := git.Clone(cfg.RepoURL); err != nil {
 		// If clone fails (e.g., empty repo), initialize new repo
-		fmt.Printf("[SyncManager] Clone failed, initializing new repo: %v\n", err)
-		if err := m.git.Init(); err != nil {
+		log.Printf("[SyncManager] Clone failed, initializing new repo: %v", err)
+		if err := git.Init(); err != nil {
 			return fmt.Errorf("failed to init repo: %w", err)
 		}
-		if err := m.git.SetRemote(cfg.RepoURL); err != nil {
+		if err := git.SetRemote(cfg.RepoURL); err != nil {
 			return fmt.Errorf("failed to set remote: %w", err)
 		}
 	}
 
 	// Write .gitignore
-	if err := m.git.WriteGitignore(m.ignore.ToGitignore()); err != nil {
+	if err := git.WriteGitignore(m.ignore.ToGitignore()); err != nil {
 		return fmt.Errorf("failed to write .gitignore: %w", err)
 	}
 
@@ -231,6 +267,7 @@ func (m *SyncManager) ensureRepo(cfg *SyncConfig) error {
 // Returns the number of files copied.
 func (m *SyncManager) copyFilesToSyncDir() (int, error) {
 	count := 0
+	selection := m.dataSelection()
 
 	// First, remove files in sync dir that no longer exist in data dir
 	// This ensures deletions are also synchronized
@@ -250,11 +287,20 @@ func (m *SyncManager) copyFilesToSyncDir() (int, error) {
 			return nil
 		}
 
+		// Leave data of plugins not selected on this device untouched,
+		// other devices may still be syncing it
+		if !selection.Includes(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		// Check if this file/directory exists in data directory
 		dataPath := filepath.Join(m.dataDir, relPath)
 		if _, err := os.Stat(dataPath); os.IsNotExist(err) {
 			// File/directory no longer exists in data dir, remove from sync dir
-			fmt.Printf("[SyncManager] Removing deleted file: %s\n", relPath)
+			log.Printf("[SyncManager] Removing deleted file: %s", relPath)
 			if err := os.RemoveAll(syncPath); err != nil {
 				return fmt.Errorf("failed to remove %s: %w", relPath, err)
 			}
@@ -288,7 +334,7 @@ func (m *SyncManager) copyFilesToSyncDir() (int, error) {
 		}
 
 		// Check if should ignore
-		if m.ignore.ShouldIgnore(relPath) {
+		if m.ignore.ShouldIgnore(relPath) || !selection.Includes(relPath) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
@@ -303,8 +349,12 @@ func (m *SyncManager) copyFilesToSyncDir() (int, error) {
 			return os.MkdirAll(destPath, info.Mode())
 		}
 
-		// Copy file
-		if err := m.copyFile(path, destPath); err != nil {
+		// Copy file, dropping machine-specific fields
+		if fields := selection.LocalFields(relPath); len(fields) > 0 {
+			if err := m.copyFileWithoutFields(path, destPath, fields); err != nil {
+				return fmt.Errorf("failed to copy %s: %w", relPath, err)
+			}
+		} else if err := m.copyFile(path, destPath); err != nil {
 			return fmt.Errorf("failed to copy %s: %w", relPath, err)
 		}
 		count++
@@ -347,89 +397,112 @@ func (m *SyncManager) copyFile(src, dst string) error {
 	return err
 }
 
-// StartAutoSync starts automatic synchronization at the configured interval.
-func (m *SyncManager) StartAutoSync() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if m.running {
-		return nil
+// copyFileWithoutFields copies a JSON file, removing the given local fields.
+func (m *SyncManager) copyFileWithoutFields(src, dst string, fields []string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
 	}
 
-	cfg := m.config.Get()
-	fmt.Printf("[SyncManager] StartAutoSync called: Enabled=%v, AutoSync=%v, SyncInterval=%d\n",
-		cfg.Enabled, cfg.AutoSync, cfg.SyncInterval)
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
 
-	if !cfg.Enabled {
-		fmt.Println("[SyncManager] Sync is disabled, auto-sync will not start")
-		return nil
+	stripped, err := stripLocalFields(data, fields)
+	if err != nil {
+		return fmt.Errorf("failed to strip local fields: %w", err)
 	}
 
-	if !cfg.AutoSync {
-		fmt.Println("[SyncManager] Auto-sync is disabled in config")
-		return nil
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
 	}
+	return os.WriteFile(dst, stripped, srcInfo.Mode())
+}
 
-	if cfg.SyncInterval <= 0 {
-		fmt.Printf("[SyncManager] Invalid sync interval: %d\n", cfg.SyncInterval)
-		return nil
+// dataSelection builds the data selection for the active device profile.
+func (m *SyncManager) dataSelection() *DataSelection {
+	m.dataMu.RLock()
+	specs := append([]PluginDataSpec{}, m.pluginData...)
+	m.dataMu.RUnlock()
+
+	cfg := m.config.Get()
+	var profile *SyncProfile
+	if cfg.ActiveProfile != "" {
+		profile = cfg.GetProfile(cfg.ActiveProfile)
 	}
+	return NewDataSelection(specs, profile)
+}
 
-	interval := time.Duration(cfg.SyncInterval) * time.Minute
-	m.ticker = time.NewTicker(interval)
-	m.running = true
-	nextSync := time.Now().Add(interval)
-
-	fmt.Printf("[SyncManager] Auto-sync started with interval %v\n", interval)
-	fmt.Printf("[SyncManager] Next sync will trigger at: %s\n", nextSync.Format("15:04:05"))
-
-	go func() {
-		fmt.Printf("[SyncManager] Auto-sync goroutine started, waiting for ticker (interval: %v)\n", interval)
-		syncCount := 0
-		for {
-			select {
-			case <-m.ticker.C:
-				syncCount++
-				fmt.Printf("[SyncManager] Ticker triggered (sync #%d at %v)\n", syncCount, time.Now().Format("15:04:05"))
-				result := m.Sync()
-				if !result.Success {
-					fmt.Printf("[SyncManager] Auto-sync failed: %s\n", result.Error)
-					m.mu.Lock()
-					m.lastError = fmt.Errorf("%s", result.Error)
-					m.mu.Unlock()
-				} else if result.Message != "" {
-					fmt.Printf("[SyncManager] Auto-sync succeeded: %s\n", result.Message)
-				}
-			case <-m.stopChan:
-				fmt.Printf("[SyncManager] Auto-sync goroutine stopped (completed %d syncs)\n", syncCount)
-				return
+// RegisterPluginData registers the data paths owned by plugins.
+func (m *SyncManager) RegisterPluginData(specs ...PluginDataSpec) {
+	m.dataMu.Lock()
+	defer m.dataMu.Unlock()
+
+	for _, spec := range specs {
+		replaced := false
+		for i := range m.pluginData {
+			if m.pluginData[i].PluginID == spec.PluginID {
+				m.pluginData[i] = spec
+				replaced = true
+				break
 			}
 		}
-	}()
-
-	fmt.Printf("[SyncManager] Auto-sync started with interval %v\n", interval)
-	return nil
+		if !replaced {
+			m.pluginData = append(m.pluginData, spec)
+		}
+	}
 }
 
-// StopAutoSync stops automatic synchronization.
-func (m *SyncManager) StopAutoSync() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// GetPluginData returns the data paths registered by plugins.
+func (m *SyncManager) GetPluginData() []PluginDataSpec {
+	m.dataMu.RLock()
+	defer m.dataMu.RUnlock()
+	return append([]PluginDataSpec{}, m.pluginData...)
+}
 
-	if !m.running {
-		return
+// SaveProfile creates or replaces a device profile.
+func (m *SyncManager) SaveProfile(profile SyncProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("profile name is required")
 	}
 
-	if m.ticker != nil {
-		m.ticker.Stop()
-		m.ticker = nil
-	}
+	return m.config.UpdateAndSave(func(c *SyncConfig) {
+		if existing := c.GetProfile(profile.Name); existing != nil {
+			*existing = profile
+			return
+		}
+		c.Profiles = append(c.Profiles, profile)
+	})
+}
 
-	close(m.stopChan)
-	m.stopChan = make(chan struct{})
-	m.running = false
+// DeleteProfile removes a device profile.
+// If it was the active profile, all plugins are synchronized again.
+func (m *SyncManager) DeleteProfile(name string) error {
+	return m.config.UpdateAndSave(func(c *SyncConfig) {
+		profiles := c.Profiles[:0]
+		for _, p := range c.Profiles {
+			if p.Name != name {
+				profiles = append(profiles, p)
+			}
+		}
+		c.Profiles = profiles
+		if c.ActiveProfile == name {
+			c.ActiveProfile = ""
+		}
+	})
+}
+
+// SetActiveProfile selects the device profile used on this machine.
+// An empty name synchronizes all plugins.
+func (m *SyncManager) SetActiveProfile(name string) error {
+	if name != "" && m.config.Get().GetProfile(name) == nil {
+		return fmt.Errorf("profile %q not found", name)
+	}
 
-	fmt.Println("[SyncManager] Auto-sync stopped")
+	return m.config.UpdateAndSave(func(c *SyncConfig) {
+		c.ActiveProfile = name
+	})
 }
 
 // GetStatus returns the current synchronization status.
@@ -448,6 +521,12 @@ func (m *SyncManager) GetStatus() *SyncStatus {
 		RemoteURL:    cfg.RepoURL,
 	}
 
+	if m.running {
+		status.NextAction = m.nextAction
+		status.NextActionTime = m.nextActionTime
+		status.PausedReason = m.pausedReason
+	}
+
 	if m.lastError != nil {
 		status.Error = m.lastError.Error()
 	}
@@ -478,27 +557,35 @@ func (m *SyncManager) SetConfig(cfg *SyncConfig) error {
 	// Check if we need to restart auto-sync
 	// Restart is needed if:
 	// 1. Auto-sync is currently running AND
-	// 2. Either AutoSync flag changed OR SyncInterval changed
+	// 2. Either AutoSync flag, SyncInterval or DebounceSeconds changed
 	needsRestart := m.running &&
 		(oldCfg.AutoSync != cfg.AutoSync ||
-			oldCfg.SyncInterval != cfg.SyncInterval)
+			oldCfg.SyncInterval != cfg.SyncInterval ||
+			oldCfg.DebounceSeconds != cfg.DebounceSeconds)
 
-	fmt.Printf("[SyncManager] Config updated: old(Enabled=%v, AutoSync=%v, Interval=%d), new(Enabled=%v, AutoSync=%v, Interval=%d)\n",
+	log.Printf("[SyncManager] Config updated: old(Enabled=%v, AutoSync=%v, Interval=%d), new(Enabled=%v, AutoSync=%v, Interval=%d)",
 		oldCfg.Enabled, oldCfg.AutoSync, oldCfg.SyncInterval,
 		cfg.Enabled, cfg.AutoSync, cfg.SyncInterval)
-	fmt.Printf("[SyncManager] Running=%v, needsRestart=%v\n", m.running, needsRestart)
+	log.Printf("[SyncManager] Running=%v, needsRestart=%v", m.running, needsRestart)
+
+	if oldCfg.GitEngine != cfg.GitEngine {
+		log.Printf("[SyncManager] Git engine changed to %q", cfg.GitEngine)
+		m.mu.Lock()
+		m.git = m.newGitBackend(cfg)
+		m.mu.Unlock()
+	}
 
 	if needsRestart {
-		fmt.Println("[SyncManager] Configuration changed, restarting auto-sync")
+		log.Println("[SyncManager] Configuration changed, restarting auto-sync")
 		m.StopAutoSync()
 	}
 
 	// Start auto-sync if enabled
 	if cfg.AutoSync && cfg.Enabled {
-		fmt.Println("[SyncManager] Starting auto-sync with new config")
+		log.Println("[SyncManager] Starting auto-sync with new config")
 		m.StartAutoSync()
 	} else if !cfg.AutoSync || !cfg.Enabled {
-		fmt.Println("[SyncManager] Auto-sync not started (AutoSync or Enabled is false)")
+		log.Println("[SyncManager] Auto-sync not started (AutoSync or Enabled is false)")
 	}
 
 	return nil
@@ -511,7 +598,7 @@ func (m *SyncManager) GetConfig() *SyncConfig {
 
 // TestConnection tests the connection to a repository.
 func (m *SyncManager) TestConnection(url string) (*ConnectionTestResult, error) {
-	return m.git.TestConnection(url)
+	return m.gitClient().TestConnection(url)
 }
 
 // StoreToken stores a Git access token securely.
@@ -536,7 +623,13 @@ func (m *SyncManager) DeleteToken() error {
 
 // IsGitInstalled checks if Git is available on the system.
 func (m *SyncManager) IsGitInstalled() bool {
-	return m.git.IsGitInstalled()
+	return isGitInstalled()
+}
+
+// IsSyncAvailable reports whether sync can run with the configured Git engine.
+// Only the system engine needs a git binary; the embedded engine is always available.
+func (m *SyncManager) IsSyncAvailable() bool {
+	return m.config.Get().GitEngine != GitEngineSystem || isGitInstalled()
 }
 
 // CheckSSHCredential checks if SSH credentials are available.
@@ -570,7 +663,7 @@ func (m *SyncManager) CheckSSHCredential() bool {
 		if perms&0077 != 0 {
 			// Group or others have permissions, this might be rejected by SSH
 			// But we still return true as the key exists
-			fmt.Printf("[SyncManager] Warning: SSH key %s has loose permissions: %o\n", keyFile, perms)
+			log.Printf("[SyncManager] Warning: SSH key %s has loose permissions: %o", keyFile, perms)
 		}
 
 		// Check if corresponding public key exists (optional but good practice)
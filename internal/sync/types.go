@@ -12,6 +12,23 @@ const (
 	AuthMethodHTTPS  AuthMethod = "https"  // HTTPS with personal access token
 )
 
+// ScheduledAction is an automatic sync action planned by the scheduler.
+type ScheduledAction string
+
+const (
+	ScheduledActionPush  ScheduledAction = "push"  // Push local changes after the debounce delay
+	ScheduledActionFetch ScheduledAction = "fetch" // Check the remote for new commits
+)
+
+// GitEngine selects how Git operations are performed.
+type GitEngine string
+
+const (
+	GitEngineAuto     GitEngine = "auto"     // System git if installed, embedded otherwise
+	GitEngineSystem   GitEngine = "system"   // System git binary
+	GitEngineEmbedded GitEngine = "embedded" // Pure-Go implementation, no git binary needed
+)
+
 // SyncStatus represents the current synchronization status.
 type SyncStatus struct {
 	// Syncing indicates if a sync operation is in progress.
@@ -37,6 +54,15 @@ type SyncStatus struct {
 
 	// AutoSync indicates if automatic sync is enabled.
 	AutoSync bool `json:"autoSync"`
+
+	// NextAction is the next scheduled automatic action, if any.
+	NextAction ScheduledAction `json:"nextAction,omitempty"`
+
+	// NextActionTime is when NextAction is scheduled to run.
+	NextActionTime time.Time `json:"nextActionTime,omitempty"`
+
+	// PausedReason explains why automatic sync is currently deferred.
+	PausedReason string `json:"pausedReason,omitempty"`
 }
 
 // SyncResult represents the result of a sync operation.
@@ -80,9 +106,20 @@ type SyncConfig struct {
 	// AutoSync indicates if automatic synchronization is enabled.
 	AutoSync bool `json:"autoSync"`
 
-	// SyncInterval is the interval between automatic syncs in minutes.
+	// SyncInterval is the interval in minutes between checks for remote changes.
+	// Local changes are pushed as soon as the file watcher settles.
 	SyncInterval int `json:"syncInterval"`
 
+	// DebounceSeconds is how long the data directory must stay quiet
+	// after a change before it is pushed.
+	DebounceSeconds int `json:"debounceSeconds"`
+
+	// PauseOnBattery defers automatic sync while running on battery.
+	PauseOnBattery bool `json:"pauseOnBattery"`
+
+	// PauseOnMetered defers automatic sync on metered networks.
+	PauseOnMetered bool `json:"pauseOnMetered"`
+
 	// LastSyncTime is the timestamp of the last successful sync.
 	LastSyncTime time.Time `json:"lastSyncTime"`
 
@@ -92,18 +129,71 @@ type SyncConfig struct {
 	// AuthMethod is the preferred authentication method.
 	AuthMethod AuthMethod `json:"authMethod"`
 
+	// GitEngine selects the system git binary or the embedded implementation.
+	GitEngine GitEngine `json:"gitEngine,omitempty"`
+
+	// SSHKeyPath is the private key used by the embedded engine for SSH remotes.
+	// If empty, the SSH agent and the default keys in ~/.ssh are tried.
+	SSHKeyPath string `json:"sshKeyPath,omitempty"`
+
 	// IgnorePatterns are additional patterns to ignore.
 	IgnorePatterns []string `json:"ignorePatterns,omitempty"`
+
+	// Profiles are the named device profiles available for selection.
+	Profiles []SyncProfile `json:"profiles,omitempty"`
+
+	// ActiveProfile is the name of the profile used on this device.
+	// An empty value means every plugin's data is synchronized.
+	ActiveProfile string `json:"activeProfile,omitempty"`
+}
+
+// SyncProfile is a named selection of plugins whose data is synchronized,
+// so that e.g. a work laptop and a home PC can share only some plugins.
+type SyncProfile struct {
+	// Name identifies the profile.
+	Name string `json:"name"`
+
+	// Plugins lists the IDs of the plugins synchronized under this profile.
+	Plugins []string `json:"plugins"`
+}
+
+// PluginDataSpec describes the data a plugin owns inside the data directory.
+type PluginDataSpec struct {
+	// PluginID is the unique ID of the plugin.
+	PluginID string `json:"pluginId"`
+
+	// Name is the display name of the plugin.
+	Name string `json:"name"`
+
+	// Paths are files or directories (ending with /) relative to the data directory.
+	Paths []string `json:"paths"`
+
+	// LocalFields are machine-specific JSON fields that never leave this device,
+	// in "file:field.path" form where "*" matches every array element.
+	LocalFields []string `json:"localFields,omitempty"`
+}
+
+// GetProfile returns the profile with the given name, or nil if none exists.
+func (c *SyncConfig) GetProfile(name string) *SyncProfile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
 }
 
 // DefaultSyncConfig returns the default synchronization configuration.
 func DefaultSyncConfig() *SyncConfig {
 	return &SyncConfig{
-		Enabled:       false,
-		AutoSync:      true,
-		SyncInterval:  5, // 5 minutes
-		AuthMethod:    AuthMethodAuto,
-		IgnorePatterns: []string{},
+		Enabled:         false,
+		AutoSync:        true,
+		SyncInterval:    5,  // 5 minutes
+		DebounceSeconds: 10, // 10 seconds
+		PauseOnMetered:  true,
+		AuthMethod:      AuthMethodAuto,
+		GitEngine:       GitEngineAuto,
+		IgnorePatterns:  []string{},
 	}
 }
 
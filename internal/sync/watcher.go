@@ -0,0 +1,125 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DataWatcher watches the data directory recursively and reports changes.
+type DataWatcher struct {
+	root    string
+	filter  func(relPath string) bool
+	watcher *fsnotify.Watcher
+	changes chan string
+	done    chan struct{}
+}
+
+// NewDataWatcher creates a DataWatcher for root.
+// filter returns false for relative paths that should not be watched.
+func NewDataWatcher(root string, filter func(relPath string) bool) (*DataWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	w := &DataWatcher{
+		root:    root,
+		filter:  filter,
+		watcher: watcher,
+		changes: make(chan string, 1),
+		done:    make(chan struct{}),
+	}
+
+	if err := w.addTree(root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Changes returns a channel that receives the relative path of changed files.
+// Bursts are coalesced, so receivers should debounce rather than count events.
+func (w *DataWatcher) Changes() <-chan string {
+	return w.changes
+}
+
+// Close stops watching.
+func (w *DataWatcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
+
+// addTree adds dir and all its watched subdirectories.
+func (w *DataWatcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Directory may have been removed while walking
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		if relPath, _ := filepath.Rel(w.root, path); relPath != "." && !w.filter(relPath) {
+			return filepath.SkipDir
+		}
+
+		if err := w.watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// run forwards relevant file system events until the watcher is closed.
+func (w *DataWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[DataWatcher] Watch error: %v", err)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// handleEvent filters an event and notifies listeners.
+func (w *DataWatcher) handleEvent(event fsnotify.Event) {
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	relPath, err := filepath.Rel(w.root, event.Name)
+	if err != nil || !w.filter(relPath) {
+		return
+	}
+
+	// Watch newly created directories as well
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(event.Name); err != nil {
+				log.Printf("[DataWatcher] %v", err)
+			}
+		}
+	}
+
+	select {
+	case w.changes <- relPath:
+	default:
+		// A change is already pending
+	}
+}
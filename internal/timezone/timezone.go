@@ -0,0 +1,203 @@
+// Package timezone resolves user-typed place names, abbreviations and UTC
+// offsets to time zones. The tz database is embedded with time/tzdata so
+// lookups work the same on systems without zoneinfo files (e.g. Windows).
+package timezone
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// zones are the IANA zones whose city can be typed on its own
+// ("Tokyo", "new york", "los_angeles").
+var zones = []string{
+	"Africa/Abidjan", "Africa/Accra", "Africa/Addis_Ababa", "Africa/Algiers", "Africa/Cairo",
+	"Africa/Casablanca", "Africa/Dar_es_Salaam", "Africa/Johannesburg", "Africa/Khartoum",
+	"Africa/Kinshasa", "Africa/Lagos", "Africa/Luanda", "Africa/Nairobi", "Africa/Tunis",
+	"America/Anchorage", "America/Argentina/Buenos_Aires", "America/Bogota", "America/Caracas",
+	"America/Chicago", "America/Denver", "America/Edmonton", "America/Halifax", "America/Havana",
+	"America/Lima", "America/Los_Angeles", "America/Mexico_City", "America/Montevideo",
+	"America/New_York", "America/Panama", "America/Phoenix", "America/Santiago",
+	"America/Sao_Paulo", "America/St_Johns", "America/Toronto", "America/Vancouver",
+	"America/Winnipeg", "Asia/Almaty", "Asia/Baghdad", "Asia/Bangkok", "Asia/Colombo",
+	"Asia/Dhaka", "Asia/Dubai", "Asia/Ho_Chi_Minh", "Asia/Hong_Kong", "Asia/Jakarta",
+	"Asia/Jerusalem", "Asia/Kabul", "Asia/Karachi", "Asia/Kathmandu", "Asia/Kolkata",
+	"Asia/Kuala_Lumpur", "Asia/Macau", "Asia/Manila", "Asia/Riyadh", "Asia/Seoul",
+	"Asia/Shanghai", "Asia/Singapore", "Asia/Taipei", "Asia/Tashkent", "Asia/Tehran",
+	"Asia/Tokyo", "Asia/Ulaanbaatar", "Asia/Urumqi", "Asia/Vladivostok", "Asia/Yangon",
+	"Atlantic/Azores", "Atlantic/Reykjavik", "Australia/Adelaide", "Australia/Brisbane",
+	"Australia/Darwin", "Australia/Melbourne", "Australia/Perth", "Australia/Sydney",
+	"Europe/Amsterdam", "Europe/Athens", "Europe/Berlin", "Europe/Brussels", "Europe/Bucharest",
+	"Europe/Budapest", "Europe/Copenhagen", "Europe/Dublin", "Europe/Helsinki", "Europe/Istanbul",
+	"Europe/Kyiv", "Europe/Lisbon", "Europe/London", "Europe/Madrid", "Europe/Moscow",
+	"Europe/Oslo", "Europe/Paris", "Europe/Prague", "Europe/Rome", "Europe/Stockholm",
+	"Europe/Vienna", "Europe/Warsaw", "Europe/Zurich", "Pacific/Auckland", "Pacific/Fiji",
+	"Pacific/Guam", "Pacific/Honolulu", "UTC",
+}
+
+// aliases maps other city and country names and unambiguous abbreviations
+// to zones. Keys are lower case.
+var aliases = map[string]string{
+	// 缩写（CST、IST、BST 有歧义，不收录）
+	"gmt": "UTC", "z": "UTC", "utc": "UTC",
+	"pst": "America/Los_Angeles", "pdt": "America/Los_Angeles", "pt": "America/Los_Angeles",
+	"mst": "America/Denver", "mdt": "America/Denver",
+	"cdt": "America/Chicago", "ct": "America/Chicago",
+	"est": "America/New_York", "edt": "America/New_York", "et": "America/New_York",
+	"cet": "Europe/Berlin", "cest": "Europe/Berlin", "wet": "Europe/Lisbon", "eet": "Europe/Athens",
+	"jst": "Asia/Tokyo", "kst": "Asia/Seoul", "hkt": "Asia/Hong_Kong", "sgt": "Asia/Singapore",
+	"aest": "Australia/Sydney", "aedt": "Australia/Sydney", "nzst": "Pacific/Auckland",
+
+	// 不是时区名最后一段的城市和国家
+	"beijing": "Asia/Shanghai", "shenzhen": "Asia/Shanghai", "guangzhou": "Asia/Shanghai",
+	"hangzhou": "Asia/Shanghai", "chengdu": "Asia/Shanghai", "china": "Asia/Shanghai",
+	"hongkong": "Asia/Hong_Kong", "taiwan": "Asia/Taipei", "japan": "Asia/Tokyo",
+	"korea": "Asia/Seoul", "india": "Asia/Kolkata", "mumbai": "Asia/Kolkata",
+	"delhi": "Asia/Kolkata", "new delhi": "Asia/Kolkata", "bangalore": "Asia/Kolkata",
+	"calcutta": "Asia/Kolkata", "saigon": "Asia/Ho_Chi_Minh", "vietnam": "Asia/Ho_Chi_Minh",
+	"thailand": "Asia/Bangkok", "indonesia": "Asia/Jakarta", "philippines": "Asia/Manila",
+	"israel": "Asia/Jerusalem", "tel aviv": "Asia/Jerusalem", "uae": "Asia/Dubai",
+	"abu dhabi": "Asia/Dubai", "kiev": "Europe/Kyiv", "germany": "Europe/Berlin",
+	"munich": "Europe/Berlin", "frankfurt": "Europe/Berlin", "hamburg": "Europe/Berlin",
+	"france": "Europe/Paris", "uk": "Europe/London", "england": "Europe/London",
+	"manchester": "Europe/London", "edinburgh": "Europe/London", "spain": "Europe/Madrid",
+	"barcelona": "Europe/Madrid", "italy": "Europe/Rome", "milan": "Europe/Rome",
+	"netherlands": "Europe/Amsterdam", "switzerland": "Europe/Zurich", "geneva": "Europe/Zurich",
+	"russia": "Europe/Moscow", "st petersburg": "Europe/Moscow", "turkey": "Europe/Istanbul",
+	"san francisco": "America/Los_Angeles", "sf": "America/Los_Angeles", "seattle": "America/Los_Angeles",
+	"silicon valley": "America/Los_Angeles", "san jose": "America/Los_Angeles", "la": "America/Los_Angeles",
+	"portland": "America/Los_Angeles", "las vegas": "America/Los_Angeles", "nyc": "America/New_York",
+	"washington": "America/New_York", "boston": "America/New_York", "miami": "America/New_York",
+	"atlanta": "America/New_York", "philadelphia": "America/New_York", "montreal": "America/Toronto",
+	"ottawa": "America/Toronto", "dallas": "America/Chicago", "houston": "America/Chicago",
+	"austin": "America/Chicago", "salt lake city": "America/Denver", "hawaii": "Pacific/Honolulu",
+	"rio": "America/Sao_Paulo", "brazil": "America/Sao_Paulo", "mexico": "America/Mexico_City",
+	"buenos aires": "America/Argentina/Buenos_Aires", "argentina": "America/Argentina/Buenos_Aires",
+	"canberra": "Australia/Sydney", "wellington": "Pacific/Auckland", "new zealand": "Pacific/Auckland",
+	"egypt": "Africa/Cairo", "south africa": "Africa/Johannesburg", "cape town": "Africa/Johannesburg",
+	"kenya": "Africa/Nairobi", "nigeria": "Africa/Lagos", "iceland": "Atlantic/Reykjavik",
+
+	// 中文名称
+	"北京": "Asia/Shanghai", "上海": "Asia/Shanghai", "深圳": "Asia/Shanghai", "广州": "Asia/Shanghai",
+	"杭州": "Asia/Shanghai", "成都": "Asia/Shanghai", "中国": "Asia/Shanghai", "乌鲁木齐": "Asia/Urumqi",
+	"香港": "Asia/Hong_Kong", "澳门": "Asia/Macau", "台北": "Asia/Taipei", "东京": "Asia/Tokyo",
+	"日本": "Asia/Tokyo", "首尔": "Asia/Seoul", "韩国": "Asia/Seoul", "新加坡": "Asia/Singapore",
+	"曼谷": "Asia/Bangkok", "雅加达": "Asia/Jakarta", "吉隆坡": "Asia/Kuala_Lumpur",
+	"马尼拉": "Asia/Manila", "河内": "Asia/Ho_Chi_Minh", "胡志明市": "Asia/Ho_Chi_Minh",
+	"孟买": "Asia/Kolkata", "新德里": "Asia/Kolkata", "印度": "Asia/Kolkata", "迪拜": "Asia/Dubai",
+	"莫斯科": "Europe/Moscow", "伦敦": "Europe/London", "英国": "Europe/London", "巴黎": "Europe/Paris",
+	"法国": "Europe/Paris", "柏林": "Europe/Berlin", "德国": "Europe/Berlin", "慕尼黑": "Europe/Berlin",
+	"阿姆斯特丹": "Europe/Amsterdam", "马德里": "Europe/Madrid", "罗马": "Europe/Rome",
+	"苏黎世": "Europe/Zurich", "斯德哥尔摩": "Europe/Stockholm", "伊斯坦布尔": "Europe/Istanbul",
+	"纽约": "America/New_York", "华盛顿": "America/New_York", "波士顿": "America/New_York",
+	"多伦多": "America/Toronto", "芝加哥": "America/Chicago", "丹佛": "America/Denver",
+	"洛杉矶": "America/Los_Angeles", "旧金山": "America/Los_Angeles", "西雅图": "America/Los_Angeles",
+	"硅谷": "America/Los_Angeles", "温哥华": "America/Vancouver", "墨西哥城": "America/Mexico_City",
+	"圣保罗": "America/Sao_Paulo", "檀香山": "Pacific/Honolulu", "悉尼": "Australia/Sydney",
+	"墨尔本": "Australia/Melbourne", "珀斯": "Australia/Perth", "奥克兰": "Pacific/Auckland",
+	"开罗": "Africa/Cairo", "约翰内斯堡": "Africa/Johannesburg", "内罗毕": "Africa/Nairobi",
+	"协调世界时": "UTC",
+}
+
+// cities maps the lower-case city of each zone ("new york") to the zone.
+var cities = func() map[string]string {
+	m := make(map[string]string, len(zones))
+	for _, zone := range zones {
+		city := zone[strings.LastIndex(zone, "/")+1:]
+		m[strings.ToLower(strings.ReplaceAll(city, "_", " "))] = zone
+	}
+	return m
+}()
+
+// offsetPattern matches fixed offsets such as UTC+8, GMT-05:30 and +0900.
+var offsetPattern = regexp.MustCompile(`(?i)^(?:utc|gmt)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// Lookup resolves name to a time zone. It accepts IANA names
+// ("Asia/Tokyo"), city and country names in English or Chinese ("Tokyo",
+// "new york", "柏林"), unambiguous abbreviations ("PST", "CET"), fixed
+// offsets ("UTC+8") and "local" for the system zone. Case and the
+// difference between spaces and underscores are ignored.
+func Lookup(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("时区名称不能为空")
+	}
+	key := strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(name, "_", " "))), " ")
+
+	if key == "local" || key == "本地" {
+		return time.Local, nil
+	}
+	if zone, ok := aliases[key]; ok {
+		return time.LoadLocation(zone)
+	}
+	if zone, ok := cities[key]; ok {
+		return time.LoadLocation(zone)
+	}
+	if m := offsetPattern.FindStringSubmatch(name); m != nil {
+		return fixedZone(m[1], m[2], m[3])
+	}
+
+	// 完整的 IANA 名称，区分大小写；再按每段首字母大写重试
+	if strings.Contains(name, "/") {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, nil
+		}
+		if loc, err := time.LoadLocation(titleZone(name)); err == nil {
+			return loc, nil
+		}
+	}
+	return nil, fmt.Errorf("未知的时区: %s", name)
+}
+
+// Names returns the zones that Lookup knows by city, sorted.
+func Names() []string {
+	names := append([]string(nil), zones...)
+	sort.Strings(names)
+	return names
+}
+
+func fixedZone(sign, hours, minutes string) (*time.Location, error) {
+	h, _ := strconv.Atoi(hours)
+	m := 0
+	if minutes != "" {
+		m, _ = strconv.Atoi(minutes)
+	}
+	if h > 14 || m > 59 {
+		return nil, fmt.Errorf("无效的时区偏移: %s%s:%02d", sign, hours, m)
+	}
+	offset := h*3600 + m*60
+	if sign == "-" {
+		offset = -offset
+	}
+	return time.FixedZone(FormatOffset(offset), offset), nil
+}
+
+// FormatOffset formats an offset in seconds as UTC+08:00.
+func FormatOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// titleZone turns "america/new york" into "America/New_York".
+func titleZone(name string) string {
+	parts := strings.Split(strings.ReplaceAll(name, " ", "_"), "/")
+	for i, part := range parts {
+		words := strings.Split(strings.ToLower(part), "_")
+		for j, w := range words {
+			if w != "" && w != "of" && w != "es" {
+				words[j] = strings.ToUpper(w[:1]) + w[1:]
+			}
+		}
+		parts[i] = strings.Join(words, "_")
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,51 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Asia/Tokyo", "Asia/Tokyo"},
+		{"america/new york", "America/New_York"},
+		{"Tokyo", "Asia/Tokyo"},
+		{"new  york", "America/New_York"},
+		{"los_angeles", "America/Los_Angeles"},
+		{"Beijing", "Asia/Shanghai"},
+		{"柏林", "Europe/Berlin"},
+		{"PST", "America/Los_Angeles"},
+		{"utc", "UTC"},
+		{"UTC+8", "UTC+08:00"},
+		{"GMT-05:30", "UTC-05:30"},
+		{"+0900", "UTC+09:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := Lookup(tt.name)
+			if err != nil {
+				t.Fatalf("Lookup(%q) error: %v", tt.name, err)
+			}
+			if loc.String() != tt.want {
+				t.Errorf("Lookup(%q) = %s, want %s", tt.name, loc, tt.want)
+			}
+		})
+	}
+
+	for _, name := range []string{"", "CST", "Atlantis", "UTC+15"} {
+		if loc, err := Lookup(name); err == nil {
+			t.Errorf("Lookup(%q) = %s, want error", name, loc)
+		}
+	}
+
+	// 列表中的时区都必须在内嵌的 tzdata 中
+	for _, zone := range Names() {
+		if _, err := time.LoadLocation(zone); err != nil {
+			t.Errorf("zone %s: %v", zone, err)
+		}
+	}
+}
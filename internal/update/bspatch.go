@@ -0,0 +1,87 @@
+package update
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bsdiffMagic bsdiff 4.x 补丁文件头
+const bsdiffMagic = "BSDIFF40"
+
+// errCorruptPatch 补丁文件损坏
+var errCorruptPatch = errors.New("corrupt patch")
+
+// applyPatch 将 bsdiff（BSDIFF40 格式）补丁应用到旧文件内容，返回新文件内容
+//
+// 补丁格式：32 字节头（magic、控制块长度、差异块长度、新文件大小），
+// 随后是 bzip2 压缩的控制块、差异块和额外数据块。
+func applyPatch(old, patch []byte) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != bsdiffMagic {
+		return nil, fmt.Errorf("%w: invalid header", errCorruptPatch)
+	}
+
+	ctrlLen := offtin(patch[8:16])
+	diffLen := offtin(patch[16:24])
+	newSize := offtin(patch[24:32])
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || 32+ctrlLen+diffLen > int64(len(patch)) {
+		return nil, fmt.Errorf("%w: invalid block sizes", errCorruptPatch)
+	}
+
+	body := patch[32:]
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	newData := make([]byte, newSize)
+	var oldPos, newPos int64
+	var buf [24]byte
+
+	for newPos < newSize {
+		// 控制元组 (x, y, z)：x 字节差异数据，y 字节额外数据，然后旧文件位置偏移 z
+		if _, err := io.ReadFull(ctrl, buf[:]); err != nil {
+			return nil, fmt.Errorf("%w: read control block: %v", errCorruptPatch, err)
+		}
+		x, y, z := offtin(buf[0:8]), offtin(buf[8:16]), offtin(buf[16:24])
+		if x < 0 || y < 0 || newPos+x > newSize {
+			return nil, fmt.Errorf("%w: invalid control tuple", errCorruptPatch)
+		}
+
+		// 差异数据与旧文件对应字节相加
+		if _, err := io.ReadFull(diff, newData[newPos:newPos+x]); err != nil {
+			return nil, fmt.Errorf("%w: read diff block: %v", errCorruptPatch, err)
+		}
+		for i := int64(0); i < x; i++ {
+			if p := oldPos + i; p >= 0 && p < int64(len(old)) {
+				newData[newPos+i] += old[p]
+			}
+		}
+		newPos += x
+		oldPos += x
+
+		if newPos+y > newSize {
+			return nil, fmt.Errorf("%w: invalid control tuple", errCorruptPatch)
+		}
+		if _, err := io.ReadFull(extra, newData[newPos:newPos+y]); err != nil {
+			return nil, fmt.Errorf("%w: read extra block: %v", errCorruptPatch, err)
+		}
+		newPos += y
+		oldPos += z
+	}
+
+	return newData, nil
+}
+
+// offtin 解码 bsdiff 的 64 位整数（小端序，最高位为符号位）
+func offtin(b []byte) int64 {
+	y := int64(b[7] & 0x7f)
+	for i := 6; i >= 0; i-- {
+		y = y<<8 | int64(b[i])
+	}
+	if b[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
@@ -0,0 +1,117 @@
+package update
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 更新通道
+const (
+	ChannelStable  = "stable"
+	ChannelBeta    = "beta"
+	ChannelNightly = "nightly"
+)
+
+// installIDFile 本机安装 ID 文件，用于分阶段发布分组（不参与同步）
+const installIDFile = "install-id"
+
+// validChannel 检查通道名
+func validChannel(channel string) bool {
+	switch channel {
+	case ChannelStable, ChannelBeta, ChannelNightly:
+		return true
+	}
+	return false
+}
+
+// manifestName 通道对应的清单文件名
+// stable 沿用 update.json，其他通道为 update-<channel>.json
+func manifestName(channel string) string {
+	if channel == ChannelStable || channel == "" {
+		return "update.json"
+	}
+	return "update-" + channel + ".json"
+}
+
+// SetChannel 切换更新通道（前端调用）
+func (s *Service) SetChannel(channel string) error {
+	if !validChannel(channel) {
+		return fmt.Errorf("未知的更新通道: %s", channel)
+	}
+
+	s.mu.Lock()
+	s.channel = channel
+	s.manifest = nil // 已缓存的清单属于旧通道
+	s.mu.Unlock()
+
+	log.Printf("[UpdateService] Update channel set to %s", channel)
+	return nil
+}
+
+// GetChannel 获取当前更新通道（前端调用）
+func (s *Service) GetChannel() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.channel
+}
+
+// inRollout 判断本机是否在清单的分阶段发布范围内
+// 按安装 ID 与版本号的哈希分桶，同一台机器对同一版本的结果保持稳定
+func (s *Service) inRollout(manifest *UpdateManifest) bool {
+	if manifest.Rollout <= 0 || manifest.Rollout >= 100 {
+		return true
+	}
+
+	id, err := s.installID()
+	if err != nil {
+		// 无法分组时按未命中处理，等待全量发布
+		log.Printf("[UpdateService] Failed to load install ID: %v", err)
+		return false
+	}
+	return rolloutBucket(id, manifest.Version) < manifest.Rollout
+}
+
+// rolloutBucket 计算 0-99 的分桶编号
+func rolloutBucket(installID, version string) int {
+	sum := sha256.Sum256([]byte(installID + ":" + strings.TrimPrefix(version, "v")))
+	return int(binary.BigEndian.Uint32(sum[:4]) % 100)
+}
+
+// installID 读取本机安装 ID，不存在时生成
+func (s *Service) installID() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.installIDCache != "" {
+		return s.installIDCache, nil
+	}
+
+	path := filepath.Join(s.dataDir, installIDFile)
+	if data, err := os.ReadFile(path); err == nil {
+		if id := strings.TrimSpace(string(data)); id != "" {
+			s.installIDCache = id
+			return id, nil
+		}
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(id+"\n"), 0644); err != nil {
+		return "", err
+	}
+	s.installIDCache = id
+	return id, nil
+}
@@ -0,0 +1,122 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// downloadPatched 下载增量补丁并应用到当前可执行文件
+// 结果以 .bin（或 .AppImage）保存在更新目录中，由 InstallUpdate 替换当前文件
+func (s *Service) downloadPatched(info *PlatformUpdateInfo, patch *PatchInfo, version string, quiet bool) (string, error) {
+	source, err := s.executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate current executable: %w", err)
+	}
+	old, err := os.ReadFile(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to read current executable: %w", err)
+	}
+
+	patchPath, err := s.downloadFile(patch.URL, patch.Checksum, version, "update.patch", quiet)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(patchPath)
+
+	patchData, err := os.ReadFile(patchPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read patch: %w", err)
+	}
+
+	log.Printf("[UpdateService] Applying patch to %s (%d bytes)", source, len(old))
+	newData, err := applyPatch(old, patchData)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	// 校验打补丁后的文件，当前文件被修改过时会在这里失败
+	expected := patch.TargetChecksum
+	if expected == "" {
+		expected = info.Checksum
+	}
+	sum := sha256.Sum256(newData)
+	actual := hex.EncodeToString(sum[:])
+	if !s.verifyChecksum(actual, expected) {
+		return "", fmt.Errorf("patched file checksum mismatch: expected=%s, got=%s", expected, actual)
+	}
+
+	ext := ".bin"
+	if strings.HasSuffix(source, ".AppImage") {
+		ext = ".AppImage"
+	}
+
+	downloadDir := filepath.Join(s.dataDir, "updates")
+	tmpFile := filepath.Join(downloadDir, "update-patched.tmp")
+	if err := os.WriteFile(tmpFile, newData, 0755); err != nil {
+		return "", fmt.Errorf("failed to write patched file: %w", err)
+	}
+	finalFile := filepath.Join(downloadDir, "update"+ext)
+	if err := os.Rename(tmpFile, finalFile); err != nil {
+		os.Remove(tmpFile)
+		return "", fmt.Errorf("failed to rename patched file: %w", err)
+	}
+
+	log.Printf("[UpdateService] Patch applied: %d bytes, checksum: %s", len(newData), actual)
+	return finalFile, nil
+}
+
+// installBinary 用打补丁得到的可执行文件替换当前文件
+func (s *Service) installBinary(filePath string) error {
+	log.Println("[UpdateService] Installing patched executable...")
+
+	currentPath, err := s.executable()
+	if err != nil {
+		return fmt.Errorf("failed to get current executable path: %w", err)
+	}
+
+	// 正在运行的可执行文件可以重命名（包括 Windows），但不能直接覆盖
+	backupPath := currentPath + ".backup"
+	os.Remove(backupPath)
+	if err := os.Rename(currentPath, backupPath); err != nil {
+		return fmt.Errorf("failed to backup current executable: %w", err)
+	}
+
+	if err := copyFile(filePath, currentPath); err != nil {
+		os.Remove(currentPath)
+		os.Rename(backupPath, currentPath)
+		return fmt.Errorf("failed to replace executable: %w", err)
+	}
+	if err := os.Chmod(currentPath, 0755); err != nil {
+		return fmt.Errorf("failed to set executable permission: %w", err)
+	}
+
+	// Windows 下运行中的旧文件无法删除，留到下次清理
+	if err := os.Remove(backupPath); err != nil {
+		log.Printf("[UpdateService] Warning: failed to remove backup: %v", err)
+	}
+
+	log.Println("[UpdateService] Patched executable installed successfully")
+	return nil
+}
+
+// currentExecutable 获取当前可执行文件路径
+// AppImage 运行时 os.Executable 指向挂载目录中的文件，需要使用 APPIMAGE 环境变量
+func currentExecutable() (string, error) {
+	if runtime.GOOS == "linux" {
+		if appImage := os.Getenv("APPIMAGE"); appImage != "" {
+			return appImage, nil
+		}
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(execPath)
+}
@@ -0,0 +1,121 @@
+package update
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/old-to-new.patch 是 old.bin 到 new.bin 的 BSDIFF40 补丁
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestApplyPatch(t *testing.T) {
+	old := readTestdata(t, "old.bin")
+	want := readTestdata(t, "new.bin")
+	patch := readTestdata(t, "old-to-new.patch")
+
+	got, err := applyPatch(old, patch)
+	if err != nil {
+		t.Fatalf("applyPatch: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("patched output differs from new.bin")
+	}
+
+	if _, err := applyPatch(old, patch[:40]); !errors.Is(err, errCorruptPatch) {
+		t.Fatalf("truncated patch error = %v", err)
+	}
+	if _, err := applyPatch(old, []byte("not a patch")); !errors.Is(err, errCorruptPatch) {
+		t.Fatalf("invalid patch error = %v", err)
+	}
+}
+
+func TestDownloadPatchedUpdate(t *testing.T) {
+	key := newTestKey(t, "current")
+	old := readTestdata(t, "old.bin")
+	newBinary := readTestdata(t, "new.bin")
+	patch := readTestdata(t, "old-to-new.patch")
+
+	tests := []struct {
+		name     string
+		current  []byte // 当前可执行文件内容
+		patch    []byte
+		wantExt  string
+		wantData []byte
+	}{
+		{"patch applied", old, patch, ".bin", newBinary},
+		{"modified executable falls back", append([]byte("x"), old[1:]...), patch, ".tar.gz", []byte("full package")},
+		{"corrupt patch falls back", old, []byte("BSDIFF40 garbage"), ".tar.gz", []byte("full package")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full := []byte("full package")
+			ms := newManifestServer(t, full)
+			ms.files["/ltools.tar.gz"] = full
+			ms.files["/0.1.0.patch"] = tt.patch
+
+			ms.setManifest(&UpdateManifest{
+				Version: "0.2.0",
+				Platforms: map[string]*PlatformUpdateInfo{
+					(&Service{}).getPlatformKey(): {
+						URL:      ms.URL + "/ltools.tar.gz",
+						Checksum: checksumOf(full),
+						Patches: map[string]*PatchInfo{
+							"0.1.0": {
+								URL:            ms.URL + "/0.1.0.patch",
+								Checksum:       checksumOf(tt.patch),
+								TargetChecksum: checksumOf(newBinary),
+							},
+						},
+					},
+				},
+			})
+			ms.sign(t, key)
+
+			current := filepath.Join(t.TempDir(), "ltools")
+			if err := os.WriteFile(current, tt.current, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			s := newTestService(t, ms, key)
+			s.currentVersion = "0.1.0"
+			s.executable = func() (string, error) { return current, nil }
+
+			info, err := s.CheckForUpdate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !info.HasPatch {
+				t.Fatal("expected patch to be offered")
+			}
+
+			path, err := s.DownloadUpdate(info.DownloadURL, info.Checksum)
+			if err != nil {
+				t.Fatalf("DownloadUpdate: %v", err)
+			}
+			if !strings.HasSuffix(path, tt.wantExt) {
+				t.Fatalf("downloaded %s, want *%s", path, tt.wantExt)
+			}
+			if data, _ := os.ReadFile(path); !bytes.Equal(data, tt.wantData) {
+				t.Fatalf("downloaded content mismatch")
+			}
+
+			// 其他版本的补丁即使在清单中也不接受
+			s.currentVersion = "0.1.1"
+			if _, err := s.DownloadUpdate(ms.URL+"/0.1.0.patch", ""); err == nil {
+				t.Fatal("accepted a patch for another version")
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+const (
+	// maxDownloadAttempts 单个文件的最大下载尝试次数
+	maxDownloadAttempts = 5
+	// stagedFileName 后台模式下已暂存更新的描述文件
+	stagedFileName = "staged.json"
+)
+
+// retryDelay 第一次重试前的等待时间，之后每次翻倍，最多 maxRetryDelay
+var (
+	retryDelay    = time.Second
+	maxRetryDelay = 30 * time.Second
+)
+
+// downloadError 下载错误，retry 表示是否值得重试
+type downloadError struct {
+	err   error
+	retry bool
+}
+
+func (e *downloadError) Error() string { return e.err.Error() }
+func (e *downloadError) Unwrap() error { return e.err }
+
+// stagedUpdate 已下载并校验、等待退出时安装的更新
+type stagedUpdate struct {
+	Version  string `json:"version"`
+	Path     string `json:"path"`
+	Checksum string `json:"checksum"`
+}
+
+// downloadFile 下载文件并校验，保存为更新目录下的 name
+// 数据先写入以版本和校验和命名的 .partial 文件，中断后再次下载同一文件时通过 Range 请求续传，
+// 并用 If-Range 确认服务器上的文件未变；网络错误按指数退避重试
+func (s *Service) downloadFile(url, expectedChecksum, version, name string, quiet bool) (string, error) {
+	log.Printf("[UpdateService] Downloading update from: %s", url)
+
+	// 创建下载目录
+	downloadDir := filepath.Join(s.dataDir, "updates")
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	partialFile := partialPath(downloadDir, name, version, expectedChecksum)
+	removeStalePartials(downloadDir, name, partialFile)
+	defer os.Remove(partialFile + validatorSuffix)
+
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.downloadAttempt(url, partialFile, quiet)
+		if err == nil {
+			break
+		}
+
+		var dlErr *downloadError
+		if errors.As(err, &dlErr) && !dlErr.retry || attempt >= maxDownloadAttempts {
+			return "", fmt.Errorf("failed to download: %w", err)
+		}
+		log.Printf("[UpdateService] Download attempt %d failed, retrying in %s: %v", attempt, delay, err)
+		time.Sleep(delay)
+		delay = min(delay*2, maxRetryDelay)
+	}
+
+	// 发送最终进度（100%）
+	if s.app != nil && !quiet {
+		s.app.Event.Emit("update:progress", 100)
+	}
+
+	// 验证校验和
+	actualChecksum, written, err := fileChecksum(partialFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read downloaded file: %w", err)
+	}
+	if !s.verifyChecksum(actualChecksum, expectedChecksum) {
+		// 校验和验证失败
+		// 保留损坏的文件供调试（添加 .corrupted 后缀），下次重新下载
+		corruptedFile := partialFile + ".corrupted"
+		if err := os.Rename(partialFile, corruptedFile); err != nil {
+			log.Printf("[UpdateService] Warning: failed to rename corrupted file: %v", err)
+			os.Remove(partialFile)
+		} else {
+			log.Printf("[UpdateService] Corrupted file saved for debugging: %s", corruptedFile)
+		}
+
+		// 返回友好的错误信息
+		return "", fmt.Errorf("checksum verification failed: expected=%s, got=%s. The download may be corrupted. Please try again", expectedChecksum, actualChecksum)
+	}
+
+	log.Printf("[UpdateService] Download completed: %d bytes, checksum: %s", written, actualChecksum)
+
+	finalFile := filepath.Join(downloadDir, name)
+	if err := os.Rename(partialFile, finalFile); err != nil {
+		return "", fmt.Errorf("failed to rename downloaded file: %w", err)
+	}
+
+	log.Printf("[UpdateService] File renamed to: %s", finalFile)
+	return finalFile, nil
+}
+
+// validatorSuffix 保存 partial 文件对应的 ETag 或 Last-Modified，续传时作为 If-Range
+const validatorSuffix = ".validator"
+
+// partialPath 返回下载中的文件路径，不同版本或校验和的文件不会续传到一起
+func partialPath(dir, name, version, checksum string) string {
+	sum := normalizeChecksum(checksum)
+	if len(sum) > 16 {
+		sum = sum[:16]
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%s-%s.partial", name, version, sum))
+}
+
+// removeStalePartials 删除同名文件其他版本未完成的下载
+func removeStalePartials(dir, name, keep string) {
+	matches, _ := filepath.Glob(filepath.Join(dir, name+".*.partial"))
+	for _, path := range matches {
+		if path != keep {
+			os.Remove(path)
+			os.Remove(path + validatorSuffix)
+		}
+	}
+}
+
+// rangeValidator 返回响应中可用于 If-Range 的校验值，弱 ETag 不能使用
+func rangeValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// downloadAttempt 从 partialFile 的当前大小开始继续下载
+func (s *Service) downloadAttempt(url, partialFile string, quiet bool) error {
+	var offset int64
+	if info, err := os.Stat(partialFile); err == nil {
+		offset = info.Size()
+	}
+	validatorFile := partialFile + validatorSuffix
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return &downloadError{err: err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		// 文件已变化时服务器返回完整内容（200），从头下载
+		if validator, err := os.ReadFile(validatorFile); err == nil && len(validator) > 0 {
+			req.Header.Set("If-Range", string(validator))
+		}
+		log.Printf("[UpdateService] Resuming download at %d bytes", offset)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &downloadError{err: err, retry: true}
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	totalSize := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// 服务器不支持续传或文件已变化，从头开始
+		flags |= os.O_TRUNC
+		offset = 0
+		if validator := rangeValidator(resp); validator != "" {
+			os.WriteFile(validatorFile, []byte(validator), 0644)
+		} else {
+			os.Remove(validatorFile)
+		}
+	case http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			os.Remove(partialFile)
+			return &downloadError{err: fmt.Errorf("unexpected Content-Range: %s", resp.Header.Get("Content-Range")), retry: true}
+		}
+		flags |= os.O_APPEND
+		if totalSize > 0 {
+			totalSize += offset
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 已下载完整，交给校验和判断
+		return nil
+	default:
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+		return &downloadError{err: fmt.Errorf("download failed with status: %d", resp.StatusCode), retry: retry}
+	}
+
+	if totalSize <= 0 {
+		log.Printf("[UpdateService] Warning: Content-Length is %d, progress tracking may be inaccurate", totalSize)
+	}
+
+	out, err := os.OpenFile(partialFile, flags, 0644)
+	if err != nil {
+		return &downloadError{err: fmt.Errorf("failed to create partial file: %w", err)}
+	}
+	defer out.Close()
+
+	// 创建进度写入器
+	var app *application.App
+	if !quiet {
+		app = s.app
+	}
+	progressWriter := &progressWriter{
+		writer:   out,
+		total:    totalSize,
+		written:  offset,
+		app:      app,
+		lastEmit: time.Now(),
+	}
+
+	if _, err := io.Copy(progressWriter, &throttledReader{reader: resp.Body, limit: s.GetBandwidthLimit}); err != nil {
+		return &downloadError{err: fmt.Errorf("failed to write file: %w", err), retry: true}
+	}
+	return nil
+}
+
+// contentRangeStart 解析 "bytes start-end/size" 中的 start
+func contentRangeStart(header string) (int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// fileChecksum 计算文件的 SHA256
+func fileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
+
+// throttledReader 按带宽上限限制读取速度
+// limit 每次读取时重新获取，下载过程中修改上限会立即生效
+type throttledReader struct {
+	reader io.Reader
+	limit  func() int64
+
+	current int64 // 当前统计周期使用的上限
+	start   time.Time
+	read    int64
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	limit := r.limit()
+	if limit <= 0 {
+		return r.reader.Read(p)
+	}
+	if limit != r.current || r.start.IsZero() {
+		r.current, r.start, r.read = limit, time.Now(), 0
+	}
+
+	// 单次读取不超过 1/10 秒的配额，避免突发
+	if chunk := max(limit/10, 1); int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	expected := time.Duration(float64(r.read) / float64(limit) * float64(time.Second))
+	if wait := expected - time.Since(r.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
+
+// SetBandwidthLimit 设置下载带宽上限（字节/秒，0 表示不限制）（前端调用）
+func (s *Service) SetBandwidthLimit(bytesPerSecond int64) {
+	s.bandwidthLimit.Store(max(bytesPerSecond, 0))
+	log.Printf("[UpdateService] Bandwidth limit set to %d B/s", bytesPerSecond)
+}
+
+// GetBandwidthLimit 获取下载带宽上限（前端调用）
+func (s *Service) GetBandwidthLimit() int64 {
+	return s.bandwidthLimit.Load()
+}
+
+// SetBackgroundMode 设置后台模式：自动下载更新并在退出时安装（前端调用）
+func (s *Service) SetBackgroundMode(enabled bool) {
+	s.background.Store(enabled)
+	log.Printf("[UpdateService] Background mode %s", map[bool]string{true: "enabled", false: "disabled"}[enabled])
+}
+
+// IsBackgroundMode 检查是否启用后台模式（前端调用）
+func (s *Service) IsBackgroundMode() bool {
+	return s.background.Load()
+}
+
+// StageUpdate 静默下载并校验更新，在下次退出应用时安装（前端调用）
+func (s *Service) StageUpdate(url string, expectedChecksum string) (string, error) {
+	if !s.enabled {
+		return "", fmt.Errorf("update service is not enabled")
+	}
+
+	target, err := s.verifiedTarget(url, expectedChecksum)
+	if err != nil {
+		log.Printf("[UpdateService] Refusing download: %v", err)
+		return "", err
+	}
+
+	s.mu.Lock()
+	version := s.manifest.Version
+	s.mu.Unlock()
+
+	path, err := s.fetchUpdate(target, true)
+	if err != nil {
+		return "", err
+	}
+
+	// 记录暂存文件的校验和，安装前再次核对
+	checksum, _, err := fileChecksum(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read staged update: %w", err)
+	}
+	data, err := json.MarshalIndent(&stagedUpdate{Version: version, Path: path, Checksum: checksum}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(s.dataDir, "updates", stagedFileName), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save staged update: %w", err)
+	}
+
+	log.Printf("[UpdateService] Update %s staged, will be installed on quit", version)
+	if s.app != nil {
+		s.app.Event.Emit("update:staged", version)
+	}
+	return path, nil
+}
+
+// GetStagedVersion 获取已暂存、等待安装的版本，没有时返回空字符串（前端调用）
+func (s *Service) GetStagedVersion() string {
+	staged, err := s.loadStaged()
+	if err != nil || staged == nil {
+		return ""
+	}
+	return staged.Version
+}
+
+// ServiceShutdown 应用退出时安装已暂存的更新
+// 正常退出同样视为启动成功（例如窗口从未显示过）
+func (s *Service) ServiceShutdown() error {
+	s.MarkLaunchSuccessful()
+	if err := s.applyStaged(); err != nil {
+		log.Printf("[UpdateService] Failed to install staged update: %v", err)
+	}
+	return nil
+}
+
+// applyStaged 安装已暂存的更新，不提示重启
+func (s *Service) applyStaged() error {
+	staged, err := s.loadStaged()
+	if err != nil || staged == nil {
+		return err
+	}
+	// 无论成功与否只尝试一次，失败时下次由用户手动更新
+	defer os.Remove(filepath.Join(s.dataDir, "updates", stagedFileName))
+
+	if compareVersions(staged.Version, s.currentVersion) <= 0 {
+		log.Printf("[UpdateService] Discarding staged update %s (current: %s)", staged.Version, s.currentVersion)
+		return nil
+	}
+
+	checksum, _, err := fileChecksum(staged.Path)
+	if err != nil {
+		return err
+	}
+	if checksum != staged.Checksum {
+		return fmt.Errorf("staged update was modified: %s", staged.Path)
+	}
+
+	log.Printf("[UpdateService] Installing staged update %s", staged.Version)
+	return s.install(staged.Path)
+}
+
+// loadStaged 读取暂存描述文件，不存在时返回 nil
+func (s *Service) loadStaged() (*stagedUpdate, error) {
+	data, err := os.ReadFile(filepath.Join(s.dataDir, "updates", stagedFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var staged stagedUpdate
+	if err := json.Unmarshal(data, &staged); err != nil {
+		return nil, fmt.Errorf("failed to parse staged update: %w", err)
+	}
+	return &staged, nil
+}
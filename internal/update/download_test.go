@@ -0,0 +1,122 @@
+package update
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResumableDownload(t *testing.T) {
+	retryDelay = time.Millisecond
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+
+	// 第一次请求只返回一半数据后断开，之后的请求支持 Range
+	var requests atomic.Int32
+	var ranges, ifRanges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		ifRanges = append(ifRanges, r.Header.Get("If-Range"))
+		w.Header().Set("ETag", `"v2"`)
+		if requests.Add(1) == 1 {
+			w.Header().Set("Content-Length", "65536")
+			w.Write(payload[:len(payload)/2])
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "ltools.bin", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer srv.Close()
+
+	s := &Service{client: srv.Client(), dataDir: t.TempDir()}
+	// 其他版本未完成的下载不会被续传
+	downloadDir := filepath.Join(s.dataDir, "updates")
+	stale := partialPath(downloadDir, "update.bin", "1.9.0", checksumOf([]byte("old")))
+	os.MkdirAll(downloadDir, 0755)
+	os.WriteFile(stale, payload[:100], 0644)
+
+	path, err := s.downloadFile(srv.URL+"/ltools.bin", checksumOf(payload), "2.0.0", "update.bin", true)
+	if err != nil {
+		t.Fatalf("downloadFile: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, payload) {
+		t.Fatal("downloaded content mismatch")
+	}
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes=32768-" {
+		t.Fatalf("requests used ranges %q", ranges)
+	}
+	if ifRanges[1] != `"v2"` {
+		t.Fatalf("resumed request used If-Range %q", ifRanges[1])
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(downloadDir, "*.partial*")); len(leftover) > 0 {
+		t.Fatalf("partial files left behind: %q", leftover)
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	payload := make([]byte, 8<<10)
+	r := &throttledReader{reader: bytes.NewReader(payload), limit: func() int64 { return 32 << 10 }}
+
+	start := time.Now()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+	// 8KB 在 32KB/s 下约需 250ms
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("read finished in %s, expected throttling", elapsed)
+	}
+	if buf.Len() != len(payload) {
+		t.Fatalf("read %d bytes", buf.Len())
+	}
+}
+
+func TestStagedUpdateInstalledOnShutdown(t *testing.T) {
+	key := newTestKey(t, "current")
+	newBinary := []byte("new executable")
+	ms := newManifestServer(t, newBinary)
+	ms.sign(t, key)
+
+	current := filepath.Join(t.TempDir(), "ltools")
+	if err := os.WriteFile(current, []byte("old executable"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestService(t, ms, key)
+	s.executable = func() (string, error) { return current, nil }
+
+	info, err := s.CheckForUpdate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StageUpdate(info.DownloadURL, info.Checksum); err != nil {
+		t.Fatalf("StageUpdate: %v", err)
+	}
+	if v := s.GetStagedVersion(); v != "9.9.9" {
+		t.Fatalf("GetStagedVersion() = %q", v)
+	}
+
+	if err := s.ServiceShutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(current); !bytes.Equal(data, newBinary) {
+		t.Fatalf("executable after shutdown = %q", data)
+	}
+	if v := s.GetStagedVersion(); v != "" {
+		t.Fatalf("staged update not cleared: %q", v)
+	}
+
+	// 被改动的暂存文件不会被安装
+	path, err := s.StageUpdate(info.DownloadURL, info.Checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path, []byte("tampered"), 0755)
+	if err := s.applyStaged(); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Fatalf("applyStaged() = %v", err)
+	}
+}
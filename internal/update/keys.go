@@ -0,0 +1,12 @@
+package update
+
+// trustedKeys 内置的更新清单签名公钥（keyID -> base64 编码的 ed25519 公钥）
+// 对应的私钥只保存在发布流水线的 UPDATE_SIGNING_KEY 中。
+//
+// 轮换密钥的步骤：
+//  1. 生成新密钥（go run ./scripts/sign-manifest -gen），把新公钥加入此表并发布新版本；
+//  2. 在大部分用户升级后，发布时同时使用新旧私钥签名（sign-manifest -append）；
+//  3. 停用旧私钥，并在后续版本中从此表移除旧公钥。
+var trustedKeys = map[string]string{
+	"ltools-2026-1": "vN1QmTKhxl013CYCa1XjTc/tQX5i2XgPsLfOoWkwTlA=",
+}
@@ -0,0 +1,289 @@
+package update
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sharedBinDirs 多个程序共用的目录，不能整体替换
+var sharedBinDirs = []string{"/usr/bin", "/usr/local/bin", "/bin", "/usr/sbin", "/opt"}
+
+// installKind 当前程序的安装方式，首次调用时检测
+func (s *Service) installKind() string {
+	s.installKindOnce.Do(func() {
+		if s.executable == nil {
+			return
+		}
+		if execPath, err := s.executable(); err == nil {
+			s.installKindName = detectInstallKind(execPath)
+		}
+	})
+	return s.installKindName
+}
+
+// installLinuxPackage 通过系统包管理器安装 .deb/.rpm（需要管理员授权）
+func (s *Service) installLinuxPackage(filePath string) error {
+	log.Println("[UpdateService] Installing system package...")
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return err
+	}
+
+	// 优先使用能处理依赖的前端工具
+	var name string
+	var args []string
+	switch {
+	case strings.HasSuffix(absPath, ".deb") && hasCommand("apt-get"):
+		name, args = "apt-get", []string{"install", "-y", "--allow-downgrades", absPath}
+	case strings.HasSuffix(absPath, ".deb"):
+		name, args = "dpkg", []string{"-i", absPath}
+	case hasCommand("dnf"):
+		name, args = "dnf", []string{"install", "-y", absPath}
+	case hasCommand("zypper"):
+		name, args = "zypper", []string{"--non-interactive", "install", "--allow-unsigned-rpm", absPath}
+	default:
+		name, args = "rpm", []string{"-U", absPath}
+	}
+
+	log.Printf("[UpdateService] Running with privilege: %s %s", name, strings.Join(args, " "))
+	if err := runWithPrivilege(name, args...); err != nil {
+		return fmt.Errorf("安装系统包失败: %w", err)
+	}
+
+	log.Println("[UpdateService] System package installed successfully")
+	return nil
+}
+
+// installLinuxTarball 将 .tar.gz 解压到当前安装目录
+// 先解压到同级临时目录，再通过重命名整体替换；替换失败时恢复原目录
+func (s *Service) installLinuxTarball(filePath string) error {
+	log.Println("[UpdateService] Installing tarball...")
+
+	execPath, err := s.executable()
+	if err != nil {
+		return fmt.Errorf("failed to get current executable path: %w", err)
+	}
+	installDir := filepath.Dir(execPath)
+	for _, dir := range sharedBinDirs {
+		if installDir == dir {
+			return fmt.Errorf("当前程序位于共享目录 %s，无法使用压缩包更新", installDir)
+		}
+	}
+
+	// 同一文件系统内的临时目录才能原子重命名；没有写权限时先解压到系统临时目录
+	staging, err := os.MkdirTemp(filepath.Dir(installDir), ".ltools-update-")
+	privileged := err != nil
+	if privileged {
+		if staging, err = os.MkdirTemp("", "ltools-update-"); err != nil {
+			return fmt.Errorf("failed to create staging dir: %w", err)
+		}
+	}
+	defer os.RemoveAll(staging)
+
+	root, err := extractTarGz(filePath, staging)
+	if err != nil {
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+	// 没有顶层目录时临时目录本身成为安装目录，MkdirTemp 创建的目录权限为 0700
+	if root == staging {
+		if err := os.Chmod(staging, 0755); err != nil {
+			return err
+		}
+	}
+
+	// 确认更新包中包含同名可执行文件，避免替换成不相关的目录
+	if info, err := os.Stat(filepath.Join(root, filepath.Base(execPath))); err != nil || info.IsDir() {
+		return fmt.Errorf("更新包中未找到 %s", filepath.Base(execPath))
+	}
+
+	if privileged {
+		log.Printf("[UpdateService] Install dir %s is not writable, requesting privilege", installDir)
+		return swapDirWithPrivilege(root, installDir)
+	}
+	return swapDir(root, installDir)
+}
+
+// swapDir 用 newDir 替换 dir，失败时恢复
+// Linux 下运行中的程序不受影响，旧文件在进程退出后释放
+func swapDir(newDir, dir string) error {
+	old := dir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if err := os.Rename(dir, old); err != nil {
+		return fmt.Errorf("failed to move current install aside: %w", err)
+	}
+	if err := os.Rename(newDir, dir); err != nil {
+		if restoreErr := os.Rename(old, dir); restoreErr != nil {
+			return fmt.Errorf("failed to install (%v) and to restore previous install: %w", err, restoreErr)
+		}
+		return fmt.Errorf("failed to install, previous install restored: %w", err)
+	}
+	if err := os.RemoveAll(old); err != nil {
+		log.Printf("[UpdateService] Warning: failed to remove previous install: %v", err)
+	}
+
+	log.Printf("[UpdateService] Tarball installed to %s", dir)
+	return nil
+}
+
+// swapDirWithPrivilege 以管理员权限执行与 swapDir 相同的替换
+// 复制出的文件归 root 所有，否则普通用户可以改写需要管理员权限的安装目录
+func swapDirWithPrivilege(newDir, dir string) error {
+	// 路径通过位置参数传入，避免引号转义问题
+	const script = `set -e
+staging="$2.new"; old="$2.old"
+rm -rf "$staging" "$old"
+cp -a "$1" "$staging"
+chown -R 0:0 "$staging"
+mv "$2" "$old"
+if mv "$staging" "$2"; then rm -rf "$old"; else mv "$old" "$2"; exit 1; fi`
+
+	if err := runWithPrivilege("sh", "-c", script, "sh", newDir, dir); err != nil {
+		return fmt.Errorf("安装失败: %w", err)
+	}
+	log.Printf("[UpdateService] Tarball installed to %s", dir)
+	return nil
+}
+
+// extractTarGz 解压到 dest，返回应用根目录
+// 压缩包只有一个顶层目录时，根目录为该目录
+// 所有写入都经过 os.Root，符号链接只能指向 dest 内部；写入时不跟随之前解压出的符号链接，
+// 已存在的同名条目先删除再创建
+func extractTarGz(archivePath, dest string) (string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	defer gz.Close()
+
+	root, err := os.OpenRoot(dest)
+	if err != nil {
+		return "", err
+	}
+	defer root.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// 拒绝绝对路径和 .. 逃逸
+		name, ok := localPath(hdr.Name)
+		if !ok {
+			return "", fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+
+		// 不经过之前解压出的符号链接写入，否则链接目标的校验会失效
+		if err := noSymlinkParents(root, name); err != nil {
+			return "", err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := root.MkdirAll(name, os.FileMode(hdr.Mode).Perm()|0700); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if err := prepareEntry(root, name); err != nil {
+				return "", err
+			}
+			out, err := root.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return "", err
+			}
+		case tar.TypeSymlink:
+			// 链接目标相对于链接所在目录解析，必须留在 dest 内
+			if filepath.IsAbs(hdr.Linkname) {
+				return "", fmt.Errorf("invalid symlink in archive: %s -> %s", hdr.Name, hdr.Linkname)
+			}
+			if _, ok := localPath(filepath.Join(filepath.Dir(name), hdr.Linkname)); !ok {
+				return "", fmt.Errorf("invalid symlink in archive: %s -> %s", hdr.Name, hdr.Linkname)
+			}
+			if err := prepareEntry(root, name); err != nil {
+				return "", err
+			}
+			if err := root.Symlink(hdr.Linkname, name); err != nil {
+				return "", err
+			}
+		default:
+			log.Printf("[UpdateService] Skipping unsupported archive entry: %s", hdr.Name)
+		}
+	}
+
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dest, entries[0].Name()), nil
+	}
+	return dest, nil
+}
+
+// localPath 清理压缩包中的路径，路径为绝对路径或逃逸出根目录时返回 false
+func localPath(name string) (string, bool) {
+	name = filepath.Clean(name)
+	if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return name, true
+}
+
+// noSymlinkParents 检查 name 的上级目录中没有符号链接
+func noSymlinkParents(root *os.Root, name string) error {
+	for dir := filepath.Dir(name); dir != "."; dir = filepath.Dir(dir) {
+		info, err := root.Lstat(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("invalid path in archive: %s is inside symlink %s", name, dir)
+		}
+	}
+	return nil
+}
+
+// prepareEntry 创建上级目录并删除已存在的同名文件或符号链接
+func prepareEntry(root *os.Root, name string) error {
+	if err := root.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	if info, err := root.Lstat(name); err == nil && !info.IsDir() {
+		return root.Remove(name)
+	}
+	return nil
+}
+
+// hasCommand 检查命令是否存在
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
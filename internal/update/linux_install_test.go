@@ -0,0 +1,138 @@
+package update
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTarGz 生成测试用压缩包，files 的值为空字符串时表示目录
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeReg, Size: int64(len(content))}
+		if content == "" {
+			hdr.Typeflag, hdr.Size = tar.TypeDir, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func TestInstallLinuxTarball(t *testing.T) {
+	base := t.TempDir()
+	installDir := filepath.Join(base, "ltools")
+	os.MkdirAll(installDir, 0755)
+	execPath := filepath.Join(installDir, "ltools")
+	os.WriteFile(execPath, []byte("old"), 0755)
+	os.WriteFile(filepath.Join(installDir, "stale.txt"), []byte("removed by update"), 0644)
+
+	s := &Service{executable: func() (string, error) { return execPath, nil }}
+
+	// 缺少可执行文件的压缩包不会替换当前安装
+	bad := filepath.Join(base, "bad.tar.gz")
+	writeTarGz(t, bad, map[string]string{"ltools-0.2.0/": "", "ltools-0.2.0/README": "readme"})
+	if err := s.installLinuxTarball(bad); err == nil {
+		t.Fatal("expected archive without executable to be rejected")
+	}
+	if data, _ := os.ReadFile(execPath); string(data) != "old" {
+		t.Fatal("current install modified by rejected archive")
+	}
+
+	// 路径逃逸
+	evil := filepath.Join(base, "evil.tar.gz")
+	writeTarGz(t, evil, map[string]string{"../escape": "x", "ltools": "new"})
+	if err := s.installLinuxTarball(evil); err == nil {
+		t.Fatal("expected path traversal to be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(base, "escape")); !os.IsNotExist(err) {
+		t.Fatal("file written outside staging dir")
+	}
+
+	good := filepath.Join(base, "good.tar.gz")
+	writeTarGz(t, good, map[string]string{
+		"ltools-0.2.0/":                "",
+		"ltools-0.2.0/ltools":          "new",
+		"ltools-0.2.0/resources/a.txt": "asset",
+	})
+	if err := s.installLinuxTarball(good); err != nil {
+		t.Fatalf("installLinuxTarball: %v", err)
+	}
+	if data, _ := os.ReadFile(execPath); string(data) != "new" {
+		t.Fatalf("executable = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(installDir, "resources", "a.txt")); string(data) != "asset" {
+		t.Fatal("resources not installed")
+	}
+	if _, err := os.Stat(filepath.Join(installDir, "stale.txt")); !os.IsNotExist(err) {
+		t.Fatal("old install contents should be replaced")
+	}
+
+	// 只剩安装目录和压缩包，没有遗留的临时目录
+	entries, _ := os.ReadDir(base)
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != "ltools" {
+			t.Fatalf("leftover directory %s", e.Name())
+		}
+	}
+}
+
+func TestExtractTarGzSymlinks(t *testing.T) {
+	type entry struct {
+		name, link, content string
+	}
+	tests := []struct {
+		name    string
+		entries []entry
+		wantErr bool
+	}{
+		{"relative link", []entry{{name: "lib/libfoo.so.1", content: "lib"}, {name: "lib/libfoo.so", link: "libfoo.so.1"}}, false},
+		{"absolute link", []entry{{name: "passwd", link: "/etc/passwd"}}, true},
+		{"escaping link", []entry{{name: "lib/up", link: "../../outside"}}, true},
+		{"write through link", []entry{{name: "self", link: "."}, {name: "self/x", content: "x"}}, true},
+		{"file replaces link", []entry{{name: "target", content: "keep"}, {name: "f", link: "target"}, {name: "f", content: "new"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			archive := filepath.Join(base, "a.tar.gz")
+			f, _ := os.Create(archive)
+			gz := gzip.NewWriter(f)
+			tw := tar.NewWriter(gz)
+			for _, e := range tt.entries {
+				hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+				if e.link != "" {
+					hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, e.link
+				}
+				tw.WriteHeader(hdr)
+				tw.Write([]byte(e.content))
+			}
+			tw.Close()
+			gz.Close()
+			f.Close()
+
+			dest := filepath.Join(base, "dest")
+			os.Mkdir(dest, 0755)
+			_, err := extractTarGz(archive, dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractTarGz() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if data, err := os.ReadFile(filepath.Join(dest, "target")); err == nil && string(data) != "keep" {
+				t.Fatalf("file written through symlink: %q", data)
+			}
+		})
+	}
+}
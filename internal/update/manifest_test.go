@@ -0,0 +1,223 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"ltools/internal/update/signing"
+)
+
+type testKey struct {
+	id   string
+	priv ed25519.PrivateKey
+	pub  string
+}
+
+func newTestKey(t *testing.T, id string) testKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{id: id, priv: priv, pub: base64.StdEncoding.EncodeToString(pub)}
+}
+
+// manifestServer 提供 update.json、update.json.sig 和更新文件
+type manifestServer struct {
+	*httptest.Server
+	manifest  []byte
+	signature []byte
+	files     map[string][]byte
+}
+
+func newManifestServer(t *testing.T, payload []byte) *manifestServer {
+	t.Helper()
+	ms := &manifestServer{files: map[string][]byte{"/ltools.bin": payload}}
+	ms.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/update.json":
+			w.Write(ms.manifest)
+		case "/update.json.sig":
+			if ms.signature == nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(ms.signature)
+		default:
+			data, ok := ms.files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(ms.Close)
+
+	ms.setManifest(&UpdateManifest{
+		Version: "9.9.9",
+		Platforms: map[string]*PlatformUpdateInfo{
+			(&Service{}).getPlatformKey(): {
+				URL:      ms.URL + "/ltools.bin",
+				Size:     int64(len(payload)),
+				Checksum: checksumOf(payload),
+			},
+		},
+	})
+	return ms
+}
+
+func (ms *manifestServer) setManifest(manifest *UpdateManifest) {
+	ms.manifest, _ = json.Marshal(manifest)
+}
+
+func checksumOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (ms *manifestServer) sign(t *testing.T, keys ...testKey) {
+	t.Helper()
+	var env signing.Envelope
+	for _, key := range keys {
+		env.Sign(ms.manifest, key.id, key.priv)
+	}
+	ms.signature, _ = json.Marshal(env)
+}
+
+func newTestService(t *testing.T, ms *manifestServer, keys ...testKey) *Service {
+	trusted := make(map[string]string)
+	for _, key := range keys {
+		trusted[key.id] = key.pub
+	}
+	return NewService(&ServiceConfig{
+		CurrentVersion: "1.0.0",
+		UpdateURL:      ms.URL + "/",
+		DataDir:        t.TempDir(),
+		Enabled:        true,
+		TrustedKeys:    trusted,
+	}, nil)
+}
+
+func TestSignedManifest(t *testing.T) {
+	current := newTestKey(t, "current")
+	payload := []byte("new ltools build")
+
+	ms := newManifestServer(t, payload)
+	ms.sign(t, current)
+
+	s := newTestService(t, ms, current)
+
+	info, err := s.CheckForUpdate()
+	if err != nil {
+		t.Fatalf("CheckForUpdate: %v", err)
+	}
+	if info == nil || info.Version != "9.9.9" {
+		t.Fatalf("CheckForUpdate = %+v", info)
+	}
+
+	path, err := s.DownloadUpdate(info.DownloadURL, info.Checksum)
+	if err != nil {
+		t.Fatalf("DownloadUpdate: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(payload) {
+		t.Fatalf("downloaded %q", data)
+	}
+
+	// URL 或校验和不在已签名清单中时拒绝下载
+	if _, err := s.DownloadUpdate(ms.URL+"/other.bin", ""); err == nil {
+		t.Fatal("expected download of unlisted URL to be refused")
+	}
+	if _, err := s.DownloadUpdate(info.DownloadURL, "sha256:"+hex.EncodeToString(make([]byte, 32))); err == nil {
+		t.Fatal("expected download with mismatched checksum to be refused")
+	}
+}
+
+func TestRejectedManifests(t *testing.T) {
+	trusted := newTestKey(t, "current")
+	untrusted := newTestKey(t, "attacker")
+
+	tests := []struct {
+		name    string
+		prepare func(ms *manifestServer)
+		want    error
+	}{
+		{"unsigned", func(ms *manifestServer) {}, signing.ErrUnsigned},
+		{"empty signature file", func(ms *manifestServer) {
+			ms.signature = []byte(`{"signatures":[]}`)
+		}, signing.ErrUnsigned},
+		{"untrusted key", func(ms *manifestServer) {
+			ms.sign(t, untrusted)
+		}, signing.ErrUntrustedKey},
+		{"tampered manifest", func(ms *manifestServer) {
+			ms.sign(t, trusted)
+			ms.manifest = bytes.Replace(ms.manifest, []byte(`"size":7`), []byte(`"size":8`), 1)
+		}, signing.ErrBadSignature},
+		{"trusted key id with forged signature", func(ms *manifestServer) {
+			env := signing.Envelope{}
+			env.Sign(ms.manifest, trusted.id, untrusted.priv)
+			ms.signature, _ = json.Marshal(env)
+		}, signing.ErrBadSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newManifestServer(t, []byte("payload"))
+			tt.prepare(ms)
+
+			s := newTestService(t, ms, trusted)
+
+			if _, err := s.CheckForUpdate(); !errors.Is(err, tt.want) {
+				t.Fatalf("CheckForUpdate error = %v, want %v", err, tt.want)
+			}
+
+			// DownloadUpdate 自行获取清单时同样拒绝
+			url := ms.URL + "/ltools.bin"
+			if _, err := s.DownloadUpdate(url, ""); !errors.Is(err, tt.want) {
+				t.Fatalf("DownloadUpdate error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := newTestKey(t, "2025")
+	newKey := newTestKey(t, "2026")
+
+	// 轮换期间清单同时由新旧密钥签名，新旧版本客户端都能验证
+	ms := newManifestServer(t, []byte("payload"))
+	ms.sign(t, oldKey, newKey)
+
+	for _, key := range []testKey{oldKey, newKey} {
+		s := newTestService(t, ms, key)
+		if _, err := s.fetchManifest(); err != nil {
+			t.Fatalf("client trusting %s: %v", key.id, err)
+		}
+	}
+
+	// 旧密钥停用后只用新密钥签名，只信任旧密钥的客户端被拒绝
+	ms.sign(t, newKey)
+	if _, err := newTestService(t, ms, oldKey).fetchManifest(); !errors.Is(err, signing.ErrUntrustedKey) {
+		t.Fatalf("expected old client to reject new key, got %v", err)
+	}
+	if _, err := newTestService(t, ms, oldKey, newKey).fetchManifest(); err != nil {
+		t.Fatalf("client trusting both keys: %v", err)
+	}
+}
+
+func TestEmbeddedKeys(t *testing.T) {
+	keys, err := signing.ParseKeyRing(trustedKeys)
+	if err != nil || len(keys) == 0 {
+		t.Fatalf("embedded keys: %v (%d)", err, len(keys))
+	}
+}
@@ -0,0 +1,59 @@
+//go:build linux
+
+package update
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runWithPrivilege 以 root 权限运行命令
+// 已经是 root 时直接运行，否则通过 pkexec（polkit）弹出图形化授权
+func runWithPrivilege(name string, args ...string) error {
+	if os.Geteuid() != 0 {
+		pkexec, err := exec.LookPath("pkexec")
+		if err != nil {
+			return fmt.Errorf("pkexec not found, please run as root: %s %v", name, args)
+		}
+		args = append([]string{name}, args...)
+		name = pkexec
+	}
+
+	cmd := exec.Command(name, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// pkexec: 126 用户取消授权，127 未获授权
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			switch exitErr.ExitCode() {
+			case 126:
+				return fmt.Errorf("已取消授权")
+			case 127:
+				return fmt.Errorf("未获得管理员授权")
+			}
+		}
+		return fmt.Errorf("failed to run with privilege: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// detectInstallKind 判断当前程序的安装方式
+// AppImage 返回空字符串（使用通用的 linux-<arch> 条目）
+func detectInstallKind(execPath string) string {
+	if os.Getenv("APPIMAGE") != "" {
+		return ""
+	}
+	if _, err := exec.LookPath("dpkg"); err == nil {
+		if exec.Command("dpkg", "-S", execPath).Run() == nil {
+			return "deb"
+		}
+	}
+	if _, err := exec.LookPath("rpm"); err == nil {
+		if exec.Command("rpm", "-qf", execPath).Run() == nil {
+			return "rpm"
+		}
+	}
+	return "tar"
+}
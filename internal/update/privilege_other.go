@@ -0,0 +1,17 @@
+//go:build !linux
+
+package update
+
+import (
+	"fmt"
+)
+
+// runWithPrivilege 以 root 权限运行命令（非 Linux 平台的存根）
+func runWithPrivilege(name string, args ...string) error {
+	return fmt.Errorf("privileged install not supported on this platform")
+}
+
+// detectInstallKind 判断当前程序的安装方式（非 Linux 平台的存根）
+func detectInstallKind(execPath string) string {
+	return ""
+}
@@ -0,0 +1,301 @@
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	// rollbackDir 上一版本备份及启动状态所在目录（不参与同步）
+	rollbackDir = "rollback"
+	// previousName 上一版本备份（文件或 .app 目录）
+	previousName = "previous"
+	// pendingName 安装过程中的备份，安装成功后替换 previousName
+	pendingName = "previous.pending"
+)
+
+// launchState 启动健康状态
+type launchState struct {
+	Version  string `json:"version"`
+	Attempts int    `json:"attempts"`          // 尚未确认成功的启动次数
+	Blocked  string `json:"blocked,omitempty"` // 已回滚的版本，不再提供更新
+}
+
+// previousInstall 安装更新前保存的上一版本
+type previousInstall struct {
+	Version string    `json:"version"`
+	Path    string    `json:"path"` // 原安装位置
+	SavedAt time.Time `json:"savedAt"`
+}
+
+// RecordLaunch 在启动时记录一次启动尝试（main 调用）
+// 当前版本连续 MaxLaunchFailures 次未能完成启动时自动回滚，返回 true 表示已回滚、需要重启
+func (s *Service) RecordLaunch() bool {
+	state := s.loadLaunchState()
+	if state.Version != s.currentVersion {
+		state = &launchState{Version: s.currentVersion, Blocked: state.Blocked}
+	}
+	state.Attempts++
+	if err := s.saveJSON("launch.json", state); err != nil {
+		log.Printf("[UpdateService] Failed to record launch: %v", err)
+		return false
+	}
+
+	failures := state.Attempts - 1
+	if failures < s.maxLaunchFailures {
+		return false
+	}
+
+	previous, err := s.loadPrevious()
+	if err != nil || previous == nil || previous.Version == s.currentVersion {
+		return false
+	}
+
+	log.Printf("[UpdateService] Version %s failed to start %d times, rolling back to %s", s.currentVersion, failures, previous.Version)
+	if err := s.Rollback(); err != nil {
+		log.Printf("[UpdateService] Rollback failed: %v", err)
+		return false
+	}
+	return true
+}
+
+// MarkLaunchSuccessful 确认本次启动成功，清零失败计数（main 调用）
+func (s *Service) MarkLaunchSuccessful() {
+	state := s.loadLaunchState()
+	state.Version = s.currentVersion
+	state.Attempts = 0
+	if err := s.saveJSON("launch.json", state); err != nil {
+		log.Printf("[UpdateService] Failed to record successful launch: %v", err)
+	}
+}
+
+// CanRollback 检查是否有可回滚的上一版本，返回其版本号（前端调用）
+func (s *Service) CanRollback() string {
+	previous, err := s.loadPrevious()
+	if err != nil || previous == nil {
+		return ""
+	}
+	return previous.Version
+}
+
+// Rollback 恢复安装更新前保存的上一版本，需要重启应用才能生效（前端调用）
+// 当前版本会被记录下来，之后不再提供该版本的更新
+func (s *Service) Rollback() error {
+	previous, err := s.loadPrevious()
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		return fmt.Errorf("没有可回滚的版本")
+	}
+
+	backup := filepath.Join(s.dataDir, rollbackDir, previousName)
+	info, err := os.Stat(backup)
+	if err != nil {
+		return fmt.Errorf("上一版本备份不存在: %w", err)
+	}
+
+	// 运行中的可执行文件可以重命名但不能覆盖，先移开当前版本
+	failed := previous.Path + ".failed"
+	os.RemoveAll(failed)
+	if err := os.Rename(previous.Path, failed); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move current version aside: %w", err)
+	}
+
+	if info.IsDir() {
+		err = copyDir(backup, previous.Path)
+	} else if err = copyFile(backup, previous.Path); err == nil {
+		err = os.Chmod(previous.Path, 0755)
+	}
+	if err != nil {
+		os.RemoveAll(previous.Path)
+		os.Rename(failed, previous.Path)
+		return fmt.Errorf("failed to restore previous version: %w", err)
+	}
+
+	if err := os.RemoveAll(failed); err != nil {
+		log.Printf("[UpdateService] Warning: failed to remove failed version: %v", err)
+	}
+
+	state := s.loadLaunchState()
+	state.Blocked = s.currentVersion
+	state.Version = previous.Version
+	state.Attempts = 0
+	if err := s.saveJSON("launch.json", state); err != nil {
+		log.Printf("[UpdateService] Warning: failed to save launch state: %v", err)
+	}
+	os.RemoveAll(backup)
+	os.Remove(filepath.Join(s.dataDir, rollbackDir, "previous.json"))
+
+	s.mu.Lock()
+	s.restoredPath = previous.Path
+	s.mu.Unlock()
+
+	log.Printf("[UpdateService] Rolled back from %s to %s", s.currentVersion, previous.Version)
+	if s.app != nil {
+		s.app.Event.Emit("update:rolledback", previous.Version)
+	}
+	return nil
+}
+
+// preservePrevious 安装更新前备份当前版本
+// macOS 备份整个 .app，其他平台备份可执行文件（AppImage 即整个应用）
+// 备份先写到 pendingName，安装成功后由 commitPrevious 启用，失败时由 discardPrevious 删除，
+// 之前保存的上一版本在此期间保持不变
+func (s *Service) preservePrevious() (*previousInstall, error) {
+	// deb/rpm 的文件属于 root 并由包管理器管理，压缩包更新会替换整个安装目录，
+	// 只恢复可执行文件无法正确回滚，这些安装方式不提供回滚
+	if kind := s.installKind(); kind != "" {
+		log.Printf("[UpdateService] Rollback is not supported for %s installs", kind)
+		return nil, nil
+	}
+
+	var current string
+	var err error
+	if runtime.GOOS == "darwin" {
+		current, err = s.getCurrentAppPath()
+	} else {
+		current, err = s.executable()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(current)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(s.dataDir, rollbackDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	pending := filepath.Join(dir, pendingName)
+	if err := os.RemoveAll(pending); err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		err = copyDir(current, pending)
+	} else {
+		err = copyFile(current, pending)
+	}
+	if err != nil {
+		os.RemoveAll(pending)
+		return nil, err
+	}
+
+	log.Printf("[UpdateService] Saved %s (%s) for rollback", current, s.currentVersion)
+	return &previousInstall{
+		Version: s.currentVersion,
+		Path:    current,
+		SavedAt: time.Now(),
+	}, nil
+}
+
+// commitPrevious 安装成功后启用 preservePrevious 保存的备份
+func (s *Service) commitPrevious(previous *previousInstall) error {
+	dir := filepath.Join(s.dataDir, rollbackDir)
+	backup := filepath.Join(dir, previousName)
+	if err := os.RemoveAll(backup); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(dir, pendingName), backup); err != nil {
+		os.Remove(filepath.Join(dir, "previous.json"))
+		return err
+	}
+	return s.saveJSON("previous.json", previous)
+}
+
+// discardPrevious 安装失败时删除 preservePrevious 保存的备份
+func (s *Service) discardPrevious() {
+	if err := os.RemoveAll(filepath.Join(s.dataDir, rollbackDir, pendingName)); err != nil {
+		log.Printf("[UpdateService] Warning: failed to remove rollback backup: %v", err)
+	}
+}
+
+// blockedVersion 返回本机回滚过的版本
+func (s *Service) blockedVersion() string {
+	return s.loadLaunchState().Blocked
+}
+
+func (s *Service) loadLaunchState() *launchState {
+	state := &launchState{}
+	data, err := os.ReadFile(filepath.Join(s.dataDir, rollbackDir, "launch.json"))
+	if err == nil {
+		if err := json.Unmarshal(data, state); err != nil {
+			log.Printf("[UpdateService] Ignoring invalid launch state: %v", err)
+			return &launchState{}
+		}
+	}
+	return state
+}
+
+func (s *Service) loadPrevious() (*previousInstall, error) {
+	data, err := os.ReadFile(filepath.Join(s.dataDir, rollbackDir, "previous.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var previous previousInstall
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return nil, fmt.Errorf("failed to parse rollback info: %w", err)
+	}
+	return &previous, nil
+}
+
+// saveJSON 写入回滚目录中的 JSON 文件
+func (s *Service) saveJSON(name string, v any) error {
+	dir := filepath.Join(s.dataDir, rollbackDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), data, 0644)
+}
+
+// copyDir 递归复制目录，保留文件权限和符号链接（.app 中的 Framework 依赖符号链接）
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			if err := copyFile(path, target); err != nil {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChannelsAndRollout(t *testing.T) {
+	key := newTestKey(t, "current")
+	ms := newManifestServer(t, []byte("payload"))
+	ms.sign(t, key)
+	stable := ms.manifest
+
+	// beta 通道清单 update-beta.json
+	platform := (&Service{}).getPlatformKey()
+	ms.setManifest(&UpdateManifest{
+		Version: "10.0.0-beta.1",
+		Platforms: map[string]*PlatformUpdateInfo{
+			platform: {URL: ms.URL + "/ltools.bin", Checksum: checksumOf([]byte("payload"))},
+		},
+	})
+	ms.sign(t, key)
+	ms.files["/update-beta.json"], ms.files["/update-beta.json.sig"] = ms.manifest, ms.signature
+	ms.manifest = stable
+	ms.sign(t, key)
+
+	s := newTestService(t, ms, key)
+	if info, err := s.CheckForUpdate(); err != nil || info.Version != "9.9.9" {
+		t.Fatalf("stable channel: %+v, %v", info, err)
+	}
+
+	if err := s.SetChannel("canary"); err == nil {
+		t.Fatal("expected unknown channel to be rejected")
+	}
+	if err := s.SetChannel(ChannelBeta); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := s.CheckForUpdate(); err != nil || info.Version != "10.0.0-beta.1" {
+		t.Fatalf("beta channel: %+v, %v", info, err)
+	}
+
+	// 分阶段发布：按安装 ID 分桶，约一半的安装能收到更新
+	offered := 0
+	for i := 0; i < 200; i++ {
+		if rolloutBucket(fmt.Sprintf("install-%d", i), "1.0.0") < 50 {
+			offered++
+		}
+	}
+	if offered < 70 || offered > 130 {
+		t.Fatalf("50%% rollout offered to %d/200 installs", offered)
+	}
+
+	id, err := s.installID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket := rolloutBucket(id, "9.9.9")
+	if !s.inRollout(&UpdateManifest{Version: "9.9.9", Rollout: bucket + 1}) {
+		t.Fatalf("install in bucket %d excluded from %d%% rollout", bucket, bucket+1)
+	}
+	if bucket > 0 && s.inRollout(&UpdateManifest{Version: "9.9.9", Rollout: bucket}) {
+		t.Fatalf("install in bucket %d included in %d%% rollout", bucket, bucket)
+	}
+
+	// 安装 ID 持久化
+	again := newTestService(t, ms, key)
+	again.dataDir = s.dataDir
+	if id2, _ := again.installID(); id2 != id {
+		t.Fatalf("install ID changed: %s -> %s", id, id2)
+	}
+}
+
+func TestRollbackAfterFailedLaunches(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "ltools")
+	os.WriteFile(current, []byte("v1 binary"), 0755)
+	update := filepath.Join(dir, "update.bin")
+	os.WriteFile(update, []byte("v2 binary"), 0755)
+
+	newService := func(version string) *Service {
+		s := NewService(&ServiceConfig{CurrentVersion: version, DataDir: filepath.Join(dir, "data"), MaxLaunchFailures: 2}, nil)
+		s.executable = func() (string, error) { return current, nil }
+		s.installKindOnce.Do(func() {}) // 单文件安装（AppImage），支持回滚
+		return s
+	}
+
+	// 1.0.0 安装 2.0.0，保存上一版本
+	if err := newService("1.0.0").install(update); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newService("2.0.0")
+	if v := s.CanRollback(); v != "1.0.0" {
+		t.Fatalf("CanRollback() = %q", v)
+	}
+
+	// 安装失败时保留之前的备份
+	unsupported := filepath.Join(dir, "update.zip")
+	os.WriteFile(unsupported, []byte("v3"), 0644)
+	if err := s.install(unsupported); err == nil {
+		t.Fatal("expected unsupported update to fail")
+	}
+	if v := s.CanRollback(); v != "1.0.0" {
+		t.Fatalf("CanRollback() after failed install = %q", v)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", rollbackDir, pendingName)); !os.IsNotExist(err) {
+		t.Fatalf("pending backup not removed: %v", err)
+	}
+
+	// 启动两次都未确认成功，第三次启动时回滚
+	if s.RecordLaunch() || s.RecordLaunch() {
+		t.Fatal("rolled back too early")
+	}
+	if !s.RecordLaunch() {
+		t.Fatal("expected rollback after repeated failed launches")
+	}
+	if data, _ := os.ReadFile(current); string(data) != "v1 binary" {
+		t.Fatalf("executable after rollback = %q", data)
+	}
+	if s.blockedVersion() != "2.0.0" {
+		t.Fatalf("blocked version = %q", s.blockedVersion())
+	}
+
+	// 回滚后的版本正常启动，不会再次回滚
+	old := newService("1.0.0")
+	if old.RecordLaunch() {
+		t.Fatal("unexpected rollback")
+	}
+	old.MarkLaunchSuccessful()
+	if old.CanRollback() != "" {
+		t.Fatal("backup should be consumed by rollback")
+	}
+}
+
+func TestNoRollbackForPackageInstalls(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "ltools")
+	os.WriteFile(current, []byte("v1 binary"), 0755)
+	update := filepath.Join(dir, "update.bin")
+	os.WriteFile(update, []byte("v2 binary"), 0755)
+
+	s := NewService(&ServiceConfig{CurrentVersion: "1.0.0", DataDir: filepath.Join(dir, "data")}, nil)
+	s.executable = func() (string, error) { return current, nil }
+	s.installKindOnce.Do(func() { s.installKindName = "deb" })
+
+	if err := s.install(update); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.CanRollback(); v != "" {
+		t.Fatalf("CanRollback() = %q, want no rollback for deb installs", v)
+	}
+}
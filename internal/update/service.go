@@ -1,8 +1,7 @@
 package update
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,9 +11,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"ltools/internal/network"
+	"ltools/internal/update/signing"
+
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
@@ -26,6 +31,22 @@ type Service struct {
 	client         *http.Client
 	dataDir        string
 	app            *application.App
+	keys           signing.KeyRing
+	executable     func() (string, error) // 当前可执行文件路径，补丁更新的基准
+
+	maxLaunchFailures int // 连续启动失败多少次后自动回滚
+
+	bandwidthLimit atomic.Int64 // 下载带宽上限（字节/秒），0 表示不限制
+	background     atomic.Bool  // 后台模式：静默下载，退出时安装
+
+	installKindOnce sync.Once
+	installKindName string // Linux 安装方式（deb/rpm/tar），其他平台为空
+	restoredPath    string // 回滚恢复的上一版本位置，重启时启动它
+
+	mu             sync.Mutex
+	manifest       *UpdateManifest // 最近一次通过签名校验的清单
+	channel        string
+	installIDCache string
 }
 
 // UpdateInfo 更新信息（用于前端）
@@ -47,6 +68,8 @@ type UpdateManifest struct {
 	ReleaseDate  string                          `json:"releaseDate"`
 	ReleaseNotes string                          `json:"releaseNotes"`
 	Mandatory    bool                            `json:"mandatory"`
+	// Rollout 分阶段发布的百分比（1-99），为 0 或 100 时全量发布
+	Rollout      int                             `json:"rollout,omitempty"`
 	Platforms    map[string]*PlatformUpdateInfo  `json:"platforms"`
 }
 
@@ -59,10 +82,13 @@ type PlatformUpdateInfo struct {
 }
 
 // PatchInfo 补丁信息
+// 补丁是对当前可执行文件（Linux 下为 AppImage）生成的 bsdiff 补丁
 type PatchInfo struct {
 	URL      string `json:"url"`
 	Size     int64  `json:"size"`
 	Checksum string `json:"checksum"`
+	// TargetChecksum 打补丁后文件的校验和；为空时使用完整包的校验和（AppImage 补丁的结果即完整包）
+	TargetChecksum string `json:"targetChecksum,omitempty"`
 }
 
 // ServiceConfig 服务配置
@@ -71,20 +97,57 @@ type ServiceConfig struct {
 	UpdateURL      string
 	DataDir        string
 	Enabled        bool
+	// TrustedKeys 受信任的清单签名公钥（keyID -> base64），为空时使用内置公钥
+	TrustedKeys map[string]string
+	// BandwidthLimit 下载带宽上限（字节/秒），0 表示不限制
+	BandwidthLimit int64
+	// Background 后台模式：静默下载更新，在退出应用时安装
+	Background bool
+	// Channel 更新通道（stable/beta/nightly），默认 stable
+	Channel string
+	// MaxLaunchFailures 新版本连续启动失败多少次后回滚到上一版本，默认 3
+	MaxLaunchFailures int
 }
 
+// maxManifestSize 清单及签名文件的大小上限
+const maxManifestSize = 1 << 20
+
 // NewService 创建更新服务
 func NewService(config *ServiceConfig, app *application.App) *Service {
-	return &Service{
-		currentVersion: config.CurrentVersion,
-		updateURL:      config.UpdateURL,
-		enabled:        config.Enabled,
-		dataDir:        config.DataDir,
-		app:            app,
-		client: &http.Client{
-			Timeout: 10 * time.Minute, // 10 分钟超时，适用于大文件下载
-		},
+	keySource := config.TrustedKeys
+	if len(keySource) == 0 {
+		keySource = trustedKeys
+	}
+	keys, err := signing.ParseKeyRing(keySource)
+	if err != nil {
+		// 没有可用公钥时所有清单都会被拒绝
+		log.Printf("[UpdateService] Failed to load trusted keys: %v", err)
+	}
+
+	channel := config.Channel
+	if !validChannel(channel) {
+		channel = ChannelStable
 	}
+	maxLaunchFailures := config.MaxLaunchFailures
+	if maxLaunchFailures <= 0 {
+		maxLaunchFailures = 3
+	}
+
+	s := &Service{
+		currentVersion:    config.CurrentVersion,
+		updateURL:         config.UpdateURL,
+		enabled:           config.Enabled,
+		dataDir:           config.DataDir,
+		app:               app,
+		keys:              keys,
+		executable:        currentExecutable,
+		channel:           channel,
+		maxLaunchFailures: maxLaunchFailures,
+		client:            network.NewClient(10 * time.Minute), // 10 分钟超时，适用于大文件下载
+	}
+	s.bandwidthLimit.Store(config.BandwidthLimit)
+	s.background.Store(config.Background)
+	return s
 }
 
 // CheckForUpdate 检查更新（前端调用）
@@ -95,9 +158,7 @@ func (s *Service) CheckForUpdate() (*UpdateInfo, error) {
 
 	log.Println("[UpdateService] Checking for updates...")
 
-	// 获取平台标识
-	platform := s.getPlatformKey()
-	log.Printf("[UpdateService] Detected platform: %s (GOOS=%s, GOARCH=%s)", platform, runtime.GOOS, runtime.GOARCH)
+	log.Printf("[UpdateService] Detected platform: %s (GOOS=%s, GOARCH=%s)", s.getPlatformKey(), runtime.GOOS, runtime.GOARCH)
 
 	// 下载更新清单
 	manifest, err := s.fetchManifest()
@@ -114,9 +175,21 @@ func (s *Service) CheckForUpdate() (*UpdateInfo, error) {
 		return nil, nil
 	}
 
+	// 回滚过的版本不再提供
+	if manifest.Version == s.blockedVersion() {
+		log.Printf("[UpdateService] Skipping version %s, it was rolled back on this machine", manifest.Version)
+		return nil, nil
+	}
+
+	// 分阶段发布：不在发布范围内时视为没有更新
+	if !s.inRollout(manifest) {
+		log.Printf("[UpdateService] Update %s is rolling out to %d%% of installs, not yet available here", manifest.Version, manifest.Rollout)
+		return nil, nil
+	}
+
 	// 获取平台特定信息
-	platformInfo, exists := manifest.Platforms[platform]
-	if !exists {
+	platform, platformInfo := s.platformInfo(manifest)
+	if platformInfo == nil {
 		return nil, fmt.Errorf("no update available for platform: %s", platform)
 	}
 
@@ -141,8 +214,8 @@ func (s *Service) CheckForUpdate() (*UpdateInfo, error) {
 
 	log.Printf("[UpdateService] Update available: %s (size: %d bytes)", info.Version, info.Size)
 
-	// 发送事件通知前端（保持与启动时自动检查的行为一致）
-	if s.app != nil {
+	// 发送事件通知前端（保持与启动时自动检查的行为一致），后台模式下静默
+	if s.app != nil && !s.IsBackgroundMode() {
 		s.app.Event.Emit("update:available", info)
 	}
 
@@ -155,83 +228,32 @@ func (s *Service) DownloadUpdate(url string, expectedChecksum string) (string, e
 		return "", fmt.Errorf("update service is not enabled")
 	}
 
-	log.Printf("[UpdateService] Downloading update from: %s", url)
-
-	// 创建下载目录
-	downloadDir := filepath.Join(s.dataDir, "updates")
-	if err := os.MkdirAll(downloadDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create download directory: %w", err)
-	}
-
-	// 下载文件
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed with status: %d", resp.StatusCode)
-	}
-
-	// 获取文件总大小
-	totalSize := resp.ContentLength
-	if totalSize <= 0 {
-		log.Printf("[UpdateService] Warning: Content-Length is %d, progress tracking may be inaccurate", totalSize)
-	}
-
-	// 创建临时文件
-	tmpFile := filepath.Join(downloadDir, "update-download.tmp")
-	out, err := os.Create(tmpFile)
+	// 只下载已签名清单中列出的文件，并以清单中的校验和为准
+	target, err := s.verifiedTarget(url, expectedChecksum)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
+		log.Printf("[UpdateService] Refusing download: %v", err)
+		return "", err
 	}
-	defer out.Close()
 
-	// 计算校验和
-	hash := sha256.New()
-
-	// 创建进度写入器
-	progressWriter := &progressWriter{
-		writer:    out,
-		hash:      hash,
-		total:     totalSize,
-		app:       s.app,
-		lastEmit:  time.Now(),
-	}
+	return s.fetchUpdate(target, false)
+}
 
-	// 复制数据
-	written, err := io.Copy(progressWriter, resp.Body)
-	if err != nil {
-		os.Remove(tmpFile)
-		return "", fmt.Errorf("failed to write file: %w", err)
-	}
-
-	// 发送最终进度（100%）
-	if s.app != nil && totalSize > 0 {
-		s.app.Event.Emit("update:progress", 100)
-	}
-
-	// 验证校验和
-	actualChecksum := hex.EncodeToString(hash.Sum(nil))
-	if expectedChecksum != "" && !s.verifyChecksum(actualChecksum, expectedChecksum) {
-		// 校验和验证失败
-		// 保留损坏的文件供调试（添加 .corrupted 后缀）
-		corruptedFile := tmpFile + ".corrupted"
-		if err := os.Rename(tmpFile, corruptedFile); err != nil {
-			log.Printf("[UpdateService] Warning: failed to rename corrupted file: %v", err)
-			os.Remove(tmpFile)
-		} else {
-			log.Printf("[UpdateService] Corrupted file saved for debugging: %s", corruptedFile)
+// fetchUpdate 下载更新文件，优先使用增量补丁，任何失败都回退到完整下载
+// quiet 为 true 时不发送进度事件（后台模式）
+func (s *Service) fetchUpdate(target *downloadTarget, quiet bool) (string, error) {
+	if target.patch != nil {
+		path, err := s.downloadPatched(target.platform, target.patch, target.version, quiet)
+		if err == nil {
+			return path, nil
 		}
-
-		// 返回友好的错误信息
-		return "", fmt.Errorf("checksum verification failed: expected=%s, got=%s. The download may be corrupted. Please try again", expectedChecksum, actualChecksum)
+		log.Printf("[UpdateService] Patch update failed, falling back to full download: %v", err)
 	}
 
-	log.Printf("[UpdateService] Download completed: %d bytes, checksum: %s", written, actualChecksum)
+	return s.downloadFile(target.platform.URL, target.platform.Checksum, target.version, "update"+updateFileExt(target.platform.URL), quiet)
+}
 
-	// 确定文件扩展名（从 URL 提取）
+// updateFileExt 根据下载地址确定更新文件扩展名
+func updateFileExt(url string) string {
 	var ext string
 	// 特殊处理 .tar.gz 双扩展名
 	if strings.HasSuffix(url, ".tar.gz") {
@@ -255,17 +277,7 @@ func (s *Service) DownloadUpdate(url string, expectedChecksum string) (string, e
 			ext = ".tar.gz"
 		}
 	}
-
-	// 重命名临时文件为最终文件名
-	finalFile := filepath.Join(downloadDir, "update"+ext)
-	if err := os.Rename(tmpFile, finalFile); err != nil {
-		log.Printf("[UpdateService] Warning: failed to rename file, using temp path: %v", err)
-		// 如果重命名失败，返回临时文件路径
-		return tmpFile, nil
-	}
-
-	log.Printf("[UpdateService] File renamed to: %s", finalFile)
-	return finalFile, nil
+	return ext
 }
 
 // InstallUpdate 安装更新（前端调用）
@@ -275,6 +287,21 @@ func (s *Service) InstallUpdate(filePath string) error {
 		return fmt.Errorf("update service is not enabled")
 	}
 
+	if err := s.install(filePath); err != nil {
+		return err
+	}
+
+	// Windows 安装程序会覆盖文件并重启应用，需要先退出当前应用
+	if runtime.GOOS == "windows" && !strings.HasSuffix(filePath, ".bin") {
+		log.Println("[UpdateService] Installer started, exiting current application...")
+		os.Exit(0)
+	}
+
+	return s.promptRestart()
+}
+
+// install 执行安装，不提示重启
+func (s *Service) install(filePath string) error {
 	log.Printf("[UpdateService] Installing update from: %s", filePath)
 
 	// 验证文件存在
@@ -282,6 +309,31 @@ func (s *Service) InstallUpdate(filePath string) error {
 		return fmt.Errorf("update file not found: %s", filePath)
 	}
 
+	// 保留当前版本，新版本无法启动时回滚；安装成功后才生效
+	previous, err := s.preservePrevious()
+	if err != nil {
+		log.Printf("[UpdateService] Warning: failed to save current version for rollback: %v", err)
+	}
+
+	if err := s.installFile(filePath); err != nil {
+		s.discardPrevious()
+		return err
+	}
+	if previous != nil {
+		if err := s.commitPrevious(previous); err != nil {
+			log.Printf("[UpdateService] Warning: failed to save rollback info: %v", err)
+		}
+	}
+	return nil
+}
+
+// installFile 按文件类型和平台安装更新
+func (s *Service) installFile(filePath string) error {
+	// 增量补丁生成的可执行文件直接替换
+	if strings.HasSuffix(filePath, ".bin") {
+		return s.installBinary(filePath)
+	}
+
 	// 平台特定的安装逻辑
 	switch runtime.GOOS {
 	case "darwin":
@@ -380,9 +432,7 @@ func (s *Service) installMacOS(filePath string) error {
 	}
 
 	log.Println("[UpdateService] macOS installation completed successfully")
-
-	// 9. 提示重启
-	return s.promptRestart()
+	return nil
 }
 
 // installWindows Windows 安装逻辑
@@ -416,12 +466,6 @@ func (s *Service) installWindows(filePath string) error {
 	// 5. 给安装程序 2 秒启动时间
 	time.Sleep(2 * time.Second)
 
-	log.Println("[UpdateService] Installer started, exiting current application...")
-
-	// 6. 退出当前应用，让安装程序完成
-	// 安装程序会自动覆盖文件并重启应用
-	os.Exit(0)
-
 	return nil
 }
 
@@ -433,7 +477,9 @@ func (s *Service) installLinux(filePath string) error {
 	if strings.HasSuffix(filePath, ".AppImage") {
 		return s.installLinuxAppImage(filePath)
 	} else if strings.HasSuffix(filePath, ".deb") || strings.HasSuffix(filePath, ".rpm") {
-		return fmt.Errorf("DEB/RPM packages require manual installation with sudo. Please run: sudo dpkg -i %s or sudo rpm -U %s", filePath, filePath)
+		return s.installLinuxPackage(filePath)
+	} else if strings.HasSuffix(filePath, ".tar.gz") {
+		return s.installLinuxTarball(filePath)
 	}
 
 	return fmt.Errorf("unsupported file format: %s", filePath)
@@ -444,7 +490,7 @@ func (s *Service) installLinuxAppImage(filePath string) error {
 	log.Println("[UpdateService] Installing AppImage...")
 
 	// 1. 获取当前 AppImage 路径
-	currentPath, err := os.Executable()
+	currentPath, err := s.executable()
 	if err != nil {
 		return fmt.Errorf("failed to get current executable path: %w", err)
 	}
@@ -477,9 +523,7 @@ func (s *Service) installLinuxAppImage(filePath string) error {
 	}
 
 	log.Println("[UpdateService] AppImage installation completed successfully")
-
-	// 6. 提示重启
-	return s.promptRestart()
+	return nil
 }
 
 // getCurrentAppPath 获取当前 .app 路径（macOS）
@@ -540,10 +584,16 @@ func (s *Service) promptRestart() error {
 func (s *Service) RestartApp() error {
 	log.Println("[UpdateService] Restarting application...")
 
-	// 获取当前可执行文件路径
-	execPath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
+	// 回滚后当前可执行文件已被移走删除，启动恢复的上一版本；
+	// AppImage 需要启动 APPIMAGE 而不是挂载目录中的文件
+	s.mu.Lock()
+	execPath := s.restoredPath
+	s.mu.Unlock()
+	if execPath == "" {
+		var err error
+		if execPath, err = s.executable(); err != nil {
+			return fmt.Errorf("failed to get executable path: %w", err)
+		}
 	}
 
 	log.Printf("[UpdateService] Current executable: %s", execPath)
@@ -565,8 +615,8 @@ func (s *Service) RestartApp() error {
 func (s *Service) restartMacOS(execPath string) error {
 	log.Println("[UpdateService] Restarting on macOS...")
 
-	// 如果是 .app bundle，需要启动整个 .app
-	if strings.Contains(execPath, ".app/Contents/MacOS/") {
+	// 如果是 .app bundle，需要启动整个 .app（回滚时传入的就是 .app）
+	if strings.HasSuffix(execPath, ".app") || strings.Contains(execPath, ".app/Contents/MacOS/") {
 		// 查找 .app 根目录
 		appPath := execPath
 		for !strings.HasSuffix(appPath, ".app") {
@@ -656,26 +706,146 @@ func (s *Service) IsEnabled() bool {
 	return s.enabled
 }
 
-// fetchManifest 获取更新清单
+// fetchManifest 获取并校验当前通道的更新清单
+// 预发布通道同时检查稳定版清单，取版本较高者；通道清单不可用时使用稳定版
 func (s *Service) fetchManifest() (*UpdateManifest, error) {
-	resp, err := s.client.Get(s.updateURL + "update.json")
+	channel := s.GetChannel()
+	manifest, err := s.fetchChannelManifest(channel)
+	if channel != ChannelStable {
+		// 通道清单在首次预发布前并不存在，此时只使用稳定版清单
+		stable, stableErr := s.fetchChannelManifest(ChannelStable)
+		switch {
+		case err != nil && stableErr != nil:
+			return nil, err
+		case err != nil:
+			log.Printf("[UpdateService] Failed to fetch %s manifest, using stable: %v", channel, err)
+			manifest, err = stable, nil
+		case stableErr != nil:
+			log.Printf("[UpdateService] Failed to fetch stable manifest: %v", stableErr)
+		case compareVersions(stable.Version, manifest.Version) > 0:
+			manifest = stable
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.manifest = manifest
+	s.mu.Unlock()
+
+	return manifest, nil
+}
+
+// fetchChannelManifest 获取并校验指定通道的清单
+// 清单必须带有受信任密钥的有效签名（<清单名>.sig），否则拒绝
+func (s *Service) fetchChannelManifest(channel string) (*UpdateManifest, error) {
+	name := manifestName(channel)
+	data, err := s.fetchFile(name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("manifest fetch failed with status: %d", resp.StatusCode)
+	sigData, err := s.fetchFile(name + ".sig")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", signing.ErrUnsigned, err)
+	}
+	envelope, err := signing.ParseEnvelope(sigData)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := envelope.Verify(data, s.keys)
+	if err != nil {
+		return nil, err
 	}
 
 	var manifest UpdateManifest
-	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+	if err := json.Unmarshal(data, &manifest); err != nil {
 		return nil, fmt.Errorf("failed to parse manifest: %w", err)
 	}
+	log.Printf("[UpdateService] Manifest %s (%s) verified with key %s", manifest.Version, channel, keyID)
 
 	return &manifest, nil
 }
 
+// fetchFile 下载更新目录中的小文件
+func (s *Service) fetchFile(name string) ([]byte, error) {
+	resp, err := s.client.Get(s.updateURL + name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s fetch failed with status: %d", name, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	return data, nil
+}
+
+// downloadTarget 已签名清单中的下载项
+type downloadTarget struct {
+	version  string
+	platform *PlatformUpdateInfo
+	patch    *PatchInfo // 非空表示下载地址是增量补丁
+}
+
+// verifiedTarget 在已签名的清单中查找下载地址
+// 尚未获取清单时先获取一次；前端传入的校验和必须与清单一致
+func (s *Service) verifiedTarget(url, expectedChecksum string) (*downloadTarget, error) {
+	s.mu.Lock()
+	manifest := s.manifest
+	s.mu.Unlock()
+
+	if manifest == nil {
+		var err error
+		if manifest, err = s.fetchManifest(); err != nil {
+			return nil, fmt.Errorf("无法验证更新清单: %w", err)
+		}
+	}
+
+	var target *downloadTarget
+	checksum := ""
+	if _, info := s.platformInfo(manifest); info != nil {
+		if info.URL == url {
+			target = &downloadTarget{version: manifest.Version, platform: info}
+			checksum = info.Checksum
+		}
+		// 只接受针对当前版本的补丁，其他版本的补丁无法应用到本机文件
+		if patch := info.Patches[s.currentVersion]; patch != nil && patch.URL == url {
+			target = &downloadTarget{version: manifest.Version, platform: info, patch: patch}
+			checksum = patch.Checksum
+		}
+	}
+
+	if target == nil || checksum == "" || target.platform.Checksum == "" {
+		return nil, fmt.Errorf("下载地址不在已签名的更新清单中: %s", url)
+	}
+	if expectedChecksum != "" && !s.verifyChecksum(normalizeChecksum(expectedChecksum), checksum) {
+		return nil, fmt.Errorf("校验和与已签名的更新清单不一致: %s", expectedChecksum)
+	}
+	return target, nil
+}
+
+// platformInfo 查找当前平台的更新信息
+// Linux 下优先使用与安装方式对应的条目（如 linux-amd64-deb），没有时使用通用条目（AppImage）
+func (s *Service) platformInfo(manifest *UpdateManifest) (string, *PlatformUpdateInfo) {
+	platform := s.getPlatformKey()
+	if kind := s.installKind(); kind != "" {
+		if info, ok := manifest.Platforms[platform+"-"+kind]; ok {
+			return platform + "-" + kind, info
+		}
+	}
+	return platform, manifest.Platforms[platform]
+}
+
 // getPlatformKey 获取平台标识
 func (s *Service) getPlatformKey() string {
 	return fmt.Sprintf("%s-%s", runtime.GOOS, runtime.GOARCH)
@@ -683,11 +853,15 @@ func (s *Service) getPlatformKey() string {
 
 // verifyChecksum 验证校验和
 func (s *Service) verifyChecksum(actual, expected string) bool {
-	// 移除 "sha256:" 前缀（如果有）
-	if len(expected) > 7 && expected[:7] == "sha256:" {
-		expected = expected[7:]
+	return actual == normalizeChecksum(expected)
+}
+
+// normalizeChecksum 移除 "sha256:" 前缀（如果有）
+func normalizeChecksum(checksum string) string {
+	if len(checksum) > 7 && checksum[:7] == "sha256:" {
+		return checksum[7:]
 	}
-	return actual == expected
+	return checksum
 }
 
 // Cleanup 清理临时文件
@@ -703,7 +877,6 @@ func (s *Service) Cleanup() error {
 // progressWriter 用于跟踪下载进度并发送事件
 type progressWriter struct {
 	writer    io.Writer
-	hash      io.Writer
 	total     int64
 	written   int64
 	app       *application.App
@@ -711,17 +884,12 @@ type progressWriter struct {
 }
 
 func (pw *progressWriter) Write(p []byte) (int, error) {
-	// 写入文件和哈希
+	// 写入文件
 	n, err := pw.writer.Write(p)
 	if err != nil {
 		return n, err
 	}
 
-	_, err = pw.hash.Write(p[:n])
-	if err != nil {
-		return n, err
-	}
-
 	// 更新已写入字节数
 	pw.written += int64(n)
 
@@ -739,15 +907,20 @@ func (pw *progressWriter) Write(p []byte) (int, error) {
 }
 
 // compareVersions 比较两个语义化版本号
+// 预发布版本低于对应的正式版本（0.2.0-beta.1 < 0.2.0-beta.2 < 0.2.0-rc.1 < 0.2.0），构建元数据不参与比较
 // 返回值: 1 (v1 > v2), 0 (v1 == v2), -1 (v1 < v2)
 func compareVersions(v1, v2 string) int {
-	// 移除可能的 'v' 前缀
-	v1 = strings.TrimPrefix(v1, "v")
-	v2 = strings.TrimPrefix(v2, "v")
+	// 移除可能的 'v' 前缀和构建元数据
+	v1, _, _ = strings.Cut(strings.TrimPrefix(v1, "v"), "+")
+	v2, _, _ = strings.Cut(strings.TrimPrefix(v2, "v"), "+")
+
+	// 拆分预发布标识
+	core1, pre1, _ := strings.Cut(v1, "-")
+	core2, pre2, _ := strings.Cut(v2, "-")
 
 	// 分割版本号
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
+	parts1 := strings.Split(core1, ".")
+	parts2 := strings.Split(core2, ".")
 
 	// 确保至少有 3 个部分 (major.minor.patch)
 	for len(parts1) < 3 {
@@ -779,7 +952,43 @@ func compareVersions(v1, v2 string) int {
 		}
 	}
 
-	return 0
+	return comparePrerelease(pre1, pre2)
+}
+
+// comparePrerelease 按 semver 规则比较预发布标识
+// 没有预发布标识的版本更高；数字标识按数值比较且低于字母标识；前缀相同时标识多的更高
+func comparePrerelease(pre1, pre2 string) int {
+	switch {
+	case pre1 == pre2:
+		return 0
+	case pre1 == "":
+		return 1
+	case pre2 == "":
+		return -1
+	}
+
+	ids1 := strings.Split(pre1, ".")
+	ids2 := strings.Split(pre2, ".")
+	for i := 0; i < len(ids1) && i < len(ids2); i++ {
+		n1, err1 := strconv.Atoi(ids1[i])
+		n2, err2 := strconv.Atoi(ids2[i])
+
+		switch {
+		case err1 == nil && err2 == nil:
+			if n1 != n2 {
+				return cmp.Compare(n1, n2)
+			}
+		case err1 == nil:
+			return -1
+		case err2 == nil:
+			return 1
+		default:
+			if c := strings.Compare(ids1[i], ids2[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(ids1), len(ids2))
 }
 
 // parseVersionPart 解析版本号的某个部分
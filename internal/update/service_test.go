@@ -36,6 +36,16 @@ func TestCompareVersions(t *testing.T) {
 		{"with beta suffix", "0.2.0-beta", "0.1.0", 1},
 		{"with rc suffix", "0.2.0-rc1", "0.1.0", 1},
 		{"with build metadata", "0.2.0+build123", "0.1.0", 1},
+
+		// 预发布版本排序
+		{"prerelease lower than release", "0.2.0-beta.1", "0.2.0", -1},
+		{"release higher than rc", "0.2.0", "0.2.0-rc.1", 1},
+		{"beta lower than rc", "0.2.0-beta.2", "0.2.0-rc.1", -1},
+		{"numeric prerelease ids", "0.2.0-beta.10", "0.2.0-beta.2", 1},
+		{"more prerelease ids", "0.2.0-beta.1", "0.2.0-beta", 1},
+		{"numeric lower than alpha", "0.2.0-1", "0.2.0-alpha", -1},
+		{"nightly dates", "0.2.0-nightly.20261018", "0.2.0-nightly.20261017", 1},
+		{"build metadata ignored", "0.2.0-beta.1+abc", "0.2.0-beta.1+def", 0},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,112 @@
+// Package signing 实现更新清单的 ed25519 签名与校验
+//
+// 签名以独立文件（update.json.sig）发布在清单旁边，签名对象是 update.json 的原始字节，
+// 因此清单的任何改动（包括格式化）都会导致校验失败。一个签名文件可以包含多个签名，
+// 用于密钥轮换期间同时使用新旧密钥签名。
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// signingContext 签名前缀，避免同一密钥签出的其他数据被当作清单签名使用
+const signingContext = "ltools-update-manifest-v1\n"
+
+var (
+	// ErrUnsigned 签名文件中没有任何签名
+	ErrUnsigned = errors.New("更新清单未签名")
+	// ErrUntrustedKey 所有签名都来自未受信任的密钥
+	ErrUntrustedKey = errors.New("更新清单签名密钥不受信任")
+	// ErrBadSignature 签名与清单内容不匹配
+	ErrBadSignature = errors.New("更新清单签名无效")
+)
+
+// Signature 单个签名
+type Signature struct {
+	KeyID     string `json:"keyId"`
+	Signature string `json:"signature"` // base64 编码
+}
+
+// Envelope 签名文件内容
+type Envelope struct {
+	Signatures []Signature `json:"signatures"`
+}
+
+// KeyRing 受信任的公钥集合（keyID -> 公钥）
+type KeyRing map[string]ed25519.PublicKey
+
+// ParseKeyRing 解析 base64 编码的公钥
+func ParseKeyRing(keys map[string]string) (KeyRing, error) {
+	ring := make(KeyRing, len(keys))
+	for id, encoded := range keys {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %s: %w", id, err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key %s: expected %d bytes, got %d", id, ed25519.PublicKeySize, len(raw))
+		}
+		ring[id] = ed25519.PublicKey(raw)
+	}
+	return ring, nil
+}
+
+// ParseEnvelope 解析签名文件
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse signature file: %w", err)
+	}
+	return &env, nil
+}
+
+// Sign 使用私钥对清单签名，并追加到签名文件中
+// 同一 keyID 的旧签名会被替换
+func (e *Envelope) Sign(manifest []byte, keyID string, key ed25519.PrivateKey) {
+	sig := Signature{
+		KeyID:     keyID,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, signedMessage(manifest))),
+	}
+	for i := range e.Signatures {
+		if e.Signatures[i].KeyID == keyID {
+			e.Signatures[i] = sig
+			return
+		}
+	}
+	e.Signatures = append(e.Signatures, sig)
+}
+
+// Verify 校验清单签名，返回验证通过的 keyID
+// 只要有一个受信任密钥的签名有效即可通过；来自受信任密钥的无效签名直接拒绝
+func (e *Envelope) Verify(manifest []byte, keys KeyRing) (string, error) {
+	if len(e.Signatures) == 0 {
+		return "", ErrUnsigned
+	}
+
+	message := signedMessage(manifest)
+	for _, sig := range e.Signatures {
+		key, ok := keys[sig.KeyID]
+		if !ok {
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+		if err != nil || !ed25519.Verify(key, message, raw) {
+			return "", fmt.Errorf("%w (key %s)", ErrBadSignature, sig.KeyID)
+		}
+		return sig.KeyID, nil
+	}
+
+	return "", ErrUntrustedKey
+}
+
+// signedMessage 构造实际签名的消息
+func signedMessage(manifest []byte) []byte {
+	message := make([]byte, 0, len(signingContext)+len(manifest))
+	message = append(message, signingContext...)
+	return append(message, manifest...)
+}
@@ -4,12 +4,19 @@ import (
 	"embed"
 	_ "embed"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
+	"ltools/internal/backup"
+	"ltools/internal/crash"
+	"ltools/internal/logging"
+	"ltools/internal/network"
+	"ltools/internal/notify"
 	"ltools/internal/plugins"
 	"ltools/internal/proxy"
 	"ltools/internal/settings"
@@ -20,6 +27,8 @@ import (
 	"ltools/plugins/calculator"
 	"ltools/plugins/clipboard"
 	"ltools/plugins/datetime"
+	"ltools/plugins/datetime/timers"
+	"ltools/plugins/datetime/worldclock"
 	"ltools/plugins/hosts"
 	"ltools/plugins/imagebed"
 	"ltools/plugins/imageprocessor"
@@ -70,6 +79,9 @@ func init() {
 	application.RegisterEvent[int]("datetime:hour")
 	application.RegisterEvent[int]("datetime:minute")
 	application.RegisterEvent[int]("datetime:second")
+	application.RegisterEvent[[]worldclock.ZoneTime]("datetime:clocks")
+	application.RegisterEvent[[]timers.State]("datetime:timers")
+	application.RegisterEvent[timers.Alert]("datetime:alert")
 
 	// Register custom events for the calculator plugin
 	application.RegisterEvent[string]("calculator:result")
@@ -212,6 +224,16 @@ func init() {
 	// Register custom events for the update service
 	application.RegisterEvent[*update.UpdateInfo]("update:available")
 	application.RegisterEvent[int]("update:progress")
+	application.RegisterEvent[string]("update:staged")
+	application.RegisterEvent[string]("update:rolledback")
+
+	// Register settings events
+	application.RegisterEvent[settings.Change]("settings:changed")
+
+	// Register backup events
+	application.RegisterEvent[*backup.Backup]("backup:created")
+	application.RegisterEvent[string]("backup:restored")
+	application.RegisterEvent[string]("backup:failed")
 
 	// Register custom event for file open (file association)
 	application.RegisterEvent[string]("file:open")
@@ -224,6 +246,43 @@ func init() {
 // registers plugins, and runs the application.
 func main() {
 
+	// Get user data directory for plugin storage
+	userDataDir, err := os.UserConfigDir()
+	if err != nil {
+		log.Fatal("Failed to get user config dir:", err)
+	}
+	dataDir := filepath.Join(userDataDir, "ltools")
+
+	// A restored backup is swapped in before anything reads the data directory
+	if applied, err := backup.ApplyPendingRestore(dataDir); err != nil {
+		log.Printf("[Main] %v", err)
+	} else if applied {
+		log.Println("[Main] Restored data directory from backup")
+	}
+
+	// Create settings service for general app settings
+	settingsService := settings.NewService(dataDir)
+
+	// Record the launch before anything else can fail, and roll back to the
+	// previous version if this one keeps failing to start
+	launchGuard := update.NewService(&update.ServiceConfig{
+		CurrentVersion:    version,
+		DataDir:           dataDir,
+		MaxLaunchFailures: settingsService.GetUpdate().MaxLaunchFailures,
+	}, nil)
+	if launchGuard.RecordLaunch() {
+		if err := launchGuard.RestartApp(); err != nil {
+			log.Printf("[Main] Failed to restart after rollback: %v", err)
+		}
+	}
+
+	// 结构化日志：写入 logs/ 下的轮转文件和内存缓冲，log.Printf 和 Wails 日志也会经过它
+	logService := logging.NewLogService(dataDir, version)
+
+	// 崩溃时在 crashes/ 下写入崩溃包（堆栈、日志、插件状态），不会上传
+	crashReporter := crash.NewReporter(dataDir, logService.RecentLines)
+	defer crashReporter.Recover()
+
 	// Create proxy manager for all plugins
 	proxyManager := proxy.NewProxyManager(&proxy.ProxyConfig{
 		RequestTimeout:  30 * time.Second,
@@ -231,6 +290,7 @@ func main() {
 		EnableCache:     true,
 		CacheTTL:        30 * time.Minute,
 		MaxCacheEntries: 1000,
+		MaxCacheSize:    1 << 30, // 1GB 磁盘缓存
 		EnableLogging:   true,
 	})
 
@@ -248,6 +308,13 @@ func main() {
 		Assets: application.AssetOptions{
 			Handler: proxyHandler,
 		},
+		Logger: slog.Default(),
+		// 服务方法中的 panic：写入崩溃包后按 Wails 的默认行为退出
+		PanicHandler: func(details *application.PanicDetails) {
+			crashReporter.ReportPanic(details.Error)
+			log.Printf("[Main] Panic: %v\n%s", details.Error, details.StackTrace)
+			os.Exit(1)
+		},
 		Mac: application.MacOptions{
 			ApplicationShouldTerminateAfterLastWindowClosed: false, // 保持应用在窗口关闭后运行
 		},
@@ -320,12 +387,13 @@ func main() {
 
 	systray.SetMenu(menu)
 
-	// Get user data directory for plugin storage
-	userDataDir, err := os.UserConfigDir()
-	if err != nil {
-		log.Fatal("Failed to get user config dir:", err)
+	// 全局网络配置（代理、证书、超时），所有 HTTP 客户端共用
+	networkService := network.NewService(dataDir)
+
+	// 代理资源的磁盘缓存（支持断点续传，重启后仍然有效）
+	if err := proxyManager.SetCacheDir(filepath.Join(dataDir, "cache", "proxy")); err != nil {
+		log.Printf("[Main] Failed to set proxy cache dir: %v", err)
 	}
-	dataDir := filepath.Join(userDataDir, "ltools")
 
 	// Create plugin manager
 	pluginManager, err := plugins.NewManager(app, dataDir)
@@ -338,6 +406,10 @@ func main() {
 	if err := pluginManager.Register(datetimePlugin); err != nil {
 		log.Fatal("Failed to register datetime plugin:", err)
 	}
+	if err := datetimePlugin.SetDataDir(dataDir); err != nil {
+		log.Printf("[Main] Failed to set data dir for datetime: %v", err)
+	}
+	datetimePlugin.SetNotifier(notify.New(app))
 
 	// Create and register password plugin
 	passwordPlugin := password.NewPasswordPlugin()
@@ -350,12 +422,18 @@ func main() {
 	if err := pluginManager.Register(calculatorPlugin); err != nil {
 		log.Fatal("Failed to register calculator plugin:", err)
 	}
+	if err := calculatorPlugin.SetDataDir(dataDir); err != nil {
+		log.Printf("[Main] Failed to set data dir for calculator: %v", err)
+	}
 
 	// Create and register the clipboard plugin
 	clipboardPlugin := clipboard.NewClipboardPlugin()
 	if err := pluginManager.Register(clipboardPlugin); err != nil {
 		log.Fatal("Failed to register clipboard plugin:", err)
 	}
+	if err := clipboardPlugin.SetDataDir(dataDir); err != nil {
+		log.Printf("[Main] Failed to set data dir for clipboard: %v", err)
+	}
 
 	// Create and register the system info plugin
 	sysInfoPlugin := sysinfo.NewSysInfoPlugin()
@@ -509,6 +587,9 @@ func main() {
 	// Create datetime service to expose datetime functionality to frontend
 	datetimeService := datetime.NewDateTimeService(datetimePlugin)
 
+	// Create time toolkit service for cron expressions and timestamp conversion
+	timeToolkitService := datetime.NewTimeToolkitService()
+
 	// Create password service to expose password functionality to frontend
 	passwordService := password.NewPasswordService(passwordPlugin, app)
 
@@ -560,12 +641,14 @@ func main() {
 	bookmarkService := bookmark.NewBookmarkService(app, bookmarkPlugin)
 
 	// Create ipinfo service to expose ipinfo functionality to frontend
+	ipinfoPlugin.SetProxyService(proxy.NewProxyService(proxyManager, ipinfoPlugin.Metadata().ID))
 	ipinfoService := ipinfo.NewService(ipinfoPlugin, app)
 
 	// Create sticky service to expose sticky functionality to frontend
 	stickyService := sticky.NewStickyService(stickyPlugin, app, dataDir)
 
 	// Create imagebed service to expose imagebed functionality to frontend
+	imagebedPlugin.SetProxyService(proxy.NewProxyService(proxyManager, imagebed.PluginID))
 	imagebedService := imagebed.NewImageBedService(imagebedPlugin, app)
 
 	// Create imageprocessor service to expose imageprocessor functionality to frontend
@@ -603,27 +686,104 @@ func main() {
 		log.Fatal("Failed to create sync service:", err)
 	}
 
-	// Create settings service for general app settings
-	settingsService := settings.NewService()
+	// Register plugin-declared data paths for per-plugin sync selection
+	for _, meta := range pluginManager.ListMetadata() {
+		if len(meta.DataPaths) == 0 {
+			continue
+		}
+		syncService.RegisterPluginData(sync.PluginDataSpec{
+			PluginID:    meta.ID,
+			Name:        meta.Name,
+			Paths:       meta.DataPaths,
+			LocalFields: meta.LocalDataFields,
+		})
+	}
+
+	// 根据插件声明的网络权限设置代理策略
+	for _, meta := range pluginManager.ListMetadata() {
+		proxyManager.SetNetworkAccess(meta.ID, slices.Contains(meta.Permissions, plugins.PermissionNetwork), meta.AllowedDomains)
+	}
+
+	// Settings sections owned by other services (shortcuts, plugins, sync, network)
+	registerSettingsSections(settingsService, shortcutService, pluginManager, syncService, networkService)
+
+	// Create backup service for scheduled backups of the whole data directory
+	backupService := backup.NewBackupService(dataDir, pluginManager)
+	backupService.OnEvent(func(name string, data any) {
+		app.Event.Emit(name, data)
+	})
 
 	// Create search window service for global search functionality
 	searchWindowService := plugins.NewSearchWindowService(app, pluginService, shortcutService)
 	// Set app launcher service for app search integration
 	searchWindowService.SetAppLauncherService(appLauncherService)
+	searchWindowService.SetCalculator(calculatorPlugin)
+	searchWindowService.AddCommandProvider(datetime.PluginID, datetimePlugin)
+	searchSettings := settingsService.GetSearch()
+	searchWindowService.SetSearchOptions(searchSettings.MaxResults, searchSettings.IncludeApps, searchSettings.IncludePaths)
 
 	// Create update service
 	// Note: version is injected at build time via -ldflags
 	// Example: go build -ldflags="-X main.version=0.1.2"
+	updateSettings := settingsService.GetUpdate()
 	updateService := update.NewService(&update.ServiceConfig{
-		CurrentVersion: version,
-		UpdateURL:      "https://raw.githubusercontent.com/lian-yang/ltools/main/",
-		DataDir:        dataDir,
-		Enabled:        true, // TODO: 从用户配置读取
+		CurrentVersion:    version,
+		UpdateURL:         "https://raw.githubusercontent.com/lian-yang/ltools/main/",
+		DataDir:           dataDir,
+		Enabled:           updateSettings.Enabled,
+		Channel:           updateSettings.Channel,
+		MaxLaunchFailures: updateSettings.MaxLaunchFailures,
 	}, app)
+	updateService.SetBackgroundMode(updateSettings.Background)
+	updateService.SetBandwidthLimit(updateSettings.BandwidthLimit)
+
+	// A restore is applied on restart, before the services load their state again
+	backupService.SetRestarter(updateService.RestartApp)
+
+	// 托盘图标在应用启动后才创建，启动时再按设置隐藏
+	applyShowInMenu := func(show bool) {
+		if show {
+			systray.Show()
+		} else {
+			systray.Hide()
+		}
+	}
+	app.Event.OnApplicationEvent(events.Common.ApplicationStarted, func(*application.ApplicationEvent) {
+		applyShowInMenu(settingsService.GetGeneral().ShowInMenu)
+	})
+
+	// Apply settings changes to the services that own them and notify the frontend
+	settingsService.OnChange(func(change settings.Change) {
+		switch change.Section {
+		case settings.SectionGeneral:
+			applyShowInMenu(change.Settings.General.ShowInMenu)
+		case settings.SectionSearch:
+			search := change.Settings.Search
+			searchWindowService.SetSearchOptions(search.MaxResults, search.IncludeApps, search.IncludePaths)
+		case settings.SectionUpdate:
+			u := change.Settings.Update
+			updateService.SetEnabled(u.Enabled)
+			if err := updateService.SetChannel(u.Channel); err != nil {
+				log.Printf("[Main] Failed to apply update channel: %v", err)
+			}
+			updateService.SetBackgroundMode(u.Background)
+			updateService.SetBandwidthLimit(u.BandwidthLimit)
+		}
+		app.Event.Emit("settings:changed", change)
+	})
+
+	// 上次运行崩溃时生成崩溃包，前端启动后通过 CrashService 提示用户
+	registerCrashSections(crashReporter, updateService, sysInfoPlugin, pluginManager)
+	if _, err := crashReporter.ProcessPending(); err != nil {
+		log.Printf("[Main] Failed to write crash bundle of previous run: %v", err)
+	}
+	crashService := crash.NewCrashService(crashReporter, plugins.OpenPathWithDefaultApp)
 
 	// Register services
+	app.RegisterService(application.NewService(logService))
 	app.RegisterService(application.NewService(pluginService))
 	app.RegisterService(application.NewService(datetimeService))
+	app.RegisterService(application.NewService(timeToolkitService))
 	app.RegisterService(application.NewService(passwordService))
 	app.RegisterService(application.NewService(calculatorService))
 	app.RegisterService(application.NewService(clipboardService))
@@ -649,6 +809,9 @@ func main() {
 	app.RegisterService(application.NewService(searchWindowService))
 	app.RegisterService(application.NewService(syncService))
 	app.RegisterService(application.NewService(settingsService))
+	app.RegisterService(application.NewService(backupService))
+	app.RegisterService(application.NewService(crashService))
+	app.RegisterService(application.NewService(networkService))
 
 	// Start sync service
 	if err := syncService.ServiceStartup(app); err != nil {
@@ -698,6 +861,11 @@ func main() {
 		e.Cancel() // 阻止窗口真正关闭
 	})
 
+	// 前端运行时就绪即视为启动成功（正常退出时 updateService 也会确认）
+	mainWindow.OnWindowEvent(events.Common.WindowRuntimeReady, func(e *application.WindowEvent) {
+		updateService.MarkLaunchSuccessful()
+	})
+
 	// 监听文件拖放事件
 	mainWindow.OnWindowEvent(events.Common.WindowFilesDropped, func(e *application.WindowEvent) {
 		ctx := e.Context()
@@ -868,6 +1036,13 @@ func main() {
 			}
 			if info != nil {
 				log.Printf("[Main] Update available: %s", info.Version)
+				// In background mode the update is downloaded silently and installed on quit
+				if updateService.IsBackgroundMode() {
+					if _, err := updateService.StageUpdate(info.DownloadURL, info.Checksum); err != nil {
+						log.Printf("[Main] Failed to stage update: %v", err)
+					}
+					return
+				}
 				// Emit event to frontend
 				app.Event.Emit("update:available", info)
 			}
@@ -41,6 +41,7 @@ func NewAppLauncherPlugin() *AppLauncherPlugin {
 			plugins.PermissionProcess,    // 启动进程
 		},
 		Keywords: []string{"app", "应用", "启动", "launch", "open"},
+		DataPaths:  []string{"applauncher/"},
 		ShowInMenu: plugins.BoolPtr(false), // 不在菜单中显示，通过快捷键/搜索调用
 		HasPage:    plugins.BoolPtr(false), // 无需独立页面
 	}
@@ -40,6 +40,7 @@ func NewBookmarkPlugin() *BookmarkPlugin {
 			plugins.PermissionFileSystem, // Read bookmark files
 		},
 		Keywords:   []string{"书签", "bookmark", "bm", "浏览器"},
+		DataPaths:  []string{"bookmark/"},
 		ShowInMenu: plugins.BoolPtr(true), // Show in sidebar menu
 		HasPage:    plugins.BoolPtr(true),  // Has standalone management page
 	}
@@ -1,11 +1,21 @@
 package calculator
 
 import (
+	"context"
 	"fmt"
-	"strconv"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
+	"ltools/internal/logging"
+	"ltools/internal/network"
 	"ltools/internal/plugins"
+	"ltools/plugins/calculator/convert"
+	"ltools/plugins/calculator/expr"
+	"ltools/plugins/calculator/history"
 )
 
 const (
@@ -18,6 +28,11 @@ const (
 type CalculatorPlugin struct {
 	*plugins.BasePlugin
 	app *application.App
+
+	mu        sync.RWMutex
+	options   expr.Options       // mode used by Calculate
+	converter *convert.Converter // unit, currency and time zone conversions
+	history   *history.Store     // history, variables and user functions
 }
 
 // NewCalculatorPlugin creates a new calculator plugin instance
@@ -27,19 +42,56 @@ func NewCalculatorPlugin() *CalculatorPlugin {
 		Name:        PluginName,
 		Version:     PluginVersion,
 		Author:      "LTools Team",
-		Description: "基本计算器插件，支持加减乘除运算",
+		Description: "计算器插件，支持表达式、函数、常量以及单位、货币和时区换算",
 		Icon:        "calculator",
 		Type:        plugins.PluginTypeBuiltIn,
 		State:       plugins.PluginStateInstalled,
-		Keywords:    []string{"计算器", "数学", "计算", "calculator", "math", "compute"},
+		Keywords:    []string{"计算器", "数学", "计算", "换算", "汇率", "calculator", "math", "compute", "convert"},
+		DataPaths:   []string{"calculator/"},
 	}
 
 	base := plugins.NewBasePlugin(metadata)
+	store, _ := history.Open("") // 设置数据目录之前只保存在内存中
 	return &CalculatorPlugin{
 		BasePlugin: base,
+		options:    expr.DefaultOptions(),
+		converter:  convert.NewConverter(convert.NewRates("", nil)),
+		history:    store,
 	}
 }
 
+// SetDataDir loads the history and user functions from dataDir/calculator and
+// the cached exchange rates from dataDir/cache/calculator/rates.json, which
+// are refreshed in the background when they are out of date. If the history
+// cannot be loaded the calculator keeps working with an in-memory history,
+// and the error is returned for logging.
+func (p *CalculatorPlugin) SetDataDir(dataDir string) error {
+	fetch := convert.NewHTTPFetcher(network.NewClient(15*time.Second), convert.DefaultRatesURL)
+	rates := convert.NewRates(filepath.Join(dataDir, "cache", "calculator", "rates.json"), fetch)
+	p.mu.Lock()
+	p.converter = convert.NewConverter(rates)
+	p.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := rates.RefreshIfStale(ctx); err != nil {
+			// 离线时继续使用缓存或内置汇率
+			logging.For(PluginID).Warn("exchange rates not refreshed", "error", err)
+		}
+	}()
+
+	store, err := history.Open(filepath.Join(dataDir, "calculator"))
+	if err != nil {
+		// 历史损坏不影响计算和换算，本次运行只在内存中保存历史
+		return fmt.Errorf("failed to load calculator history: %w", err)
+	}
+	p.mu.Lock()
+	p.history = store
+	p.mu.Unlock()
+	return nil
+}
+
 // Init initializes the plugin
 func (p *CalculatorPlugin) Init(app *application.App) error {
 	if err := p.BasePlugin.Init(app); err != nil {
@@ -108,20 +160,136 @@ func (p *CalculatorPlugin) Divide(a, b float64) (float64, error) {
 	return result, nil
 }
 
-// Evaluate evaluates a mathematical expression string
-// Supported operators: +, -, *, /, (, )
+// Evaluate evaluates a mathematical expression string in float mode.
+// Supported: + - * / % ^ with precedence, parentheses, unary minus, postfix ! and %,
+// scientific notation, pi/e and sin/cos/tan/log/ln/sqrt/abs/round, ans,
+// variables ("x = 3") and user functions ("f(a, b) = a*b+1", which returns 0).
+// Conversions such as "5 km in miles", "100 USD in CNY" return the converted
+// amount; "3pm Tokyo in Berlin" returns the Unix time.
+// Errors are *expr.Error with the position in the expression.
 func (p *CalculatorPlugin) Evaluate(expression string) (float64, error) {
-	// Simple implementation - in production, use a proper parser
-	// This is a basic evaluator for demonstration
-	result, err := evaluateSimple(expression)
+	result, err := p.run(expression, expr.DefaultOptions())
 	if err != nil {
-		p.emitEvent("error", err.Error())
 		return 0, err
 	}
-	p.emitEvent("result", fmt.Sprintf("%.2f", result))
+	return result.Value, nil
+}
+
+// Calculate evaluates an expression in the current mode (see SetOptions) and
+// returns the formatted result; in programmer mode it includes the hex, octal
+// and binary forms and whether the integer type overflowed. Conversions set
+// Unit to the target unit, currency or time zone. Assignments ("x = 3") and
+// function definitions ("f(a, b) = a*b+1") are accepted as well; every result
+// is recorded in the history and becomes ans.
+func (p *CalculatorPlugin) Calculate(expression string) (*expr.Result, error) {
+	return p.run(expression, p.GetOptions())
+}
+
+// run evaluates a conversion, expression, assignment or function definition
+// and records it in the history.
+func (p *CalculatorPlugin) run(expression string, opts expr.Options) (*expr.Result, error) {
+	converter := p.getConverter()
+	result, err := p.getHistory().Do(expression, func(env *expr.Env) (*expr.Result, error) {
+		if result, ok, err := converter.Convert(expression, opts, env); ok {
+			return result, err
+		}
+		return expr.Exec(expression, opts, env)
+	})
+	if result == nil {
+		p.emitEvent("error", err.Error())
+		return nil, err
+	}
+	if err != nil {
+		// 结果有效，只是历史没有保存成功
+		logging.For(PluginID).Warn("failed to save calculator history", "error", err)
+	}
+	p.emitEvent("result", result.Text)
+	p.emitEvent("history", "updated")
 	return result, nil
 }
 
+// GetOptions returns the calculation mode and its settings
+func (p *CalculatorPlugin) GetOptions() expr.Options {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.options
+}
+
+// SetOptions sets the calculation mode: float, decimal with Precision decimal
+// places, or programmer with a Width-bit signed or unsigned integer type
+func (p *CalculatorPlugin) SetOptions(options expr.Options) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.options = options
+	p.mu.Unlock()
+	return nil
+}
+
+// GetRates returns the exchange rates used for currency conversions
+func (p *CalculatorPlugin) GetRates() convert.RateTable {
+	return p.getConverter().Rates().Table()
+}
+
+// RefreshRates downloads the current exchange rates
+func (p *CalculatorPlugin) RefreshRates() (convert.RateTable, error) {
+	rates := p.getConverter().Rates()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := rates.Refresh(ctx); err != nil {
+		return rates.Table(), err
+	}
+	return rates.Table(), nil
+}
+
+// Answer returns the result of a query typed into the search window, e.g.
+// "2*(3+4)" or "5 km in miles". ok is false for queries that are not a
+// calculation, including plain numbers and words.
+func (p *CalculatorPlugin) Answer(query string) (answer string, ok bool) {
+	if !p.Enabled() {
+		return "", false
+	}
+	query = strings.TrimSpace(query)
+	// 使用变量和函数，但不改变它们，也不记入历史
+	env := p.getHistory().Env()
+	result, converted, err := p.getConverter().Convert(query, p.GetOptions(), env)
+	if !converted {
+		// 只有数字或只有名称的查询不是计算
+		if !strings.ContainsAny(query, "0123456789") || isNumber(query) {
+			return "", false
+		}
+		result, err = expr.EvaluateEnv(query, p.GetOptions(), env)
+	}
+	if err != nil {
+		return "", false
+	}
+	if result.Unit != "" {
+		return result.Text + " " + result.Unit, true
+	}
+	return result.Text, true
+}
+
+func (p *CalculatorPlugin) getConverter() *convert.Converter {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.converter
+}
+
+func (p *CalculatorPlugin) getHistory() *history.Store {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.history
+}
+
+// plainNumber matches a decimal number without any operator
+var plainNumber = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)$`)
+
+// isNumber reports whether s is a single decimal number
+func isNumber(s string) bool {
+	return plainNumber.MatchString(s)
+}
+
 // Percentage calculates percentage
 func (p *CalculatorPlugin) Percentage(part, total float64) float64 {
 	if total == 0 {
@@ -133,107 +301,47 @@ func (p *CalculatorPlugin) Percentage(part, total float64) float64 {
 	return result
 }
 
-// ClearHistory clears calculation history (placeholder for future feature)
-func (p *CalculatorPlugin) ClearHistory() {
-	p.emitEvent("history", "cleared")
+// GetHistory returns up to limit calculations of all sessions, newest first (0 for all)
+func (p *CalculatorPlugin) GetHistory(limit int) []history.Entry {
+	return p.getHistory().Entries(false, limit)
 }
 
-// GetLastResult returns the last calculation result
-// This is a placeholder - would need to store history state
-func (p *CalculatorPlugin) GetLastResult() float64 {
-	p.emitEvent("history", "requested")
-	return 0
+// GetSessionHistory returns the calculations since the app started, newest first
+func (p *CalculatorPlugin) GetSessionHistory() []history.Entry {
+	return p.getHistory().Entries(true, 0)
 }
 
-// Simple expression evaluator (basic implementation)
-func evaluateSimple(expr string) (float64, error) {
-	// Remove whitespace
-	expr = removeWhitespace(expr)
-
-	// For now, just handle simple cases
-	// This would need a proper parser in production
-	if len(expr) == 0 {
-		return 0, fmt.Errorf("empty expression")
-	}
-
-	// Try to parse as single number
-	if num, err := strconv.ParseFloat(expr, 64); err == nil {
-		return num, nil
-	}
-
-	// Simple addition/subtraction parsing
-	result := 0.0
-	current := 0.0
-	op := '+'
-	i := 0
-
-	for i < len(expr) {
-		// Parse number
-		j := i
-		for j < len(expr) && (isdigit(expr[j]) || expr[j] == '.') {
-			j++
-		}
-
-		if j > i {
-			num, err := strconv.ParseFloat(expr[i:j], 64)
-			if err != nil {
-				return 0, fmt.Errorf("invalid number at position %d", i)
-			}
-
-			switch op {
-			case '+':
-				current = num
-			case '-':
-				current = -num
-			case '*':
-				current *= num
-			case '/':
-				if num == 0 {
-					return 0, fmt.Errorf("division by zero")
-				}
-				current /= num
-			}
-
-			// If next is + or -, add to result
-			if j < len(expr) && (expr[j] == '+' || expr[j] == '-') {
-				result += current
-				current = 0
-			}
-
-			i = j
-		}
-
-		// Parse operator
-		if i < len(expr) && isOperator(expr[i]) {
-			op = rune(expr[i])
-			i++
-		} else {
-			i++
-		}
+// ClearHistory clears the calculation history and ans
+func (p *CalculatorPlugin) ClearHistory() error {
+	if err := p.getHistory().Clear(); err != nil {
+		return err
 	}
+	p.emitEvent("history", "cleared")
+	return nil
+}
 
-	result += current
-	return result, nil
+// GetLastResult returns the value of the last calculation, or 0 if there is none
+func (p *CalculatorPlugin) GetLastResult() float64 {
+	last, _ := p.getHistory().Last()
+	return last.Value
 }
 
-func isdigit(c byte) bool {
-	return c >= '0' && c <= '9'
+// GetVariables returns the variables of this session, including ans
+func (p *CalculatorPlugin) GetVariables() []expr.Variable {
+	return p.getHistory().Variables()
 }
 
-func isOperator(c byte) bool {
-	return c == '+' || c == '-' || c == '*' || c == '/'
+// DeleteVariable removes a variable
+func (p *CalculatorPlugin) DeleteVariable(name string) {
+	p.getHistory().DeleteVariable(name)
 }
 
-func removeWhitespace(s string) string {
-	result := ""
-	for _, c := range s {
-		if !isWhitespace(rune(c)) {
-			result += string(c)
-		}
-	}
-	return result
+// GetFunctions returns the user-defined functions
+func (p *CalculatorPlugin) GetFunctions() []expr.FuncInfo {
+	return p.getHistory().Functions()
 }
 
-func isWhitespace(c rune) bool {
-	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+// DeleteFunction removes a user-defined function
+func (p *CalculatorPlugin) DeleteFunction(name string) error {
+	return p.getHistory().DeleteFunction(name)
 }
@@ -0,0 +1,295 @@
+// Package convert converts quantities between units, currencies and time
+// zones for the calculator: "5 km in miles", "3GiB to MB", "72F to C",
+// "100 USD in CNY" and "3pm Tokyo in Berlin".
+package convert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"ltools/internal/timezone"
+	"ltools/plugins/calculator/expr"
+)
+
+// connector splits "<quantity> in|to|as|-> <target>". The first group is
+// greedy so that "1 in to cm" means inches.
+var connector = regexp.MustCompile(`(?i)^(.+)(?:\s+(?:in|to|as|into)\s+|\s*(?:->|→|=>)\s*)(.+)$`)
+
+// clockPattern matches a time of day at the start of a time zone conversion:
+// 15:30, 3pm, 3:30 pm, now, noon and midnight.
+var clockPattern = regexp.MustCompile(`(?i)^(now|现在|noon|midnight|(\d{1,2})(?::(\d{2}))?\s*(am|pm)|(\d{1,2}):(\d{2}))(?:\s+(.+))?$`)
+
+// Converter evaluates conversions.
+type Converter struct {
+	rates *Rates
+	now   func() time.Time
+}
+
+// NewConverter creates a converter that uses rates for currencies.
+func NewConverter(rates *Rates) *Converter {
+	return &Converter{rates: rates, now: time.Now}
+}
+
+// Rates returns the exchange rates used by the converter.
+func (c *Converter) Rates() *Rates {
+	return c.rates
+}
+
+// Convert evaluates input if it is a conversion. ok is false if input does
+// not look like one, so it can be evaluated as a plain expression instead.
+// The quantity before the unit is an expression evaluated with opts and the
+// variables and functions of env, which may be nil.
+//
+// Unit and currency results have the converted amount in Value and Text and
+// the target in Unit. Time zone results have the Unix time in Value and the
+// date and time in the target zone in Text.
+func (c *Converter) Convert(input string, opts expr.Options, env *expr.Env) (result *expr.Result, ok bool, err error) {
+	m := connector.FindStringSubmatch(strings.TrimSpace(input))
+	if m == nil {
+		return nil, false, nil
+	}
+	left, target := strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+
+	if to := units.lookup(target); to != nil {
+		if amount, from, found := splitSuffix(left, func(s string) bool { return units.lookup(s) != nil }); found {
+			return c.convertUnit(amount, units.lookup(from), to, opts, env)
+		}
+	}
+
+	if c.rates != nil {
+		if to := c.rates.lookupCurrency(target); to != "" {
+			if amount, from, found := c.splitCurrency(left); found {
+				return c.convertCurrency(amount, from, to, opts, env)
+			}
+		}
+	}
+
+	if to, err := timezone.Lookup(target); err == nil {
+		if t, found := c.parseTime(left); found {
+			return convertTime(t, to), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (c *Converter) convertUnit(amount string, from, to *Unit, opts expr.Options, env *expr.Env) (*expr.Result, bool, error) {
+	if from.Dim != to.Dim {
+		return nil, true, fmt.Errorf("无法把 %s（%s）换算为 %s（%s）", from.Symbol, dimensionNames[from.Dim], to.Symbol, dimensionNames[to.Dim])
+	}
+	v, err := evalAmount(amount, opts, env)
+	if err != nil {
+		return nil, true, err
+	}
+	base := from.toBase(v)
+	converted := snapZero(to.fromBase(base), base, to)
+	if math.IsNaN(converted) || math.IsInf(converted, 0) {
+		return nil, true, fmt.Errorf("结果超出范围")
+	}
+	return &expr.Result{Text: formatQuantity(converted), Value: converted, Unit: to.Symbol}, true, nil
+}
+
+// snapZero rounds results that are zero up to floating-point error to zero.
+// Offsets that cancel out leave a remainder, e.g. 32 °F in °C gives 5.7e-14.
+func snapZero(converted, base float64, to *Unit) float64 {
+	scale := math.Max(math.Abs(base), math.Abs(to.Offset)) / math.Abs(to.Factor)
+	if math.Abs(converted) < scale*1e-9 {
+		return 0
+	}
+	return converted
+}
+
+func (c *Converter) convertCurrency(amount, from, to string, opts expr.Options, env *expr.Env) (*expr.Result, bool, error) {
+	v, err := evalAmount(amount, opts, env)
+	if err != nil {
+		return nil, true, err
+	}
+	converted, err := c.rates.Convert(v, from, to)
+	if err != nil {
+		return nil, true, err
+	}
+
+	table := c.rates.Table()
+	note := fmt.Sprintf("汇率更新于 %s（%s）", table.UpdatedAt.Local().Format("2006-01-02 15:04"), table.Source)
+	if table.FetchedAt.IsZero() {
+		note = fmt.Sprintf("离线内置汇率（%s），可能已过时", table.UpdatedAt.Format("2006-01-02"))
+	}
+	return &expr.Result{Text: formatMoney(converted), Value: converted, Unit: to, Note: note}, true, nil
+}
+
+// splitCurrency splits "100 USD", "100美元" or "$100" into the amount and the currency code.
+func (c *Converter) splitCurrency(left string) (amount, code string, ok bool) {
+	match := func(s string) bool { return c.rates.lookupCurrency(s) != "" }
+	if amount, name, ok := splitSuffix(left, match); ok {
+		return amount, c.rates.lookupCurrency(name), true
+	}
+	// 货币符号在前
+	if r, size := utf8.DecodeRuneInString(left); size > 0 {
+		if code := c.rates.lookupCurrency(string(r)); code != "" && !unicode.IsLetter(r) {
+			return left[size:], code, true
+		}
+	}
+	return "", "", false
+}
+
+// parseTime parses "3pm Tokyo", "15:30", "now" or "Tokyo" as a time today in
+// the given place; without a place the local zone is used.
+func (c *Converter) parseTime(left string) (time.Time, bool) {
+	now := c.now()
+	m := clockPattern.FindStringSubmatch(left)
+	if m == nil {
+		// 只有地点：该地的当前时间
+		loc, err := timezone.Lookup(left)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return now.In(loc), true
+	}
+
+	loc := time.Local
+	if m[7] != "" {
+		var err error
+		if loc, err = timezone.Lookup(m[7]); err != nil {
+			return time.Time{}, false
+		}
+	}
+	now = now.In(loc)
+
+	var hour, minute int
+	switch strings.ToLower(m[1]) {
+	case "now", "现在":
+		return now, true
+	case "noon":
+		hour = 12
+	case "midnight":
+		hour = 0
+	default:
+		if m[2] != "" {
+			hour, _ = strconv.Atoi(m[2])
+			minute, _ = strconv.Atoi(m[3])
+			if hour < 1 || hour > 12 {
+				return time.Time{}, false
+			}
+			hour %= 12
+			if strings.EqualFold(m[4], "pm") {
+				hour += 12
+			}
+		} else {
+			hour, _ = strconv.Atoi(m[5])
+			minute, _ = strconv.Atoi(m[6])
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return time.Time{}, false
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc), true
+}
+
+func convertTime(t time.Time, to *time.Location) *expr.Result {
+	converted := t.In(to)
+	_, offset := converted.Zone()
+	note := fmt.Sprintf("%s %s", t.Format("2006-01-02 15:04"), zoneLabel(t))
+	if day := dayDiff(t, converted); day != "" {
+		note += "，" + day
+	}
+	return &expr.Result{
+		Text:  converted.Format("2006-01-02 15:04"),
+		Value: float64(converted.Unix()),
+		Unit:  fmt.Sprintf("%s (%s)", to.String(), timezone.FormatOffset(offset)),
+		Note:  note,
+	}
+}
+
+// zoneLabel formats the zone of t as "Asia/Tokyo (UTC+09:00)".
+func zoneLabel(t time.Time) string {
+	_, offset := t.Zone()
+	return fmt.Sprintf("%s (%s)", t.Location().String(), timezone.FormatOffset(offset))
+}
+
+// dayDiff describes whether the converted time falls on another calendar day.
+func dayDiff(from, to time.Time) string {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	switch days := int(b.Sub(a).Hours() / 24); {
+	case days == 1:
+		return "次日"
+	case days == -1:
+		return "前一日"
+	case days != 0:
+		return fmt.Sprintf("相差 %d 天", days)
+	}
+	return ""
+}
+
+var dimensionNames = map[Dimension]string{
+	Length: "长度", Area: "面积", Volume: "体积", Mass: "质量", Duration: "时间", Speed: "速度",
+	Data: "数据量", Temperature: "温度", Energy: "能量", Power: "功率", Pressure: "压强",
+	Angle: "角度", Frequency: "频率",
+}
+
+// splitSuffix splits s into an expression and the longest suffix accepted by
+// match. A suffix that starts with a letter must not continue a word, so
+// "5 skm" is not "5 s" and "km".
+func splitSuffix(s string, match func(string) bool) (amount, suffix string, ok bool) {
+	prev := rune(0)
+	for i, r := range s {
+		if i > 0 && isWordRune(prev) && isWordRune(r) {
+			prev = r
+			continue
+		}
+		prev = r
+		candidate := strings.TrimSpace(s[i:])
+		if candidate != "" && !startsWithDigit(candidate) && match(candidate) {
+			return strings.TrimSpace(s[:i]), candidate, true
+		}
+	}
+	return "", "", false
+}
+
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || r == '_')
+}
+
+func startsWithDigit(s string) bool {
+	return s[0] >= '0' && s[0] <= '9' || s[0] == '.'
+}
+
+// evalAmount evaluates the quantity before a unit; "km in miles" means 1 km.
+func evalAmount(amount string, opts expr.Options, env *expr.Env) (float64, error) {
+	if amount == "" {
+		return 1, nil
+	}
+	result, err := expr.EvaluateEnv(amount, opts, env)
+	if err != nil {
+		return 0, err
+	}
+	return result.Value, nil
+}
+
+// formatQuantity formats v with 10 significant digits, which hides the
+// rounding of conversion factors.
+func formatQuantity(v float64) string {
+	if v == 0 {
+		return "0"
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 10, 64), 64)
+	if math.Abs(rounded) >= 1e-6 && math.Abs(rounded) < 1e21 {
+		return strconv.FormatFloat(rounded, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(rounded, 'g', -1, 64)
+}
+
+// formatMoney shows amounts of at least 1 with two decimals and smaller ones
+// with four significant digits.
+func formatMoney(v float64) string {
+	if math.Abs(v) >= 1 {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 4, 64), 64)
+	return formatQuantity(rounded)
+}
@@ -0,0 +1,124 @@
+package convert
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ltools/plugins/calculator/expr"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		unit  string
+	}{
+		// 单位
+		{"5 km in miles", "3.106855961", "mi"},
+		{"3GiB to MB", "3221.225472", "MB"},
+		{"1 MB to KiB", "976.5625", "KiB"},
+		{"8 Mb in MB", "1", "MB"},
+		{"72F to C", "22.22222222", "°C"},
+		{"-40 °C in °F", "-40", "°F"},
+		{"0 K to celsius", "-273.15", "°C"},
+		{"32 F in C", "0", "°C"},
+		{"273.15 K to C", "0", "°C"},
+		{"1 nm to m", "1e-09", "m"},
+		{"1 in to cm", "2.54", "cm"},
+		{"2e3m -> km", "2", "km"},
+		{"(1+2) * 2 feet in meters", "1.8288", "m"},
+		{"km in m", "1000", "m"},
+		{"1.5 hours to min", "90", "min"},
+		{"100 km/h in mph", "62.13711922", "mph"},
+		{"1 acre in m2", "4046.856422", "m²"},
+		{"10 公里 to 英里", "6.213711922", "mi"},
+		{"180 deg to rad", "3.141592654", "rad"},
+
+		// 货币：内置汇率
+		{"100 USD in CNY", "712.00", "CNY"},
+		{"$20 to eur", "17.20", "EUR"},
+		{"712 人民币 to 美元", "100.00", "USD"},
+		{"1 JPY in USD", "0.006645", "USD"},
+
+		// 时区：当前时间固定为 2026-10-18 10:00 UTC
+		{"3pm Tokyo in Berlin", "2026-10-18 08:00", "Europe/Berlin (UTC+02:00)"},
+		{"9:30 am new york to shanghai", "2026-10-18 21:30", "Asia/Shanghai (UTC+08:00)"},
+		{"23:00 UTC in Tokyo", "2026-10-19 08:00", "Asia/Tokyo (UTC+09:00)"},
+		{"now UTC to UTC+5:30", "2026-10-18 15:30", "UTC+05:30 (UTC+05:30)"},
+		{"London in 纽约", "2026-10-18 06:00", "America/New_York (UTC-04:00)"},
+	}
+
+	c := NewConverter(NewRates("", nil))
+	c.now = func() time.Time { return time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC) }
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok, err := c.Convert(tt.input, expr.DefaultOptions(), nil)
+			if !ok || err != nil {
+				t.Fatalf("Convert(%q) = ok %v, error %v", tt.input, ok, err)
+			}
+			if got.Text != tt.want || got.Unit != tt.unit {
+				t.Errorf("Convert(%q) = %q %q, want %q %q", tt.input, got.Text, got.Unit, tt.want, tt.unit)
+			}
+		})
+	}
+
+	// 不是换算的输入交给普通表达式
+	for _, input := range []string{"1+2", "5 to 10", "5 km", "pi in pie"} {
+		if _, ok, err := c.Convert(input, expr.DefaultOptions(), nil); ok {
+			t.Errorf("Convert(%q) treated as a conversion (error %v)", input, err)
+		}
+	}
+
+	// 量纲不同、表达式错误
+	for _, input := range []string{"5 kg in m", "1/0 km in m"} {
+		if _, ok, err := c.Convert(input, expr.DefaultOptions(), nil); !ok || err == nil {
+			t.Errorf("Convert(%q) = ok %v, error %v; want an error", input, ok, err)
+		}
+	}
+}
+
+func TestRatesRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	fetch := func(ctx context.Context) (*RateTable, error) {
+		return &RateTable{
+			Base:      "EUR",
+			Rates:     map[string]float64{"EUR": 1, "USD": 1.25, "GBP": 0.5},
+			UpdatedAt: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+			Source:    "test",
+		}, nil
+	}
+
+	rates := NewRates(path, fetch)
+	if !rates.Stale() {
+		t.Fatal("builtin rates are not stale")
+	}
+	if err := rates.RefreshIfStale(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := rates.Convert(10, "USD", "GBP"); got != 4 {
+		t.Errorf("10 USD = %v GBP, want 4", got)
+	}
+
+	// 缓存文件在下次启动时使用
+	cached := NewRates(path, nil)
+	if cached.Stale() || cached.Table().Source != "test" {
+		t.Errorf("cached table = %+v", cached.Table())
+	}
+	if _, err := cached.Convert(1, "USD", "CNY"); err == nil {
+		t.Error("converted a currency missing from the table")
+	}
+
+	// 获取失败时保留原来的汇率
+	failing := NewRates(path, func(ctx context.Context) (*RateTable, error) {
+		return &RateTable{Base: "USD", Rates: map[string]float64{"USD": 2}}, nil
+	})
+	if err := failing.Refresh(context.Background()); err == nil {
+		t.Error("accepted a table whose base rate is not 1")
+	}
+	if failing.Table().Source != "test" {
+		t.Error("failed refresh replaced the table")
+	}
+}
@@ -0,0 +1,245 @@
+package convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRatesURL serves daily rates for USD without an API key.
+const DefaultRatesURL = "https://open.er-api.com/v6/latest/USD"
+
+// RatesMaxAge is how long cached rates are used before they are refreshed.
+const RatesMaxAge = 12 * time.Hour
+
+// RateTable holds exchange rates relative to a base currency.
+type RateTable struct {
+	Base string `json:"base"`
+
+	// Rates maps currency codes to the amount of that currency per unit of Base.
+	Rates map[string]float64 `json:"rates"`
+
+	UpdatedAt time.Time `json:"updatedAt"` // 来源发布汇率的时间
+	FetchedAt time.Time `json:"fetchedAt"` // 下载的时间，内置汇率为零值
+	Source    string    `json:"source"`
+}
+
+// builtinRates is the offline fallback used until rates have been fetched.
+var builtinRates = RateTable{
+	Base: "USD",
+	Rates: map[string]float64{
+		"USD": 1, "CNY": 7.12, "EUR": 0.86, "GBP": 0.75, "JPY": 150.5, "HKD": 7.78,
+		"TWD": 30.6, "KRW": 1420, "SGD": 1.30, "AUD": 1.54, "NZD": 1.74, "CAD": 1.40,
+		"CHF": 0.80, "SEK": 9.45, "NOK": 10.1, "DKK": 6.42, "PLN": 3.66, "CZK": 20.9,
+		"HUF": 337, "RUB": 80.5, "TRY": 41.8, "INR": 88.2, "IDR": 16550, "MYR": 4.22,
+		"THB": 32.5, "VND": 26300, "PHP": 58.1, "AED": 3.6725, "SAR": 3.75, "ILS": 3.32,
+		"ZAR": 17.4, "BRL": 5.42, "MXN": 18.4, "ARS": 1450, "CLP": 950, "MOP": 8.01,
+	},
+	UpdatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	Source:    "builtin",
+}
+
+// currencyAliases maps names and symbols to currency codes. Keys are lower case.
+var currencyAliases = map[string]string{
+	"$": "USD", "dollar": "USD", "dollars": "USD", "美元": "USD", "美金": "USD",
+	"€": "EUR", "euro": "EUR", "euros": "EUR", "欧元": "EUR",
+	"£": "GBP", "pound sterling": "GBP", "英镑": "GBP",
+	"¥": "CNY", "rmb": "CNY", "yuan": "CNY", "人民币": "CNY", "元": "CNY", "块": "CNY",
+	"yen": "JPY", "日元": "JPY", "円": "JPY",
+	"港币": "HKD", "港元": "HKD", "新台币": "TWD", "台币": "TWD", "won": "KRW", "韩元": "KRW",
+	"新加坡元": "SGD", "澳元": "AUD", "加元": "CAD", "瑞士法郎": "CHF", "卢布": "RUB",
+	"rupee": "INR", "rupees": "INR", "卢比": "INR", "泰铢": "THB", "澳门元": "MOP",
+}
+
+// Fetcher downloads a current rate table.
+type Fetcher func(ctx context.Context) (*RateTable, error)
+
+// Rates is the rate table used for conversions. It starts with the cached
+// file, or the built-in table if there is none, and is refreshed with the
+// fetcher when the cache is older than RatesMaxAge.
+type Rates struct {
+	mu    sync.RWMutex
+	path  string
+	table *RateTable
+	fetch Fetcher
+
+	refreshing sync.Mutex
+}
+
+// NewRates loads the rate cache at path. fetch may be nil to stay offline.
+func NewRates(path string, fetch Fetcher) *Rates {
+	r := &Rates{path: path, fetch: fetch, table: &builtinRates}
+	if path == "" {
+		return r
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r
+	}
+	var table RateTable
+	if err := json.Unmarshal(data, &table); err != nil || table.validate() != nil {
+		return r
+	}
+	r.table = &table
+	return r
+}
+
+// Table returns a copy of the current rate table.
+func (r *Rates) Table() RateTable {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	table := *r.table
+	table.Rates = make(map[string]float64, len(r.table.Rates))
+	for code, rate := range r.table.Rates {
+		table.Rates[code] = rate
+	}
+	return table
+}
+
+// Stale reports whether the table was fetched more than RatesMaxAge ago.
+func (r *Rates) Stale() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return time.Since(r.table.FetchedAt) > RatesMaxAge
+}
+
+// Refresh fetches the rates and writes them to the cache file. On failure
+// the current table is kept.
+func (r *Rates) Refresh(ctx context.Context) error {
+	if r.fetch == nil {
+		return fmt.Errorf("未配置汇率来源")
+	}
+	// 同一时间只刷新一次
+	r.refreshing.Lock()
+	defer r.refreshing.Unlock()
+
+	table, err := r.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("获取汇率失败: %w", err)
+	}
+	if err := table.validate(); err != nil {
+		return fmt.Errorf("获取汇率失败: %w", err)
+	}
+	table.FetchedAt = time.Now().UTC()
+
+	r.mu.Lock()
+	r.table = table
+	r.mu.Unlock()
+	return r.save(table)
+}
+
+// RefreshIfStale refreshes the rates when they were fetched more than RatesMaxAge ago.
+func (r *Rates) RefreshIfStale(ctx context.Context) error {
+	if !r.Stale() {
+		return nil
+	}
+	return r.Refresh(ctx)
+}
+
+func (r *Rates) save(table *RateTable) error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(table, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// Convert converts amount from one currency to another.
+func (r *Rates) Convert(amount float64, from, to string) (float64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fromRate, ok := r.table.Rates[from]
+	if !ok {
+		return 0, fmt.Errorf("没有 %s 的汇率", from)
+	}
+	toRate, ok := r.table.Rates[to]
+	if !ok {
+		return 0, fmt.Errorf("没有 %s 的汇率", to)
+	}
+	return amount / fromRate * toRate, nil
+}
+
+// lookupCurrency returns the currency code for a code, name or symbol known to
+// the table, or "".
+func (r *Rates) lookupCurrency(s string) string {
+	s = strings.TrimSpace(s)
+	code, ok := currencyAliases[strings.ToLower(s)]
+	if !ok {
+		code = strings.ToUpper(s)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.table.Rates[code]; ok {
+		return code
+	}
+	return ""
+}
+
+func (t *RateTable) validate() error {
+	if t.Base == "" || len(t.Rates) == 0 {
+		return fmt.Errorf("汇率表为空")
+	}
+	if rate, ok := t.Rates[t.Base]; !ok || rate != 1 {
+		return fmt.Errorf("汇率表缺少基准货币 %s", t.Base)
+	}
+	for code, rate := range t.Rates {
+		if rate <= 0 {
+			return fmt.Errorf("无效的汇率 %s: %v", code, rate)
+		}
+	}
+	return nil
+}
+
+// NewHTTPFetcher returns a Fetcher for the open.er-api.com response format
+// ({"result": "success", "base_code": ..., "time_last_update_unix": ..., "rates": {...}}).
+func NewHTTPFetcher(client *http.Client, url string) Fetcher {
+	return func(ctx context.Context) (*RateTable, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+
+		var body struct {
+			Result     string             `json:"result"`
+			Base       string             `json:"base_code"`
+			UpdateUnix int64              `json:"time_last_update_unix"`
+			Rates      map[string]float64 `json:"rates"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+			return nil, err
+		}
+		if body.Result != "success" {
+			return nil, fmt.Errorf("result %q", body.Result)
+		}
+		updated := time.Unix(body.UpdateUnix, 0).UTC()
+		if body.UpdateUnix == 0 {
+			updated = time.Now().UTC()
+		}
+		return &RateTable{Base: body.Base, Rates: body.Rates, UpdatedAt: updated, Source: req.URL.Host}, nil
+	}
+}
@@ -0,0 +1,261 @@
+package convert
+
+import (
+	"strings"
+)
+
+// Dimension is the physical quantity a unit measures. Only units of the same
+// dimension convert into each other.
+type Dimension string
+
+const (
+	Length      Dimension = "length"
+	Area        Dimension = "area"
+	Volume      Dimension = "volume"
+	Mass        Dimension = "mass"
+	Duration    Dimension = "time"
+	Speed       Dimension = "speed"
+	Data        Dimension = "data"
+	Temperature Dimension = "temperature"
+	Energy      Dimension = "energy"
+	Power       Dimension = "power"
+	Pressure    Dimension = "pressure"
+	Angle       Dimension = "angle"
+	Frequency   Dimension = "frequency"
+)
+
+// Unit is a unit of measurement. A value v in this unit is v*Factor+Offset in
+// the base unit of its dimension; Offset is only used by temperatures.
+type Unit struct {
+	Symbol string    `json:"symbol"`
+	Name   string    `json:"name"`
+	Dim    Dimension `json:"dimension"`
+	Factor float64   `json:"factor"`
+	Offset float64   `json:"offset,omitempty"`
+}
+
+// toBase converts v in u to the base unit.
+func (u *Unit) toBase(v float64) float64 { return v*u.Factor + u.Offset }
+
+// fromBase converts v in the base unit to u.
+func (u *Unit) fromBase(v float64) float64 { return (v - u.Offset) / u.Factor }
+
+// prefix is a metric or binary multiplier.
+type prefix struct {
+	symbol string
+	name   string
+	factor float64
+}
+
+var (
+	prefixTera  = prefix{"T", "tera", 1e12}
+	prefixGiga  = prefix{"G", "giga", 1e9}
+	prefixMega  = prefix{"M", "mega", 1e6}
+	prefixKilo  = prefix{"k", "kilo", 1e3}
+	prefixHecto = prefix{"h", "hecto", 1e2}
+	prefixDeci  = prefix{"d", "deci", 1e-1}
+	prefixCenti = prefix{"c", "centi", 1e-2}
+	prefixMilli = prefix{"m", "milli", 1e-3}
+	prefixMicro = prefix{"µ", "micro", 1e-6}
+	prefixNano  = prefix{"n", "nano", 1e-9}
+
+	// 数据量的十进制前缀（1 MB = 10^6 B）和二进制前缀（1 MiB = 2^20 B）
+	decimalData = []prefix{prefixKilo, {"K", "kilo", 1e3}, prefixMega, prefixGiga, prefixTera, {"P", "peta", 1e15}}
+	binaryData  = []prefix{{"Ki", "kibi", 1 << 10}, {"Mi", "mebi", 1 << 20}, {"Gi", "gibi", 1 << 30}, {"Ti", "tebi", 1 << 40}, {"Pi", "pebi", 1 << 50}}
+)
+
+// unitDef describes a unit in the registry table.
+type unitDef struct {
+	symbol   string
+	names    []string // 其他写法，包括复数和中文名
+	dim      Dimension
+	factor   float64
+	offset   float64
+	prefixes []prefix // 可以加在 symbol 和英文名前面的前缀
+}
+
+// Base units: metre, square metre, litre, kilogram, second, metre per second,
+// byte, kelvin, joule, watt, pascal, radian and hertz.
+var unitDefs = []unitDef{
+	// 长度
+	{"m", []string{"meter", "meters", "metre", "metres", "米"}, Length, 1, 0,
+		[]prefix{prefixKilo, prefixDeci, prefixCenti, prefixMilli, prefixMicro, prefixNano}},
+	{"in", []string{"inch", "inches", "\"", "英寸"}, Length, 0.0254, 0, nil},
+	{"ft", []string{"foot", "feet", "'", "英尺"}, Length, 0.3048, 0, nil},
+	{"yd", []string{"yard", "yards", "码"}, Length, 0.9144, 0, nil},
+	{"mi", []string{"mile", "miles", "英里"}, Length, 1609.344, 0, nil},
+	{"nmi", []string{"nautical mile", "nautical miles", "海里"}, Length, 1852, 0, nil},
+	{"au", []string{"astronomical unit", "astronomical units", "天文单位"}, Length, 149597870700, 0, nil},
+	{"ly", []string{"light year", "light years", "lightyear", "lightyears", "光年"}, Length, 9460730472580800, 0, nil},
+	{"公里", []string{"千米"}, Length, 1000, 0, nil},
+	{"厘米", nil, Length, 0.01, 0, nil},
+	{"毫米", nil, Length, 0.001, 0, nil},
+	{"里", []string{"市里"}, Length, 500, 0, nil},
+	{"尺", []string{"市尺"}, Length, 1.0 / 3, 0, nil},
+	{"寸", []string{"市寸"}, Length, 1.0 / 30, 0, nil},
+
+	// 面积
+	{"m²", []string{"m2", "m^2", "sqm", "square meter", "square meters", "square metre", "square metres", "平方米"}, Area, 1, 0, nil},
+	{"km²", []string{"km2", "km^2", "square kilometer", "square kilometers", "square kilometre", "square kilometres", "平方公里", "平方千米"}, Area, 1e6, 0, nil},
+	{"cm²", []string{"cm2", "cm^2", "square centimeter", "square centimeters", "平方厘米"}, Area, 1e-4, 0, nil},
+	{"ft²", []string{"ft2", "ft^2", "sqft", "square foot", "square feet", "平方英尺"}, Area, 0.09290304, 0, nil},
+	{"mi²", []string{"mi2", "mi^2", "square mile", "square miles", "平方英里"}, Area, 2589988.110336, 0, nil},
+	{"ha", []string{"hectare", "hectares", "公顷"}, Area, 1e4, 0, nil},
+	{"acre", []string{"acres", "ac", "英亩"}, Area, 4046.8564224, 0, nil},
+	{"亩", nil, Area, 10000.0 / 15, 0, nil},
+
+	// 体积
+	{"L", []string{"l", "liter", "liters", "litre", "litres", "升"}, Volume, 1, 0,
+		[]prefix{prefixMilli, prefixCenti, prefixDeci, prefixKilo}},
+	{"m³", []string{"m3", "m^3", "cubic meter", "cubic meters", "cubic metre", "cubic metres", "立方米"}, Volume, 1000, 0, nil},
+	{"cm³", []string{"cm3", "cm^3", "cc", "cubic centimeter", "cubic centimeters", "立方厘米"}, Volume, 0.001, 0, nil},
+	{"gal", []string{"gallon", "gallons", "加仑"}, Volume, 3.785411784, 0, nil},
+	{"qt", []string{"quart", "quarts"}, Volume, 0.946352946, 0, nil},
+	{"pt", []string{"pint", "pints", "品脱"}, Volume, 0.473176473, 0, nil},
+	{"cup", []string{"cups", "杯"}, Volume, 0.2365882365, 0, nil},
+	{"floz", []string{"fl oz", "fluid ounce", "fluid ounces"}, Volume, 0.0295735295625, 0, nil},
+	{"tbsp", []string{"tablespoon", "tablespoons"}, Volume, 0.01478676478125, 0, nil},
+	{"tsp", []string{"teaspoon", "teaspoons"}, Volume, 0.00492892159375, 0, nil},
+	{"毫升", nil, Volume, 0.001, 0, nil},
+
+	// 质量
+	{"g", []string{"gram", "grams", "gramme", "grammes", "克"}, Mass, 0.001, 0,
+		[]prefix{prefixKilo, prefixMilli, prefixMicro}},
+	{"t", []string{"tonne", "tonnes", "metric ton", "metric tons", "吨"}, Mass, 1000, 0, nil},
+	{"lb", []string{"lbs", "pound", "pounds", "磅"}, Mass, 0.45359237, 0, nil},
+	{"oz", []string{"ounce", "ounces", "盎司"}, Mass, 0.028349523125, 0, nil},
+	{"st", []string{"stone", "stones", "英石"}, Mass, 6.35029318, 0, nil},
+	{"ct", []string{"carat", "carats", "克拉"}, Mass, 0.0002, 0, nil},
+	{"公斤", []string{"千克"}, Mass, 1, 0, nil},
+	{"斤", []string{"市斤"}, Mass, 0.5, 0, nil},
+	{"两", []string{"市两"}, Mass, 0.05, 0, nil},
+
+	// 时间
+	{"s", []string{"sec", "secs", "second", "seconds", "秒"}, Duration, 1, 0,
+		[]prefix{prefixMilli, prefixMicro, prefixNano}},
+	{"min", []string{"mins", "minute", "minutes", "分钟"}, Duration, 60, 0, nil},
+	{"h", []string{"hr", "hrs", "hour", "hours", "小时"}, Duration, 3600, 0, nil},
+	{"d", []string{"day", "days", "天"}, Duration, 86400, 0, nil},
+	{"wk", []string{"week", "weeks", "周", "星期"}, Duration, 604800, 0, nil},
+	{"mo", []string{"month", "months", "月"}, Duration, 2629746, 0, nil}, // 平均每月 30.436875 天
+	{"yr", []string{"year", "years", "年"}, Duration, 31556952, 0, nil},  // 格里历平均年
+
+	// 速度
+	{"m/s", []string{"mps", "meters per second", "米每秒"}, Speed, 1, 0, nil},
+	{"km/h", []string{"kmh", "kph", "kmph", "kilometers per hour", "kilometres per hour", "公里每小时"}, Speed, 1000.0 / 3600, 0, nil},
+	{"mph", []string{"mi/h", "miles per hour", "英里每小时"}, Speed, 0.44704, 0, nil},
+	{"ft/s", []string{"fps", "feet per second"}, Speed, 0.3048, 0, nil},
+	{"kn", []string{"kt", "knot", "knots", "节"}, Speed, 1852.0 / 3600, 0, nil},
+
+	// 数据量
+	{"B", []string{"byte", "bytes", "字节"}, Data, 1, 0, append(decimalData, binaryData...)},
+	{"b", []string{"bit", "bits", "比特"}, Data, 0.125, 0, append(decimalData, binaryData...)},
+
+	// 温度
+	{"°C", []string{"C", "℃", "celsius", "degC", "摄氏度"}, Temperature, 1, 273.15, nil},
+	{"°F", []string{"F", "℉", "fahrenheit", "degF", "华氏度"}, Temperature, 5.0 / 9, 273.15 - 32*5.0/9, nil},
+	{"K", []string{"kelvin", "kelvins", "开尔文"}, Temperature, 1, 0, nil},
+
+	// 能量
+	{"J", []string{"joule", "joules", "焦耳"}, Energy, 1, 0, []prefix{prefixKilo, prefixMega, prefixGiga}},
+	{"cal", []string{"calorie", "calories", "卡"}, Energy, 4.184, 0, nil},
+	{"kcal", []string{"kilocalorie", "kilocalories", "Cal", "千卡", "大卡"}, Energy, 4184, 0, nil},
+	{"Wh", []string{"watt hour", "watt hours"}, Energy, 3600, 0, []prefix{prefixKilo, prefixMega, prefixGiga}},
+	{"eV", []string{"electronvolt", "electronvolts"}, Energy, 1.602176634e-19, 0, nil},
+	{"BTU", []string{"btu"}, Energy, 1055.05585262, 0, nil},
+
+	// 功率
+	{"W", []string{"watt", "watts", "瓦"}, Power, 1, 0, []prefix{prefixKilo, prefixMega, prefixGiga, prefixMilli}},
+	{"hp", []string{"horsepower", "马力"}, Power, 745.69987158227022, 0, nil},
+
+	// 压强
+	{"Pa", []string{"pascal", "pascals", "帕"}, Pressure, 1, 0, []prefix{prefixHecto, prefixKilo, prefixMega}},
+	{"bar", []string{"bars", "巴"}, Pressure, 1e5, 0, nil},
+	{"mbar", []string{"millibar", "millibars"}, Pressure, 100, 0, nil},
+	{"atm", []string{"atmosphere", "atmospheres", "标准大气压"}, Pressure, 101325, 0, nil},
+	{"psi", nil, Pressure, 6894.757293168361, 0, nil},
+	{"mmHg", []string{"torr"}, Pressure, 133.322387415, 0, nil},
+
+	// 角度
+	{"rad", []string{"radian", "radians", "弧度"}, Angle, 1, 0, nil},
+	{"°", []string{"deg", "degree", "degrees", "度"}, Angle, 0.017453292519943295, 0, nil},
+	{"grad", []string{"gon", "gradian", "gradians"}, Angle, 0.015707963267948967, 0, nil},
+	{"turn", []string{"turns", "rev", "revolution", "revolutions", "圈"}, Angle, 6.283185307179586, 0, nil},
+
+	// 频率
+	{"Hz", []string{"hertz", "赫兹"}, Frequency, 1, 0, []prefix{prefixKilo, prefixMega, prefixGiga}},
+	{"rpm", nil, Frequency, 1.0 / 60, 0, nil},
+}
+
+// registry indexes units by every spelling. exact holds the spellings as
+// written; folded holds lower-case spellings, keeping the first unit so that
+// "gb" means gigabytes rather than gigabits.
+type registry struct {
+	exact  map[string]*Unit
+	folded map[string]*Unit
+}
+
+var units = newRegistry(unitDefs)
+
+func newRegistry(defs []unitDef) *registry {
+	r := &registry{exact: make(map[string]*Unit), folded: make(map[string]*Unit)}
+	for _, def := range defs {
+		base := &Unit{Symbol: def.symbol, Name: firstOr(def.names, def.symbol), Dim: def.dim, Factor: def.factor, Offset: def.offset}
+		r.add(base, append([]string{def.symbol}, def.names...))
+
+		for _, p := range def.prefixes {
+			u := &Unit{Symbol: p.symbol + def.symbol, Name: p.name + base.Name, Dim: def.dim, Factor: p.factor * def.factor}
+			spellings := []string{u.Symbol}
+			for _, name := range def.names {
+				if isASCIIWord(name) && len(name) > 1 {
+					spellings = append(spellings, p.name+name)
+				}
+			}
+			r.add(u, spellings)
+		}
+	}
+	return r
+}
+
+func (r *registry) add(u *Unit, spellings []string) {
+	for _, s := range spellings {
+		if _, ok := r.exact[s]; !ok {
+			r.exact[s] = u
+		}
+		if _, ok := r.folded[strings.ToLower(s)]; !ok {
+			r.folded[strings.ToLower(s)] = u
+		}
+	}
+}
+
+// lookup finds a unit by symbol or name: exact spelling first, then ignoring case.
+func (r *registry) lookup(s string) *Unit {
+	s = strings.Join(strings.Fields(s), " ")
+	if u, ok := r.exact[s]; ok {
+		return u
+	}
+	return r.folded[strings.ToLower(s)]
+}
+
+// LookupUnit returns the unit with the given symbol or name, or nil.
+func LookupUnit(s string) *Unit {
+	return units.lookup(s)
+}
+
+func firstOr(names []string, fallback string) string {
+	for _, name := range names {
+		if isASCIIWord(name) && len(name) > 2 {
+			return name
+		}
+	}
+	return fallback
+}
+
+func isASCIIWord(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && c != ' ' {
+			return false
+		}
+	}
+	return s != ""
+}
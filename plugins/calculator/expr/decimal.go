@@ -0,0 +1,292 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Constants with more digits than MaxPrecision.
+const (
+	piDigits = "3.14159265358979323846264338327950288419716939937510582097494459230781640628620899862803482534211706798214808651328230664709384460955058223172535940812848111745028410270193852110555964462294895493038196"
+	eDigits  = "2.71828182845904523536028747135266249775724709369995957496696762772407663035354759457138217852516642742746639193200305992181741359662904357290033429526059563073813232862794349076323382988075319525101901"
+)
+
+// Limits that keep exact results from growing without bound.
+const (
+	maxDecimalFactorial = 1000
+	maxResultBits       = 1 << 20 // about 315000 digits
+)
+
+var decimalConsts = map[string]string{"pi": piDigits, "π": piDigits, "e": eDigits}
+
+// decimalEval evaluates with exact rationals.
+type decimalEval struct {
+	precision int
+	approx    bool // a float64 fallback was used
+	scope     *scope
+}
+
+func evalDecimalNode(n node, precision int, sc *scope) (*Result, string, error) {
+	d := &decimalEval{precision: precision, scope: sc}
+	r, err := d.eval(n)
+	if err != nil {
+		return nil, "", err
+	}
+
+	v, _ := r.Float64()
+	result := &Result{Value: v, Approximate: d.approx}
+	if d.approx {
+		result.Text = new(big.Float).SetRat(r).Text('g', 15)
+	} else {
+		result.Text = formatRat(r, precision)
+	}
+	return result, r.RatString(), nil
+}
+
+// formatRat rounds r to precision decimal places and removes trailing zeros.
+func formatRat(r *big.Rat, precision int) string {
+	s := r.FloatString(precision)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func (d *decimalEval) eval(n node) (*big.Rat, error) {
+	switch n := n.(type) {
+	case *numberNode:
+		if i, ok := parsePrefixedInt(n.text); ok {
+			return new(big.Rat).SetInt(i), nil
+		}
+		r, ok := new(big.Rat).SetString(n.text)
+		if !ok {
+			return nil, errorf(n.at, "无效的数字 %s", n.text)
+		}
+		return r, nil
+
+	case *identNode:
+		if r, ok := d.scope.lookupVar(n.name); ok {
+			return r, nil
+		}
+		if digits, ok := decimalConsts[strings.ToLower(n.name)]; ok {
+			r, _ := new(big.Rat).SetString(digits)
+			return r, nil
+		}
+		if _, ok := floatFuncs[strings.ToLower(n.name)]; ok {
+			return nil, errorf(n.at, "函数 %s 缺少括号", n.name)
+		}
+		return nil, errorf(n.at, "未知的名称 %s", n.name)
+
+	case *unaryNode:
+		x, err := d.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "-":
+			return x.Neg(x), nil
+		case "~":
+			return nil, errBitwise(n.at, n.op)
+		}
+		return x, nil
+
+	case *postfixNode:
+		x, err := d.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "%" {
+			return x.Quo(x, big.NewRat(100, 1)), nil
+		}
+		if !x.IsInt() || x.Sign() < 0 {
+			return nil, errorf(n.at, "阶乘只支持非负整数")
+		}
+		if x.Num().Cmp(big.NewInt(maxDecimalFactorial)) > 0 {
+			return nil, errorf(n.at, "阶乘的参数不能超过 %d", maxDecimalFactorial)
+		}
+		f := new(big.Int).MulRange(1, x.Num().Int64())
+		return new(big.Rat).SetInt(f), nil
+
+	case *binaryNode:
+		x, err := d.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		y, err := d.eval(n.y)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "+":
+			return x.Add(x, y), nil
+		case "-":
+			return x.Sub(x, y), nil
+		case "*":
+			return x.Mul(x, y), nil
+		case "/":
+			if y.Sign() == 0 {
+				return nil, errorf(n.at, "除数不能为零")
+			}
+			return x.Quo(x, y), nil
+		case "%":
+			if y.Sign() == 0 {
+				return nil, errorf(n.at, "除数不能为零")
+			}
+			// 与 float 模式一致：商向零取整，余数与被除数同号
+			q := new(big.Rat).Quo(x, y)
+			trunc := new(big.Int).Quo(q.Num(), q.Denom())
+			return x.Sub(x, new(big.Rat).Mul(new(big.Rat).SetInt(trunc), y)), nil
+		case "**":
+			return d.pow(n, x, y)
+		}
+		return nil, errBitwise(n.at, n.op)
+
+	case *callNode:
+		return d.call(n)
+	}
+	return nil, errorf(n.pos(), "无法计算的表达式")
+}
+
+// pow computes integer powers exactly and other powers in float64.
+func (d *decimalEval) pow(n *binaryNode, x, y *big.Rat) (*big.Rat, error) {
+	if !y.IsInt() {
+		if x.Sign() < 0 {
+			return nil, errorf(n.at, "负数不能开非整数次方")
+		}
+		xf, _ := x.Float64()
+		yf, _ := y.Float64()
+		return d.fromFloat(n.at, math.Pow(xf, yf))
+	}
+
+	e := y.Num()
+	if !e.IsInt64() || int64(max(x.Num().BitLen(), x.Denom().BitLen()))*abs64(e.Int64()) > maxResultBits {
+		return nil, errorf(n.at, "结果过大")
+	}
+	if e.Sign() < 0 && x.Sign() == 0 {
+		return nil, errorf(n.at, "除数不能为零")
+	}
+	exp := new(big.Int).Abs(e)
+	num := new(big.Int).Exp(x.Num(), exp, nil)
+	den := new(big.Int).Exp(x.Denom(), exp, nil)
+	if e.Sign() < 0 {
+		num, den = den, num
+	}
+	return new(big.Rat).SetFrac(num, den), nil
+}
+
+func (d *decimalEval) call(n *callNode) (*big.Rat, error) {
+	name := strings.ToLower(n.name)
+	f, ok := floatFuncs[name]
+	if !ok {
+		return d.callUser(n)
+	}
+	if len(n.args) < f.minArgs || len(n.args) > f.maxArgs {
+		return nil, errorf(n.at, "函数 %s 的参数个数错误", n.name)
+	}
+	args := make([]*big.Rat, len(n.args))
+	for i, arg := range n.args {
+		v, err := d.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	// 精确计算的函数
+	switch name {
+	case "abs":
+		return args[0].Abs(args[0]), nil
+	case "round":
+		places := int64(0)
+		if len(args) == 2 {
+			if !args[1].IsInt() || args[1].Sign() < 0 || args[1].Num().Cmp(big.NewInt(MaxPrecision)) > 0 {
+				return nil, errorf(n.at, "小数位数必须是 0 到 %d 的整数", MaxPrecision)
+			}
+			places = args[1].Num().Int64()
+		}
+		return roundRat(args[0], places), nil
+	case "sqrt":
+		if args[0].Sign() < 0 {
+			return nil, errorf(n.at, "不能对负数开平方")
+		}
+		// 比显示精度多几位，舍入后各位都是准确的
+		prec := uint(float64(d.precision+10)*math.Log2(10)) + 64
+		if bits := uint(max(args[0].Num().BitLen(), args[0].Denom().BitLen())); bits > prec {
+			prec = bits + 64
+		}
+		root := new(big.Float).SetPrec(prec).SetRat(args[0])
+		r, _ := root.Sqrt(root).Rat(nil)
+		return r, nil
+	}
+
+	// 其他函数用 float64 计算
+	floats := make([]float64, len(args))
+	for i, a := range args {
+		floats[i], _ = a.Float64()
+	}
+	v, msg := f.fn(floats)
+	if msg != "" {
+		return nil, errorf(n.at, "%s", msg)
+	}
+	return d.fromFloat(n.at, v)
+}
+
+// callUser calls a user-defined function.
+func (d *decimalEval) callUser(n *callNode) (*big.Rat, error) {
+	f, ok := d.scope.lookupFunc(n.name)
+	if !ok {
+		return nil, errorf(n.at, "未知的函数 %s", n.name)
+	}
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		v, err := d.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v.RatString()
+	}
+	sc, body, err := d.scope.enter(n, f, args, false)
+	if err != nil {
+		return nil, err
+	}
+	inner := &decimalEval{precision: d.precision, scope: sc}
+	v, err := inner.eval(body)
+	if err != nil {
+		return nil, callError(n, err)
+	}
+	d.approx = d.approx || inner.approx
+	return v, nil
+}
+
+func (d *decimalEval) fromFloat(pos int, v float64) (*big.Rat, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, errorf(pos, "结果超出范围")
+	}
+	d.approx = true
+	return new(big.Rat).SetFloat64(v), nil
+}
+
+// roundRat rounds x to places decimal places, halves away from zero.
+func roundRat(x *big.Rat, places int64) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(places), nil)
+	scaled := new(big.Rat).Mul(x, new(big.Rat).SetInt(scale))
+
+	// |scaled| + 1/2 向下取整，再恢复符号
+	half := new(big.Rat).Add(new(big.Rat).Abs(scaled), big.NewRat(1, 2))
+	q := new(big.Int).Quo(half.Num(), half.Denom())
+	if scaled.Sign() < 0 {
+		q.Neg(q)
+	}
+	return new(big.Rat).SetFrac(q, scale)
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
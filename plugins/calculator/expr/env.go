@@ -0,0 +1,253 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ans is the variable that holds the last result.
+const Ans = "ans"
+
+// maxCallDepth limits nested calls of user functions, which may be recursive.
+const maxCallDepth = 64
+
+// Env holds the names a user defined: variables, including ans, and
+// functions. Values are kept as exact text (integers, decimals or fractions
+// such as "1/3") so that they can be used in every mode. Env is not safe for
+// concurrent use.
+type Env struct {
+	Vars  map[string]string `json:"vars"`
+	Funcs map[string]*Func  `json:"funcs"`
+}
+
+// Func is a user-defined function.
+type Func struct {
+	Params []string `json:"params"`
+	Body   string   `json:"body"`
+}
+
+// NewEnv returns an empty Env.
+func NewEnv() *Env {
+	return &Env{Vars: make(map[string]string), Funcs: make(map[string]*Func)}
+}
+
+// Clone returns a copy that can be changed without affecting e.
+func (e *Env) Clone() *Env {
+	c := NewEnv()
+	for name, v := range e.Vars {
+		c.Vars[name] = v
+	}
+	for name, f := range e.Funcs {
+		fc := *f
+		fc.Params = append([]string(nil), f.Params...)
+		c.Funcs[name] = &fc
+	}
+	return c
+}
+
+// Variable is a variable as shown to the user.
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"` // 保留 DefaultPrecision 位小数
+}
+
+// Variables returns the variables sorted by name.
+func (e *Env) Variables() []Variable {
+	vars := make([]Variable, 0, len(e.Vars))
+	for name, text := range e.Vars {
+		value := text
+		if r, ok := new(big.Rat).SetString(text); ok {
+			value = formatRat(r, DefaultPrecision)
+		}
+		vars = append(vars, Variable{Name: name, Value: value})
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+// FuncInfo is a user function as shown to the user.
+type FuncInfo struct {
+	Name       string   `json:"name"`
+	Params     []string `json:"params"`
+	Body       string   `json:"body"`
+	Definition string   `json:"definition"` // f(a, b) = a*b+1
+}
+
+// Functions returns the functions sorted by name.
+func (e *Env) Functions() []FuncInfo {
+	funcs := make([]FuncInfo, 0, len(e.Funcs))
+	for name, f := range e.Funcs {
+		funcs = append(funcs, FuncInfo{Name: name, Params: f.Params, Body: f.Body, Definition: formatDefinition(name, f)})
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs
+}
+
+func formatDefinition(name string, f *Func) string {
+	return fmt.Sprintf("%s(%s) = %s", name, strings.Join(f.Params, ", "), f.Body)
+}
+
+// EvaluateEnv evaluates an expression like Evaluate, with the variables and
+// functions of env. env is not changed and may be nil.
+func EvaluateEnv(input string, opts Options, env *Env) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	n, err := parse(input, opts.Mode == ModeProgrammer)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := evalNode(n, opts, &scope{env: env})
+	return result, err
+}
+
+// Exec evaluates an expression, assigns a variable ("x = 3") or defines a
+// function ("f(a, b) = a*b+1") in env. The value of an expression or
+// assignment is also stored as ans. Function bodies are checked for syntax
+// only; the names they use are resolved when the function is called.
+func Exec(input string, opts Options, env *Env) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	programmer := opts.Mode == ModeProgrammer
+	st, err := parseStatement(input, programmer)
+	if err != nil {
+		return nil, err
+	}
+
+	if st.isFunc {
+		if err := checkName(st.name, st.namePos, true); err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, param := range st.params {
+			if seen[param] {
+				return nil, errorf(st.namePos, "参数 %s 重复", param)
+			}
+			seen[param] = true
+		}
+		f := &Func{Params: st.params, Body: st.bodyText}
+		env.Funcs[st.name] = f
+		return &Result{Text: formatDefinition(st.name, f), Name: st.name, Function: true}, nil
+	}
+
+	if st.name != "" {
+		if err := checkName(st.name, st.namePos, false); err != nil {
+			return nil, err
+		}
+	}
+	result, exact, err := evalNode(st.body, opts, &scope{env: env})
+	if err != nil {
+		return nil, err
+	}
+	if st.name != "" {
+		env.Vars[st.name] = exact
+		result.Name = st.name
+	}
+	env.Vars[Ans] = exact
+	return result, nil
+}
+
+// checkName rejects names of constants, built-in functions and ans.
+func checkName(name string, pos int, isFunc bool) *Error {
+	lower := strings.ToLower(name)
+	if _, ok := floatFuncs[lower]; ok {
+		return errorf(pos, "%s 是内置函数，不能重新定义", name)
+	}
+	if _, ok := floatConsts[lower]; ok && !isFunc {
+		return errorf(pos, "%s 是常量，不能赋值", name)
+	}
+	if lower == Ans && !isFunc {
+		return errorf(pos, "ans 保存上一次的结果，不能赋值")
+	}
+	return nil
+}
+
+// evalNode evaluates a parsed expression in the mode of opts. It returns the
+// result and the exact value as text for storing in an Env.
+func evalNode(n node, opts Options, sc *scope) (*Result, string, error) {
+	switch opts.Mode {
+	case ModeDecimal:
+		return evalDecimalNode(n, opts.Precision, sc)
+	case ModeProgrammer:
+		return evalProgrammerNode(n, opts.Width, opts.Signed, sc)
+	}
+	v, err := (&floatEval{scope: sc}).eval(n)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := checkFloat(v); err != nil {
+		return nil, "", err
+	}
+	return &Result{Text: formatFloat(v), Value: v}, floatText(v), nil
+}
+
+// scope resolves user-defined names during evaluation: the parameters of the
+// function being called, then the variables and functions of the Env.
+type scope struct {
+	env    *Env
+	locals map[string]string
+	depth  int
+}
+
+// lookupVar returns the exact value of a parameter or variable.
+func (s *scope) lookupVar(name string) (*big.Rat, bool) {
+	if s == nil {
+		return nil, false
+	}
+	text, ok := s.locals[name]
+	if !ok && s.env != nil {
+		text, ok = s.env.Vars[name]
+	}
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetString(text)
+}
+
+func (s *scope) lookupFunc(name string) (*Func, bool) {
+	if s == nil || s.env == nil {
+		return nil, false
+	}
+	f, ok := s.env.Funcs[name]
+	return f, ok
+}
+
+// enter checks a call of the user function f and returns the scope and the
+// parsed body to evaluate it with. args are the exact values of the arguments.
+func (s *scope) enter(n *callNode, f *Func, args []string, programmer bool) (*scope, node, error) {
+	if len(args) != len(f.Params) {
+		return nil, nil, errorf(n.at, "函数 %s 需要 %d 个参数", n.name, len(f.Params))
+	}
+	if s.depth >= maxCallDepth {
+		return nil, nil, errorf(n.at, "函数 %s 调用层数过多", n.name)
+	}
+	body, err := parse(f.Body, programmer)
+	if err != nil {
+		return nil, nil, callError(n, err)
+	}
+	locals := make(map[string]string, len(args))
+	for i, param := range f.Params {
+		locals[param] = args[i]
+	}
+	return &scope{env: s.env, locals: locals, depth: s.depth + 1}, body, nil
+}
+
+// callError reports an error in the body of a user function at the call.
+func callError(n *callNode, err error) error {
+	if e, ok := err.(*Error); ok {
+		if strings.HasPrefix(e.Msg, "函数 ") {
+			return errorf(n.at, "%s", e.Msg) // 内层调用已经带上了函数名
+		}
+		return errorf(n.at, "函数 %s: %s", n.name, e.Msg)
+	}
+	return err
+}
+
+// floatText formats v so that it parses back to the same float64.
+func floatText(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,249 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// floatConsts are the constants known to Eval.
+var floatConsts = map[string]float64{
+	"pi": math.Pi,
+	"π":  math.Pi,
+	"e":  math.E,
+}
+
+// floatFunc is a function callable from expressions.
+type floatFunc struct {
+	minArgs, maxArgs int
+	fn               func(args []float64) (float64, string) // result or error message
+}
+
+// floatFuncs are the functions known to Eval. Angles are in radians.
+var floatFuncs = map[string]floatFunc{
+	"sin": {1, 1, func(a []float64) (float64, string) { return math.Sin(a[0]), "" }},
+	"cos": {1, 1, func(a []float64) (float64, string) { return math.Cos(a[0]), "" }},
+	"tan": {1, 1, func(a []float64) (float64, string) { return math.Tan(a[0]), "" }},
+	"abs": {1, 1, func(a []float64) (float64, string) { return math.Abs(a[0]), "" }},
+	"log": {1, 1, func(a []float64) (float64, string) {
+		if a[0] <= 0 {
+			return 0, "对数的参数必须大于零"
+		}
+		return math.Log10(a[0]), ""
+	}},
+	"ln": {1, 1, func(a []float64) (float64, string) {
+		if a[0] <= 0 {
+			return 0, "对数的参数必须大于零"
+		}
+		return math.Log(a[0]), ""
+	}},
+	"sqrt": {1, 1, func(a []float64) (float64, string) {
+		if a[0] < 0 {
+			return 0, "不能对负数开平方"
+		}
+		return math.Sqrt(a[0]), ""
+	}},
+	// round(x) 四舍五入到整数，round(x, n) 保留 n 位小数
+	"round": {1, 2, func(a []float64) (float64, string) {
+		if len(a) == 1 {
+			return math.Round(a[0]), ""
+		}
+		if a[1] != math.Trunc(a[1]) || a[1] < 0 || a[1] > 15 {
+			return 0, "小数位数必须是 0 到 15 的整数"
+		}
+		scale := math.Pow(10, a[1])
+		return math.Round(a[0]*scale) / scale, ""
+	}},
+}
+
+// maxFactorial is the largest n whose factorial fits in a float64.
+const maxFactorial = 170
+
+// Eval parses and evaluates an expression.
+func Eval(input string) (float64, error) {
+	n, err := parse(input, false)
+	if err != nil {
+		return 0, err
+	}
+	v, err := (&floatEval{}).eval(n)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkFloat(v); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+func checkFloat(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return errorf(0, "结果超出范围")
+	}
+	return nil
+}
+
+// floatEval evaluates in float64.
+type floatEval struct {
+	scope *scope
+}
+
+func (e *floatEval) eval(n node) (float64, error) {
+	switch n := n.(type) {
+	case *numberNode:
+		if i, ok := parsePrefixedInt(n.text); ok {
+			v, _ := new(big.Float).SetInt(i).Float64()
+			return v, nil
+		}
+		v, err := strconv.ParseFloat(n.text, 64)
+		if err != nil {
+			return 0, errorf(n.at, "无效的数字 %s", n.text)
+		}
+		return v, nil
+
+	case *identNode:
+		if r, ok := e.scope.lookupVar(n.name); ok {
+			v, _ := r.Float64()
+			return v, nil
+		}
+		if v, ok := floatConsts[strings.ToLower(n.name)]; ok {
+			return v, nil
+		}
+		if _, ok := floatFuncs[strings.ToLower(n.name)]; ok {
+			return 0, errorf(n.at, "函数 %s 缺少括号", n.name)
+		}
+		return 0, errorf(n.at, "未知的名称 %s", n.name)
+
+	case *unaryNode:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return 0, err
+		}
+		switch n.op {
+		case "-":
+			return -x, nil
+		case "~":
+			return 0, errBitwise(n.at, n.op)
+		}
+		return x, nil
+
+	case *postfixNode:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return 0, err
+		}
+		if n.op == "%" {
+			return x / 100, nil
+		}
+		if x < 0 || x != math.Trunc(x) {
+			return 0, errorf(n.at, "阶乘只支持非负整数")
+		}
+		if x > maxFactorial {
+			return 0, errorf(n.at, "阶乘的参数不能超过 %d", maxFactorial)
+		}
+		result := 1.0
+		for i := 2.0; i <= x; i++ {
+			result *= i
+		}
+		return result, nil
+
+	case *binaryNode:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := e.eval(n.y)
+		if err != nil {
+			return 0, err
+		}
+		switch n.op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/":
+			if y == 0 {
+				return 0, errorf(n.at, "除数不能为零")
+			}
+			return x / y, nil
+		case "%":
+			if y == 0 {
+				return 0, errorf(n.at, "除数不能为零")
+			}
+			return math.Mod(x, y), nil
+		case "**":
+			v := math.Pow(x, y)
+			if math.IsNaN(v) {
+				return 0, errorf(n.at, "负数不能开非整数次方")
+			}
+			return v, nil
+		}
+		return 0, errBitwise(n.at, n.op)
+
+	case *callNode:
+		f, ok := floatFuncs[strings.ToLower(n.name)]
+		if !ok {
+			return e.callUser(n)
+		}
+		if len(n.args) < f.minArgs || len(n.args) > f.maxArgs {
+			if f.minArgs == f.maxArgs {
+				return 0, errorf(n.at, "函数 %s 需要 %d 个参数", n.name, f.minArgs)
+			}
+			return 0, errorf(n.at, "函数 %s 需要 %d 到 %d 个参数", n.name, f.minArgs, f.maxArgs)
+		}
+		args := make([]float64, len(n.args))
+		for i, arg := range n.args {
+			v, err := e.eval(arg)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = v
+		}
+		v, msg := f.fn(args)
+		if msg != "" {
+			return 0, errorf(n.at, "%s", msg)
+		}
+		return v, nil
+	}
+	return 0, errorf(n.pos(), "无法计算的表达式")
+}
+
+// callUser calls a user-defined function.
+func (e *floatEval) callUser(n *callNode) (float64, error) {
+	f, ok := e.scope.lookupFunc(n.name)
+	if !ok {
+		return 0, errorf(n.at, "未知的函数 %s", n.name)
+	}
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		v, err := e.eval(arg)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = floatText(v)
+	}
+	sc, body, err := e.scope.enter(n, f, args, false)
+	if err != nil {
+		return 0, err
+	}
+	v, err := (&floatEval{scope: sc}).eval(body)
+	if err != nil {
+		return 0, callError(n, err)
+	}
+	return v, nil
+}
+
+// parsePrefixedInt parses an integer literal with a 0x, 0o or 0b prefix.
+func parsePrefixedInt(text string) (*big.Int, bool) {
+	if len(text) < 2 || text[0] != '0' || prefixBase(rune(text[1])) == nil {
+		return nil, false
+	}
+	return new(big.Int).SetString(text, 0)
+}
+
+// errBitwise reports an operator that only the programmer mode supports.
+func errBitwise(pos int, op string) *Error {
+	return errorf(pos, "运算符 %s 仅在程序员模式中可用", op)
+}
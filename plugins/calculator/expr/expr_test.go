@@ -0,0 +1,285 @@
+package expr
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		// 优先级和结合性
+		{"1+2*3", 7},
+		{"2*3+4*5", 26},
+		{"10-4-3", 3},
+		{"100/10/5", 2},
+		{"2^3^2", 512},
+		{"2**10", 1024},
+		{"-2^2", -4},
+		{"(-2)^2", 4},
+		{"2^-1", 0.5},
+
+		// 括号和一元运算符
+		{"(1+2)*3", 9},
+		{"((2))", 2},
+		{"-(3+4)", -7},
+		{"--3", 3},
+		{"+5", 5},
+		{"3*-2", -6},
+
+		// 取模、百分号和阶乘
+		{"10 % 3", 1},
+		{"50%", 0.5},
+		{"200*15%", 30},
+		{"(10%)-5", -4.9},
+		{"7 % -2", 1},
+		{"7%+2", 1},
+		{"-7 % -2", -1},
+		{"5!", 120},
+		{"0!", 1},
+		{"3!^2", 36},
+		{"-3!", -6},
+
+		// 数字格式
+		{"1.5e3", 1500},
+		{"2E-2", 0.02},
+		{".5+.25", 0.75},
+		{"1e+2", 100},
+
+		// 常量和函数
+		{"pi", math.Pi},
+		{"2*PI", 2 * math.Pi},
+		{"e", math.E},
+		{"sin(pi/2)", 1},
+		{"cos(0)", 1},
+		{"log(1000)", 3},
+		{"ln(e)", 1},
+		{"sqrt(16)+abs(-2)", 6},
+		{"round(2.5)", 3},
+		{"round(3.14159, 2)", 3.14},
+		{"sqrt(2^2 + 3*4)", 4},
+
+		// 空白和替代字符
+		{"  3 × 4 ÷ 2 ", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Eval(tt.input)
+			if err != nil {
+				t.Fatalf("Eval(%q) error: %v", tt.input, err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Eval(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"", 0},
+		{"1 +", 3},
+		{"(1+2", 4},
+		{"1+2)", 3},
+		{"2 $ 3", 2},
+		{"1/0", 1},
+		{"5 % 0", 2},
+		{"1.2.3", 3},
+		{"foo(1)", 0},
+		{"2 + bar", 4},
+		{"sqrt(-1)", 0},
+		{"sqrt(1, 2)", 0},
+		{"sqrt", 0},
+		{"2.5!", 3},
+		{"171!", 3},
+		{"(-8)^0.5", 4},
+		{"1e308*10", 0},
+		{"2 3", 2},
+		{"max(1,)", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Eval(tt.input)
+			var exprErr *Error
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("Eval(%q) error = %v, want *Error", tt.input, err)
+			}
+			if exprErr.Pos != tt.pos {
+				t.Errorf("Eval(%q) error at %d (%v), want %d", tt.input, exprErr.Pos, err, tt.pos)
+			}
+		})
+	}
+}
+
+func TestEvaluateDecimal(t *testing.T) {
+	tests := []struct {
+		input     string
+		precision int
+		want      string
+		approx    bool
+	}{
+		{"0.1+0.2", 20, "0.3", false},
+		{"2^100", 20, "1267650600228229401496703205376", false},
+		{"12345678901234567890*10", 20, "123456789012345678900", false},
+		{"1/3", 20, "0.33333333333333333333", false},
+		{"2/3", 5, "0.66667", false},
+		{"-7 % 3", 20, "-1", false},
+		{"25!", 20, "15511210043330985984000000", false},
+		{"2^-2", 20, "0.25", false},
+		{"1.1^2", 20, "1.21", false},
+		{"1.5e3 + 0xFF", 20, "1755", false},
+		{"sqrt(2)", 30, "1.41421356237309504880168872421", false},
+		{"pi", 30, "3.14159265358979323846264338328", false},
+		{"round(2.345, 2)", 20, "2.35", false},
+		{"round(-2.5)", 20, "-3", false},
+		{"15%", 20, "0.15", false},
+		{"sin(1)", 20, "0.841470984807897", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Mode = ModeDecimal
+			opts.Precision = tt.precision
+			got, err := Evaluate(tt.input, opts)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) error: %v", tt.input, err)
+			}
+			if got.Text != tt.want || got.Approximate != tt.approx {
+				t.Errorf("Evaluate(%q) = %q (approximate %v), want %q (approximate %v)", tt.input, got.Text, got.Approximate, tt.want, tt.approx)
+			}
+		})
+	}
+}
+
+func TestEvaluateProgrammer(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		signed   bool
+		want     string
+		hex      string
+		overflow bool
+	}{
+		{"0xFF & 0x0F", 64, true, "15", "0xF", false},
+		{"0b1010 | 0o5", 64, true, "15", "0xF", false},
+		{"6 ^ 3", 64, true, "5", "0x5", false},
+		{"1 << 4 + 1", 64, true, "32", "0x20", false},
+		{"~0", 8, false, "255", "0xFF", false},
+		{"~0", 8, true, "-1", "0xFF", false},
+		{"-1 >> 1", 32, true, "-1", "0xFFFFFFFF", false},
+		{"0xFF", 8, true, "-1", "0xFF", false},
+		{"127 + 1", 8, true, "-128", "0x80", true},
+		{"255 + 1", 8, false, "0", "0x0", true},
+		{"0 - 1", 16, false, "65535", "0xFFFF", true},
+		{"2 ** 10", 16, true, "1024", "0x400", false},
+		{"7 / 2", 32, true, "3", "0x3", false},
+		{"-7 % 3", 32, true, "-1", "0xFFFFFFFF", false},
+		{"10 % ~0", 32, true, "0", "0x0", false},
+		{"10 % -3", 32, true, "1", "0x1", false},
+		{"1 << 63", 64, true, "-9223372036854775808", "0x8000000000000000", true},
+		{"0x1_0000_0000", 32, false, "0", "0x0", true},
+		{"21!", 64, false, "14197454024290336768", "0xC5077D36B8C40000", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			opts := Options{Mode: ModeProgrammer, Precision: DefaultPrecision, Width: tt.width, Signed: tt.signed}
+			got, err := Evaluate(tt.input, opts)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) error: %v", tt.input, err)
+			}
+			if got.Text != tt.want || got.Hex != tt.hex || got.Overflow != tt.overflow {
+				t.Errorf("Evaluate(%q) = %s %s overflow=%v, want %s %s overflow=%v", tt.input, got.Text, got.Hex, got.Overflow, tt.want, tt.hex, tt.overflow)
+			}
+		})
+	}
+
+	// 位运算和非整数在其他模式中报错
+	if _, err := Eval("6 & 3"); err == nil {
+		t.Error("float mode accepted &")
+	}
+	if _, err := Evaluate("1.5 + 1", Options{Mode: ModeProgrammer, Precision: DefaultPrecision, Width: 32}); err == nil {
+		t.Error("programmer mode accepted 1.5")
+	}
+}
+
+func TestExec(t *testing.T) {
+	env := NewEnv()
+	steps := []struct {
+		input string
+		mode  Mode
+		want  string
+	}{
+		{"2+3", ModeFloat, "5"},
+		{"ans*2", ModeFloat, "10"},
+		{"x = 3", ModeFloat, "3"},
+		{"x^2 + ans", ModeFloat, "12"},
+		{"f(a, b) = a*b + 1", ModeFloat, "f(a, b) = a*b + 1"},
+		{"f(x, 4)", ModeFloat, "13"},
+		{"g(n) = f(n, n) - x", ModeFloat, "g(n) = f(n, n) - x"},
+		{"g(2)", ModeFloat, "2"},
+		{"third = 1/3", ModeDecimal, "0.33333333333333333333"},
+		{"third*3", ModeDecimal, "1"}, // 变量保存精确值
+		{"0.1 + 0.2", ModeFloat, "0.3"},
+		{"mask(v) = v & 0xF", ModeProgrammer, "mask(v) = v & 0xF"},
+		{"mask(0xAB)", ModeProgrammer, "11"},
+		{"x % -2", ModeFloat, "1"},
+		{"x % -1", ModeProgrammer, "0"},
+		{"x ^ 1", ModeProgrammer, "2"}, // 程序员模式中 ^ 是异或
+	}
+
+	for _, step := range steps {
+		opts := DefaultOptions()
+		opts.Mode = step.mode
+		got, err := Exec(step.input, opts, env)
+		if err != nil {
+			t.Fatalf("Exec(%q) error: %v", step.input, err)
+		}
+		if got.Text != step.want {
+			t.Fatalf("Exec(%q) = %q, want %q", step.input, got.Text, step.want)
+		}
+	}
+
+	// 函数和变量可以在 EvaluateEnv 中使用，但不会改变 env
+	if got, err := EvaluateEnv("f(2, 3)", DefaultOptions(), env); err != nil || got.Text != "7" {
+		t.Errorf("EvaluateEnv(f(2, 3)) = %v, %v", got, err)
+	}
+	if env.Vars[Ans] != "2" {
+		t.Errorf("ans = %s, want 2", env.Vars[Ans])
+	}
+
+	// 定义时只检查语法，调用时才解析名称
+	for _, input := range []string{"loop(n) = loop(n)", "bad(n) = n + y"} {
+		if _, err := Exec(input, DefaultOptions(), env); err != nil {
+			t.Fatalf("Exec(%q) error: %v", input, err)
+		}
+	}
+
+	for _, input := range []string{
+		"pi = 3",      // 常量
+		"sqrt(x) = x", // 内置函数
+		"ans = 1",
+		"h(a, a) = a", // 重复参数
+		"f(1)",        // 参数个数
+		"loop(1)",     // 递归过深
+		"bad(1)",      // 未定义的变量
+		"f(2) = 3",    // 不是定义
+		"x = ",
+	} {
+		if _, err := Exec(input, DefaultOptions(), env); err == nil {
+			t.Errorf("Exec(%q) succeeded, want error", input)
+		}
+	}
+	if _, err := Evaluate("x = 3", DefaultOptions()); err == nil {
+		t.Error("Evaluate accepted an assignment")
+	}
+}
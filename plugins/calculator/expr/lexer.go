@@ -0,0 +1,193 @@
+// Package expr parses and evaluates calculator expressions.
+//
+// Expressions support + - * / % ^ with the usual precedence, parentheses,
+// unary minus, postfix ! (factorial) and % (percent), scientific notation,
+// the constants pi and e and the functions in floatFuncs. They are evaluated
+// in one of the modes of Options: float64, exact decimal or fixed-width integer
+// (programmer mode, which adds bitwise operators and uses ^ for xor).
+// Exec also accepts assignments ("x = 3") and function definitions
+// ("f(a, b) = a*b+1"), which are kept in an Env together with ans.
+package expr
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Error is an error at a position in the expression.
+type Error struct {
+	Pos int // 0-based character offset
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("位置 %d: %s", e.Pos+1, e.Msg)
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokAssign
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "表达式结尾"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// operators are matched longest first.
+var operators = []string{"**", "<<", ">>", "+", "-", "*", "/", "%", "^", "!", "&", "|", "~"}
+
+// aliases maps alternative operator characters to their ASCII form.
+var aliases = map[rune]string{'×': "*", '÷': "/", '−': "-"}
+
+// tokenize splits the input into tokens. Positions are character offsets.
+func tokenize(input string) ([]token, error) {
+	src := []rune(input)
+	var toks []token
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			end, err := scanNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{tokNumber, string(src[i:end]), i})
+			i = end
+
+		case unicode.IsLetter(c) || c == '_':
+			end := i + 1
+			for end < len(src) && (unicode.IsLetter(src[end]) || isDigit(src[end]) || src[end] == '_') {
+				end++
+			}
+			toks = append(toks, token{tokIdent, string(src[i:end]), i})
+			i = end
+
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			toks = append(toks, token{tokComma, ",", i})
+			i++
+		case c == '=':
+			toks = append(toks, token{tokAssign, "=", i})
+			i++
+
+		default:
+			if op, ok := aliases[c]; ok {
+				toks = append(toks, token{tokOp, op, i})
+				i++
+				continue
+			}
+			op := matchOperator(src[i:])
+			if op == "" {
+				return nil, errorf(i, "无法识别的字符 '%c'", c)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len([]rune(op))
+		}
+	}
+
+	return append(toks, token{tokEOF, "", len(src)}), nil
+}
+
+// scanNumber returns the end of the number starting at start: an integer with
+// a 0x, 0o or 0b prefix, or digits, an optional fraction and an optional exponent.
+func scanNumber(src []rune, start int) (int, error) {
+	i := start
+	if src[i] == '0' && i+1 < len(src) {
+		if inBase := prefixBase(src[i+1]); inBase != nil {
+			j := i + 2
+			for j < len(src) && (inBase(src[j]) || src[j] == '_') {
+				j++
+			}
+			if j == i+2 {
+				return 0, errorf(i, "数字格式错误")
+			}
+			if j < len(src) && (isDigit(src[j]) || unicode.IsLetter(src[j]) || src[j] == '.') {
+				return 0, errorf(j, "数字格式错误")
+			}
+			return j, nil
+		}
+	}
+
+	for i < len(src) && isDigit(src[i]) {
+		i++
+	}
+	if i < len(src) && src[i] == '.' {
+		i++
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && isDigit(src[j]) {
+			for j < len(src) && isDigit(src[j]) {
+				j++
+			}
+			i = j
+		}
+	}
+	if i < len(src) && src[i] == '.' {
+		return 0, errorf(i, "数字格式错误")
+	}
+	return i, nil
+}
+
+func matchOperator(src []rune) string {
+	for _, op := range operators {
+		r := []rune(op)
+		if len(src) >= len(r) && string(src[:len(r)]) == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// prefixBase returns the digit test for an integer prefix letter (0x, 0o, 0b), or nil.
+func prefixBase(c rune) func(rune) bool {
+	switch c {
+	case 'x', 'X':
+		return func(c rune) bool { return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') }
+	case 'o', 'O':
+		return func(c rune) bool { return c >= '0' && c <= '7' }
+	case 'b', 'B':
+		return func(c rune) bool { return c == '0' || c == '1' }
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Mode selects how expressions are evaluated.
+type Mode string
+
+const (
+	// ModeFloat evaluates in float64.
+	ModeFloat Mode = "float"
+
+	// ModeDecimal evaluates exactly with rational numbers, so 0.1+0.2 is 0.3 and
+	// large integers keep every digit. Results are rounded to Precision decimal places.
+	ModeDecimal Mode = "decimal"
+
+	// ModeProgrammer evaluates integers of a fixed width with wrap-around,
+	// supports hex/oct/bin literals and bitwise operators, and uses ^ for xor.
+	ModeProgrammer Mode = "programmer"
+)
+
+const (
+	DefaultPrecision = 20
+	MaxPrecision     = 100
+)
+
+// Options configures Evaluate.
+type Options struct {
+	Mode Mode `json:"mode"`
+
+	// Precision is the number of decimal places in decimal mode.
+	Precision int `json:"precision"`
+
+	// Width (8, 16, 32 or 64) and Signed set the integer type in programmer mode.
+	Width  int  `json:"width"`
+	Signed bool `json:"signed"`
+}
+
+// DefaultOptions returns float mode with the defaults for the other modes.
+func DefaultOptions() Options {
+	return Options{Mode: ModeFloat, Precision: DefaultPrecision, Width: 64, Signed: true}
+}
+
+// Validate checks the options.
+func (o Options) Validate() error {
+	switch o.Mode {
+	case ModeFloat, ModeDecimal, ModeProgrammer:
+	default:
+		return fmt.Errorf("未知的计算模式: %s", o.Mode)
+	}
+	if o.Precision < 1 || o.Precision > MaxPrecision {
+		return fmt.Errorf("精度必须在 1 到 %d 位之间", MaxPrecision)
+	}
+	switch o.Width {
+	case 8, 16, 32, 64:
+	default:
+		return fmt.Errorf("整数位宽必须是 8、16、32 或 64")
+	}
+	return nil
+}
+
+// Result is the result of Evaluate.
+type Result struct {
+	// Text is the result formatted for the mode.
+	Text string `json:"text"`
+
+	// Value is the result as float64, possibly rounded.
+	Value float64 `json:"value"`
+
+	// Approximate is set in decimal mode when a function or a non-integer power
+	// was computed in float64, so only about 15 digits are significant.
+	Approximate bool `json:"approximate,omitempty"`
+
+	// Hex, Oct and Bin show the bits of the result in programmer mode;
+	// Overflow is set if an operation did not fit the integer type.
+	Hex      string `json:"hex,omitempty"`
+	Oct      string `json:"oct,omitempty"`
+	Bin      string `json:"bin,omitempty"`
+	Overflow bool   `json:"overflow,omitempty"`
+
+	// Unit is the target unit, currency or time zone of a conversion, and Note
+	// says where its data came from, such as the date of the exchange rates.
+	Unit string `json:"unit,omitempty"`
+	Note string `json:"note,omitempty"`
+
+	// Name is the variable assigned ("x = 3") or the function defined
+	// ("f(a) = a*2") by Exec; Function is set for definitions, whose Text is
+	// the definition and which have no value.
+	Name     string `json:"name,omitempty"`
+	Function bool   `json:"function,omitempty"`
+}
+
+// Evaluate parses and evaluates an expression in the mode of opts.
+func Evaluate(input string, opts Options) (*Result, error) {
+	return EvaluateEnv(input, opts, nil)
+}
+
+// formatFloat formats v with up to 15 significant digits, enough to hide
+// binary rounding such as 0.1+0.2.
+func formatFloat(v float64) string {
+	if v == 0 {
+		return "0" // 避免 -0
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+	if math.Abs(rounded) >= 1e-6 && math.Abs(rounded) < 1e21 {
+		return strconv.FormatFloat(rounded, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(rounded, 'g', -1, 64)
+}
@@ -0,0 +1,328 @@
+package expr
+
+import "strings"
+
+// node is an expression tree node.
+type node interface {
+	pos() int
+}
+
+type (
+	numberNode struct {
+		at   int
+		text string
+	}
+	identNode struct {
+		at   int
+		name string
+	}
+	unaryNode struct {
+		at int
+		op string
+		x  node
+	}
+	binaryNode struct {
+		at   int
+		op   string
+		x, y node
+	}
+	postfixNode struct {
+		at int
+		op string
+		x  node
+	}
+	callNode struct {
+		at   int
+		name string
+		args []node
+	}
+)
+
+func (n *numberNode) pos() int  { return n.at }
+func (n *identNode) pos() int   { return n.at }
+func (n *unaryNode) pos() int   { return n.at }
+func (n *binaryNode) pos() int  { return n.at }
+func (n *postfixNode) pos() int { return n.at }
+func (n *callNode) pos() int    { return n.at }
+
+// Binding powers, from loosest to tightest.
+const (
+	bpOr      = 2  // |
+	bpXor     = 3  // ^ in programmer mode
+	bpAnd     = 4  // &
+	bpShift   = 5  // << >>
+	bpAdd     = 10 // + -
+	bpMul     = 20 // * / % (modulo)
+	bpUnary   = 30 // prefix - + ~
+	bpPow     = 40 // ^ **, right-associative
+	bpPostfix = 50 // ! % (percent)
+)
+
+// parse parses an expression. In programmer mode ^ is xor instead of power.
+// Power is always stored as "**".
+func parse(input string, programmer bool) (node, error) {
+	toks, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	return parseTokens(toks, programmer)
+}
+
+// parseTokens parses tokens that end with tokEOF as one expression.
+func parseTokens(toks []token, programmer bool) (node, error) {
+	if toks[0].kind == tokEOF {
+		return nil, errorf(toks[0].pos, "表达式为空")
+	}
+
+	p := &parser{toks: toks, programmer: programmer}
+	n, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		if t.kind == tokRParen {
+			return nil, errorf(t.pos, "多余的右括号")
+		}
+		return nil, errorf(t.pos, "意外的 %s", t)
+	}
+	return n, nil
+}
+
+// statement is a line of input: an expression, an assignment "x = 3" or a
+// function definition "f(a, b) = a*b+1".
+type statement struct {
+	name     string // 赋值或定义的名称，表达式为空
+	namePos  int
+	params   []string // 函数参数
+	isFunc   bool
+	body     node
+	bodyText string // "=" 之后的原文，函数定义保存它
+	at       int    // "=" 的位置
+}
+
+// parseStatement parses an expression, an assignment or a function definition.
+func parseStatement(input string, programmer bool) (*statement, error) {
+	toks, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &statement{}
+	head := 0 // "=" 的下标，0 表示普通表达式
+	if len(toks) > 2 && toks[0].kind == tokIdent {
+		switch {
+		case toks[1].kind == tokAssign:
+			head = 1
+		case toks[1].kind == tokLParen:
+			params, end, ok := parseParams(toks, 2)
+			if ok && toks[end].kind == tokAssign {
+				head = end
+				st.params = params
+				st.isFunc = true
+			}
+		}
+	}
+	if head == 0 {
+		st.body, err = parseTokens(toks, programmer)
+		return st, err
+	}
+
+	st.name, st.namePos = toks[0].text, toks[0].pos
+	st.at = toks[head].pos
+	st.bodyText = strings.TrimSpace(string([]rune(input)[st.at+1:]))
+	if st.body, err = parseTokens(toks[head+1:], programmer); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// parseParams parses "a, b)" starting at toks[i]. It returns the parameter
+// names and the index after ")"; ok is false if the tokens are not a plain
+// parameter list, which makes "f(2) = 3" a syntax error instead of a definition.
+func parseParams(toks []token, i int) (params []string, end int, ok bool) {
+	if toks[i].kind == tokRParen {
+		return nil, i + 1, true
+	}
+	for {
+		if toks[i].kind != tokIdent {
+			return nil, 0, false
+		}
+		params = append(params, toks[i].text)
+		switch toks[i+1].kind {
+		case tokComma:
+			i += 2
+		case tokRParen:
+			return params, i + 2, true
+		default:
+			return nil, 0, false
+		}
+	}
+}
+
+// parser is a Pratt parser over the tokens.
+type parser struct {
+	toks       []token
+	i          int
+	programmer bool
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// expr parses operators that bind tighter than rbp.
+func (p *parser) expr(rbp int) (node, error) {
+	left, err := p.prefix()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		lbp := p.infixPower(t)
+		if lbp <= rbp {
+			return left, nil
+		}
+		p.next()
+
+		switch lbp {
+		case bpPostfix:
+			left = &postfixNode{at: t.pos, op: t.text, x: left}
+		case bpPow:
+			right, err := p.expr(bpPow - 1)
+			if err != nil {
+				return nil, err
+			}
+			left = &binaryNode{at: t.pos, op: "**", x: left, y: right}
+		default:
+			right, err := p.expr(lbp)
+			if err != nil {
+				return nil, err
+			}
+			left = &binaryNode{at: t.pos, op: t.text, x: left, y: right}
+		}
+	}
+}
+
+// infixPower returns the binding power of t after an operand, 0 if it does not continue the expression.
+func (p *parser) infixPower(t token) int {
+	if t.kind != tokOp {
+		return 0
+	}
+	switch t.text {
+	case "+", "-":
+		return bpAdd
+	case "*", "/":
+		return bpMul
+	case "**":
+		return bpPow
+	case "^":
+		if p.programmer {
+			return bpXor
+		}
+		return bpPow
+	case "|":
+		return bpOr
+	case "&":
+		return bpAnd
+	case "<<", ">>":
+		return bpShift
+	case "!":
+		return bpPostfix
+	case "%":
+		// 后面跟操作数（包括一元 - + ~）时为取模，否则为百分号；程序员模式中总是取模
+		if p.programmer || startsOperand(p.toks[p.i+1]) {
+			return bpMul
+		}
+		return bpPostfix
+	}
+	return 0
+}
+
+// startsOperand reports whether t can begin an operand.
+func startsOperand(t token) bool {
+	switch t.kind {
+	case tokNumber, tokIdent, tokLParen:
+		return true
+	case tokOp:
+		return t.text == "-" || t.text == "+" || t.text == "~"
+	}
+	return false
+}
+
+// prefix parses an operand: a number, constant, call, parenthesized expression or unary operator.
+func (p *parser) prefix() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &numberNode{at: t.pos, text: t.text}, nil
+
+	case tokIdent:
+		if p.peek().kind != tokLParen {
+			return &identNode{at: t.pos, name: t.text}, nil
+		}
+		p.next()
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		return &callNode{at: t.pos, name: t.text, args: args}, nil
+
+	case tokLParen:
+		n, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokRParen {
+			return nil, errorf(r.pos, "缺少右括号，第 %d 个字符的左括号未闭合", t.pos+1)
+		}
+		return n, nil
+
+	case tokOp:
+		if t.text == "-" || t.text == "+" || t.text == "~" {
+			x, err := p.expr(bpUnary)
+			if err != nil {
+				return nil, err
+			}
+			return &unaryNode{at: t.pos, op: t.text, x: x}, nil
+		}
+	}
+
+	if t.kind == tokEOF {
+		return nil, errorf(t.pos, "表达式不完整")
+	}
+	return nil, errorf(t.pos, "意外的 %s", t)
+}
+
+// args parses call arguments after the opening parenthesis.
+func (p *parser) args() ([]node, error) {
+	var args []node
+	if p.peek().kind == tokRParen {
+		p.next()
+		return args, nil
+	}
+	for {
+		arg, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		switch t := p.next(); t.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return args, nil
+		default:
+			return nil, errorf(t.pos, "函数参数后应为 ',' 或 ')'，而不是 %s", t)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package expr
+
+import (
+	"math/big"
+	"strings"
+)
+
+// maxIntFactorial keeps factorials in programmer mode from allocating huge numbers;
+// anything above 20! overflows 64 bits anyway.
+const maxIntFactorial = 1000
+
+// intEval evaluates fixed-width integers. Every operation wraps around like
+// the integer type would; Overflow records that a result did not fit.
+type intEval struct {
+	width    int
+	signed   bool
+	min, max *big.Int
+	modulus  *big.Int // 2^width
+	overflow bool
+	scope    *scope
+}
+
+func newIntEval(width int, signed bool, sc *scope) *intEval {
+	e := &intEval{width: width, signed: signed, scope: sc}
+	e.modulus = new(big.Int).Lsh(big.NewInt(1), uint(width))
+	if signed {
+		e.max = new(big.Int).Lsh(big.NewInt(1), uint(width-1))
+		e.min = new(big.Int).Neg(e.max)
+		e.max.Sub(e.max, big.NewInt(1))
+	} else {
+		e.min = big.NewInt(0)
+		e.max = new(big.Int).Sub(e.modulus, big.NewInt(1))
+	}
+	return e
+}
+
+func evalProgrammerNode(n node, width int, signed bool, sc *scope) (*Result, string, error) {
+	e := newIntEval(width, signed, sc)
+	v, err := e.eval(n)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bits := new(big.Int).Mod(v, e.modulus) // 补码表示
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return &Result{
+		Text:     v.String(),
+		Value:    f,
+		Hex:      "0x" + strings.ToUpper(bits.Text(16)),
+		Oct:      "0o" + bits.Text(8),
+		Bin:      "0b" + bits.Text(2),
+		Overflow: e.overflow,
+	}, v.String(), nil
+}
+
+// wrap reduces v to the integer type. If overflow is set, a value out of range
+// is recorded as an overflow; bitwise operations and bit-pattern literals only
+// reinterpret the bits.
+func (e *intEval) wrap(v *big.Int, overflow bool) *big.Int {
+	if v.Cmp(e.min) >= 0 && v.Cmp(e.max) <= 0 {
+		return v
+	}
+	if overflow {
+		e.overflow = true
+	}
+	v.Mod(v, e.modulus)
+	if e.signed && v.Cmp(e.max) > 0 {
+		v.Sub(v, e.modulus)
+	}
+	return v
+}
+
+func (e *intEval) eval(n node) (*big.Int, error) {
+	switch n := n.(type) {
+	case *numberNode:
+		if v, ok := parsePrefixedInt(n.text); ok {
+			// 0xFF 在 int8 中是 -1：不超过位宽的字面量按位模式解释
+			return e.wrap(v, v.BitLen() > e.width), nil
+		}
+		v, ok := new(big.Int).SetString(n.text, 10)
+		if !ok {
+			return nil, errorf(n.at, "程序员模式只支持整数")
+		}
+		return e.wrap(v, true), nil
+
+	case *identNode:
+		if r, ok := e.scope.lookupVar(n.name); ok {
+			if !r.IsInt() {
+				return nil, errorf(n.at, "变量 %s 不是整数", n.name)
+			}
+			return e.wrap(new(big.Int).Set(r.Num()), true), nil
+		}
+		return nil, errorf(n.at, "程序员模式只支持整数，不支持 %s", n.name)
+
+	case *callNode:
+		if _, ok := e.scope.lookupFunc(n.name); ok {
+			return e.callUser(n)
+		}
+		return nil, errorf(n.at, "程序员模式不支持函数 %s", n.name)
+
+	case *unaryNode:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "-":
+			return e.wrap(x.Neg(x), true), nil
+		case "~":
+			return e.wrap(x.Not(x), false), nil
+		}
+		return x, nil
+
+	case *postfixNode:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "%" {
+			return nil, errorf(n.at, "程序员模式不支持百分号")
+		}
+		if x.Sign() < 0 {
+			return nil, errorf(n.at, "阶乘只支持非负整数")
+		}
+		if x.Cmp(big.NewInt(maxIntFactorial)) > 0 {
+			return nil, errorf(n.at, "阶乘的参数不能超过 %d", maxIntFactorial)
+		}
+		return e.wrap(new(big.Int).MulRange(1, x.Int64()), true), nil
+
+	case *binaryNode:
+		x, err := e.eval(n.x)
+		if err != nil {
+			return nil, err
+		}
+		y, err := e.eval(n.y)
+		if err != nil {
+			return nil, err
+		}
+		return e.binary(n, x, y)
+	}
+	return nil, errorf(n.pos(), "无法计算的表达式")
+}
+
+// callUser calls a user-defined function.
+func (e *intEval) callUser(n *callNode) (*big.Int, error) {
+	f, _ := e.scope.lookupFunc(n.name)
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		v, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v.String()
+	}
+	sc, body, err := e.scope.enter(n, f, args, true)
+	if err != nil {
+		return nil, err
+	}
+	inner := newIntEval(e.width, e.signed, sc)
+	v, err := inner.eval(body)
+	if err != nil {
+		return nil, callError(n, err)
+	}
+	e.overflow = e.overflow || inner.overflow
+	return v, nil
+}
+
+func (e *intEval) binary(n *binaryNode, x, y *big.Int) (*big.Int, error) {
+	z := new(big.Int)
+	switch n.op {
+	case "+":
+		return e.wrap(z.Add(x, y), true), nil
+	case "-":
+		return e.wrap(z.Sub(x, y), true), nil
+	case "*":
+		return e.wrap(z.Mul(x, y), true), nil
+	case "/", "%":
+		if y.Sign() == 0 {
+			return nil, errorf(n.at, "除数不能为零")
+		}
+		// 与 C/Go 一致：商向零取整
+		if n.op == "/" {
+			return e.wrap(z.Quo(x, y), true), nil
+		}
+		return e.wrap(z.Rem(x, y), true), nil
+	case "**":
+		if y.Sign() < 0 {
+			return nil, errorf(n.at, "程序员模式不支持负指数")
+		}
+		if y.BitLen() > 16 {
+			return nil, errorf(n.at, "指数过大")
+		}
+		return e.wrap(z.Exp(x, y, nil), true), nil
+	case "&":
+		return e.wrap(z.And(x, y), false), nil
+	case "|":
+		return e.wrap(z.Or(x, y), false), nil
+	case "^":
+		return e.wrap(z.Xor(x, y), false), nil
+	case "<<", ">>":
+		if y.Sign() < 0 {
+			return nil, errorf(n.at, "移位位数不能为负")
+		}
+		// 超过位宽的移位结果相同，限制位数避免分配过大的数
+		shift := uint(e.width + 1)
+		if y.IsInt64() && y.Int64() < int64(shift) {
+			shift = uint(y.Int64())
+		}
+		if n.op == "<<" {
+			return e.wrap(z.Lsh(x, shift), true), nil
+		}
+		return e.wrap(z.Rsh(x, shift), false), nil
+	}
+	return nil, errorf(n.at, "不支持的运算符 %s", n.op)
+}
@@ -0,0 +1,229 @@
+// Package history keeps the calculator's history and the names the user
+// defined. History entries and functions are saved in the data directory;
+// variables, including ans, last for the session.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"ltools/plugins/calculator/expr"
+)
+
+// MaxEntries is the number of history entries kept on disk.
+const MaxEntries = 1000
+
+const (
+	historyFile   = "history.json"
+	functionsFile = "functions.json"
+)
+
+// Entry is a calculation in the history.
+type Entry struct {
+	ID         int64     `json:"id"`
+	Expression string    `json:"expression"`
+	Result     string    `json:"result"`
+	Unit       string    `json:"unit,omitempty"`
+	Value      float64   `json:"value"`
+	Session    string    `json:"session"`
+	Time       time.Time `json:"time"`
+}
+
+// Store is the history and environment of the calculator.
+type Store struct {
+	mu      sync.Mutex
+	dir     string // 为空时只保存在内存中
+	session string
+	entries []Entry // 从旧到新
+	nextID  int64
+	env     *expr.Env
+}
+
+// Open loads the history and functions from dir. An empty dir keeps
+// everything in memory.
+func Open(dir string) (*Store, error) {
+	s := &Store{
+		dir:     dir,
+		session: strconv.FormatInt(time.Now().UnixNano(), 36), // 每次启动不同
+		env:     expr.NewEnv(),
+		nextID:  1,
+	}
+	if dir == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建计算器数据目录失败: %w", err)
+	}
+
+	if err := readJSON(filepath.Join(dir, historyFile), &s.entries); err != nil {
+		return nil, err
+	}
+	for _, e := range s.entries {
+		s.nextID = max(s.nextID, e.ID+1)
+	}
+	if err := readJSON(filepath.Join(dir, functionsFile), &s.env.Funcs); err != nil {
+		return nil, err
+	}
+	if s.env.Funcs == nil {
+		s.env.Funcs = make(map[string]*expr.Func)
+	}
+	return s, nil
+}
+
+// Do runs eval with the environment and records a successful result in the
+// history. Results that are not function definitions become ans. If saving
+// fails, the error is returned together with the result.
+func (s *Store) Do(input string, eval func(env *expr.Env) (*expr.Result, error)) (*expr.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := eval(s.env)
+	if err != nil {
+		return nil, err
+	}
+	if result.Function {
+		return result, s.saveFunctions()
+	}
+	// 换算结果不经过 expr.Exec，在这里更新 ans
+	if result.Unit != "" {
+		s.env.Vars[expr.Ans] = strconv.FormatFloat(result.Value, 'g', -1, 64)
+	}
+
+	s.entries = append(s.entries, Entry{
+		ID:         s.nextID,
+		Expression: input,
+		Result:     result.Text,
+		Unit:       result.Unit,
+		Value:      result.Value,
+		Session:    s.session,
+		Time:       time.Now(),
+	})
+	s.nextID++
+	if len(s.entries) > MaxEntries {
+		s.entries = append([]Entry(nil), s.entries[len(s.entries)-MaxEntries:]...)
+	}
+	return result, s.saveHistory()
+}
+
+// Env returns a copy of the environment for evaluating without side effects,
+// e.g. while the user types in the search window.
+func (s *Store) Env() *expr.Env {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.env.Clone()
+}
+
+// Entries returns up to limit entries, newest first. With sessionOnly only
+// entries of the current session are returned. limit <= 0 means all.
+func (s *Store) Entries(sessionOnly bool, limit int) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Entry, 0)
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if sessionOnly && s.entries[i].Session != s.session {
+			continue
+		}
+		result = append(result, s.entries[i])
+	}
+	return result
+}
+
+// Last returns the newest entry.
+func (s *Store) Last() (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return Entry{}, false
+	}
+	return s.entries[len(s.entries)-1], true
+}
+
+// Clear removes all history entries and ans.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+	delete(s.env.Vars, expr.Ans)
+	return s.saveHistory()
+}
+
+// Variables returns the variables of the session.
+func (s *Store) Variables() []expr.Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.env.Variables()
+}
+
+// DeleteVariable removes a variable.
+func (s *Store) DeleteVariable(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.env.Vars, name)
+}
+
+// Functions returns the user functions.
+func (s *Store) Functions() []expr.FuncInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.env.Functions()
+}
+
+// DeleteFunction removes a user function.
+func (s *Store) DeleteFunction(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.env.Funcs[name]; !ok {
+		return fmt.Errorf("函数 %s 不存在", name)
+	}
+	delete(s.env.Funcs, name)
+	return s.saveFunctions()
+}
+
+func (s *Store) saveHistory() error {
+	return s.writeJSON(historyFile, s.entries)
+}
+
+func (s *Store) saveFunctions() error {
+	return s.writeJSON(functionsFile, s.env.Funcs)
+}
+
+// writeJSON writes a file in the store directory through a temporary file.
+func (s *Store) writeJSON(name string, v any) error {
+	if s.dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存 %s 失败: %w", name, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// readJSON reads path into v; a missing file leaves v unchanged.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %w", filepath.Base(path), err)
+	}
+	return nil
+}
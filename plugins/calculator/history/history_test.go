@@ -0,0 +1,76 @@
+package history
+
+import (
+	"testing"
+
+	"ltools/plugins/calculator/expr"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exec := func(input string) *expr.Result {
+		t.Helper()
+		result, err := s.Do(input, func(env *expr.Env) (*expr.Result, error) {
+			return expr.Exec(input, expr.DefaultOptions(), env)
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		return result
+	}
+
+	exec("1+1")
+	exec("x = ans * 10")
+	exec("area(w, h) = w*h")
+	if got := exec("area(x, 2)"); got.Text != "40" {
+		t.Errorf("area(x, 2) = %s, want 40", got.Text)
+	}
+	if _, err := s.Do("1/0", func(env *expr.Env) (*expr.Result, error) {
+		return expr.Exec("1/0", expr.DefaultOptions(), env)
+	}); err == nil {
+		t.Error("1/0 succeeded")
+	}
+
+	// 函数定义和错误不记入历史
+	entries := s.Entries(false, 0)
+	if len(entries) != 3 || entries[0].Expression != "area(x, 2)" || entries[2].Result != "2" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if last, _ := s.Last(); last.Value != 40 {
+		t.Errorf("last = %+v", last)
+	}
+
+	// 重新打开：历史和函数保留，变量属于上一个会话
+	s, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.Entries(false, 0)); n != 3 {
+		t.Errorf("reopened history has %d entries, want 3", n)
+	}
+	if n := len(s.Entries(true, 0)); n != 0 {
+		t.Errorf("new session has %d entries, want 0", n)
+	}
+	if len(s.Variables()) != 0 {
+		t.Errorf("variables survived the session: %v", s.Variables())
+	}
+	if got := exec("area(3, 4)"); got.Text != "12" {
+		t.Errorf("area(3, 4) = %s after reopening", got.Text)
+	}
+
+	if err := s.DeleteFunction("area"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = Open(dir)
+	if len(s.Functions()) != 0 || len(s.Entries(false, 0)) != 0 {
+		t.Errorf("functions %v and history %v not cleared", s.Functions(), s.Entries(false, 0))
+	}
+}
@@ -2,6 +2,9 @@ package calculator
 
 import (
 	"github.com/wailsapp/wails/v3/pkg/application"
+	"ltools/plugins/calculator/convert"
+	"ltools/plugins/calculator/expr"
+	"ltools/plugins/calculator/history"
 )
 
 // CalculatorService exposes Calculator functionality to the frontend
@@ -50,22 +53,77 @@ func (s *CalculatorService) Divide(a, b float64) (float64, error) {
 	return s.plugin.Divide(a, b)
 }
 
-// Evaluate evaluates a mathematical expression
+// Evaluate evaluates a mathematical expression, assignment or function definition
 func (s *CalculatorService) Evaluate(expression string) (float64, error) {
 	return s.plugin.Evaluate(expression)
 }
 
+// Calculate evaluates an expression in the current mode
+func (s *CalculatorService) Calculate(expression string) (*expr.Result, error) {
+	return s.plugin.Calculate(expression)
+}
+
+// GetOptions returns the calculation mode and its settings
+func (s *CalculatorService) GetOptions() expr.Options {
+	return s.plugin.GetOptions()
+}
+
+// SetOptions sets the calculation mode (float, decimal or programmer) and its settings
+func (s *CalculatorService) SetOptions(options expr.Options) error {
+	return s.plugin.SetOptions(options)
+}
+
+// GetRates returns the exchange rates used for currency conversions
+func (s *CalculatorService) GetRates() convert.RateTable {
+	return s.plugin.GetRates()
+}
+
+// RefreshRates downloads the current exchange rates
+func (s *CalculatorService) RefreshRates() (convert.RateTable, error) {
+	return s.plugin.RefreshRates()
+}
+
 // Percentage calculates percentage
 func (s *CalculatorService) Percentage(part, total float64) float64 {
 	return s.plugin.Percentage(part, total)
 }
 
+// GetHistory returns up to limit calculations of all sessions, newest first (0 for all)
+func (s *CalculatorService) GetHistory(limit int) []history.Entry {
+	return s.plugin.GetHistory(limit)
+}
+
+// GetSessionHistory returns the calculations since the app started, newest first
+func (s *CalculatorService) GetSessionHistory() []history.Entry {
+	return s.plugin.GetSessionHistory()
+}
+
 // ClearHistory clears calculation history
-func (s *CalculatorService) ClearHistory() {
-	s.plugin.ClearHistory()
+func (s *CalculatorService) ClearHistory() error {
+	return s.plugin.ClearHistory()
 }
 
 // GetLastResult returns the last calculation result
 func (s *CalculatorService) GetLastResult() float64 {
 	return s.plugin.GetLastResult()
 }
+
+// GetVariables returns the variables of this session, including ans
+func (s *CalculatorService) GetVariables() []expr.Variable {
+	return s.plugin.GetVariables()
+}
+
+// DeleteVariable removes a variable
+func (s *CalculatorService) DeleteVariable(name string) {
+	s.plugin.DeleteVariable(name)
+}
+
+// GetFunctions returns the user-defined functions
+func (s *CalculatorService) GetFunctions() []expr.FuncInfo {
+	return s.plugin.GetFunctions()
+}
+
+// DeleteFunction removes a user-defined function
+func (s *CalculatorService) DeleteFunction(name string) error {
+	return s.plugin.DeleteFunction(name)
+}
@@ -3,56 +3,26 @@
 package clipboard
 
 import (
-	"encoding/base64"
 	"fmt"
-	"log"
-	"os"
-	"os/exec"
+	"log/slog"
+	"net/http"
 	"path/filepath"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
 
+	"ltools/internal/logging"
 	"ltools/internal/plugins"
+	clipboardpkg "ltools/internal/plugins/clipboard"
+	"ltools/plugins/clipboard/history"
+	"ltools/plugins/clipboard/watch"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
-var debugLog *os.File
-var debugLogger *log.Logger
-
-func init() {
-	// Initialize debug log file in user's config directory
-	// Use ~/.config/ltools/logs (macOS: ~/Library/Application Support/ltools/logs)
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		fmt.Println("[Clipboard Plugin] Failed to get user config dir:", err)
-		return
-	}
-
-	logsDir := filepath.Join(configDir, "ltools", "logs")
-	if err := os.MkdirAll(logsDir, 0755); err != nil {
-		fmt.Println("[Clipboard Plugin] Failed to create logs directory:", err)
-		return
-	}
-
-	logFilePath := filepath.Join(logsDir, "clipboard-debug.log")
-	f, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err == nil {
-		debugLog = f
-		// Create a separate logger for clipboard instead of modifying global log
-		debugLogger = log.New(f, "", log.LstdFlags)
-		fmt.Println("[Clipboard Plugin] Debug log initialized:", logFilePath)
-		debugWrite("Debug log initialized")
-	} else {
-		fmt.Println("[Clipboard Plugin] Failed to initialize debug log:", err)
-	}
-}
-
-// debugWrite writes a message to the debug log file
-func debugWrite(format string, args ...interface{}) {
-	if debugLogger != nil {
-		debugLogger.Printf(format, args...)
-	}
+// logger returns the plugin's logger. Its level can be changed in the log viewer.
+func logger() *slog.Logger {
+	return logging.For(PluginID)
 }
 
 const (
@@ -61,21 +31,15 @@ const (
 	PluginVersion = "1.0.0"
 )
 
-// ClipboardItem represents an item in the clipboard history
-type ClipboardItem struct {
-	Content   string    `json:"content"`
-	Timestamp time.Time `json:"timestamp"`
-	Type      string    `json:"type"` // text, image, etc.
-}
-
 // ClipboardPlugin provides clipboard management functionality
 type ClipboardPlugin struct {
 	*plugins.BasePlugin
 	app            *application.App
-	history        []ClipboardItem
-	maxHistory     int
-	lastClipboard  string        // Track last clipboard content to detect changes
-	stopMonitoring chan struct{} // Channel to stop monitoring
+	dir            string // 历史数据目录，由 SetDataDir 设置
+	mu             sync.Mutex
+	store          *history.Store // 关闭期间为 nil
+	lastHash       string         // 上次读取的剪贴板内容的哈希，只在监控协程中使用
+	stopMonitoring chan struct{}  // Channel to stop monitoring
 }
 
 // NewClipboardPlugin creates a new clipboard plugin
@@ -97,11 +61,70 @@ func NewClipboardPlugin() *ClipboardPlugin {
 
 	base := plugins.NewBasePlugin(metadata)
 	return &ClipboardPlugin{
-		BasePlugin:     base,
-		history:        make([]ClipboardItem, 0),
-		maxHistory:     100, // Keep last 100 items
-		stopMonitoring: make(chan struct{}),
+		BasePlugin: base,
+	}
+}
+
+// SetDataDir opens the clipboard history in dataDir/clipboard, closing the
+// one opened before
+func (p *ClipboardPlugin) SetDataDir(dataDir string) error {
+	if err := p.closeStore(); err != nil {
+		logger().Warn("Failed to close history", "error", err)
+	}
+	p.mu.Lock()
+	p.dir = filepath.Join(dataDir, "clipboard")
+	p.mu.Unlock()
+	return p.openStore()
+}
+
+// openStore opens the history database unless it is already open.
+func (p *ClipboardPlugin) openStore() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.store != nil || p.dir == "" {
+		return nil
+	}
+	store, err := history.Open(p.dir)
+	if err != nil {
+		return fmt.Errorf("failed to open clipboard history: %w", err)
+	}
+	p.store = store
+	return nil
+}
+
+// closeStore closes the history database, e.g. before a backup is restored
+// over it.
+func (p *ClipboardPlugin) closeStore() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.store == nil {
+		return nil
+	}
+	err := p.store.Close()
+	p.store = nil
+	return err
+}
+
+func (p *ClipboardPlugin) historyStore() *history.Store {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.store
+}
+
+// Quiesce pauses writes to the history while the data directory is backed up
+func (p *ClipboardPlugin) Quiesce() error {
+	if store := p.historyStore(); store != nil {
+		return store.Pause()
 	}
+	return nil
+}
+
+// Resume continues the writes paused by Quiesce
+func (p *ClipboardPlugin) Resume() error {
+	if store := p.historyStore(); store != nil {
+		store.Resume()
+	}
+	return nil
 }
 
 // Init initializes the plugin
@@ -115,20 +138,35 @@ func (p *ClipboardPlugin) Init(app *application.App) error {
 
 // ServiceStartup is called when the application starts
 func (p *ClipboardPlugin) ServiceStartup(app *application.App) error {
-	log.Printf("[Clipboard Plugin] ServiceStartup called\n")
+	logger().Debug("ServiceStartup called")
 	if err := p.BasePlugin.ServiceStartup(app); err != nil {
-		log.Printf("[Clipboard Plugin] ServiceStartup BasePlugin error: %v\n", err)
+		logger().Error("ServiceStartup failed", "error", err)
 		return err
 	}
 	p.app = app
 
+	// Reopen the history closed by ServiceShutdown
+	if err := p.openStore(); err != nil {
+		logger().Error("Failed to open history", "error", err)
+	}
+
 	// Request clipboard permission
 	p.emitEvent("permission:requested", "clipboard")
 
 	// Start clipboard monitoring
-	log.Printf("[Clipboard Plugin] Starting clipboard monitoring...\n")
-	fmt.Println("[Clipboard Plugin] Starting clipboard monitoring...")
-	go p.monitorClipboard()
+	p.mu.Lock()
+	if p.stopMonitoring == nil {
+		logger().Info("Starting clipboard monitoring")
+		p.stopMonitoring = make(chan struct{})
+		// 启动时不把仍在剪贴板里的内容当作新复制
+		if p.store != nil {
+			if item, err := p.store.Latest(); err == nil {
+				p.lastHash = item.Hash
+			}
+		}
+		go p.monitorClipboard(p.stopMonitoring)
+	}
+	p.mu.Unlock()
 
 	return nil
 }
@@ -136,7 +174,16 @@ func (p *ClipboardPlugin) ServiceStartup(app *application.App) error {
 // ServiceShutdown is called when the application shuts down
 func (p *ClipboardPlugin) ServiceShutdown(app *application.App) error {
 	// Stop monitoring
-	close(p.stopMonitoring)
+	p.mu.Lock()
+	if p.stopMonitoring != nil {
+		close(p.stopMonitoring)
+		p.stopMonitoring = nil
+	}
+	p.mu.Unlock()
+
+	if err := p.closeStore(); err != nil {
+		logger().Warn("Failed to close history", "error", err)
+	}
 	return p.BasePlugin.ServiceShutdown(app)
 }
 
@@ -157,248 +204,267 @@ func (p *ClipboardPlugin) emitEvent(eventName, data string) {
 	}
 }
 
-// monitorClipboard monitors the clipboard for changes
-func (p *ClipboardPlugin) monitorClipboard() {
-	ticker := time.NewTicker(500 * time.Millisecond) // Check every 500ms
-	defer ticker.Stop()
-
-	fmt.Println("[Clipboard Plugin] Monitor started, checking every 500ms")
+// monitorClipboard reads the clipboard whenever the watch sources of the
+// platform report a change, and adds new content to the history
+func (p *ClipboardPlugin) monitorClipboard(stop <-chan struct{}) {
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		// 合并读取期间到达的多次通知
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	go watch.Run(stop, notify, logger(), p.watchSources()...)
 
+	// 记录应用未运行期间复制的内容
+	notify()
 	for {
 		select {
-		case <-p.stopMonitoring:
-			fmt.Println("[Clipboard Plugin] Monitor stopped")
+		case <-stop:
+			logger().Info("Monitor stopped")
 			return
-		case <-ticker.C:
+		case <-changed:
 			// Check both plugin enabled state and metadata state
-			enabled := p.Enabled()
-			metadataState := p.Metadata().State
-			stateEnabled := plugins.PluginStateEnabled
-			shouldMonitor := enabled || metadataState == stateEnabled
-
-			// Debug logging
-			// log.Printf("[Clipboard Plugin] Debug: enabled=%v, state=%q, stateEnabled=%q, shouldMonitor=%v\n",
-			// 	enabled, metadataState, stateEnabled, shouldMonitor)
-
-			if shouldMonitor {
-				// Check for image first
-				imageData := p.getClipboardImage()
-				if imageData != "" {
-					// Image found in clipboard
-					if imageData != p.lastClipboard {
-						log.Printf("[Clipboard Plugin] Clipboard image changed! Length: %d\n", len(imageData))
-						p.lastClipboard = imageData
-						p.AddToHistory(imageData, "image")
-					}
-				} else {
-					// No image, check for text
-					currentClipboard := p.getSystemClipboard()
-					if currentClipboard != "" && currentClipboard != p.lastClipboard {
-						// Clipboard has changed
-						log.Printf("[Clipboard Plugin] Clipboard changed! New content length: %d\n", len(currentClipboard))
-						p.lastClipboard = currentClipboard
-						p.AddToHistory(currentClipboard, "text")
-					}
-				}
+			if p.Enabled() || p.Metadata().State == plugins.PluginStateEnabled {
+				p.checkClipboard()
 			}
 		}
 	}
 }
 
-// getSystemClipboard gets the current system clipboard content
-func (p *ClipboardPlugin) getSystemClipboard() string {
-	// Use osascript to get clipboard on macOS
-	cmd := exec.Command("osascript", "-e", "get the clipboard")
-	output, err := cmd.Output()
-	if err != nil {
-		// Silently fail - clipboard might be empty or not accessible
-		return ""
-	}
-	content := strings.TrimSpace(string(output))
-	// Debug: Log clipboard content (truncated)
-	// if len(content) > 0 {
-	// 	fmt.Printf("[Clipboard Plugin] Read clipboard: %q (%d chars)\n",
-	// 		truncateString(content, 50), len(content))
-	// }
-	return content
-}
-
-// getClipboardImage gets image from clipboard as base64 data URL
-func (p *ClipboardPlugin) getClipboardImage() string {
-	// Check if clipboard contains image
-	cmd := exec.Command("osascript", "-e", "clipboard info")
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-
-	info := string(output)
-	// Check if clipboard contains image types
-	if !strings.Contains(info, "TIFF") && !strings.Contains(info, "PNG") && !strings.Contains(info, "JPEG") {
-		return ""
-	}
-
-	// Try to save clipboard image to a temp file and convert to base64
-	tmpFile := "/tmp/ltools_clipboard_image.png"
-
-	// Use osascript to save clipboard image as PNG
-	script := fmt.Sprintf(`
-		tell application "System Events"
-			try
-				set theData to the clipboard as «class PNGf»
-				set theFile to open for access POSIX file "%s" with write permission
-				set eof of theFile to 0
-				write theData to theFile
-				close access theFile
-			on error
-				try
-					close access POSIX file "%s"
-				end try
-				return ""
-			end try
-		end tell
-	`, tmpFile, tmpFile)
-
-	cmd = exec.Command("osascript", "-e", script)
-	_, err = cmd.Output()
-	if err != nil {
-		debugWrite("Failed to save clipboard image: %v", err)
-		return ""
+// checkClipboard reads the clipboard and adds it to the history if it
+// differs from what was read last time. Images take precedence over text.
+func (p *ClipboardPlugin) checkClipboard() {
+	if data, mime := p.readImage(); len(data) > 0 {
+		if hash := history.Hash(data); hash != p.lastHash {
+			logger().Debug("Clipboard image changed", "size", len(data))
+			p.lastHash = hash
+			p.add(history.TypeImage, "", data, mime)
+		}
+		return
 	}
-
-	// Read the file and convert to base64
-	imageData, err := os.ReadFile(tmpFile)
-	if err != nil {
-		debugWrite("Failed to read temp image file: %v", err)
-		return ""
+	text := readText()
+	if text == "" {
+		return
+	}
+	if hash := history.Hash([]byte(text)); hash != p.lastHash {
+		logger().Debug("Clipboard text changed", "length", len(text))
+		p.lastHash = hash
+		p.add(history.TypeText, text, nil, "")
 	}
-
-	// Clean up temp file
-	defer os.Remove(tmpFile)
-
-	// Convert to base64 data URL
-	base64Data := "data:image/png;base64," + base64.StdEncoding.EncodeToString(imageData)
-	debugWrite("Got clipboard image, length: %d", len(base64Data))
-
-	return base64Data
 }
 
-// Helper function to truncate string for logging
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// readImage returns the image on the clipboard, if any
+func (p *ClipboardPlugin) readImage() ([]byte, string) {
+	data, err := clipboardpkg.NewImageClipboard(p.app).GetImage()
+	if err != nil || len(data) == 0 {
+		return nil, ""
 	}
-	return s[:maxLen] + "..."
+	return data, http.DetectContentType(data)
 }
 
 // GetCurrentClipboard returns the current system clipboard content
 func (p *ClipboardPlugin) GetCurrentClipboard() string {
-	return p.getSystemClipboard()
-}
-
-// GetHistory returns the clipboard history
-func (p *ClipboardPlugin) GetHistory() []ClipboardItem {
-	return p.history
+	return readText()
 }
 
-// AddToHistory adds an item to the clipboard history
-func (p *ClipboardPlugin) AddToHistory(content, itemType string) {
-	log.Printf("[Clipboard Plugin] AddToHistory called with content length: %d, type: %s\n", len(content), itemType)
+// errNoHistory is returned when the history database could not be opened.
+var errNoHistory = fmt.Errorf("剪贴板历史不可用")
 
-	item := ClipboardItem{
-		Content:   content,
-		Timestamp: time.Now(),
-		Type:      itemType,
+// withImage fills in the content of an image item as a data URL.
+func (p *ClipboardPlugin) withImage(store *history.Store, item history.Item) history.Item {
+	if item.Type != history.TypeImage {
+		return item
+	}
+	data, err := store.ReadImage(item)
+	if err != nil {
+		logger().Warn("Failed to read clipboard image", "id", item.ID, "error", err)
+		return item
 	}
+	item.Content = history.EncodeDataURL(item.Mime, data)
+	return item
+}
 
-	// Add to history
-	p.history = append([]ClipboardItem{item}, p.history...)
+// withThumbnail fills in the thumbnail of an image item as a data URL.
+func (p *ClipboardPlugin) withThumbnail(store *history.Store, item history.Item) history.Item {
+	if item.Type != history.TypeImage {
+		return item
+	}
+	data, err := store.ReadThumbnail(item)
+	if err != nil {
+		logger().Warn("Failed to read clipboard thumbnail", "id", item.ID, "error", err)
+		return item
+	}
+	item.Thumbnail = history.EncodeDataURL("image/png", data)
+	return item
+}
 
-	// Trim if exceeds max history
-	if len(p.history) > p.maxHistory {
-		p.history = p.history[:p.maxHistory]
+// GetHistoryPage returns a page of the clipboard history, most recent first.
+// Images only carry a thumbnail; the full image is loaded with GetItem.
+func (p *ClipboardPlugin) GetHistoryPage(query history.Query) (history.Page, error) {
+	store := p.historyStore()
+	if store == nil {
+		return history.Page{}, errNoHistory
+	}
+	page, err := store.List(query)
+	if err != nil {
+		return history.Page{}, err
+	}
+	for i, item := range page.Items {
+		page.Items[i] = p.withThumbnail(store, item)
 	}
+	return page, nil
+}
 
-	// Emit event
-	log.Printf("[Clipboard Plugin] Added to history: %q (total: %d items)\n", truncateString(content, 50), len(p.history))
-	p.emitEvent("new", content)
-	p.emitEvent("count", fmt.Sprintf("%d", len(p.history)))
+// GetHistoryCount returns the number of items in the clipboard history
+func (p *ClipboardPlugin) GetHistoryCount() (int, error) {
+	store := p.historyStore()
+	if store == nil {
+		return 0, errNoHistory
+	}
+	return store.Count()
 }
 
-// ClearHistory clears all clipboard history
-func (p *ClipboardPlugin) ClearHistory() {
-	log.Printf("[Clipboard Plugin] ClearHistory called, current history size: %d\n", len(p.history))
-	p.history = make([]ClipboardItem, 0)
-	log.Printf("[Clipboard Plugin] History cleared, new size: %d\n", len(p.history))
-	p.emitEvent("cleared", "")
-	p.emitEvent("count", "0")
+// GetItem returns a clipboard history item by ID. Images are returned in full
+// as data URLs.
+func (p *ClipboardPlugin) GetItem(id int64) (*history.Item, error) {
+	store := p.historyStore()
+	if store == nil {
+		return nil, errNoHistory
+	}
+	item, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	item = p.withImage(store, item)
+	return &item, nil
 }
 
-// GetLastItem returns the most recent clipboard item
-func (p *ClipboardPlugin) GetLastItem() *ClipboardItem {
-	if len(p.history) == 0 {
-		return nil
+// AddToHistory adds an item to the clipboard history. The content of an
+// image is a base64 data URL.
+func (p *ClipboardPlugin) AddToHistory(content, itemType string) error {
+	if itemType == history.TypeImage {
+		mime, data, err := history.DecodeDataURL(content)
+		if err != nil {
+			return err
+		}
+		return p.add(itemType, "", data, mime)
 	}
-	return &p.history[0]
+	return p.add(history.TypeText, content, nil, "")
 }
 
-// SearchHistory searches clipboard history by content
-func (p *ClipboardPlugin) SearchHistory(query string) []ClipboardItem {
-	var results []ClipboardItem
+// add adds text or an image to the history and notifies the frontend
+func (p *ClipboardPlugin) add(itemType, content string, data []byte, mime string) error {
+	store := p.historyStore()
+	if store == nil {
+		return errNoHistory
+	}
 
-	for _, item := range p.history {
-		if contains(item.Content, query) {
-			results = append(results, item)
-		}
+	var (
+		item  history.Item
+		added bool
+		err   error
+	)
+	if itemType == history.TypeImage {
+		item, added, err = store.AddImage(data, mime, time.Now())
+	} else {
+		item, added, err = store.AddText(content, time.Now())
+	}
+	if err != nil {
+		logger().Error("Failed to add to history", "type", itemType, "error", err)
+		return err
 	}
 
-	return results
+	// 不记录剪贴板内容本身，日志可能被导出用于问题反馈
+	logger().Debug("Added to history", "type", itemType, "id", item.ID, "size", item.Size, "new", added)
+	if itemType == history.TypeImage {
+		// 图片可能很大，由前端按需通过 GetHistoryPage / GetItem 读取
+		p.emitEvent("new", "")
+	} else {
+		p.emitEvent("new", content)
+	}
+	p.emitCount(store)
+	return nil
 }
 
-// SetMaxHistory sets the maximum number of history items to keep
-func (p *ClipboardPlugin) SetMaxHistory(max int) {
-	p.maxHistory = max
-
-	// Trim existing history if needed
-	if len(p.history) > max {
-		p.history = p.history[:max]
-		p.emitEvent("count", fmt.Sprintf("%d", len(p.history)))
+func (p *ClipboardPlugin) emitCount(store *history.Store) {
+	count, err := store.Count()
+	if err != nil {
+		logger().Warn("Failed to count history", "error", err)
+		return
 	}
+	p.emitEvent("count", strconv.Itoa(count))
 }
 
-// GetMaxHistory returns the maximum number of history items
-func (p *ClipboardPlugin) GetMaxHistory() int {
-	return p.maxHistory
+// ClearHistory clears all clipboard history
+func (p *ClipboardPlugin) ClearHistory() error {
+	store := p.historyStore()
+	if store == nil {
+		return errNoHistory
+	}
+	logger().Info("Clearing history")
+	if err := store.Clear(); err != nil {
+		return err
+	}
+	p.emitEvent("cleared", "")
+	p.emitEvent("count", "0")
+	return nil
 }
 
-// DeleteItem removes an item from history by index
-func (p *ClipboardPlugin) DeleteItem(index int) error {
-	if index < 0 || index >= len(p.history) {
-		return fmt.Errorf("index out of bounds")
+// GetLastItem returns the most recent clipboard item
+func (p *ClipboardPlugin) GetLastItem() *history.Item {
+	store := p.historyStore()
+	if store == nil {
+		return nil
 	}
+	item, err := store.Latest()
+	if err != nil {
+		return nil
+	}
+	item = p.withImage(store, item)
+	return &item
+}
 
-	p.history = append(p.history[:index], p.history[index+1:]...)
-	p.emitEvent("deleted", fmt.Sprintf("%d", index))
-	p.emitEvent("count", fmt.Sprintf("%d", len(p.history)))
+// DeleteItem removes an item from history by ID
+func (p *ClipboardPlugin) DeleteItem(id int64) error {
+	store := p.historyStore()
+	if store == nil {
+		return errNoHistory
+	}
+	if err := store.Delete(id); err != nil {
+		return err
+	}
+	p.emitEvent("deleted", strconv.FormatInt(id, 10))
+	p.emitCount(store)
 	return nil
 }
 
-// Helper function to check if a string contains a substring (case-insensitive)
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) &&
-		(s == substr ||
-			len(s) > len(substr) && (s[0:len(substr)] == substr ||
-				s[len(s)-len(substr):] == substr ||
-				containsMiddle(s, substr)))
+// GetRetention returns how much history is kept
+func (p *ClipboardPlugin) GetRetention() (history.Retention, error) {
+	store := p.historyStore()
+	if store == nil {
+		return history.Retention{}, errNoHistory
+	}
+	return store.Retention(), nil
 }
 
-func containsMiddle(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+// SetRetention changes how much history is kept and removes the items beyond
+// the new limits. It returns the number of items removed.
+func (p *ClipboardPlugin) SetRetention(retention history.Retention) (int, error) {
+	store := p.historyStore()
+	if store == nil {
+		return 0, errNoHistory
+	}
+	removed, err := store.SetRetention(retention, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if removed > 0 {
+		p.emitEvent("deleted", "")
+		p.emitCount(store)
 	}
-	return false
+	return removed, nil
 }
 
 // GetImageFromClipboard returns the current image from clipboard as base64
@@ -425,7 +491,7 @@ func (p *ClipboardPlugin) SaveImageToFile(base64Data, defaultFilename string) (s
 // GetClipboardContentType returns the type of content in the clipboard
 func (p *ClipboardPlugin) GetClipboardContentType() string {
 	// Check if there's text in clipboard
-	currentClipboard := p.getSystemClipboard()
+	currentClipboard := readText()
 	if currentClipboard != "" {
 		return "text"
 	}
@@ -5,6 +5,7 @@ package clipboard
 import (
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"ltools/internal/plugins"
+	"ltools/plugins/clipboard/history"
 )
 
 const (
@@ -13,13 +14,6 @@ const (
 	PluginVersion = "1.0.0"
 )
 
-// ClipboardItem represents an item in the clipboard history
-type ClipboardItem struct {
-	Content   string `json:"content"`
-	Timestamp string `json:"timestamp"`
-	Type      string `json:"type"` // text, image, etc.
-}
-
 // ClipboardPlugin provides clipboard management functionality (Windows stub)
 type ClipboardPlugin struct {
 	*plugins.BasePlugin
@@ -47,6 +41,11 @@ func NewClipboardPlugin() *ClipboardPlugin {
 	}
 }
 
+// SetDataDir is a no-op on Windows
+func (p *ClipboardPlugin) SetDataDir(dataDir string) error {
+	return nil
+}
+
 // Init initializes the plugin
 func (p *ClipboardPlugin) Init(app *application.App) error {
 	return p.BasePlugin.Init(app)
@@ -74,36 +73,42 @@ func (p *ClipboardPlugin) SetEnabled(enabled bool) error {
 
 // Stub implementations for Windows
 
-func (p *ClipboardPlugin) GetHistory() []ClipboardItem {
-	return []ClipboardItem{}
+func (p *ClipboardPlugin) GetHistoryPage(query history.Query) (history.Page, error) {
+	return history.Page{Items: []history.Item{}}, nil
 }
 
-func (p *ClipboardPlugin) AddToHistory(content, itemType string) {
-	// Not supported on Windows
+func (p *ClipboardPlugin) GetHistoryCount() (int, error) {
+	return 0, nil
+}
+
+func (p *ClipboardPlugin) GetItem(id int64) (*history.Item, error) {
+	return nil, history.ErrNotFound
 }
 
-func (p *ClipboardPlugin) ClearHistory() {
+func (p *ClipboardPlugin) AddToHistory(content, itemType string) error {
 	// Not supported on Windows
+	return nil
 }
 
-func (p *ClipboardPlugin) GetLastItem() *ClipboardItem {
+func (p *ClipboardPlugin) ClearHistory() error {
+	// Not supported on Windows
 	return nil
 }
 
-func (p *ClipboardPlugin) SearchHistory(query string) []ClipboardItem {
-	return []ClipboardItem{}
+func (p *ClipboardPlugin) GetLastItem() *history.Item {
+	return nil
 }
 
-func (p *ClipboardPlugin) SetMaxHistory(max int) {
-	// Not supported on Windows
+func (p *ClipboardPlugin) DeleteItem(id int64) error {
+	return nil
 }
 
-func (p *ClipboardPlugin) GetMaxHistory() int {
-	return 0
+func (p *ClipboardPlugin) GetRetention() (history.Retention, error) {
+	return history.DefaultRetention(), nil
 }
 
-func (p *ClipboardPlugin) DeleteItem(index int) error {
-	return nil
+func (p *ClipboardPlugin) SetRetention(retention history.Retention) (int, error) {
+	return 0, nil
 }
 
 func (p *ClipboardPlugin) GetCurrentClipboard() string {
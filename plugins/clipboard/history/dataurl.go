@@ -0,0 +1,26 @@
+package history
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// DecodeDataURL splits a base64 data URL ("data:image/png;base64,...") into
+// its media type and content.
+func DecodeDataURL(url string) (mime string, data []byte, err error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok || !strings.HasPrefix(url, "data:") || !strings.HasSuffix(header, ";base64") {
+		return "", nil, fmt.Errorf("不是 base64 data URL")
+	}
+	data, err = base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("图片数据无效: %w", err)
+	}
+	return strings.TrimSuffix(header, ";base64"), data, nil
+}
+
+// EncodeDataURL returns data as a base64 data URL.
+func EncodeDataURL(mime string, data []byte) string {
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,134 @@
+package history
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Page sizes of List
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Query selects a page of the history.
+type Query struct {
+	// Search is full-text search: every word must appear, the last one may
+	// be the start of a word. Chinese and Japanese match any substring.
+	Search string `json:"search"`
+	// Type is TypeText or TypeImage, or empty for both.
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"` // 0 为 DefaultPageSize
+}
+
+// Page is a page of the history, most recently copied first.
+type Page struct {
+	Items  []Item `json:"items"`
+	Total  int    `json:"total"` // 符合条件的记录总数
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+// List returns a page of the items matching q.
+func (s *Store) List(q Query) (Page, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	q.Limit = min(q.Limit, MaxPageSize)
+	q.Offset = max(q.Offset, 0)
+
+	var (
+		where []string
+		args  []any
+	)
+	if q.Type != "" {
+		where = append(where, "type = ?")
+		args = append(args, q.Type)
+	}
+	if search := strings.TrimSpace(q.Search); search != "" {
+		if match := ftsQuery(search); match != "" {
+			where = append(where, "id IN (SELECT docid FROM items_fts WHERE items_fts MATCH ?)")
+			args = append(args, match)
+		} else {
+			// 只有标点符号，全文索引不收录
+			where = append(where, `type = ? AND content LIKE ? ESCAPE '\'`)
+			args = append(args, TypeText, "%"+escapeLike(search)+"%")
+		}
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	page := Page{Items: []Item{}, Offset: q.Offset, Limit: q.Limit}
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM items"+cond, args...).Scan(&page.Total); err != nil {
+		return Page{}, err
+	}
+	rows, err := s.db.Query("SELECT "+itemColumns+" FROM items"+cond+" ORDER BY copied_at DESC, id DESC LIMIT ? OFFSET ?",
+		append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return Page{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Items = append(page.Items, item)
+	}
+	return page, rows.Err()
+}
+
+// isCJK reports whether r is written without spaces between words.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// segment puts spaces around CJK characters, so that the unicode61 tokenizer
+// indexes each one as a word and "粘贴" is found in "复制粘贴".
+func segment(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isCJK(r) {
+			b.WriteByte(' ')
+			b.WriteRune(r)
+			b.WriteByte(' ')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tokens splits s the way the unicode61 tokenizer does after segment.
+func tokens(s string) []string {
+	return strings.FieldsFunc(segment(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// ftsQuery turns search words into an FTS MATCH expression. Each word is a
+// phrase of its tokens, so "foo.bar" and "剪贴板" match only in sequence; the
+// last token of the last word is a prefix, for search as you type.
+func ftsQuery(search string) string {
+	words := strings.Fields(search)
+	var phrases []string
+	for i, word := range words {
+		toks := tokens(word)
+		if len(toks) == 0 {
+			continue
+		}
+		phrase := strings.Join(toks, " ")
+		if i == len(words)-1 && !isCJK([]rune(toks[len(toks)-1])[0]) {
+			phrase += "*"
+		}
+		phrases = append(phrases, `"`+phrase+`"`)
+	}
+	return strings.Join(phrases, " ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,118 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Retention limits how much history is kept. Zero fields have no limit.
+type Retention struct {
+	MaxItems   int   `json:"maxItems"`
+	MaxAgeDays int   `json:"maxAgeDays"`
+	MaxBytes   int64 `json:"maxBytes"` // 文本和图片的总大小
+}
+
+// DefaultRetention keeps 1000 items for 30 days, up to 512 MB.
+func DefaultRetention() Retention {
+	return Retention{MaxItems: 1000, MaxAgeDays: 30, MaxBytes: 512 << 20}
+}
+
+// Validate checks the limits.
+func (r Retention) Validate() error {
+	if r.MaxItems < 0 || r.MaxAgeDays < 0 || r.MaxBytes < 0 {
+		return fmt.Errorf("保留限制不能为负数")
+	}
+	return nil
+}
+
+const retentionKey = "retention"
+
+func (s *Store) loadRetention() error {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM settings WHERE key = ?", retentionKey).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var r Retention
+	if err := json.Unmarshal([]byte(value), &r); err != nil {
+		return fmt.Errorf("failed to parse clipboard retention: %w", err)
+	}
+	s.retention = r
+	return nil
+}
+
+// Retention returns the limits of the history.
+func (s *Store) Retention() Retention {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retention
+}
+
+// SetRetention saves the limits and removes the items beyond them. It
+// returns the number of items removed.
+func (s *Store) SetRetention(r Retention, now time.Time) (int, error) {
+	if err := r.Validate(); err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.db.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", retentionKey, string(data)); err != nil {
+		return 0, err
+	}
+	s.retention = r
+	return s.prune(now)
+}
+
+// Prune removes the items beyond the limits and returns how many.
+func (s *Store) Prune(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prune(now)
+}
+
+// prune removes, oldest first, the items older than MaxAgeDays, beyond
+// MaxItems or beyond MaxBytes in total. s.mu must be held.
+func (s *Store) prune(now time.Time) (int, error) {
+	r := s.retention
+	rows, err := s.db.Query("SELECT id, size, copied_at FROM items ORDER BY copied_at DESC, id DESC")
+	if err != nil {
+		return 0, err
+	}
+	var (
+		ids    []int64
+		count  int
+		total  int64
+		cutoff = now.AddDate(0, 0, -r.MaxAgeDays).UnixMilli()
+	)
+	for rows.Next() {
+		var (
+			id, size, copied int64
+		)
+		if err := rows.Scan(&id, &size, &copied); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		count++
+		total += size
+		if (r.MaxItems > 0 && count > r.MaxItems) ||
+			(r.MaxAgeDays > 0 && copied < cutoff) ||
+			(r.MaxBytes > 0 && total > r.MaxBytes) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return s.deleteIDs(ids)
+}
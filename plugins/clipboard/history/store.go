@@ -0,0 +1,442 @@
+// Package history stores the clipboard history in a sqlite database.
+//
+// Text is kept in the database with a full-text index. Images are kept as
+// files in the images directory, named by the SHA-256 of their content, so an
+// image copied twice is stored once. Thumbnails of the images are created on
+// first use in the thumbs directory under the same name. Copying the same text
+// or image again moves the existing item to the top instead of adding another.
+package history
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disintegration/imaging"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Item types
+const (
+	TypeText  = "text"
+	TypeImage = "image"
+)
+
+// Item is one entry of the history.
+type Item struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+	// Content is the text of a text item. For an image it is empty in the
+	// store; the plugin fills in a data URL when the item is shown.
+	Content string `json:"content"`
+	// Thumbnail is a data URL of a small preview of an image, filled in by
+	// the plugin for history pages instead of the full image.
+	Thumbnail string    `json:"thumbnail,omitempty"`
+	Hash      string    `json:"hash"`
+	Mime      string    `json:"mime,omitempty"`
+	Size      int64     `json:"size"`
+	Timestamp time.Time `json:"timestamp"` // 最近一次复制的时间
+	CreatedAt time.Time `json:"createdAt"`
+	CopyCount int       `json:"copyCount"`
+}
+
+// ErrNotFound is returned for an item that is not in the history.
+var ErrNotFound = errors.New("剪贴板记录不存在")
+
+// schemaVersion is stored in PRAGMA user_version.
+const schemaVersion = 1
+
+const schema = `
+CREATE TABLE IF NOT EXISTS items (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	type       TEXT    NOT NULL,
+	hash       TEXT    NOT NULL,
+	content    TEXT    NOT NULL DEFAULT '',
+	mime       TEXT    NOT NULL DEFAULT '',
+	size       INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	copied_at  INTEGER NOT NULL,
+	copy_count INTEGER NOT NULL DEFAULT 1,
+	UNIQUE (type, hash)
+);
+CREATE INDEX IF NOT EXISTS items_copied_at ON items (copied_at);
+CREATE VIRTUAL TABLE IF NOT EXISTS items_fts USING fts4 (body, tokenize=unicode61);
+CREATE TABLE IF NOT EXISTS settings (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// Store is the clipboard history database. It is safe for concurrent use.
+type Store struct {
+	db        *sql.DB
+	imageDir  string
+	thumbDir  string
+	mu        sync.Mutex // 写操作；Pause 期间一直持有
+	retention Retention
+}
+
+// Open opens or creates the history in dir: history.db and the images and
+// thumbs directories.
+func Open(dir string) (*Store, error) {
+	imageDir := filepath.Join(dir, "images")
+	thumbDir := filepath.Join(dir, "thumbs")
+	for _, d := range []string{imageDir, thumbDir} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create clipboard directory: %w", err)
+		}
+	}
+	dsn := "file:" + filepath.Join(dir, "history.db") + "?_journal_mode=WAL&_busy_timeout=5000"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open clipboard history: %w", err)
+	}
+	// 只用一个连接：写操作已由 mu 串行化，也避免 "database is locked"
+	db.SetMaxOpenConns(1)
+
+	s := &Store{db: db, imageDir: imageDir, thumbDir: thumbDir, retention: DefaultRetention()}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.loadRetention(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.removeOrphanImages(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read clipboard history version: %w", err)
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("剪贴板历史由更新的版本创建（%d），请升级", version)
+	}
+	if _, err := s.db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create clipboard history: %w", err)
+	}
+	_, err := s.db.Exec("PRAGMA user_version = " + strconv.Itoa(schemaVersion))
+	return err
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Pause writes the database into its main file and blocks further writes
+// until Resume, so that the directory can be copied. Every Pause must be
+// followed by a Resume.
+func (s *Store) Pause() error {
+	s.mu.Lock()
+	if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("failed to checkpoint clipboard history: %w", err)
+	}
+	return nil
+}
+
+// Resume allows the writes blocked by Pause.
+func (s *Store) Resume() {
+	s.mu.Unlock()
+}
+
+// Hash returns the hash by which the store recognises content copied again.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AddText adds text to the history, or moves it to the top if it is already
+// there. added reports whether a new item was created.
+func (s *Store) AddText(text string, now time.Time) (item Item, added bool, err error) {
+	if text == "" {
+		return Item{}, false, fmt.Errorf("内容为空")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(Item{Type: TypeText, Content: text, Hash: Hash([]byte(text)), Size: int64(len(text))}, nil, now)
+}
+
+// AddImage adds an image to the history, or moves it to the top if the same
+// image is already there.
+func (s *Store) AddImage(data []byte, mime string, now time.Time) (item Item, added bool, err error) {
+	if len(data) == 0 {
+		return Item{}, false, fmt.Errorf("图片为空")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(Item{Type: TypeImage, Hash: Hash(data), Mime: mime, Size: int64(len(data))}, data, now)
+}
+
+func (s *Store) add(item Item, image []byte, now time.Time) (Item, bool, error) {
+	ms := now.UnixMilli()
+	res, err := s.db.Exec("UPDATE items SET copied_at = ?, copy_count = copy_count + 1 WHERE type = ? AND hash = ?",
+		ms, item.Type, item.Hash)
+	if err != nil {
+		return Item{}, false, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		existing, err := s.getBy("type = ? AND hash = ?", item.Type, item.Hash)
+		return existing, false, err
+	}
+
+	// 先写文件再写记录：中途失败只会留下孤立文件，下次打开时清理
+	if image != nil {
+		if err := writeFileAtomic(s.imagePath(item.Hash), image); err != nil {
+			return Item{}, false, fmt.Errorf("failed to save clipboard image: %w", err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return Item{}, false, err
+	}
+	defer tx.Rollback()
+	res, err = tx.Exec(`INSERT INTO items (type, hash, content, mime, size, created_at, copied_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, item.Type, item.Hash, item.Content, item.Mime, item.Size, ms, ms)
+	if err != nil {
+		return Item{}, false, err
+	}
+	item.ID, _ = res.LastInsertId()
+	if item.Type == TypeText {
+		if _, err := tx.Exec("INSERT INTO items_fts (docid, body) VALUES (?, ?)", item.ID, segment(item.Content)); err != nil {
+			return Item{}, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Item{}, false, err
+	}
+
+	if _, err := s.prune(now); err != nil {
+		return Item{}, false, err
+	}
+	item.CreatedAt = time.UnixMilli(ms)
+	item.Timestamp = item.CreatedAt
+	item.CopyCount = 1
+	return item, true, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil // 内容由文件名决定，已存在即相同
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *Store) imagePath(hash string) string {
+	return filepath.Join(s.imageDir, hash)
+}
+
+// ReadImage returns the content of an image item.
+func (s *Store) ReadImage(item Item) ([]byte, error) {
+	if item.Type != TypeImage {
+		return nil, fmt.Errorf("不是图片: %d", item.ID)
+	}
+	return os.ReadFile(s.imagePath(item.Hash))
+}
+
+// thumbnailSize is the longest side of a thumbnail in pixels.
+const thumbnailSize = 240
+
+func (s *Store) thumbPath(hash string) string {
+	return filepath.Join(s.thumbDir, hash)
+}
+
+// ReadThumbnail returns a PNG thumbnail of an image item. It is created from
+// the image the first time and kept until the item is deleted.
+func (s *Store) ReadThumbnail(item Item) ([]byte, error) {
+	if item.Type != TypeImage {
+		return nil, fmt.Errorf("不是图片: %d", item.ID)
+	}
+	path := s.thumbPath(item.Hash)
+	if data, err := os.ReadFile(path); err == nil {
+		return data, nil
+	}
+
+	data, err := os.ReadFile(s.imagePath(item.Hash))
+	if err != nil {
+		return nil, err
+	}
+	img, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode clipboard image: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, imaging.Fit(img, thumbnailSize, thumbnailSize, imaging.Box), imaging.PNG); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to save thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const itemColumns = "id, type, hash, content, mime, size, created_at, copied_at, copy_count"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanItem(row scanner) (Item, error) {
+	var (
+		item            Item
+		created, copied int64
+	)
+	err := row.Scan(&item.ID, &item.Type, &item.Hash, &item.Content, &item.Mime, &item.Size, &created, &copied, &item.CopyCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Item{}, ErrNotFound
+	}
+	item.CreatedAt = time.UnixMilli(created)
+	item.Timestamp = time.UnixMilli(copied)
+	return item, err
+}
+
+func (s *Store) getBy(where string, args ...any) (Item, error) {
+	return scanItem(s.db.QueryRow("SELECT "+itemColumns+" FROM items WHERE "+where, args...))
+}
+
+// Get returns an item by ID.
+func (s *Store) Get(id int64) (Item, error) {
+	return s.getBy("id = ?", id)
+}
+
+// Latest returns the most recently copied item.
+func (s *Store) Latest() (Item, error) {
+	return s.getBy("1 ORDER BY copied_at DESC, id DESC LIMIT 1")
+}
+
+// Count returns the number of items.
+func (s *Store) Count() (int, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM items").Scan(&n)
+	return n, err
+}
+
+// Delete removes an item.
+func (s *Store) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, err := s.deleteIDs([]int64{id})
+	if err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Clear removes every item.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{"DELETE FROM items", "DELETE FROM items_fts"} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return s.removeOrphanImages()
+}
+
+// deleteIDs removes items and their images. s.mu must be held.
+func (s *Store) deleteIDs(ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := s.db.Query("SELECT hash FROM items WHERE type = '"+TypeImage+"' AND id IN ("+placeholders+")", args...)
+	if err != nil {
+		return 0, err
+	}
+	var images []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		images = append(images, hash)
+	}
+	rows.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("DELETE FROM items WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM items_fts WHERE docid IN ("+placeholders+")", args...); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	for _, hash := range images {
+		for _, path := range []string{s.imagePath(hash), s.thumbPath(hash)} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return 0, err
+			}
+		}
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// removeOrphanImages deletes image files and thumbnails that no item refers
+// to, left by a crash between writing the file and the item.
+func (s *Store) removeOrphanImages() error {
+	for _, dir := range []string{s.imageDir, s.thumbDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			var n int
+			name := strings.TrimSuffix(e.Name(), ".tmp")
+			if name == e.Name() {
+				if err := s.db.QueryRow("SELECT COUNT(*) FROM items WHERE type = ? AND hash = ?", TypeImage, name).Scan(&n); err != nil {
+					return err
+				}
+			}
+			if n == 0 {
+				if err := os.Remove(filepath.Join(dir, e.Name())); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package history
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	tick := func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	for _, text := range []string{"hello world", "复制粘贴的内容", "https://example.com/path", "hello again"} {
+		if _, added, err := s.AddText(text, tick()); err != nil || !added {
+			t.Fatalf("AddText(%q) = %v, %v", text, added, err)
+		}
+	}
+	png := []byte("\x89PNG fake image")
+	img, added, err := s.AddImage(png, "image/png", tick())
+	if err != nil || !added {
+		t.Fatalf("AddImage = %v, %v", added, err)
+	}
+
+	// 重复复制：不新增，移到最前
+	again, added, err := s.AddText("hello world", tick())
+	if err != nil || added || again.CopyCount != 2 {
+		t.Fatalf("AddText again = %+v, %v, %v", again, added, err)
+	}
+	if _, added, _ := s.AddImage(png, "image/png", tick()); added {
+		t.Error("same image added twice")
+	}
+	if latest, _ := s.Latest(); latest.Type != TypeImage {
+		t.Errorf("Latest = %+v, want the image", latest)
+	}
+	if n, _ := s.Count(); n != 5 {
+		t.Errorf("Count = %d, want 5", n)
+	}
+
+	search := []struct {
+		query string
+		want  int
+	}{
+		{"hello", 2},
+		{"hel", 2},
+		{"hello wor", 1},
+		{"粘贴", 1},
+		{"贴复", 0},
+		{"example.com", 1},
+		{"://", 1},
+		{"missing", 0},
+	}
+	for _, tt := range search {
+		page, err := s.List(Query{Search: tt.query})
+		if err != nil {
+			t.Fatalf("List(%q): %v", tt.query, err)
+		}
+		if page.Total != tt.want || len(page.Items) != tt.want {
+			t.Errorf("List(%q) = %d items, total %d, want %d", tt.query, len(page.Items), page.Total, tt.want)
+		}
+	}
+
+	page, err := s.List(Query{Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 5 || len(page.Items) != 2 || page.Items[0].Content != "hello world" {
+		t.Errorf("List page = %+v", page)
+	}
+	if page, _ := s.List(Query{Type: TypeImage}); page.Total != 1 {
+		t.Errorf("images = %d, want 1", page.Total)
+	}
+
+	data, err := s.ReadImage(img)
+	if err != nil || string(data) != string(png) {
+		t.Errorf("ReadImage = %q, %v", data, err)
+	}
+	if err := s.Delete(img.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "images", img.Hash)); !os.IsNotExist(err) {
+		t.Errorf("image file not removed: %v", err)
+	}
+	if err := s.Delete(img.ID); err != ErrNotFound {
+		t.Errorf("Delete twice = %v, want ErrNotFound", err)
+	}
+	if page, _ := s.List(Query{Search: "hello"}); page.Total != 2 {
+		t.Errorf("search after delete = %d, want 2", page.Total)
+	}
+
+	// 重新打开后记录仍在
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if n, _ := s.Count(); n != 4 {
+		t.Errorf("Count after reopen = %d, want 4", n)
+	}
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if page, _ := s.List(Query{Search: "hello"}); page.Total != 0 {
+		t.Errorf("search after clear = %d, want 0", page.Total)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.SetRetention(Retention{}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 10 {
+		if _, _, err := s.AddText(string(rune('a'+i))+"0123456789", start.AddDate(0, 0, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range 3 {
+		if _, _, err := s.AddImage([]byte{byte(i), 1, 2, 3}, "image/png", start.AddDate(0, 0, 10+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := start.AddDate(0, 0, 12)
+
+	tests := []struct {
+		name      string
+		retention Retention
+		removed   int
+		oldest    string // 保留下来的最早文本
+	}{
+		{"age", Retention{MaxAgeDays: 10}, 2, "c0123456789"},
+		{"count", Retention{MaxItems: 9}, 2, "e0123456789"},
+		{"bytes", Retention{MaxBytes: 3*4 + 3*11}, 3, "h0123456789"},
+		{"none", Retention{}, 0, "h0123456789"},
+	}
+	for _, tt := range tests {
+		removed, err := s.SetRetention(tt.retention, now)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		page, _ := s.List(Query{Type: TypeText, Limit: MaxPageSize})
+		oldest := page.Items[len(page.Items)-1].Content
+		if removed != tt.removed || oldest != tt.oldest {
+			t.Errorf("%s: removed %d, oldest %q; want %d, %q", tt.name, removed, oldest, tt.removed, tt.oldest)
+		}
+	}
+
+	// 保留设置持久化
+	s.Close()
+	if s, err = Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Retention(); got != (Retention{}) {
+		t.Errorf("Retention after reopen = %+v", got)
+	}
+	if _, err := s.SetRetention(Retention{MaxItems: -1}, now); err == nil {
+		t.Error("negative retention accepted")
+	}
+	if _, err := s.SetRetention(Retention{MaxItems: 1}, now); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "images"))
+	if len(entries) != 1 {
+		t.Errorf("%d image files left, want 1", len(entries))
+	}
+}
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct {
+		search, want string
+	}{
+		{"hello", `"hello*"`},
+		{"hello wor", `"hello" "wor*"`},
+		{"剪贴板", `"剪 贴 板"`},
+		{"foo.bar", `"foo bar*"`},
+		{"::", ""},
+	}
+	for _, tt := range tests {
+		if got := ftsQuery(tt.search); got != tt.want {
+			t.Errorf("ftsQuery(%q) = %s, want %s", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestDataURL(t *testing.T) {
+	url := EncodeDataURL("image/png", []byte("png"))
+	if url != "data:image/png;base64,cG5n" {
+		t.Errorf("EncodeDataURL = %s", url)
+	}
+	mime, data, err := DecodeDataURL(url)
+	if err != nil || mime != "image/png" || string(data) != "png" {
+		t.Errorf("DecodeDataURL = %s, %q, %v", mime, data, err)
+	}
+	for _, bad := range []string{"", "cG5n", "data:image/png,cG5n", "data:image/png;base64,!!"} {
+		if _, _, err := DecodeDataURL(bad); err == nil {
+			t.Errorf("DecodeDataURL(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1000, 500))); err != nil {
+		t.Fatal(err)
+	}
+	item, _, err := s.AddImage(buf.Bytes(), "image/png", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.ReadThumbnail(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width != thumbnailSize || cfg.Height != thumbnailSize/2 {
+		t.Fatalf("thumbnail = %dx%d, %v", cfg.Width, cfg.Height, err)
+	}
+
+	// 缩略图随记录一起删除
+	if err := s.Delete(item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "thumbs", item.Hash)); !os.IsNotExist(err) {
+		t.Errorf("thumbnail left behind: %v", err)
+	}
+
+	bad, _, err := s.AddImage([]byte("not an image"), "image/png", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ReadThumbnail(bad); err == nil {
+		t.Error("ReadThumbnail succeeded for an invalid image")
+	}
+}
@@ -0,0 +1,36 @@
+package clipboard
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	clipboardpkg "ltools/internal/plugins/clipboard"
+	"ltools/plugins/clipboard/watch"
+)
+
+// watchSources polls the pasteboard change count: macOS has no clipboard
+// change notification, but the count is cheap to read and the clipboard
+// itself is only read after it changes.
+func (p *ClipboardPlugin) watchSources() []watch.Source {
+	return []watch.Source{
+		watch.Poll{
+			Interval: 500 * time.Millisecond,
+			Probe: func() (string, error) {
+				return strconv.FormatInt(clipboardpkg.ChangeCount(), 10), nil
+			},
+		},
+	}
+}
+
+// readText gets the text on the clipboard
+func readText() string {
+	// Use osascript to get clipboard on macOS
+	output, err := exec.Command("osascript", "-e", "get the clipboard").Output()
+	if err != nil {
+		// Silently fail - clipboard might be empty or not accessible
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,94 @@
+//go:build !windows && !darwin
+
+package clipboard
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"ltools/plugins/clipboard/history"
+	"ltools/plugins/clipboard/watch"
+)
+
+// watchSources prefers change notifications: wl-paste --watch on Wayland,
+// XFixes on X11 (including XWayland), and falls back to polling.
+func (p *ClipboardPlugin) watchSources() []watch.Source {
+	var sources []watch.Source
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if path, err := exec.LookPath("wl-paste"); err == nil {
+			sources = append(sources, watch.WlPaste{Path: path})
+		}
+	}
+	if os.Getenv("DISPLAY") != "" {
+		sources = append(sources, watch.XFixes{})
+	}
+	return append(sources, watch.Poll{Interval: 500 * time.Millisecond, Probe: probeClipboard})
+}
+
+// probeClipboard returns a cheap signal of clipboard changes: the formats on
+// the clipboard and, on X11, the time the owner took the selection. Neither
+// X11 nor Wayland has a sequence number; the text is only read when the owner
+// gives no timestamp, and the content when the signal changes.
+func probeClipboard() (string, error) {
+	types, err := firstOutput(
+		wayland("wl-paste", "--list-types"),
+		x11("xclip", "-selection", "clipboard", "-o", "-t", "TARGETS"),
+	)
+	if err != nil {
+		return "", err
+	}
+	stamp, err := firstOutput(x11("xclip", "-selection", "clipboard", "-o", "-t", "TIMESTAMP"))
+	if err != nil || len(stamp) == 0 {
+		stamp = []byte(readText())
+	}
+	return history.Hash(append(types, stamp...)), nil
+}
+
+// readText gets the text on the clipboard with wl-paste, xclip or xsel
+func readText() string {
+	output, err := firstOutput(
+		wayland("wl-paste", "--no-newline", "--type", "text"),
+		x11("xclip", "-selection", "clipboard", "-o", "-t", "UTF8_STRING"),
+		x11("xsel", "--clipboard", "--output"),
+	)
+	if err != nil {
+		return ""
+	}
+	return string(output)
+}
+
+// wayland returns the command in a Wayland session, nil otherwise
+func wayland(args ...string) []string {
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		return nil
+	}
+	return args
+}
+
+// x11 returns the command when an X server is available, nil otherwise
+func x11(args ...string) []string {
+	if os.Getenv("DISPLAY") == "" {
+		return nil
+	}
+	return args
+}
+
+// firstOutput runs the installed commands in turn and returns the output of
+// the first that succeeds.
+func firstOutput(commands ...[]string) ([]byte, error) {
+	err := exec.ErrNotFound
+	for _, args := range commands {
+		if args == nil {
+			continue
+		}
+		if _, lookErr := exec.LookPath(args[0]); lookErr != nil {
+			continue
+		}
+		var output []byte
+		if output, err = exec.Command(args[0], args[1:]...).Output(); err == nil {
+			return output, nil
+		}
+	}
+	return nil, err
+}
@@ -2,6 +2,7 @@ package clipboard
 
 import (
 	"github.com/wailsapp/wails/v3/pkg/application"
+	"ltools/plugins/clipboard/history"
 )
 
 // ClipboardService exposes Clipboard functionality to the frontend
@@ -28,44 +29,51 @@ func (s *ClipboardService) ServiceShutdown(app *application.App) error {
 	return s.plugin.ServiceShutdown(app)
 }
 
-// GetHistory returns the clipboard history
-func (s *ClipboardService) GetHistory() []ClipboardItem {
-	return s.plugin.GetHistory()
+// GetHistoryPage returns a page of the clipboard history, filtered by a
+// full-text search and item type
+func (s *ClipboardService) GetHistoryPage(query history.Query) (history.Page, error) {
+	return s.plugin.GetHistoryPage(query)
+}
+
+// GetHistoryCount returns the number of items in the clipboard history
+func (s *ClipboardService) GetHistoryCount() (int, error) {
+	return s.plugin.GetHistoryCount()
+}
+
+// GetItem returns a clipboard history item by ID
+func (s *ClipboardService) GetItem(id int64) (*history.Item, error) {
+	return s.plugin.GetItem(id)
 }
 
 // AddToHistory adds an item to the clipboard history
-func (s *ClipboardService) AddToHistory(content, itemType string) {
-	s.plugin.AddToHistory(content, itemType)
+func (s *ClipboardService) AddToHistory(content, itemType string) error {
+	return s.plugin.AddToHistory(content, itemType)
 }
 
 // ClearHistory clears all clipboard history
-func (s *ClipboardService) ClearHistory() {
-	s.plugin.ClearHistory()
+func (s *ClipboardService) ClearHistory() error {
+	return s.plugin.ClearHistory()
 }
 
 // GetLastItem returns the most recent clipboard item
-func (s *ClipboardService) GetLastItem() *ClipboardItem {
+func (s *ClipboardService) GetLastItem() *history.Item {
 	return s.plugin.GetLastItem()
 }
 
-// SearchHistory searches clipboard history by content
-func (s *ClipboardService) SearchHistory(query string) []ClipboardItem {
-	return s.plugin.SearchHistory(query)
-}
-
-// SetMaxHistory sets the maximum number of history items to keep
-func (s *ClipboardService) SetMaxHistory(max int) {
-	s.plugin.SetMaxHistory(max)
+// DeleteItem removes an item from history by ID
+func (s *ClipboardService) DeleteItem(id int64) error {
+	return s.plugin.DeleteItem(id)
 }
 
-// GetMaxHistory returns the maximum number of history items
-func (s *ClipboardService) GetMaxHistory() int {
-	return s.plugin.GetMaxHistory()
+// GetRetention returns the count, age and size limits of the history
+func (s *ClipboardService) GetRetention() (history.Retention, error) {
+	return s.plugin.GetRetention()
 }
 
-// DeleteItem removes an item from history by index
-func (s *ClipboardService) DeleteItem(index int) error {
-	return s.plugin.DeleteItem(index)
+// SetRetention changes the limits of the history and returns the number of
+// items removed
+func (s *ClipboardService) SetRetention(retention history.Retention) (int, error) {
+	return s.plugin.SetRetention(retention)
 }
 
 // GetCurrentClipboard returns the current system clipboard content
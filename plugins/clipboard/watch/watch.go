@@ -0,0 +1,85 @@
+// Package watch tells when the clipboard changes, so that its content is
+// only read after a change.
+//
+// Change notifications are used where the platform has them: XFixes selection
+// events on X11 and wl-paste --watch on Wayland. Otherwise Poll compares a
+// cheap token, such as the clipboard sequence number, at an interval.
+package watch
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Source notifies about clipboard changes.
+type Source interface {
+	// Name describes the mechanism, for logs.
+	Name() string
+
+	// Watch calls notify whenever the clipboard may have changed, until stop
+	// is closed. It returns nil when stopped, or an error when the mechanism
+	// is not available or fails, so that the next source can take over.
+	Watch(stop <-chan struct{}, notify func()) error
+}
+
+// Run watches with the first source that works and moves on to the next when
+// it fails. The last one is normally a Poll, which does not fail. Run returns
+// when stop is closed or every source has failed.
+func Run(stop <-chan struct{}, notify func(), logger *slog.Logger, sources ...Source) {
+	for i, source := range sources {
+		logger.Info("Watching clipboard", "source", source.Name())
+		err := source.Watch(stop, notify)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if i < len(sources)-1 {
+			logger.Warn("Clipboard watch failed, falling back", "source", source.Name(), "next", sources[i+1].Name(), "error", err)
+		} else {
+			logger.Error("Clipboard watch failed", "source", source.Name(), "error", err)
+		}
+		// 切换期间可能错过了变化
+		notify()
+	}
+}
+
+// Poll is the fallback Source. It calls Probe at every Interval and notifies
+// when the token it returns changes. Probe should not read the clipboard
+// content: a sequence number, or a hash of the formats on offer.
+type Poll struct {
+	Interval time.Duration
+	Probe    func() (string, error)
+}
+
+// Name implements Source.
+func (p Poll) Name() string {
+	return "poll " + p.Interval.String()
+}
+
+// Watch implements Source. Probe errors count as a change of token, so
+// the clipboard is read once more after it becomes readable again.
+func (p Poll) Watch(stop <-chan struct{}, notify func()) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	last, err := p.Probe()
+	if err != nil {
+		last = ""
+	}
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			token, err := p.Probe()
+			if err != nil {
+				token = ""
+			}
+			if token != last {
+				last = token
+				notify()
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package watch
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	var token, probes, notified atomic.Int64
+	poll := Poll{
+		Interval: time.Millisecond,
+		Probe: func() (string, error) {
+			probes.Add(1)
+			if token.Load() < 0 {
+				return "", errors.New("clipboard busy")
+			}
+			return string(rune('0' + token.Load())), nil
+		},
+	}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- poll.Watch(stop, func() { notified.Add(1) }) }()
+
+	// 令牌不变时不通知
+	waitFor(t, "probes", func() bool { return probes.Load() > 10 })
+	if n := notified.Load(); n != 0 {
+		t.Errorf("notified %d times without a change", n)
+	}
+	token.Store(1)
+	waitFor(t, "change", func() bool { return notified.Load() == 1 })
+	token.Store(-1)
+	waitFor(t, "error", func() bool { return notified.Load() == 2 })
+	token.Store(1)
+	waitFor(t, "recovery", func() bool { return notified.Load() == 3 })
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("Watch = %v", err)
+	}
+}
+
+type failing struct{ calls *atomic.Int64 }
+
+func (f failing) Name() string { return "failing" }
+
+func (f failing) Watch(stop <-chan struct{}, notify func()) error {
+	f.calls.Add(1)
+	return errors.New("not available")
+}
+
+func TestRunFallback(t *testing.T) {
+	var calls, notified atomic.Int64
+	var token atomic.Int64
+	poll := Poll{Interval: time.Millisecond, Probe: func() (string, error) {
+		return string(rune('0' + token.Load())), nil
+	}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		Run(stop, func() { notified.Add(1) }, logger, failing{&calls}, failing{&calls}, poll)
+		close(done)
+	}()
+
+	// 每次回退都补一次通知
+	waitFor(t, "fallback", func() bool { return notified.Load() == 2 })
+	token.Store(1)
+	waitFor(t, "poll", func() bool { return notified.Load() == 3 })
+	close(stop)
+	<-done
+	if calls.Load() != 2 {
+		t.Errorf("failing sources called %d times, want 2", calls.Load())
+	}
+}
+
+func TestWlPaste(t *testing.T) {
+	// 模拟 wl-paste --watch：每行输入对应一次剪贴板变化
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events")
+	if err := os.WriteFile(events, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "wl-paste")
+	err := os.WriteFile(script, []byte("#!/bin/sh\n"+
+		"[ \"$1\" = --watch ] || exit 2\n"+
+		"while read line; do echo \"$line\" | \"$2\" \"$3\" \"$4\"; done < "+events+"\n"+
+		"exec sleep 60\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notified atomic.Int64
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- WlPaste{Path: script}.Watch(stop, func() { notified.Add(1) }) }()
+	waitFor(t, "notifications", func() bool { return notified.Load() == 3 })
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("Watch = %v", err)
+	}
+
+	// 合成器不支持时 wl-paste 立即退出
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'Watch mode requires a compositor that supports the data-control protocol' >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err = WlPaste{Path: script}.Watch(make(chan struct{}), func() {})
+	if err == nil || !strings.Contains(err.Error(), "data-control") {
+		t.Errorf("Watch = %v, want the wl-paste error", err)
+	}
+}
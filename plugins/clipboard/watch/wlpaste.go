@@ -0,0 +1,71 @@
+package watch
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// WlPaste watches the Wayland clipboard with wl-paste --watch, which needs a
+// compositor with the data-control protocol (wlroots, KDE). On others, such
+// as GNOME, wl-paste exits at once and Watch returns its error.
+type WlPaste struct {
+	// Path of wl-paste, found in PATH if empty.
+	Path string
+}
+
+// Name implements Source.
+func (w WlPaste) Name() string {
+	return "wl-paste --watch"
+}
+
+// Watch implements Source.
+func (w WlPaste) Watch(stop <-chan struct{}, notify func()) error {
+	path := w.Path
+	if path == "" {
+		var err error
+		if path, err = exec.LookPath("wl-paste"); err != nil {
+			return err
+		}
+	}
+
+	// wl-paste 每次变化都运行一次命令，并把内容写到它的标准输入：
+	// 读完丢弃后输出一行作为通知，内容本身不经过本进程
+	cmd := exec.Command(path, "--watch", "sh", "-c", "cat >/dev/null; echo")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		notify()
+	}
+	err = cmd.Wait()
+	select {
+	case <-stop:
+		return nil
+	default:
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Errorf("wl-paste exited: %v: %s", err, msg)
+	}
+	return fmt.Errorf("wl-paste exited: %v", err)
+}
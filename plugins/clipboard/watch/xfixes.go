@@ -0,0 +1,81 @@
+//go:build !windows && !darwin
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xfixes"
+	"github.com/jezek/xgb/xproto"
+)
+
+// XFixes watches the CLIPBOARD selection of an X server with XFixes
+// selection events, which are sent when another client takes ownership of
+// the selection, i.e. on every copy. Under XWayland the compositor passes
+// Wayland copies on to X as well.
+type XFixes struct {
+	// Display to connect to, $DISPLAY if empty.
+	Display string
+}
+
+// Name implements Source.
+func (x XFixes) Name() string {
+	return "XFixes"
+}
+
+// Watch implements Source.
+func (x XFixes) Watch(stop <-chan struct{}, notify func()) error {
+	conn, err := xgb.NewConnDisplay(x.Display)
+	if err != nil {
+		return fmt.Errorf("failed to connect to X server: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// 关闭连接以结束 WaitForEvent
+		select {
+		case <-stop:
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	if err := xfixes.Init(conn); err != nil {
+		return fmt.Errorf("XFixes not available: %w", err)
+	}
+	// 必须先协商版本，否则服务器不接受 XFixes 请求
+	if _, err := xfixes.QueryVersion(conn, 5, 0).Reply(); err != nil {
+		return fmt.Errorf("XFixes not available: %w", err)
+	}
+
+	const name = "CLIPBOARD"
+	atom, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return fmt.Errorf("failed to intern %s: %w", name, err)
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	mask := uint32(xfixes.SelectionEventMaskSetSelectionOwner |
+		xfixes.SelectionEventMaskSelectionWindowDestroy |
+		xfixes.SelectionEventMaskSelectionClientClose)
+	if err := xfixes.SelectSelectionInputChecked(conn, root, atom.Atom, mask).Check(); err != nil {
+		return fmt.Errorf("failed to select selection events: %w", err)
+	}
+
+	for {
+		ev, xerr := conn.WaitForEvent()
+		if ev == nil && xerr == nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+				return errors.New("X connection closed")
+			}
+		}
+		if _, ok := ev.(xfixes.SelectionNotifyEvent); ok {
+			notify()
+		}
+	}
+}
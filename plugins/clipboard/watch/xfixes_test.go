@@ -0,0 +1,13 @@
+//go:build !windows && !darwin
+
+package watch
+
+import "testing"
+
+func TestXFixesWithoutServer(t *testing.T) {
+	// 没有 X 服务器时返回错误，由 Run 回退到下一个来源
+	err := XFixes{Display: ":987"}.Watch(make(chan struct{}), func() {})
+	if err == nil {
+		t.Error("Watch succeeded without an X server")
+	}
+}
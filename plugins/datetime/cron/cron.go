@@ -0,0 +1,585 @@
+// Package cron parses cron expressions, computes when they fire and explains
+// them in words.
+//
+// Two dialects are understood:
+//
+//   - Unix: five fields (minute, hour, day of month, month, day of week), or
+//     six with seconds first. Days of the week are 0-7, where 0 and 7 are
+//     Sunday. If both the day of month and the day of week are restricted, a
+//     day matching either one fires, as in Vixie cron.
+//   - Quartz: six or seven fields (second, minute, hour, day of month, month,
+//     day of week, optional year). Days of the week are 1-7 for Sunday to
+//     Saturday, exactly one of the day fields is "?", and the day fields may
+//     use L (last), W (nearest weekday) and # (nth weekday of the month).
+//
+// Both accept lists, ranges, steps and English names, the macros @yearly,
+// @monthly, @weekly, @daily, @hourly and "@every <duration>", and a
+// "CRON_TZ=<zone>" or "TZ=<zone>" prefix that sets the time zone.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ltools/internal/timezone"
+)
+
+// Schedule says when a job fires.
+type Schedule interface {
+	// Next returns the first time after t that the schedule fires, in the
+	// location of t (or of the expression's time zone), or the zero time if
+	// it never fires again.
+	Next(t time.Time) time.Time
+}
+
+// Dialect selects how an expression is read.
+type Dialect string
+
+const (
+	// DialectAuto reads five fields as Unix and seven as Quartz. Six fields
+	// are Quartz if they use "?", L, W or #, and Unix with seconds otherwise.
+	DialectAuto   Dialect = ""
+	DialectUnix   Dialect = "unix"
+	DialectQuartz Dialect = "quartz"
+)
+
+// searchYears bounds the search for the next time, so that expressions such
+// as "0 0 30 2 *" (February 30) end instead of looping.
+const searchYears = 5
+
+// Quartz 的年份字段范围
+const (
+	minYear = 1970
+	maxYear = 2099
+)
+
+// field describes one field of an expression.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = field{name: "秒", min: 0, max: 59}
+	minuteField = field{name: "分钟", min: 0, max: 59}
+	hourField   = field{name: "小时", min: 0, max: 23}
+	domField    = field{name: "日", min: 1, max: 31}
+	monthField  = field{name: "月", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Unix 的星期中 0 和 7 都是周日
+	dowField = field{name: "星期", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+	// Quartz 的星期从周日 1 到周六 7
+	quartzDowField = field{name: "星期", min: 1, max: 7, names: map[string]int{
+		"sun": 1, "mon": 2, "tue": 3, "wed": 4, "thu": 5, "fri": 6, "sat": 7,
+	}}
+	yearField = field{name: "年", min: minYear, max: maxYear}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression in either dialect.
+func Parse(spec string) (Schedule, error) {
+	return ParseDialect(spec, DialectAuto)
+}
+
+// ParseDialect parses a cron expression in the given dialect.
+func ParseDialect(spec string, dialect Dialect) (Schedule, error) {
+	return parse(spec, dialect)
+}
+
+func parse(spec string, dialect Dialect) (Schedule, error) {
+	switch dialect {
+	case DialectAuto, DialectUnix, DialectQuartz:
+	default:
+		return nil, fmt.Errorf("未知的 cron 方言: %s", dialect)
+	}
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("cron 表达式为空")
+	}
+
+	var loc *time.Location
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(spec, prefix) {
+			zone, rest, _ := strings.Cut(spec[len(prefix):], " ")
+			var err error
+			if loc, err = timezone.Lookup(zone); err != nil {
+				return nil, err
+			}
+			spec = strings.TrimSpace(rest)
+			break
+		}
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("无效的间隔: %w", err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("间隔不能小于 1 秒")
+		}
+		return every{d: d, loc: loc}, nil
+	}
+	if expanded, ok := macros[strings.ToLower(spec)]; ok {
+		spec = expanded
+		dialect = DialectUnix
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("未知的宏: %s", spec)
+	}
+
+	parts := strings.Fields(spec)
+	if dialect == DialectAuto {
+		dialect = detect(parts)
+	}
+	s := &spec6{loc: loc, dialect: dialect}
+	var err error
+	if dialect == DialectQuartz {
+		err = s.parseQuartz(parts)
+	} else {
+		err = s.parseUnix(parts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// detect picks the dialect of an expression by its fields.
+func detect(parts []string) Dialect {
+	switch len(parts) {
+	case 7:
+		return DialectQuartz
+	case 6:
+		if strings.ContainsAny(parts[3]+parts[5], "?LW#") {
+			return DialectQuartz
+		}
+	}
+	return DialectUnix
+}
+
+// spec6 is an expression as bit sets of the allowed values, with the
+// special day rules of Quartz.
+type spec6 struct {
+	second, minute, hour, dom, month, dow uint64
+	years                                 []bool // years[y-minYear]；nil 表示每年
+	domStar, dowStar                      bool
+
+	lastDay        bool // L，或带 lastOffset 的 L-n
+	lastOffset     int
+	lastWeekday    bool   // LW
+	nearestWeekday int    // nW，0 表示没有
+	lastDow        uint64 // 5L：这些星期在本月的最后一次
+	nthDow         []nth  // 6#3
+
+	loc     *time.Location
+	dialect Dialect
+	fields  []string // 秒 分 时 日 月 星期 [年]，五字段的表达式补上秒 0
+	seconds bool     // 是否写了秒字段
+}
+
+// nth is the nth occurrence of a weekday in the month.
+type nth struct {
+	weekday time.Weekday
+	n       int
+}
+
+func (s *spec6) parseUnix(parts []string) error {
+	switch len(parts) {
+	case 5:
+		parts = append([]string{"0"}, parts...)
+	case 6:
+		s.seconds = true
+	default:
+		return fmt.Errorf("cron 表达式需要 5 或 6 个字段，实际为 %d 个", len(parts))
+	}
+	for _, p := range parts {
+		if strings.ContainsAny(p, "?LW#") && !hasName(p) {
+			return fmt.Errorf("?、L、W 和 # 只能用于 Quartz 表达式: %q", p)
+		}
+	}
+	s.fields = parts
+	if err := s.parseTime(parts); err != nil {
+		return err
+	}
+	var err error
+	if s.dom, err = parseField(parts[3], domField); err != nil {
+		return err
+	}
+	if s.month, err = parseField(parts[4], monthField); err != nil {
+		return err
+	}
+	if s.dow, err = parseField(parts[5], dowField); err != nil {
+		return err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 是周日
+	}
+	s.domStar = strings.HasPrefix(parts[3], "*")
+	s.dowStar = strings.HasPrefix(parts[5], "*")
+	return nil
+}
+
+// hasName reports whether a field uses an English name, which may contain
+// the letters L and W (JUL, WED).
+func hasName(p string) bool {
+	lower := strings.ToLower(p)
+	for _, names := range []map[string]int{monthField.names, dowField.names} {
+		for name := range names {
+			if strings.Contains(lower, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *spec6) parseQuartz(parts []string) error {
+	if len(parts) != 6 && len(parts) != 7 {
+		return fmt.Errorf("Quartz 表达式需要 6 或 7 个字段，实际为 %d 个", len(parts))
+	}
+	s.fields, s.seconds = parts, true
+	if err := s.parseTime(parts); err != nil {
+		return err
+	}
+	var err error
+	if s.month, err = parseField(parts[4], monthField); err != nil {
+		return err
+	}
+	if len(parts) == 7 && parts[6] != "*" {
+		bits, err := parseYears(parts[6])
+		if err != nil {
+			return err
+		}
+		s.years = bits
+	}
+
+	dom, dow := strings.ToUpper(parts[3]), strings.ToUpper(parts[5])
+	if (dom == "?") == (dow == "?") {
+		return fmt.Errorf("Quartz 表达式的日和星期字段必须有且只有一个为 ?")
+	}
+	s.domStar = dom == "?" || dom == "*"
+	s.dowStar = dow == "?" || dow == "*"
+	if err := s.parseQuartzDom(dom); err != nil {
+		return err
+	}
+	return s.parseQuartzDow(dow)
+}
+
+func (s *spec6) parseTime(parts []string) error {
+	var err error
+	if s.second, err = parseField(parts[0], secondField); err != nil {
+		return err
+	}
+	if s.minute, err = parseField(parts[1], minuteField); err != nil {
+		return err
+	}
+	s.hour, err = parseField(parts[2], hourField)
+	return err
+}
+
+func (s *spec6) parseQuartzDom(dom string) error {
+	switch {
+	case dom == "?":
+		s.dom = fieldBits(domField)
+	case dom == "L":
+		s.lastDay = true
+	case strings.HasPrefix(dom, "L-"):
+		n, err := strconv.Atoi(dom[2:])
+		if err != nil || n < 1 || n > 30 {
+			return fmt.Errorf("日字段的值无效: %q", dom)
+		}
+		s.lastDay, s.lastOffset = true, n
+	case dom == "LW":
+		s.lastWeekday = true
+	case strings.HasSuffix(dom, "W"):
+		n, err := domField.value(strings.TrimSuffix(dom, "W"))
+		if err != nil {
+			return err
+		}
+		s.nearestWeekday = n
+	default:
+		var err error
+		s.dom, err = parseField(dom, domField)
+		return err
+	}
+	return nil
+}
+
+func (s *spec6) parseQuartzDow(dow string) error {
+	switch {
+	case dow == "?":
+		s.dow = fieldBits(dowField)
+	case strings.Contains(dow, "#"):
+		day, n, _ := strings.Cut(dow, "#")
+		v, err := quartzDowField.value(day)
+		if err != nil {
+			return err
+		}
+		k, err := strconv.Atoi(n)
+		if err != nil || k < 1 || k > 5 {
+			return fmt.Errorf("星期字段中 # 之后应为 1 到 5: %q", dow)
+		}
+		s.nthDow = append(s.nthDow, nth{weekday: time.Weekday(v - 1), n: k})
+	case dow == "L":
+		s.dow = 1 << uint(time.Saturday) // 单独的 L 是周六
+	case strings.HasSuffix(dow, "L"):
+		v, err := quartzDowField.value(strings.TrimSuffix(dow, "L"))
+		if err != nil {
+			return err
+		}
+		s.lastDow = 1 << uint(v-1)
+	default:
+		bits, err := parseField(dow, quartzDowField)
+		if err != nil {
+			return err
+		}
+		s.dow = bits >> 1 // 1-7 → 0-6
+	}
+	return nil
+}
+
+// parseField parses a comma-separated list of *, n, a-b, with optional /step.
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		lo, hi, step, err := parseRange(part, f)
+		if err != nil {
+			return 0, err
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseYears parses the year field of Quartz, whose values do not fit in a
+// bit set of 64.
+func parseYears(expr string) ([]bool, error) {
+	years := make([]bool, maxYear-minYear+1)
+	for _, part := range strings.Split(expr, ",") {
+		lo, hi, step, err := parseRange(part, yearField)
+		if err != nil {
+			return nil, err
+		}
+		for v := lo; v <= hi; v += step {
+			years[v-minYear] = true
+		}
+	}
+	return years, nil
+}
+
+// parseRange parses *, n, a-b, * or ? with an optional /step.
+func parseRange(part string, f field) (lo, hi, step int, err error) {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+	step = 1
+	if hasStep {
+		n, err := strconv.Atoi(stepPart)
+		if err != nil || n < 1 {
+			return 0, 0, 0, fmt.Errorf("%s字段的步长无效: %q", f.name, part)
+		}
+		step = n
+	}
+
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		lo, hi = f.min, f.max
+	case strings.Contains(rangePart, "-"):
+		a, b, _ := strings.Cut(rangePart, "-")
+		if lo, err = f.value(a); err != nil {
+			return 0, 0, 0, err
+		}
+		if hi, err = f.value(b); err != nil {
+			return 0, 0, 0, err
+		}
+		if lo > hi {
+			return 0, 0, 0, fmt.Errorf("%s字段的范围无效: %q", f.name, part)
+		}
+	default:
+		if lo, err = f.value(rangePart); err != nil {
+			return 0, 0, 0, err
+		}
+		hi = lo
+		if hasStep {
+			hi = f.max // "5/15" 表示从 5 开始每 15
+		}
+	}
+	return lo, hi, step, nil
+}
+
+// value parses a number or name of the field.
+func (f field) value(s string) (int, error) {
+	if n, ok := f.names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s字段的值无效: %q", f.name, s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s字段的值 %d 超出范围 %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// fieldBits returns the bits of every value of f.
+func fieldBits(f field) uint64 {
+	bits, _ := parseField("*", f)
+	return bits
+}
+
+func (s *spec6) Next(t time.Time) time.Time {
+	if s.loc != nil {
+		t = t.In(s.loc)
+	}
+	loc := t.Location()
+	// 从下一个整秒开始
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + searchYears
+	if s.years != nil {
+		limit = maxYear
+	}
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+	for s.years != nil && (t.Year() < minYear || !s.years[t.Year()-minYear]) {
+		t = time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+		if t.Year() > limit {
+			return time.Time{}
+		}
+	}
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		// 加绝对时间而不是 Hour()+1，夏令时跳过的小时不会出现
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for s.second&(1<<uint(t.Second())) == 0 {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+func (s *spec6) dayMatches(t time.Time) bool {
+	day, wd := t.Day(), t.Weekday()
+	last := daysIn(t.Year(), t.Month())
+
+	dom := s.dom&(1<<uint(day)) != 0 ||
+		(s.lastDay && day == last-s.lastOffset) ||
+		(s.lastWeekday && day == lastWeekday(t.Year(), t.Month())) ||
+		(s.nearestWeekday > 0 && day == nearestWeekday(t.Year(), t.Month(), s.nearestWeekday))
+	dow := s.dow&(1<<uint(wd)) != 0 ||
+		(s.lastDow&(1<<uint(wd)) != 0 && day+7 > last)
+	for _, n := range s.nthDow {
+		if wd == n.weekday && (day-1)/7+1 == n.n {
+			dow = true
+		}
+	}
+
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// lastWeekday returns the last Monday to Friday of the month.
+func lastWeekday(year int, month time.Month) int {
+	day := daysIn(year, month)
+	switch time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		return day - 1
+	case time.Sunday:
+		return day - 2
+	}
+	return day
+}
+
+// nearestWeekday returns the Monday to Friday nearest to day within the
+// month, as Quartz's "15W".
+func nearestWeekday(year int, month time.Month, day int) int {
+	last := daysIn(year, month)
+	day = min(day, last)
+	switch time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return 3 // 不跨到上个月，改为周一
+		}
+		return day - 1
+	case time.Sunday:
+		if day == last {
+			return day - 2 // 不跨到下个月，改为周五
+		}
+		return day + 1
+	}
+	return day
+}
+
+// every fires at a fixed interval.
+type every struct {
+	d   time.Duration
+	loc *time.Location
+}
+
+func (e every) Next(t time.Time) time.Time {
+	if e.loc != nil {
+		t = t.In(e.loc)
+	}
+	return t.Truncate(time.Second).Add(e.d)
+}
+
+// NextN returns the next n times s fires after t.
+func NextN(s Schedule, t time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, n)
+	for len(times) < n {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
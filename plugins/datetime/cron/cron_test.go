@@ -0,0 +1,166 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tz database:", err)
+	}
+	// 2026-10-18 是周日
+	from := time.Date(2026, 10, 18, 10, 7, 30, 0, berlin)
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"*/15 * * * *", []string{"2026-10-18 10:15", "2026-10-18 10:30"}},
+		{"0 9 * * mon-fri", []string{"2026-10-19 09:00", "2026-10-20 09:00"}},
+		{"30 8 1,15 * *", []string{"2026-11-01 08:30", "2026-11-15 08:30"}},
+		{"0 0 13 * 5", []string{"2026-10-23 00:00", "2026-10-30 00:00"}}, // 13 日或周五
+		{"0 12 * * 7", []string{"2026-10-18 12:00", "2026-10-25 12:00"}},
+		{"@monthly", []string{"2026-11-01 00:00", "2026-12-01 00:00"}},
+		{"0 0 29 feb *", []string{"2028-02-29 00:00"}},
+		{"@every 90m", []string{"2026-10-18 11:37", "2026-10-18 13:07"}},
+		// 10/25 凌晨 3 点回拨到 2 点
+		{"30 2 25 oct *", []string{"2026-10-25 02:30"}},
+		{"30 2 29 mar *", []string{"2027-03-29 02:30"}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		next := from
+		for _, want := range tt.want {
+			next = s.Next(next)
+			if got := next.Format("2006-01-02 15:04"); got != want {
+				t.Errorf("%q: next = %s, want %s", tt.spec, got, want)
+				break
+			}
+		}
+	}
+
+	if s, _ := Parse("0 0 30 2 *"); !s.Next(from).IsZero() {
+		t.Error("February 30 fired")
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@often", "@every 10ms", "* * * foo *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestNextDialects(t *testing.T) {
+	// 2026-10-18 是周日
+	from := time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"*/20 * * * * *", []string{"2026-10-18 10:07:40", "2026-10-18 10:08:00"}},
+		{"30 0 9 * * mon-fri", []string{"2026-10-19 09:00:30"}},
+		// Quartz：星期 2 是周一
+		{"0 15 10 ? * 2-6", []string{"2026-10-19 10:15:00", "2026-10-20 10:15:00"}},
+		{"0 0 12 L * ?", []string{"2026-10-31 12:00:00", "2026-11-30 12:00:00"}},
+		{"0 0 12 L-2 * ?", []string{"2026-10-29 12:00:00", "2026-11-28 12:00:00"}},
+		{"0 0 12 LW * ?", []string{"2026-10-30 12:00:00", "2026-11-30 12:00:00"}},
+		// 11/1 是周日，最近的工作日是 11/2；2027/5/1 是周六，不跨月，改为 5/3
+		{"0 0 9 1W * ?", []string{"2026-11-02 09:00:00", "2026-12-01 09:00:00"}},
+		{"0 0 9 1W 5 ? 2027", []string{"2027-05-03 09:00:00"}},
+		// 没有 31 日的月份取最后一天
+		{"0 0 9 31W * ?", []string{"2026-10-30 09:00:00", "2026-11-30 09:00:00"}},
+		{"0 0 9 ? * 6#3", []string{"2026-11-20 09:00:00", "2026-12-18 09:00:00"}},
+		{"0 0 9 ? * FRI#5", []string{"2026-10-30 09:00:00", "2027-01-29 09:00:00"}},
+		{"0 0 9 ? * 6L", []string{"2026-10-30 09:00:00", "2026-11-27 09:00:00"}},
+		{"0 0 0 1 1 ? 2030/5", []string{"2030-01-01 00:00:00", "2035-01-01 00:00:00"}},
+		{"0 0 0 * * ? 2020", nil},
+		{"CRON_TZ=Asia/Tokyo 0 9 * * *", []string{"2026-10-19 09:00:00"}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		var got []string
+		for _, next := range NextN(s, from, len(tt.want)+1) {
+			got = append(got, next.Format("2006-01-02 15:04:05"))
+		}
+		if len(got) > len(tt.want) {
+			got = got[:len(tt.want)]
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q: next = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		spec    string
+		dialect Dialect
+	}{
+		{"0 0 L * ?", DialectUnix},
+		{"0 0 12 * * *", DialectQuartz},
+		{"0 0 12 ? * ?", DialectQuartz},
+		{"0 0 12 ? * 8", DialectQuartz},
+		{"0 0 12 ? * 6#6", DialectQuartz},
+		{"0 0 12 L-40 * ?", DialectQuartz},
+		{"0 0 12 * * ? 1969", DialectQuartz},
+		{"* * * * *", "cronie"},
+		{"CRON_TZ=Nowhere/City * * * * *", DialectAuto},
+	} {
+		if _, err := ParseDialect(tt.spec, tt.dialect); err == nil {
+			t.Errorf("ParseDialect(%q, %q) succeeded", tt.spec, tt.dialect)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		spec    string
+		dialect Dialect
+		want    string
+	}{
+		{"0 9 * * mon-fri", DialectAuto, "周一到周五 09:00"},
+		{"*/15 * * * *", DialectAuto, "每 15 分钟"},
+		{"* * * * *", DialectAuto, "每分钟"},
+		{"* * * * * *", DialectAuto, "每秒"},
+		{"0 * * * *", DialectAuto, "每小时的第 0 分钟"},
+		{"*/5 9-17 * * *", DialectAuto, "9 点到 17 点的每 5 分钟"},
+		{"30 8 1,15 * *", DialectAuto, "每月 1 日、15 日 08:30"},
+		{"0 0 13 * 5", DialectAuto, "每月 13 日或周五 00:00"},
+		{"@monthly", DialectAuto, "每月 1 日 00:00"},
+		{"@every 1h30m", DialectAuto, "每 1h30m0s"},
+		{"0 0 12 L * ?", DialectAuto, "每月最后一天 12:00"},
+		{"0 15 10 ? * 6#3", DialectAuto, "每月第 3 个周五 10:15"},
+		{"0 0 9 ? * 6L", DialectAuto, "每月最后一个周五 09:00"},
+		{"0 0 12 1 1 ? 2027", DialectAuto, "2027 年 1 月 1 日 12:00"},
+		{"0 15 10 ? * MON-FRI", DialectQuartz, "周一到周五 10:15"},
+		{"15 0 8 * * *", DialectAuto, "每天 08:00:15"},
+	}
+	for _, tt := range tests {
+		e, err := Explain(tt.spec, tt.dialect)
+		if err != nil {
+			t.Errorf("Explain(%q): %v", tt.spec, err)
+			continue
+		}
+		if e.Description != tt.want {
+			t.Errorf("Explain(%q) = %q, want %q", tt.spec, e.Description, tt.want)
+		}
+	}
+
+	e, err := Explain("TZ=Asia/Shanghai 0 0 9 ? * 2-6 2026-2030", DialectAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Dialect != DialectQuartz || e.Zone != "Asia/Shanghai" || len(e.Fields) != 7 || e.Fields[5].Meaning != "周一到周五" {
+		t.Errorf("explanation = %+v", e)
+	}
+	if _, err := Explain("61 * * * *", DialectAuto); err == nil {
+		t.Error("explained an invalid expression")
+	}
+}
@@ -0,0 +1,269 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Explanation describes a cron expression in words.
+type Explanation struct {
+	Spec        string      `json:"spec"`
+	Dialect     Dialect     `json:"dialect"`
+	Description string      `json:"description"`
+	Fields      []FieldInfo `json:"fields,omitempty"`
+	Zone        string      `json:"zone,omitempty"`
+}
+
+// FieldInfo is one field of an expression and what it means.
+type FieldInfo struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Meaning string `json:"meaning"`
+}
+
+// wording is how the values of a field are said.
+type wording struct {
+	value func(v int) string // 单个值，例如 "9 点"
+	every string             // "*"
+	step  string             // "*/n"，%d 为步长
+}
+
+var (
+	secondWording = wording{func(v int) string { return fmt.Sprintf("第 %d 秒", v) }, "每秒", "每 %d 秒"}
+	minuteWording = wording{func(v int) string { return fmt.Sprintf("第 %d 分钟", v) }, "每分钟", "每 %d 分钟"}
+	hourWording   = wording{func(v int) string { return fmt.Sprintf("%d 点", v) }, "每小时", "每 %d 小时"}
+	domWording    = wording{func(v int) string { return fmt.Sprintf("%d 日", v) }, "每天", "每 %d 天"}
+	monthWording  = wording{func(v int) string { return fmt.Sprintf("%d 月", v) }, "每月", "每 %d 个月"}
+	dowWording    = wording{func(v int) string { return weekdayNames[v%7] }, "每天", "每 %d 天"}
+	yearWording   = wording{func(v int) string { return fmt.Sprintf("%d 年", v) }, "每年", "每 %d 年"}
+)
+
+var weekdayNames = [...]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// Explain validates a cron expression and describes it, e.g. "0 9 * * 1-5"
+// is "周一到周五 09:00".
+func Explain(spec string, dialect Dialect) (*Explanation, error) {
+	schedule, err := parse(spec, dialect)
+	if err != nil {
+		return nil, err
+	}
+	e := &Explanation{Spec: strings.TrimSpace(spec)}
+
+	switch s := schedule.(type) {
+	case every:
+		e.Dialect = DialectUnix
+		e.Description = "每 " + s.d.String()
+		if s.loc != nil {
+			e.Zone = s.loc.String()
+		}
+	case *spec6:
+		e.Dialect = s.dialect
+		if s.loc != nil {
+			e.Zone = s.loc.String()
+		}
+		e.Description, e.Fields = s.explain()
+	}
+	return e, nil
+}
+
+func (s *spec6) explain() (string, []FieldInfo) {
+	dowWord, dowF := dowWording, dowField
+	if s.dialect == DialectQuartz {
+		dowF = quartzDowField
+		dowWord.value = func(v int) string { return weekdayNames[(v+6)%7] }
+	}
+
+	second := describe(s.fields[0], secondField, secondWording)
+	minute := describe(s.fields[1], minuteField, minuteWording)
+	hour := describe(s.fields[2], hourField, hourWording)
+	dom := s.describeDom()
+	month := describe(s.fields[4], monthField, monthWording)
+	dow := s.describeDow(dowF, dowWord)
+	var year string
+	if len(s.fields) == 7 {
+		year = describe(s.fields[6], yearField, yearWording)
+	}
+
+	var fields []FieldInfo
+	add := func(name, value, meaning string) {
+		if meaning == "" {
+			meaning = "任意"
+		}
+		fields = append(fields, FieldInfo{Name: name, Value: value, Meaning: meaning})
+	}
+	if s.seconds {
+		add(secondField.name, s.fields[0], second)
+	}
+	add(minuteField.name, s.fields[1], minute)
+	add(hourField.name, s.fields[2], hour)
+	add(domField.name, s.fields[3], dom)
+	add(monthField.name, s.fields[4], month)
+	add(dowField.name, s.fields[5], dow)
+	if len(s.fields) == 7 {
+		add(yearField.name, s.fields[6], year)
+	}
+
+	// 日期部分
+	var date []string
+	if year != "" {
+		date = append(date, year)
+	}
+	if month != "" {
+		date = append(date, month)
+	}
+	days := dom
+	if month == "" && days != "" && !strings.HasPrefix(days, "每") {
+		days = "每月 " + days
+	}
+	switch {
+	case days != "" && dow != "":
+		days += "或" + dow // 两个都限定时满足其一即可
+	case dow != "":
+		days = dow
+	}
+	if days != "" {
+		date = append(date, days)
+	}
+
+	// 时间部分
+	var clock string
+	if h, m, sec, ok := s.clock(); ok {
+		clock = fmt.Sprintf("%02d:%02d", h, m)
+		if sec != 0 {
+			clock += fmt.Sprintf(":%02d", sec)
+		}
+		if len(date) == 0 {
+			date = append(date, "每天")
+		}
+	} else {
+		levels := []string{hour, minute}
+		units := []string{"小时", "分钟"}
+		if s.seconds && s.fields[0] != "0" {
+			levels = append(levels, second)
+			units = append(units, "秒")
+		}
+		clock = describeTime(levels, units)
+	}
+	return strings.Join(append(date, clock), " "), fields
+}
+
+// clock returns the time of day if the expression fires once a day.
+func (s *spec6) clock() (h, m, sec int, ok bool) {
+	var err error
+	if sec, err = strconv.Atoi(s.fields[0]); err != nil {
+		return 0, 0, 0, false
+	}
+	if m, err = strconv.Atoi(s.fields[1]); err != nil {
+		return 0, 0, 0, false
+	}
+	if h, err = strconv.Atoi(s.fields[2]); err != nil {
+		return 0, 0, 0, false
+	}
+	return h, m, sec, true
+}
+
+// describeTime joins the hour, minute and second descriptions, such as
+// "9 点到 17 点的每 15 分钟". Empty ones are "*".
+func describeTime(levels, units []string) string {
+	first := -1
+	for i, d := range levels {
+		if d != "" {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return "每" + units[len(units)-1]
+	}
+
+	var parts []string
+	if first > 0 && !strings.HasPrefix(levels[first], "每") {
+		parts = append(parts, "每"+units[first-1])
+	}
+	for i := first; i < len(levels); i++ {
+		if levels[i] != "" {
+			parts = append(parts, levels[i])
+		} else {
+			parts = append(parts, "每"+units[i])
+		}
+	}
+	return strings.Join(parts, "的")
+}
+
+func (s *spec6) describeDom() string {
+	switch {
+	case s.lastDay && s.lastOffset > 0:
+		return fmt.Sprintf("每月倒数第 %d 天", s.lastOffset+1)
+	case s.lastDay:
+		return "每月最后一天"
+	case s.lastWeekday:
+		return "每月最后一个工作日"
+	case s.nearestWeekday > 0:
+		return fmt.Sprintf("离 %d 日最近的工作日", s.nearestWeekday)
+	}
+	return describe(s.fields[3], domField, domWording)
+}
+
+func (s *spec6) describeDow(f field, w wording) string {
+	if len(s.nthDow) > 0 {
+		n := s.nthDow[0]
+		return fmt.Sprintf("每月第 %d 个%s", n.n, weekdayNames[n.weekday])
+	}
+	if s.lastDow != 0 {
+		for wd := range 7 {
+			if s.lastDow&(1<<uint(wd)) != 0 {
+				return "每月最后一个" + weekdayNames[wd]
+			}
+		}
+	}
+	if strings.EqualFold(s.fields[5], "L") {
+		return weekdayNames[6]
+	}
+	return describe(s.fields[5], f, w)
+}
+
+// describe says what the values of a field are, or "" for "*" and "?".
+func describe(expr string, f field, w wording) string {
+	if expr == "*" || expr == "?" {
+		return ""
+	}
+	var items []string
+	for _, part := range strings.Split(expr, ",") {
+		items = append(items, describeItem(part, f, w))
+	}
+	return strings.Join(items, "、")
+}
+
+func describeItem(part string, f field, w wording) string {
+	rangePart, stepPart, hasStep := strings.Cut(part, "/")
+	step, _ := strconv.Atoi(stepPart)
+
+	var text string
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		if hasStep {
+			return fmt.Sprintf(w.step, step)
+		}
+		return w.every
+	case strings.Contains(rangePart, "-"):
+		a, b, _ := strings.Cut(rangePart, "-")
+		lo, _ := f.value(a)
+		hi, _ := f.value(b)
+		to := w.value(hi)
+		if to[0] >= '0' && to[0] <= '9' {
+			to = " " + to // "9 点到 17 点"
+		}
+		text = w.value(lo) + "到" + to
+	default:
+		v, _ := f.value(rangePart)
+		text = w.value(v)
+		if hasStep {
+			return "从" + text + "起" + fmt.Sprintf(w.step, step)
+		}
+	}
+	if hasStep {
+		text += fmt.Sprintf(w.step, step)
+	}
+	return text
+}
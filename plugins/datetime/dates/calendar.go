@@ -0,0 +1,186 @@
+package dates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Holiday is a day or a range of days in a Calendar.
+type Holiday struct {
+	Date string `json:"date"`          // 2006-01-02
+	End  string `json:"end,omitempty"` // 区间的最后一天（含），为空时只有 Date 一天
+	Name string `json:"name"`
+}
+
+// Calendar decides which days are business days: days outside the weekend,
+// minus holidays, plus make-up workdays (调休) that fall on the weekend.
+// A nil *Calendar has a Saturday and Sunday weekend and no holidays.
+type Calendar struct {
+	Weekend  []time.Weekday `json:"weekend"`
+	Holidays []Holiday      `json:"holidays"`
+	Workdays []Holiday      `json:"workdays"`
+}
+
+// DefaultCalendar returns a calendar with a Saturday and Sunday weekend.
+func DefaultCalendar() *Calendar {
+	return &Calendar{
+		Weekend:  []time.Weekday{time.Saturday, time.Sunday},
+		Holidays: []Holiday{},
+		Workdays: []Holiday{},
+	}
+}
+
+// LoadCalendar reads a calendar file. A missing file gives DefaultCalendar.
+func LoadCalendar(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultCalendar(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := DefaultCalendar()
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("解析节假日文件失败: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Save writes the calendar to path through a temporary file.
+func (c *Calendar) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存节假日文件失败: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Validate checks the weekend and the dates of holidays and workdays.
+func (c *Calendar) Validate() error {
+	seen := make(map[time.Weekday]bool)
+	for _, wd := range c.Weekend {
+		if wd < time.Sunday || wd > time.Saturday {
+			return fmt.Errorf("无效的周末: %d", wd)
+		}
+		seen[wd] = true
+	}
+	if len(seen) == 7 {
+		return fmt.Errorf("一周中至少要有一个工作日")
+	}
+	for _, list := range [][]Holiday{c.Holidays, c.Workdays} {
+		for _, h := range list {
+			if !validDate(h.Date) {
+				return fmt.Errorf("%s: 日期格式应为 YYYY-MM-DD: %q", h.Name, h.Date)
+			}
+			if h.End != "" && (!validDate(h.End) || h.End < h.Date) {
+				return fmt.Errorf("%s: 无效的结束日期 %q", h.Name, h.End)
+			}
+		}
+	}
+	return nil
+}
+
+func validDate(s string) bool {
+	t, err := time.Parse(dateLayout, s)
+	return err == nil && t.Format(dateLayout) == s
+}
+
+// find returns the entry of list that contains the date of t.
+func find(list []Holiday, t time.Time) (Holiday, bool) {
+	key := t.Format(dateLayout) // 同一格式的日期可以按字符串比较
+	for _, h := range list {
+		end := h.End
+		if end == "" {
+			end = h.Date
+		}
+		if key >= h.Date && key <= end {
+			return h, true
+		}
+	}
+	return Holiday{}, false
+}
+
+// Holiday returns the name of the holiday on the date of t.
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	h, ok := find(c.Holidays, t)
+	return h.Name, ok
+}
+
+// IsBusinessDay reports whether the date of t is a business day. A make-up
+// workday wins over the weekend and a holiday.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	if c == nil {
+		return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+	}
+	if _, ok := find(c.Workdays, t); ok {
+		return true
+	}
+	if _, ok := find(c.Holidays, t); ok {
+		return false
+	}
+	for _, wd := range c.Weekend {
+		if t.Weekday() == wd {
+			return false
+		}
+	}
+	return true
+}
+
+// BusinessDays counts the business days from the date of from up to, but
+// not including, the date of to; Monday to Friday of one week is 4. The
+// count is negative if to is before from.
+func (c *Calendar) BusinessDays(from, to time.Time) int {
+	sign := 1
+	a, b := midnight(from), midnight(to.In(from.Location()))
+	if b.Before(a) {
+		a, b, sign = b, a, -1
+	}
+	n := 0
+	for d := a; d.Before(b); d = d.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(d) {
+			n++
+		}
+	}
+	return sign * n
+}
+
+// AddBusinessDays moves t by n business days, keeping the time of day.
+// Adding 0 moves a day off forward to the next business day.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for !c.IsBusinessDay(t) && n == 0 {
+		t = t.AddDate(0, 0, 1)
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
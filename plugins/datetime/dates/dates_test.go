@@ -0,0 +1,192 @@
+package dates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var cst = time.FixedZone("CST", 8*3600)
+
+// now 是 2026-10-18（周日）10:00 CST
+var now = time.Date(2026, 10, 18, 10, 0, 0, 0, cst)
+
+func TestParse(t *testing.T) {
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, cst)
+	}
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		// 格式
+		{"2026-10-18T02:00:00Z", now},
+		{"2026-10-18T10:00:00.5+08:00", now.Add(500 * time.Millisecond)},
+		{"Sun, 18 Oct 2026 02:00:00 GMT", now},
+		{"Sun, 18 Oct 2026 10:00:00 +0800", now},
+		{"2026-10-18 15:04", at(10, 18, 15, 4)},
+		{"2026/1/5", at(1, 5, 0, 0)},
+		{"2026年10月1日 9:30", at(10, 1, 9, 30)},
+		{"Oct 1, 2026 3:04pm", at(10, 1, 15, 4)},
+		{"10月1日", at(10, 1, 0, 0)},
+		{"20260101", at(1, 1, 0, 0)},
+		{"12:30", at(10, 18, 12, 30)},
+
+		// Unix 时间戳
+		{"1792288800", now},
+		{"1792288800.25", now.Add(250 * time.Millisecond)},
+		{"1792288800123", now.Add(123 * time.Millisecond)},
+		{"1792288800123456", now.Add(123456 * time.Microsecond)},
+		{"1792288800123456789", now.Add(123456789)},
+		{"@0", time.Unix(0, 0)},
+
+		// ISO 周
+		{"2026-W03-2", at(1, 13, 0, 0)},
+		{"2026w42", at(10, 12, 0, 0)},
+		{"2026-W53-7", time.Date(2027, 1, 3, 0, 0, 0, 0, cst)},
+
+		// 自然语言
+		{"now", now},
+		{"tomorrow 9am", at(10, 19, 9, 0)},
+		{"yesterday at noon", at(10, 17, 12, 0)},
+		{"next friday 3pm", at(10, 23, 15, 0)},
+		{"Friday", at(10, 23, 0, 0)},
+		{"sunday", at(10, 18, 0, 0)},
+		{"last monday", at(10, 12, 0, 0)},
+		{"this friday", at(10, 16, 0, 0)},
+		{"周五", at(10, 16, 0, 0)},
+		{"下周五 14:00", at(10, 23, 14, 0)},
+		{"明天下午3点半", at(10, 19, 15, 30)},
+		{"in 90 minutes", at(10, 18, 11, 30)},
+		{"3 days ago", at(10, 15, 10, 0)},
+		{"2小时后", at(10, 18, 12, 0)},
+		{"1个月前", at(9, 18, 10, 0)},
+
+		// 偏移
+		{"2026-01-01 + 45d", at(2, 15, 0, 0)},
+		{"now - 1w + 2h", at(10, 11, 12, 0)},
+		{"+1.5h", at(10, 18, 11, 30)},
+		{"2026-01-31 + 1mo", at(2, 28, 0, 0)},
+		{"2026-01-31 + 1M", at(2, 28, 0, 0)},
+		{"2026-03-31 - 1mo", at(2, 28, 0, 0)},
+		{"2026-05-31 + 1mo", at(6, 30, 0, 0)},
+		{"now + 30m", at(10, 18, 10, 30)},
+		{"in 2 M", at(12, 18, 10, 0)},
+		{"5 MIN ago", at(10, 18, 9, 55)},
+		{"next friday 3pm + 2 days", at(10, 25, 15, 0)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input, now)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{
+		"", "foo", "13pm", "25:00", "2025-W53", "2026-01-01 + 1.5d",
+		"in 3 parsecs", "12345678901234567890", "1792288800123.5",
+	} {
+		if got, err := Parse(input, now); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", input, got)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	cal := DefaultCalendar()
+	cal.Holidays = []Holiday{{Date: "2026-10-01", End: "2026-10-07", Name: "国庆节"}}
+	cal.Workdays = []Holiday{{Date: "2026-10-10", Name: "国庆节调休"}}
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 9, 0, 0, 0, cst) }
+
+	// 9/28–9/30 三天，10/8、10/9 两天，调休的 10/10 周六一天
+	if n := cal.BusinessDays(day(9, 28), day(10, 12)); n != 6 {
+		t.Errorf("BusinessDays = %d, want 6", n)
+	}
+	if n := cal.BusinessDays(day(10, 12), day(9, 28)); n != -6 {
+		t.Errorf("reversed BusinessDays = %d, want -6", n)
+	}
+	if n := (*Calendar)(nil).BusinessDays(day(10, 12), day(10, 16)); n != 4 {
+		t.Errorf("nil calendar BusinessDays = %d, want 4", n)
+	}
+	for _, tt := range []struct {
+		from time.Time
+		n    int
+		want time.Time
+	}{
+		{day(9, 30), 1, day(10, 8)},
+		{day(10, 9), 1, day(10, 10)},
+		{day(10, 8), -1, day(9, 30)},
+		{day(10, 3), 0, day(10, 8)},
+	} {
+		if got := cal.AddBusinessDays(tt.from, tt.n); !got.Equal(tt.want) {
+			t.Errorf("AddBusinessDays(%s, %d) = %s, want %s", tt.from.Format(dateLayout), tt.n, got.Format(dateLayout), tt.want.Format(dateLayout))
+		}
+	}
+
+	info := NewInfo(day(10, 1), cal)
+	if info.Holiday != "国庆节" || info.BusinessDay || info.Quarter != 4 ||
+		info.QuarterStart != "2026-10-01" || info.QuarterEnd != "2026-12-31" ||
+		info.ISOWeekDate != "2026-W40-4" || info.DaysInMonth != 31 || info.LeapYear {
+		t.Errorf("NewInfo = %+v", info)
+	}
+
+	// 保存后重新读取；无效的文件被拒绝
+	path := filepath.Join(t.TempDir(), "holidays.json")
+	if err := cal.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCalendar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := loaded.BusinessDays(day(9, 28), day(10, 12)); n != 6 {
+		t.Errorf("loaded calendar BusinessDays = %d, want 6", n)
+	}
+	os.WriteFile(path, []byte(`{"holidays": [{"date": "2026-13-01", "name": "x"}]}`), 0644)
+	if _, err := LoadCalendar(path); err == nil {
+		t.Error("loaded a holiday with an invalid date")
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 30, 0, 0, cst)
+	}
+	tests := []struct {
+		from time.Time
+		n    int
+		want time.Time
+	}{
+		{date(2026, 1, 31), 1, date(2026, 2, 28)},
+		{date(2024, 1, 31), 1, date(2024, 2, 29)},
+		{date(2024, 2, 29), 12, date(2025, 2, 28)},
+		{date(2024, 2, 29), 48, date(2028, 2, 29)},
+		{date(2026, 3, 31), -1, date(2026, 2, 28)},
+		{date(2026, 12, 31), 2, date(2027, 2, 28)},
+		{date(2026, 1, 15), 1, date(2026, 2, 15)},
+	}
+	for _, tt := range tests {
+		if got := addMonths(tt.from, tt.n); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.from.Format(time.DateOnly), tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	from := time.Date(2024, 2, 29, 0, 0, 0, 0, cst)
+	to := time.Date(2026, 3, 1, 8, 0, 0, 0, cst)
+	s := Between(from, to, nil)
+	if s.Text != "2年8小时" || s.Days != 731 || s.Negative {
+		t.Errorf("Between = %+v", s)
+	}
+
+	s = Between(now, now.Add(-90*time.Minute), nil)
+	if s.Text != "1小时30分钟前" || !s.Negative || s.Seconds != 5400 {
+		t.Errorf("negative Between = %+v", s)
+	}
+}
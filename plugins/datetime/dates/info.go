@@ -0,0 +1,173 @@
+package dates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ltools/internal/timezone"
+)
+
+var weekdayNames = [...]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// WeekdayName returns the Chinese name of a weekday, e.g. 周五.
+func WeekdayName(wd time.Weekday) string {
+	return weekdayNames[wd]
+}
+
+// ISOWeeksInYear returns 52 or 53, the number of ISO weeks in year.
+func ISOWeeksInYear(year int) int {
+	// 12 月 28 日总在最后一周
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
+
+// Info describes a moment: its formats, its place in the week, quarter and
+// year, and whether it is a business day.
+type Info struct {
+	Time        time.Time `json:"time"`
+	DateTime    string    `json:"dateTime"` // 2006-01-02 15:04:05
+	Date        string    `json:"date"`
+	Unix        int64     `json:"unix"`
+	UnixMilli   int64     `json:"unixMilli"`
+	Zone        string    `json:"zone"`
+	Weekday     string    `json:"weekday"`
+	ISOYear     int       `json:"isoYear"`
+	ISOWeek     int       `json:"isoWeek"`
+	ISOWeekDate string    `json:"isoWeekDate"` // 2026-W42-7
+	Quarter     int       `json:"quarter"`
+
+	// QuarterStart and QuarterEnd are the first and last days of the quarter.
+	QuarterStart string `json:"quarterStart"`
+	QuarterEnd   string `json:"quarterEnd"`
+
+	DayOfYear   int  `json:"dayOfYear"`
+	DaysInYear  int  `json:"daysInYear"`
+	DaysInMonth int  `json:"daysInMonth"`
+	LeapYear    bool `json:"leapYear"`
+
+	BusinessDay bool   `json:"businessDay"`
+	Holiday     string `json:"holiday,omitempty"`
+}
+
+// NewInfo describes t. Business days follow cal, which may be nil.
+func NewInfo(t time.Time, cal *Calendar) *Info {
+	year, month, _ := t.Date()
+	isoYear, isoWeek := t.ISOWeek()
+	quarter := (int(month)-1)/3 + 1
+	qStart := time.Date(year, time.Month(quarter*3-2), 1, 0, 0, 0, 0, t.Location())
+	daysInYear := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	zone, offset := t.Zone()
+	holiday, _ := cal.Holiday(t)
+
+	return &Info{
+		Time:         t,
+		DateTime:     t.Format("2006-01-02 15:04:05"),
+		Date:         t.Format(dateLayout),
+		Unix:         t.Unix(),
+		UnixMilli:    t.UnixMilli(),
+		Zone:         fmt.Sprintf("%s (%s)", zone, timezone.FormatOffset(offset)),
+		Weekday:      WeekdayName(t.Weekday()),
+		ISOYear:      isoYear,
+		ISOWeek:      isoWeek,
+		ISOWeekDate:  fmt.Sprintf("%04d-W%02d-%d", isoYear, isoWeek, isoWeekday(t.Weekday())),
+		Quarter:      quarter,
+		QuarterStart: qStart.Format(dateLayout),
+		QuarterEnd:   qStart.AddDate(0, 3, -1).Format(dateLayout),
+		DayOfYear:    t.YearDay(),
+		DaysInYear:   daysInYear,
+		DaysInMonth:  time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day(),
+		LeapYear:     daysInYear == 366,
+		BusinessDay:  cal.IsBusinessDay(t),
+		Holiday:      holiday,
+	}
+}
+
+// Breakdown is a span in calendar units, the way people read it.
+type Breakdown struct {
+	Years   int `json:"years"`
+	Months  int `json:"months"`
+	Days    int `json:"days"`
+	Hours   int `json:"hours"`
+	Minutes int `json:"minutes"`
+	Seconds int `json:"seconds"`
+}
+
+// String formats the non-zero parts, e.g. 1年2个月3天4小时.
+func (b Breakdown) String() string {
+	var sb strings.Builder
+	parts := []struct {
+		n    int
+		unit string
+	}{
+		{b.Years, "年"}, {b.Months, "个月"}, {b.Days, "天"},
+		{b.Hours, "小时"}, {b.Minutes, "分钟"}, {b.Seconds, "秒"},
+	}
+	for _, p := range parts {
+		if p.n != 0 {
+			fmt.Fprintf(&sb, "%d%s", p.n, p.unit)
+		}
+	}
+	if sb.Len() == 0 {
+		return "0秒"
+	}
+	return sb.String()
+}
+
+// Span is the time between two moments.
+type Span struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	// Negative is set if To is before From; the other fields are then the
+	// span from To to From, except BusinessDays, which is negative.
+	Negative bool  `json:"negative"`
+	Seconds  int64 `json:"seconds"`
+
+	// Days counts calendar days between the dates, ignoring the time of day,
+	// and Weeks is Days/7.
+	Days  int     `json:"days"`
+	Weeks float64 `json:"weeks"`
+
+	BusinessDays int       `json:"businessDays"`
+	Breakdown    Breakdown `json:"breakdown"`
+	Text         string    `json:"text"`
+}
+
+// Between returns the span from from to to. Business days follow cal, which
+// may be nil, and are counted as in Calendar.BusinessDays.
+func Between(from, to time.Time, cal *Calendar) *Span {
+	to = to.In(from.Location())
+	s := &Span{From: from, To: to, BusinessDays: cal.BusinessDays(from, to)}
+	a, b := from, to
+	if b.Before(a) {
+		a, b = b, a
+		s.Negative = true
+	}
+	s.Seconds = int64(b.Sub(a) / time.Second)
+	s.Days = int(midnight(b).Sub(midnight(a)).Round(24*time.Hour) / (24 * time.Hour))
+	s.Weeks = float64(s.Days) / 7
+
+	// 先按日历累加年、月、日，剩下的不足一天
+	var br Breakdown
+	for !a.AddDate(br.Years+1, 0, 0).After(b) {
+		br.Years++
+	}
+	for !a.AddDate(br.Years, br.Months+1, 0).After(b) {
+		br.Months++
+	}
+	for !a.AddDate(br.Years, br.Months, br.Days+1).After(b) {
+		br.Days++
+	}
+	rest := b.Sub(a.AddDate(br.Years, br.Months, br.Days))
+	br.Hours = int(rest / time.Hour)
+	br.Minutes = int(rest % time.Hour / time.Minute)
+	br.Seconds = int(rest % time.Minute / time.Second)
+	s.Breakdown = br
+
+	s.Text = br.String()
+	if s.Negative {
+		s.Text += "前"
+	}
+	return s
+}
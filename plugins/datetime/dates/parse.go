@@ -0,0 +1,514 @@
+// Package dates parses dates written in many forms and does calendar
+// arithmetic: offsets, spans between dates, business days with a holiday
+// calendar, and ISO week and quarter information.
+package dates
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unit is a unit of an offset such as "45d" or "in 90 minutes". Seconds,
+// minutes and hours have a fixed length; days, weeks, months and years follow
+// the calendar, so adding a day across a DST change keeps the time of day.
+type unit struct {
+	d      time.Duration
+	days   int
+	months int
+}
+
+var units = func() map[string]unit {
+	table := []struct {
+		names []string
+		u     unit
+	}{
+		{[]string{"s", "sec", "secs", "second", "seconds", "秒", "秒钟"}, unit{d: time.Second}},
+		{[]string{"m", "min", "mins", "minute", "minutes", "分", "分钟"}, unit{d: time.Minute}},
+		{[]string{"h", "hr", "hrs", "hour", "hours", "小时", "个小时", "钟头"}, unit{d: time.Hour}},
+		{[]string{"d", "day", "days", "天", "日"}, unit{days: 1}},
+		{[]string{"w", "wk", "wks", "week", "weeks", "周", "星期", "个星期"}, unit{days: 7}},
+		{[]string{"mo", "mon", "mons", "month", "months", "月", "个月"}, unit{months: 1}},
+		{[]string{"y", "yr", "yrs", "year", "years", "年"}, unit{months: 12}},
+	}
+	m := make(map[string]unit)
+	for _, entry := range table {
+		for _, name := range entry.names {
+			m[name] = entry.u
+		}
+	}
+	return m
+}()
+
+// lookupUnit finds a unit by name. Names are case-insensitive, except that
+// m is a minute and M is a month.
+func lookupUnit(name string) (unit, bool) {
+	if name == "M" {
+		return units["mo"], true
+	}
+	u, ok := units[strings.ToLower(name)]
+	return u, ok
+}
+
+// maxOffset keeps fixed-length offsets within the range of time.Duration.
+const maxOffset = float64(100 * 365 * 24 * time.Hour)
+
+// add moves t by n units.
+func (u unit) add(t time.Time, n float64) (time.Time, error) {
+	if u.d != 0 {
+		d := n * float64(u.d)
+		if math.Abs(d) > maxOffset {
+			return t, fmt.Errorf("偏移量太大")
+		}
+		return t.Add(time.Duration(d)), nil
+	}
+	if n != math.Trunc(n) {
+		return t, fmt.Errorf("天、周、月和年只能加减整数")
+	}
+	if math.Abs(n) > 100000 {
+		return t, fmt.Errorf("偏移量太大")
+	}
+	if u.months != 0 {
+		return addMonths(t, int(n)*u.months), nil
+	}
+	return t.AddDate(0, 0, int(n)*u.days), nil
+}
+
+// addMonths moves t by n months. Unlike time.AddDate, a day that does not
+// exist in the target month is clamped to its last day, so 01-31 + 1 month
+// is 02-28 rather than 03-03.
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+var (
+	// 2026-01-01 + 45d, now - 2h, +1y-3d
+	offsetPattern = regexp.MustCompile(`(?i)^(.*?)\s*([+-])\s*(\d+(?:\.\d+)?)\s*([a-z\p{Han}]+)$`)
+
+	// in 90 minutes / 3 days ago / 90分钟后 / 3天前
+	inPattern  = regexp.MustCompile(`(?i)^in\s+(\d+(?:\.\d+)?)\s*([a-z]+)$`)
+	agoPattern = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([a-z]+)\s+ago$`)
+	cnRelative = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(\p{Han}+?)(后|以后|之后|前|以前|之前)$`)
+
+	// 一个日期后跟一个时刻：friday 3pm, tomorrow at 9:30, 明天下午3点
+	clockSuffix = regexp.MustCompile(`(?i)^(.*?)(\s*)(?:at\s+|@\s*)?(` +
+		`\d{1,2}:\d{2}(?::\d{2}(?:\.\d{1,9})?)?(?:\s*[ap]\.?m\.?)?` +
+		`|\d{1,2}\s*[ap]\.?m\.?` +
+		`|noon|midnight|中午|午夜` +
+		`|(?:凌晨|早上|上午|中午|下午|晚上)?\d{1,2}[点时](?:半|\d{1,2}分?)?(?:\d{1,2}秒)?` +
+		`)$`)
+	clockPattern   = regexp.MustCompile(`(?i)^(\d{1,2})(?::(\d{2})(?::(\d{2})(?:\.(\d{1,9}))?)?)?\s*(?:([ap])\.?m\.?)?$`)
+	cnClockPattern = regexp.MustCompile(`^(凌晨|早上|上午|中午|下午|晚上)?(\d{1,2})[点时](?:(半)|(\d{1,2})分?)?(?:(\d{1,2})秒)?$`)
+
+	weekdayPattern   = regexp.MustCompile(`(?i)^(?:(next|last|this)\s+)?([a-z]+)$`)
+	cnWeekdayPattern = regexp.MustCompile(`^(下|上|这|本)?(?:周|星期|礼拜)([一二三四五六日天])$`)
+
+	// 2026-W03, 2026-W03-2, 2026W032
+	isoWeekPattern = regexp.MustCompile(`(?i)^(\d{4})-?w(\d{2})(?:-?([1-7]))?$`)
+
+	unixPattern = regexp.MustCompile(`^@?(-?\d+)(?:\.(\d{1,9}))?$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var cnWeekdays = map[string]time.Weekday{
+	"一": time.Monday, "二": time.Tuesday, "三": time.Wednesday, "四": time.Thursday,
+	"五": time.Friday, "六": time.Saturday, "日": time.Sunday, "天": time.Sunday,
+}
+
+// datetimeLayouts are tried on the whole input; they carry a time and often a zone.
+var datetimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -0700 MST",
+	time.RFC1123,
+	time.RFC1123Z,
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	time.RFC850,
+	time.RFC822,
+	time.RFC822Z,
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	"20060102T150405Z0700",
+	"20060102T150405",
+}
+
+// dateLayouts are tried on the date part; the time comes from a clock suffix.
+// Layouts without a year use the current year.
+var dateLayouts = []string{
+	"2006-1-2",
+	"2006/1/2",
+	"2006.1.2",
+	"2006年1月2日",
+	"20060102",
+	"Jan 2 2006",
+	"Jan 2, 2006",
+	"January 2 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"02-Jan-2006",
+	"Mon Jan 2 2006",
+	"1月2日",
+	"Jan 2",
+	"January 2",
+	"2 Jan",
+}
+
+// Parse reads a date or time in one of many forms, relative to now and in
+// its location:
+//
+//   - layouts such as RFC 3339, RFC 1123, "2006-01-02 15:04", "2006/1/2" and "2006年1月2日"
+//   - Unix timestamps, with seconds, milliseconds, microseconds or nanoseconds
+//     detected by the number of digits ("1760781600", "1760781600123");
+//     eight digits that form a date are read as YYYYMMDD
+//   - ISO week dates: "2026-W03-2", "2026-W03" (the Monday)
+//   - words: "now", "today", "tomorrow 9am", "next friday 3pm", "last monday",
+//     "明天下午3点", "下周五"
+//   - relative times: "in 90 minutes", "3 days ago", "2小时后"
+//   - offsets after any of the above: "2026-01-01 + 45d", "now - 1w + 2h"
+//
+// A bare weekday is today or the next such day; "next friday" is the first
+// Friday after today and "last friday" the last one before it. "this friday"
+// and "周五" are the Friday of the current ISO week, "下周五" the one after.
+func Parse(input string, now time.Time) (time.Time, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("日期为空")
+	}
+
+	// 从末尾剥离偏移量，再按顺序加回去
+	type offset struct {
+		n float64
+		u unit
+	}
+	var offsets []offset
+	for {
+		m := offsetPattern.FindStringSubmatch(s)
+		if m == nil {
+			break
+		}
+		u, ok := lookupUnit(m[4])
+		if !ok {
+			break // 例如 "-0700 MST" 中的时区
+		}
+		n, _ := strconv.ParseFloat(m[3], 64)
+		if m[2] == "-" {
+			n = -n
+		}
+		offsets = append([]offset{{n, u}}, offsets...)
+		s = strings.TrimSpace(m[1])
+	}
+
+	t := now
+	if s != "" {
+		var err error
+		if t, err = parseBase(s, now); err != nil {
+			return time.Time{}, err
+		}
+	}
+	for _, o := range offsets {
+		var err error
+		if t, err = o.u.add(t, o.n); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return t, nil
+}
+
+// parseBase parses an input without trailing offsets.
+func parseBase(s string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "now", "现在", "此刻":
+		return now, nil
+	}
+	if m := inPattern.FindStringSubmatch(s); m != nil {
+		return relative(now, m[1], m[2], 1)
+	}
+	if m := agoPattern.FindStringSubmatch(s); m != nil {
+		return relative(now, m[1], m[2], -1)
+	}
+	if m := cnRelative.FindStringSubmatch(s); m != nil {
+		sign := 1.0
+		if strings.HasSuffix(m[3], "前") {
+			sign = -1
+		}
+		return relative(now, m[1], m[2], sign)
+	}
+
+	loc := now.Location()
+	if t, ok := parseCompactDate(s, loc); ok {
+		return t, nil
+	}
+	if t, ok, err := parseUnix(s, loc); ok {
+		return t, err
+	}
+	if t, ok := parseLayouts(s, datetimeLayouts, now); ok {
+		return t, nil
+	}
+
+	dayPart, clock := s, ""
+	if m := clockSuffix.FindStringSubmatch(s); m != nil {
+		// "2026-01-0112:30" 这样粘在数字上的不算时刻
+		if m[1] == "" || m[2] != "" || !isDigit(m[1][len(m[1])-1]) {
+			dayPart, clock = strings.TrimSpace(m[1]), m[3]
+		}
+	}
+	day, ok := parseDay(dayPart, now)
+	if !ok {
+		return time.Time{}, fmt.Errorf("无法识别的日期: %s", s)
+	}
+	if clock == "" {
+		return day, nil
+	}
+	h, min, sec, nsec, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), h, min, sec, nsec, loc), nil
+}
+
+func relative(now time.Time, amount, name string, sign float64) (time.Time, error) {
+	u, ok := lookupUnit(name)
+	if !ok {
+		return time.Time{}, fmt.Errorf("未知的时间单位: %s", name)
+	}
+	n, _ := strconv.ParseFloat(amount, 64)
+	return u.add(now, sign*n)
+}
+
+// parseDay parses the date part of an input and returns its midnight. An
+// empty input is today.
+func parseDay(s string, now time.Time) (time.Time, bool) {
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch strings.ToLower(s) {
+	case "", "today", "今天", "今日":
+		return today, true
+	case "tomorrow", "明天", "明日":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday", "昨天", "昨日":
+		return today.AddDate(0, 0, -1), true
+	case "后天":
+		return today.AddDate(0, 0, 2), true
+	case "前天":
+		return today.AddDate(0, 0, -2), true
+	}
+
+	if m := weekdayPattern.FindStringSubmatch(s); m != nil {
+		if wd, ok := weekdays[strings.ToLower(m[2])]; ok {
+			diff := int(wd - today.Weekday())
+			switch strings.ToLower(m[1]) {
+			case "next":
+				if diff <= 0 {
+					diff += 7
+				}
+			case "last":
+				if diff >= 0 {
+					diff -= 7
+				}
+			case "this":
+				diff = isoWeekday(wd) - isoWeekday(today.Weekday())
+			default:
+				if diff < 0 {
+					diff += 7
+				}
+			}
+			return today.AddDate(0, 0, diff), true
+		}
+	}
+	if m := cnWeekdayPattern.FindStringSubmatch(s); m != nil {
+		diff := isoWeekday(cnWeekdays[m[2]]) - isoWeekday(today.Weekday())
+		switch m[1] {
+		case "下":
+			diff += 7
+		case "上":
+			diff -= 7
+		}
+		return today.AddDate(0, 0, diff), true
+	}
+
+	if m := isoWeekPattern.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		day := 1
+		if m[3] != "" {
+			day, _ = strconv.Atoi(m[3])
+		}
+		return fromISOWeek(year, week, day, loc)
+	}
+
+	return parseLayouts(s, dateLayouts, now)
+}
+
+// parseLayouts tries layouts in the location of now. A result without a
+// year gets the current one.
+func parseLayouts(s string, layouts []string, now time.Time) (time.Time, bool) {
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, s, now.Location())
+		if err != nil {
+			continue
+		}
+		if t.Year() == 0 {
+			t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseCompactDate reads eight digits as YYYYMMDD when they form a plausible
+// date; otherwise they are a Unix timestamp from 1970.
+func parseCompactDate(s string, loc *time.Location) (time.Time, bool) {
+	if len(s) != 8 || !isDigit(s[0]) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("20060102", s, loc)
+	if err != nil || t.Year() < 1900 || t.Year() > 2199 {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// parseUnix reads a Unix timestamp. The precision is detected by the number
+// of digits: up to 10 are seconds, 13 milliseconds, 16 microseconds and 19
+// nanoseconds. Seconds may have a fraction ("1760781600.5").
+func parseUnix(s string, loc *time.Location) (time.Time, bool, error) {
+	m := unixPattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false, nil
+	}
+	digits := strings.TrimPrefix(m[1], "-")
+	if m[2] != "" && len(digits) > 10 {
+		return time.Time{}, true, fmt.Errorf("只有秒级时间戳可以带小数: %s", s)
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("时间戳超出范围: %s", s)
+	}
+
+	var t time.Time
+	switch {
+	case len(digits) <= 10:
+		nsec := 0
+		if m[2] != "" {
+			nsec, _ = strconv.Atoi((m[2] + "000000000")[:9])
+		}
+		if n < 0 {
+			nsec = -nsec
+		}
+		t = time.Unix(n, int64(nsec))
+	case len(digits) <= 13:
+		t = time.UnixMilli(n)
+	case len(digits) <= 16:
+		t = time.UnixMicro(n)
+	default:
+		t = time.Unix(0, n)
+	}
+	return t.In(loc), true, nil
+}
+
+// parseClock parses a time of day such as "15:04", "3:30pm", "noon" or "下午3点半".
+func parseClock(s string) (h, min, sec, nsec int, err error) {
+	switch strings.ToLower(s) {
+	case "noon", "中午":
+		return 12, 0, 0, 0, nil
+	case "midnight", "午夜":
+		return 0, 0, 0, 0, nil
+	}
+
+	if m := cnClockPattern.FindStringSubmatch(s); m != nil {
+		h, _ = strconv.Atoi(m[2])
+		switch {
+		case m[3] != "":
+			min = 30
+		case m[4] != "":
+			min, _ = strconv.Atoi(m[4])
+		}
+		if m[5] != "" {
+			sec, _ = strconv.Atoi(m[5])
+		}
+		switch m[1] {
+		case "下午", "晚上":
+			if h < 12 {
+				h += 12
+			}
+		case "中午":
+			if h < 11 {
+				h += 12
+			}
+		}
+	} else if m := clockPattern.FindStringSubmatch(s); m != nil {
+		h, _ = strconv.Atoi(m[1])
+		if m[2] != "" {
+			min, _ = strconv.Atoi(m[2])
+		}
+		if m[3] != "" {
+			sec, _ = strconv.Atoi(m[3])
+		}
+		if m[4] != "" {
+			nsec, _ = strconv.Atoi((m[4] + "000000000")[:9])
+		}
+		if m[5] != "" {
+			if h < 1 || h > 12 {
+				return 0, 0, 0, 0, fmt.Errorf("无效的时刻: %s", s)
+			}
+			h %= 12
+			if strings.EqualFold(m[5], "p") {
+				h += 12
+			}
+		}
+	} else {
+		return 0, 0, 0, 0, fmt.Errorf("无效的时刻: %s", s)
+	}
+
+	if h > 23 || min > 59 || sec > 59 {
+		return 0, 0, 0, 0, fmt.Errorf("无效的时刻: %s", s)
+	}
+	return h, min, sec, nsec, nil
+}
+
+// fromISOWeek returns the day of an ISO week date.
+func fromISOWeek(year, week, day int, loc *time.Location) (time.Time, bool) {
+	if week < 1 || week > ISOWeeksInYear(year) {
+		return time.Time{}, false
+	}
+	// 1 月 4 日总在第 1 周
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, 1-isoWeekday(jan4.Weekday()))
+	return monday.AddDate(0, 0, (week-1)*7+day-1), true
+}
+
+// isoWeekday numbers the days from Monday (1) to Sunday (7).
+func isoWeekday(wd time.Weekday) int {
+	if wd == time.Sunday {
+		return 7
+	}
+	return int(wd)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -2,10 +2,17 @@ package datetime
 
 import (
 	"fmt"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
+	"ltools/internal/notify"
 	"ltools/internal/plugins"
+	"ltools/internal/timezone"
+	"ltools/plugins/datetime/dates"
+	"ltools/plugins/datetime/timers"
+	"ltools/plugins/datetime/worldclock"
 )
 
 const (
@@ -14,10 +21,21 @@ const (
 	PluginVersion = "1.0.0"
 )
 
+// maxBusinessDays limits AddBusinessDays, which steps one day at a time.
+const maxBusinessDays = 100000
+
 // DateTimePlugin provides current date and time functionality
 type DateTimePlugin struct {
 	*plugins.BasePlugin
 	app *application.App
+
+	mu           sync.RWMutex
+	calendar     *dates.Calendar
+	calendarPath string // 为空时节假日不保存
+	clocks       *worldclock.List
+	timers       *timers.Manager
+	notifier     notify.Notifier
+	stopUpdates  chan struct{} // 关闭后停止每秒的时间事件
 }
 
 // NewDateTimePlugin creates a new DateTime plugin
@@ -32,14 +50,47 @@ func NewDateTimePlugin() *DateTimePlugin {
 		Type:        plugins.PluginTypeBuiltIn,
 		State:       plugins.PluginStateInstalled,
 		Keywords:    []string{"时间", "日期", "时钟", "time", "date", "clock"},
+		DataPaths:   []string{"datetime/"},
 	}
 
 	base := plugins.NewBasePlugin(metadata)
+	// 在 SetDataDir 之前只保存在内存中
+	clocks, _ := worldclock.Load("")
+	timerManager, _ := timers.Load("")
 	return &DateTimePlugin{
 		BasePlugin: base,
+		calendar:   dates.DefaultCalendar(),
+		clocks:     clocks,
+		timers:     timerManager,
 	}
 }
 
+// SetDataDir loads the holiday calendar used for business days, the world
+// clock list and the timers from the data directory.
+func (p *DateTimePlugin) SetDataDir(dataDir string) error {
+	path := filepath.Join(dataDir, "datetime", "holidays.json")
+	cal, err := dates.LoadCalendar(path)
+	if err != nil {
+		return fmt.Errorf("failed to load holiday calendar: %w", err)
+	}
+	clocks, err := worldclock.Load(filepath.Join(dataDir, "datetime", "clocks.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load world clocks: %w", err)
+	}
+	timerManager, err := timers.Load(filepath.Join(dataDir, "datetime", "timers.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load timers: %w", err)
+	}
+
+	p.mu.Lock()
+	p.calendar = cal
+	p.calendarPath = path
+	p.clocks = clocks
+	p.timers = timerManager
+	p.mu.Unlock()
+	return nil
+}
+
 // Metadata returns the plugin metadata
 func (p *DateTimePlugin) Metadata() *plugins.PluginMetadata {
 	return p.BasePlugin.Metadata()
@@ -60,12 +111,23 @@ func (p *DateTimePlugin) ServiceStartup(app *application.App) error {
 		return err
 	}
 	// Start a goroutine to emit time updates
-	go p.emitTimeUpdates()
+	p.mu.Lock()
+	if p.stopUpdates == nil {
+		p.stopUpdates = make(chan struct{})
+		go p.emitTimeUpdates(p.stopUpdates)
+	}
+	p.mu.Unlock()
 	return nil
 }
 
 // ServiceShutdown is called when the application shuts down
 func (p *DateTimePlugin) ServiceShutdown(app *application.App) error {
+	p.mu.Lock()
+	if p.stopUpdates != nil {
+		close(p.stopUpdates)
+		p.stopUpdates = nil
+	}
+	p.mu.Unlock()
 	return p.BasePlugin.ServiceShutdown(app)
 }
 
@@ -81,14 +143,20 @@ func (p *DateTimePlugin) SetEnabled(enabled bool) error {
 
 
 // emitTimeUpdates emits time update events every second
-func (p *DateTimePlugin) emitTimeUpdates() {
+func (p *DateTimePlugin) emitTimeUpdates(stop <-chan struct{}) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		if p.Enabled() {
-			now := time.Now()
-			p.emitTimeEvent(now)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if p.Enabled() {
+				now := time.Now()
+				p.emitTimeEvent(now)
+				p.tickTimers(now)
+			}
 		}
 	}
 }
@@ -109,6 +177,9 @@ func (p *DateTimePlugin) emitTimeEvent(now time.Time) {
 	p.app.Event.Emit("datetime:hour", now.Hour())
 	p.app.Event.Emit("datetime:minute", now.Minute())
 	p.app.Event.Emit("datetime:second", now.Second())
+
+	// 世界时钟
+	p.app.Event.Emit("datetime:clocks", p.getClocks().At(now, time.Local))
 }
 
 // GetCurrentTime returns the current time
@@ -140,15 +211,153 @@ func (p *DateTimePlugin) TimestampToDateTime(timestamp int64) string {
 	return time.Unix(timestamp, 0).Format("2006-01-02 15:04:05")
 }
 
-// DateTimeToTimestamp converts a datetime string to Unix timestamp
+// DateTimeToTimestamp converts a datetime string to Unix timestamp. It
+// accepts every form ParseDate does; times without a zone are local.
 func (p *DateTimePlugin) DateTimeToTimestamp(datetimeStr string) (int64, error) {
-	t, err := time.Parse("2006-01-02 15:04:05", datetimeStr)
+	t, err := dates.Parse(datetimeStr, time.Now())
 	if err != nil {
 		return 0, fmt.Errorf("invalid datetime format: %w", err)
 	}
 	return t.Unix(), nil
 }
 
+// ParseDate parses a date in one of many forms, such as RFC 3339, a Unix
+// timestamp of any precision, "2026-W03-2", "next friday 3pm", "in 90 minutes"
+// or "2026-01-01 + 45d", and describes it.
+func (p *DateTimePlugin) ParseDate(input string) (*dates.Info, error) {
+	t, err := dates.Parse(input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return dates.NewInfo(t, p.getCalendar()), nil
+}
+
+// DateDiff returns the span between two dates, including the number of
+// business days.
+func (p *DateTimePlugin) DateDiff(from, to string) (*dates.Span, error) {
+	now := time.Now()
+	a, err := dates.Parse(from, now)
+	if err != nil {
+		return nil, err
+	}
+	b, err := dates.Parse(to, now)
+	if err != nil {
+		return nil, err
+	}
+	return dates.Between(a, b, p.getCalendar()), nil
+}
+
+// AddBusinessDays moves a date by a number of business days, skipping
+// weekends and holidays.
+func (p *DateTimePlugin) AddBusinessDays(input string, days int) (*dates.Info, error) {
+	if days > maxBusinessDays || days < -maxBusinessDays {
+		return nil, fmt.Errorf("工作日数不能超过 %d", maxBusinessDays)
+	}
+	t, err := dates.Parse(input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	cal := p.getCalendar()
+	return dates.NewInfo(cal.AddBusinessDays(t, days), cal), nil
+}
+
+// GetHolidayCalendar returns the weekend, holidays and make-up workdays.
+func (p *DateTimePlugin) GetHolidayCalendar() *dates.Calendar {
+	return p.getCalendar()
+}
+
+// SetHolidayCalendar replaces the holiday calendar and saves it.
+func (p *DateTimePlugin) SetHolidayCalendar(cal *dates.Calendar) error {
+	if cal == nil {
+		return fmt.Errorf("节假日日历为空")
+	}
+	if err := cal.Validate(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.calendarPath != "" {
+		if err := cal.Save(p.calendarPath); err != nil {
+			return err
+		}
+	}
+	p.calendar = cal
+	return nil
+}
+
+// GetWorldClocks returns the configured time zones in display order.
+func (p *DateTimePlugin) GetWorldClocks() []worldclock.Clock {
+	return p.getClocks().Clocks()
+}
+
+// AddWorldClock adds a time zone, given as an IANA name, a city, an
+// abbreviation or a UTC offset, to the world clocks.
+func (p *DateTimePlugin) AddWorldClock(zone, label string) (worldclock.Clock, error) {
+	return p.getClocks().Add(zone, label)
+}
+
+// RemoveWorldClock removes the world clock at index.
+func (p *DateTimePlugin) RemoveWorldClock(index int) error {
+	return p.getClocks().Remove(index)
+}
+
+// MoveWorldClock moves a world clock to another position.
+func (p *DateTimePlugin) MoveWorldClock(from, to int) error {
+	return p.getClocks().Move(from, to)
+}
+
+// GetWorldTimes returns the current time in every world clock.
+func (p *DateTimePlugin) GetWorldTimes() []worldclock.ZoneTime {
+	return p.getClocks().At(time.Now(), time.Local)
+}
+
+// ConvertTime parses a time in the zone fromZone (local if empty), e.g.
+// "tomorrow 3pm" in "Tokyo", and shows it in every world clock.
+func (p *DateTimePlugin) ConvertTime(input, fromZone string) ([]worldclock.ZoneTime, error) {
+	loc, err := lookupZone(fromZone)
+	if err != nil {
+		return nil, err
+	}
+	t, err := dates.Parse(input, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+	return p.getClocks().At(t, loc), nil
+}
+
+// PlanMeeting finds times in the coming days when every zone of the request
+// is within working hours. Without zones, the world clocks and the local
+// zone are used.
+func (p *DateTimePlugin) PlanMeeting(req worldclock.PlanRequest) ([]worldclock.Slot, error) {
+	if len(req.Zones) == 0 {
+		req.Zones = []string{"Local"}
+		for _, c := range p.GetWorldClocks() {
+			req.Zones = append(req.Zones, c.Zone)
+		}
+	}
+	return worldclock.Plan(req, time.Now())
+}
+
+// ListTimezones returns the time zones that can be found by city name.
+func (p *DateTimePlugin) ListTimezones() []string {
+	return timezone.Names()
+}
+
+func (p *DateTimePlugin) getClocks() *worldclock.List {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.clocks
+}
+
+// getCalendar returns the holiday calendar. It is replaced, never changed
+// in place, so callers may use it without the lock.
+func (p *DateTimePlugin) getCalendar() *dates.Calendar {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.calendar
+}
+
 // GetWeekday returns the weekday name for the current date
 func (p *DateTimePlugin) GetWeekday() string {
 	weekdays := map[time.Weekday]string{
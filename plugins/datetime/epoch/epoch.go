@@ -0,0 +1,184 @@
+// Package epoch converts between times and the timestamps found in numbers,
+// IDs and file formats: Unix epochs, Snowflake IDs, ULIDs, UUIDs (v1, v6 and
+// v7), Windows FILETIME and Excel serial dates.
+package epoch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format is a kind of timestamp.
+type Format string
+
+const (
+	Unix      Format = "unix"    // 秒，可带小数
+	UnixMilli Format = "unix_ms" // 毫秒
+	UnixMicro Format = "unix_us" // 微秒
+	UnixNano  Format = "unix_ns" // 纳秒
+	Snowflake Format = "snowflake"
+	ULID      Format = "ulid"
+	UUIDv1    Format = "uuid_v1"
+	UUIDv6    Format = "uuid_v6"
+	UUIDv7    Format = "uuid_v7"
+	FileTime  Format = "filetime" // Windows FILETIME：1601 年起的 100 纳秒数
+	Excel     Format = "excel"    // Excel 序列日期
+)
+
+// Formats lists every format, in the order they are tried and shown.
+var Formats = []Format{Unix, UnixMilli, UnixMicro, UnixNano, Snowflake, ULID, UUIDv1, UUIDv6, UUIDv7, FileTime, Excel}
+
+// SnowflakeEpochs are the epochs of well-known Snowflake IDs, in Unix
+// milliseconds.
+var SnowflakeEpochs = map[string]int64{
+	"twitter": 1288834974657,
+	"discord": 1420070400000,
+}
+
+// Options are the parameters of the formats that need them.
+type Options struct {
+	// SnowflakeEpoch is the epoch of Snowflake IDs in Unix milliseconds.
+	// 0 is Twitter's.
+	SnowflakeEpoch int64 `json:"snowflakeEpoch"`
+	// Excel1904 uses the 1904 date system of Excel for Mac before 2011.
+	Excel1904 bool `json:"excel1904"`
+	// Location is the time zone of Excel serial dates, which have none.
+	// nil is the local time zone.
+	Location *time.Location `json:"-"`
+}
+
+func (o Options) snowflakeEpoch() int64 {
+	if o.SnowflakeEpoch == 0 {
+		return SnowflakeEpochs["twitter"]
+	}
+	return o.SnowflakeEpoch
+}
+
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+	return o.Location
+}
+
+// Decoded is a time read from a timestamp.
+type Decoded struct {
+	Format Format    `json:"format"`
+	Time   time.Time `json:"time"`
+	// Detail is what else the timestamp holds, such as the worker of a
+	// Snowflake ID or the node of a UUID v1.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Value is a time written in a format.
+type Value struct {
+	Format Format `json:"format"`
+	Value  string `json:"value"`
+}
+
+// Decode reads the time in input as the given format.
+func Decode(input string, format Format, opts Options) (Decoded, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return Decoded{}, fmt.Errorf("输入为空")
+	}
+	var (
+		t      time.Time
+		detail string
+		err    error
+	)
+	switch format {
+	case Unix, UnixMilli, UnixMicro, UnixNano:
+		t, err = decodeUnix(input, format)
+	case Snowflake:
+		t, detail, err = decodeSnowflake(input, opts.snowflakeEpoch())
+	case ULID:
+		t, err = decodeULID(input)
+	case UUIDv1, UUIDv6, UUIDv7:
+		t, detail, err = decodeUUID(input, format)
+	case FileTime:
+		t, err = decodeFileTime(input)
+	case Excel:
+		t, detail, err = decodeExcel(input, opts.Excel1904, opts.location())
+	default:
+		return Decoded{}, fmt.Errorf("未知的时间戳格式: %s", format)
+	}
+	if err != nil {
+		return Decoded{}, err
+	}
+	return Decoded{Format: format, Time: t, Detail: detail}, nil
+}
+
+// DecodeAny reads input in every format that gives a plausible time, so that
+// "1760781600000" is found to be Unix milliseconds and
+// "01ARZ3NDEKTSV4RRFFQ69G5FAV" a ULID.
+func DecodeAny(input string, opts Options) []Decoded {
+	input = strings.TrimSpace(input)
+	var results []Decoded
+	for _, format := range Formats {
+		d, err := Decode(input, format, opts)
+		if err == nil && plausible(d, input) {
+			results = append(results, d)
+		}
+	}
+	return results
+}
+
+// DecodeAny 只保留这些年份之间的结果
+const (
+	minPlausibleYear = 1980
+	maxPlausibleYear = 2200
+)
+
+// minSnowflakeDigits is the fewest digits of a Snowflake ID for DecodeAny:
+// any small number read as one falls just after the epoch.
+const minSnowflakeDigits = 15
+
+// plausible reports whether d is a likely reading of input.
+func plausible(d Decoded, input string) bool {
+	if y := d.Time.UTC().Year(); y < minPlausibleYear || y > maxPlausibleYear {
+		return false
+	}
+	return d.Format != Snowflake || len(input) >= minSnowflakeDigits
+}
+
+// Encode writes t in the given format. IDs are the smallest with the time
+// of t: the other bits are zero.
+func Encode(t time.Time, format Format, opts Options) (string, error) {
+	switch format {
+	case Unix, UnixMilli, UnixMicro, UnixNano:
+		return encodeUnix(t, format)
+	case Snowflake:
+		return encodeSnowflake(t, opts.snowflakeEpoch())
+	case ULID:
+		return encodeULID(t)
+	case UUIDv1, UUIDv6, UUIDv7:
+		return encodeUUID(t, format)
+	case FileTime:
+		return encodeFileTime(t)
+	case Excel:
+		return encodeExcel(t, opts.Excel1904, opts.location())
+	}
+	return "", fmt.Errorf("未知的时间戳格式: %s", format)
+}
+
+// EncodeAll writes t in every format that can hold it.
+func EncodeAll(t time.Time, opts Options) []Value {
+	var values []Value
+	for _, format := range Formats {
+		if v, err := Encode(t, format, opts); err == nil {
+			values = append(values, Value{Format: format, Value: v})
+		}
+	}
+	return values
+}
+
+// Convert reads input as from and writes the time as to.
+func Convert(input string, from, to Format, opts Options) (string, error) {
+	d, err := Decode(input, from, opts)
+	if err != nil {
+		return "", err
+	}
+	return Encode(d.Time, to, opts)
+}
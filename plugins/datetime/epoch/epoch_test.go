@@ -0,0 +1,193 @@
+package epoch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	opts := Options{Location: time.UTC}
+	discord := Options{SnowflakeEpoch: SnowflakeEpochs["discord"]}
+	tests := []struct {
+		input  string
+		format Format
+		opts   Options
+		want   string
+		detail string
+	}{
+		{"1760781600", Unix, opts, "2025-10-18T10:00:00Z", ""},
+		{"1760781600.25", Unix, opts, "2025-10-18T10:00:00.25Z", ""},
+		{"-1.5", Unix, opts, "1969-12-31T23:59:58.5Z", ""},
+		{"1760781600123", UnixMilli, opts, "2025-10-18T10:00:00.123Z", ""},
+		{"1760781600123456", UnixMicro, opts, "2025-10-18T10:00:00.123456Z", ""},
+		{"1760781600123456789", UnixNano, opts, "2025-10-18T10:00:00.123456789Z", ""},
+		{"175928847299117063", Snowflake, discord, "2016-04-30T11:18:25.796Z", "机器 1，进程 0，序号 7"},
+		{"1212161644108865536", Snowflake, opts, "2020-01-01T00:00:31.486Z", "机器 27，进程 6，序号 0"},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", ULID, opts, "2016-07-30T23:54:10.259Z", ""},
+		{"01arz3ndektsv4rrffq69g5fav", ULID, opts, "2016-07-30T23:54:10.259Z", ""},
+		// RFC 9562 附录 A 的示例
+		{"C232AB00-9414-11EC-B3C8-9F6BDECED846", UUIDv1, opts, "2022-02-22T19:22:22Z", "时钟序列 13256，节点 9f:6b:de:ce:d8:46"},
+		{"{1ec9414c-232a-6b00-b3c8-9f6bdeced846}", UUIDv6, opts, "2022-02-22T19:22:22Z", "时钟序列 13256，节点 9f:6b:de:ce:d8:46"},
+		{"urn:uuid:017f22e2-79b0-7cc3-98c4-dc0c0c07398f", UUIDv7, opts, "2022-02-22T19:22:22Z", ""},
+		{"116444736000000000", FileTime, opts, "1970-01-01T00:00:00Z", ""},
+		{"0x01DC4015F731D000", FileTime, opts, "2025-10-18T10:00:00Z", ""},
+		{"0", FileTime, opts, "1601-01-01T00:00:00Z", ""},
+		{"45000", Excel, opts, "2023-03-15T00:00:00Z", "1900 日期系统"},
+		{"44197.75", Excel, opts, "2021-01-01T18:00:00Z", "1900 日期系统"},
+		{"1", Excel, opts, "1900-01-01T00:00:00Z", "1900 日期系统"},
+		{"59", Excel, opts, "1900-02-28T00:00:00Z", "1900 日期系统"},
+		{"61", Excel, opts, "1900-03-01T00:00:00Z", "1900 日期系统"},
+		{"0.5", Excel, Options{Excel1904: true, Location: time.UTC}, "1904-01-01T12:00:00Z", "1904 日期系统"},
+	}
+	for _, tt := range tests {
+		d, err := Decode(tt.input, tt.format, tt.opts)
+		if err != nil {
+			t.Errorf("Decode(%q, %s): %v", tt.input, tt.format, err)
+			continue
+		}
+		if got := d.Time.UTC().Format(time.RFC3339Nano); got != tt.want || d.Detail != tt.detail {
+			t.Errorf("Decode(%q, %s) = %s %q, want %s %q", tt.input, tt.format, got, d.Detail, tt.want, tt.detail)
+		}
+	}
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("no tz database:", err)
+	}
+	if d, _ := Decode("45000.5", Excel, Options{Location: shanghai}); d.Time.Format("2006-01-02 15:04 MST") != "2023-03-15 12:00 CST" {
+		t.Errorf("Excel in Shanghai = %v", d.Time)
+	}
+
+	for _, tt := range []struct {
+		input  string
+		format Format
+	}{
+		{"1.5", UnixMilli},
+		{"abc", Unix},
+		{"99999999999999999999", Unix},
+		{"-1", Snowflake},
+		{"81ARZ3NDEKTSV4RRFFQ69G5FAV", ULID},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FA", ULID},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAU", ULID},
+		{"f47ac10b-58cc-4372-a567-0e02b2c3d479", UUIDv7},
+		{"017f22e2-79b0-7cc3-98c4-dc0c0c07398f", UUIDv1},
+		{"017f22e2-79b0", UUIDv7},
+		{"-1", FileTime},
+		{"60", Excel},
+		{"-1", Excel},
+		{"3000000", Excel},
+		{"NaN", Excel},
+		{"", Unix},
+		{"1", "mjd"},
+	} {
+		if d, err := Decode(tt.input, tt.format, Options{}); err == nil {
+			t.Errorf("Decode(%q, %s) = %v, want an error", tt.input, tt.format, d.Time)
+		}
+	}
+}
+
+func TestDecodeAny(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Format
+	}{
+		{"1760781600", []Format{Unix}},
+		{"1760781600123", []Format{UnixMilli}},
+		{"1212161644108865536", []Format{UnixNano, Snowflake}},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", []Format{ULID}},
+		{"017f22e2-79b0-7cc3-98c4-dc0c0c07398f", []Format{UUIDv7}},
+		{"133000000000000000", []Format{Snowflake, FileTime}},
+		{"45000", []Format{Excel}},
+		{"hello", nil},
+	}
+	for _, tt := range tests {
+		var got []Format
+		for _, d := range DecodeAny(tt.input, Options{}) {
+			got = append(got, d.Format)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("DecodeAny(%q) = %v, want %v", tt.input, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("DecodeAny(%q) = %v, want %v", tt.input, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	opts := Options{Location: time.UTC}
+	at := time.Date(2025, 10, 18, 10, 0, 0, 250_000_000, time.UTC)
+	want := map[Format]string{
+		Unix:      "1760781600.25",
+		UnixMilli: "1760781600250",
+		UnixMicro: "1760781600250000",
+		UnixNano:  "1760781600250000000",
+		Snowflake: "1979487619511222272",
+		ULID:      "01K7VC61FT0000000000000000",
+		UUIDv1:    "359ab5a0-ac09-11f0-8000-000000000000",
+		UUIDv6:    "1f0ac093-59ab-65a0-8000-000000000000",
+		UUIDv7:    "0199f6c3-05fa-7000-8000-000000000000",
+		FileTime:  "134052552002500000",
+		Excel:     "45948.41666956018",
+	}
+	values := EncodeAll(at, opts)
+	if len(values) != len(Formats) {
+		t.Errorf("EncodeAll = %d values, want %d", len(values), len(Formats))
+	}
+	for _, v := range values {
+		if v.Value != want[v.Format] {
+			t.Errorf("Encode(%s) = %s, want %s", v.Format, v.Value, want[v.Format])
+		}
+		// 编码后再解码得到同一时间（精确到格式的精度）
+		d, err := Decode(v.Value, v.Format, opts)
+		if err != nil {
+			t.Errorf("Decode(%s, %s): %v", v.Value, v.Format, err)
+			continue
+		}
+		if diff := d.Time.Sub(at); diff < -time.Millisecond || diff > time.Millisecond {
+			t.Errorf("%s round trip = %v, want %v", v.Format, d.Time, at)
+		}
+	}
+
+	for _, tt := range []struct {
+		t      time.Time
+		format Format
+	}{
+		{time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), Snowflake},
+		{time.Date(1969, 1, 1, 0, 0, 0, 0, time.UTC), ULID},
+		{time.Date(1500, 1, 1, 0, 0, 0, 0, time.UTC), UUIDv1},
+		{time.Date(1500, 1, 1, 0, 0, 0, 0, time.UTC), FileTime},
+		{time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC), UnixNano},
+		{time.Date(1899, 1, 1, 0, 0, 0, 0, time.UTC), Excel},
+		{time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC), Excel},
+	} {
+		if v, err := Encode(tt.t, tt.format, opts); err == nil {
+			t.Errorf("Encode(%v, %s) = %s, want an error", tt.t, tt.format, v)
+		}
+	}
+
+	for _, tt := range []struct {
+		t    time.Time
+		opts Options
+		want string
+	}{
+		{time.Date(1900, 2, 28, 0, 0, 0, 0, time.UTC), opts, "59"},
+		{time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), opts, "61"},
+		{time.Date(1904, 1, 2, 6, 0, 0, 0, time.UTC), Options{Excel1904: true, Location: time.UTC}, "1.25"},
+	} {
+		if got, _ := Encode(tt.t, Excel, tt.opts); got != tt.want {
+			t.Errorf("Excel(%v) = %s, want %s", tt.t, got, tt.want)
+		}
+	}
+
+	if got, _ := Encode(time.Unix(-2, 500_000_000), Unix, opts); got != "-1.5" {
+		t.Errorf("Unix(-1.5s) = %s", got)
+	}
+	if got, _ := Convert("1760781600", Unix, ULID, opts); got != "01K7VC61800000000000000000" {
+		t.Errorf("Convert = %s", got)
+	}
+}
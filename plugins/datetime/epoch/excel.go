@@ -0,0 +1,75 @@
+package epoch
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Excel 的序列日期是从纪元起的天数，小数部分是一天中的时间。1900 日期
+// 系统沿用了 Lotus 1-2-3 的错误，把 1900 年当作闰年：序列号 60 是不存在的
+// 1900-02-29，之后的日期都多算了一天。
+const (
+	excelMaxSerial  = 2958466 // 10000-01-01
+	excelLeapBug    = 60
+	excel1904Offset = 1462 // 1904-01-01 在 1900 日期系统中的序列号
+	msPerDay        = 24 * 60 * 60 * 1000
+)
+
+// excelEpoch is the day that serial 0 would be if 1900 had no February 29.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+func decodeExcel(s string, use1904 bool, loc *time.Location) (time.Time, string, error) {
+	serial, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(serial) || math.IsInf(serial, 0) {
+		return time.Time{}, "", fmt.Errorf("无效的 Excel 序列日期: %s", s)
+	}
+	if serial < 0 {
+		return time.Time{}, "", fmt.Errorf("Excel 序列日期不能为负数: %s", s)
+	}
+	detail := "1900 日期系统"
+	if use1904 {
+		serial += excel1904Offset
+		detail = "1904 日期系统"
+	}
+	if serial >= excelMaxSerial {
+		return time.Time{}, "", fmt.Errorf("Excel 序列日期超出范围: %s", s)
+	}
+
+	days := int(serial)
+	if !use1904 {
+		switch {
+		case days == excelLeapBug:
+			return time.Time{}, "", fmt.Errorf("Excel 序列日期 60 是不存在的 1900-02-29")
+		case days < excelLeapBug:
+			days++
+		}
+	}
+	ms := int(math.Round((serial - math.Floor(serial)) * msPerDay))
+	d := excelEpoch.AddDate(0, 0, days)
+	// 序列日期是墙上时间，没有时区
+	t := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, ms*int(time.Millisecond), loc)
+	return t, detail, nil
+}
+
+func encodeExcel(t time.Time, use1904 bool, loc *time.Location) (string, error) {
+	t = t.In(loc)
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	days := int((date.Unix() - excelEpoch.Unix()) / (24 * 60 * 60))
+	if days >= excelMaxSerial {
+		return "", fmt.Errorf("Excel 序列日期无法表示 %s", t.Format("2006-01-02"))
+	}
+	if use1904 {
+		days -= excel1904Offset
+	} else if days <= excelLeapBug {
+		days-- // 1900-03-01 之前
+	}
+	if days < 0 {
+		return "", fmt.Errorf("Excel 序列日期无法表示 %s", t.Format("2006-01-02"))
+	}
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	serial := float64(days) + float64(clock.Milliseconds())/msPerDay
+	return strconv.FormatFloat(serial, 'f', -1, 64), nil
+}
@@ -0,0 +1,198 @@
+package epoch
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snowflake ID：41 位毫秒时间戳、5 位机器、5 位进程和 12 位序号
+const snowflakeTimeShift = 22
+
+func decodeSnowflake(s string, epoch int64) (time.Time, string, error) {
+	id, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || id >= 1<<63 {
+		return time.Time{}, "", fmt.Errorf("无效的 Snowflake ID: %s", s)
+	}
+	ms := int64(id>>snowflakeTimeShift) + epoch
+	detail := fmt.Sprintf("机器 %d，进程 %d，序号 %d", id>>17&0x1f, id>>12&0x1f, id&0xfff)
+	return time.UnixMilli(ms).UTC(), detail, nil
+}
+
+func encodeSnowflake(t time.Time, epoch int64) (string, error) {
+	ms := t.UnixMilli() - epoch
+	if ms < 0 {
+		return "", fmt.Errorf("Snowflake ID 无法表示纪元 %s 之前的时间", time.UnixMilli(epoch).UTC().Format(time.RFC3339))
+	}
+	if ms >= 1<<(63-snowflakeTimeShift) {
+		return "", fmt.Errorf("Snowflake ID 无法表示 %d 年", t.Year())
+	}
+	return strconv.FormatUint(uint64(ms)<<snowflakeTimeShift, 10), nil
+}
+
+// ULID 使用 Crockford Base32：前 10 个字符是 48 位毫秒时间戳，后 16 个是随机数
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const (
+	ulidLength     = 26
+	ulidTimeLength = 10
+)
+
+// crockfordValue returns the value of a Crockford Base32 character, which
+// is case-insensitive and reads I and L as 1 and O as 0.
+func crockfordValue(c byte) (int, bool) {
+	switch c = byte(strings.ToUpper(string(c))[0]); c {
+	case 'I', 'L':
+		return 1, true
+	case 'O':
+		return 0, true
+	}
+	i := strings.IndexByte(crockford, c)
+	return i, i >= 0
+}
+
+func decodeULID(s string) (time.Time, error) {
+	if len(s) != ulidLength {
+		return time.Time{}, fmt.Errorf("ULID 应为 %d 个字符: %s", ulidLength, s)
+	}
+	var ms int64
+	for i := 0; i < len(s); i++ {
+		v, ok := crockfordValue(s[i])
+		if !ok {
+			return time.Time{}, fmt.Errorf("ULID 含有无效字符 %q: %s", s[i], s)
+		}
+		if i == 0 && v > 7 {
+			return time.Time{}, fmt.Errorf("ULID 超出范围: %s", s) // 时间戳只有 48 位
+		}
+		if i < ulidTimeLength {
+			ms = ms<<5 | int64(v)
+		}
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+func encodeULID(t time.Time) (string, error) {
+	ms := t.UnixMilli()
+	if ms < 0 || ms >= 1<<48 {
+		return "", fmt.Errorf("ULID 无法表示 %d 年", t.Year())
+	}
+	b := []byte(strings.Repeat("0", ulidLength))
+	for i := ulidTimeLength - 1; i >= 0; i-- {
+		b[i] = crockford[ms&0x1f]
+		ms >>= 5
+	}
+	return string(b), nil
+}
+
+// UUID v1 和 v6 的时间戳是 1582-10-15 起的 100 纳秒数
+const gregorianUnixOffset = 122192928000000000
+
+// parseUUID reads the 16 bytes of a UUID, with or without hyphens, braces
+// or a "urn:uuid:" prefix.
+func parseUUID(s string) ([]byte, error) {
+	h := strings.TrimPrefix(strings.ToLower(s), "urn:uuid:")
+	h = strings.Trim(h, "{}")
+	h = strings.ReplaceAll(h, "-", "")
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) != 16 {
+		return nil, fmt.Errorf("无效的 UUID: %s", s)
+	}
+	return b, nil
+}
+
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func uuidVersion(format Format) int {
+	switch format {
+	case UUIDv1:
+		return 1
+	case UUIDv6:
+		return 6
+	}
+	return 7
+}
+
+func decodeUUID(s string, format Format) (time.Time, string, error) {
+	b, err := parseUUID(s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	version := int(b[6] >> 4)
+	if version != uuidVersion(format) {
+		if version != 1 && version != 6 && version != 7 {
+			return time.Time{}, "", fmt.Errorf("UUID v%d 不含时间戳", version)
+		}
+		return time.Time{}, "", fmt.Errorf("这是 UUID v%d，不是 v%d", version, uuidVersion(format))
+	}
+
+	if version == 7 {
+		var ms int64
+		for _, c := range b[:6] {
+			ms = ms<<8 | int64(c)
+		}
+		return time.UnixMilli(ms).UTC(), "", nil
+	}
+
+	var ts int64
+	if version == 1 {
+		ts = int64(b[6]&0x0f)<<56 | int64(b[7])<<48 | int64(b[4])<<40 | int64(b[5])<<32 |
+			int64(b[0])<<24 | int64(b[1])<<16 | int64(b[2])<<8 | int64(b[3])
+	} else {
+		for _, c := range b[:6] {
+			ts = ts<<8 | int64(c)
+		}
+		ts = ts<<12 | int64(b[6]&0x0f)<<8 | int64(b[7])
+	}
+	ts -= gregorianUnixOffset
+	t := time.Unix(ts/1e7, ts%1e7*100).UTC()
+	clockSeq := int(b[8]&0x3f)<<8 | int(b[9])
+	node := make([]string, 6)
+	for i, c := range b[10:] {
+		node[i] = fmt.Sprintf("%02x", c)
+	}
+	return t, fmt.Sprintf("时钟序列 %d，节点 %s", clockSeq, strings.Join(node, ":")), nil
+}
+
+func encodeUUID(t time.Time, format Format) (string, error) {
+	b := make([]byte, 16)
+	version := uuidVersion(format)
+	if version == 7 {
+		ms := t.UnixMilli()
+		if ms < 0 || ms >= 1<<48 {
+			return "", fmt.Errorf("UUID v7 无法表示 %d 年", t.Year())
+		}
+		for i := 5; i >= 0; i-- {
+			b[i] = byte(ms)
+			ms >>= 8
+		}
+	} else {
+		// 60 位的时间戳可以表示 1582 到 5236 年
+		if t.Year() < 1582 || t.Year() > 5235 {
+			return "", fmt.Errorf("UUID v%d 无法表示 %d 年", version, t.Year())
+		}
+		ts := (t.Unix()*1e7 + int64(t.Nanosecond()/100)) + gregorianUnixOffset
+		if ts < 0 {
+			return "", fmt.Errorf("UUID v%d 无法表示 1582-10-15 之前的时间", version)
+		}
+		if version == 1 {
+			b[0], b[1], b[2], b[3] = byte(ts>>24), byte(ts>>16), byte(ts>>8), byte(ts)
+			b[4], b[5] = byte(ts>>40), byte(ts>>32)
+			b[6], b[7] = byte(ts>>56), byte(ts>>48)
+		} else {
+			high := ts >> 12
+			for i := 5; i >= 0; i-- {
+				b[i] = byte(high)
+				high >>= 8
+			}
+			b[6], b[7] = byte(ts>>8&0x0f), byte(ts)
+		}
+	}
+	b[6] = b[6]&0x0f | byte(version)<<4
+	b[8] = 0x80 // RFC 9562 变体
+	return formatUUID(b), nil
+}
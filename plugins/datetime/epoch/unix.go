@@ -0,0 +1,99 @@
+package epoch
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var unixPattern = regexp.MustCompile(`^(-?\d+)(?:\.(\d{1,9}))?$`)
+
+// Windows FILETIME 从 1601-01-01 UTC 起，以 100 纳秒为单位
+const (
+	fileTimeUnixOffset = 11644473600 // 1601 到 1970 的秒数
+	fileTimeTicks      = 10000000    // 每秒的 100 纳秒数
+)
+
+func decodeUnix(s string, format Format) (time.Time, error) {
+	m := unixPattern.FindStringSubmatch(s)
+	if m == nil || (m[2] != "" && format != Unix) {
+		return time.Time{}, fmt.Errorf("无效的时间戳: %s", s)
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("时间戳超出范围: %s", s)
+	}
+	switch format {
+	case UnixMilli:
+		return time.UnixMilli(n).UTC(), nil
+	case UnixMicro:
+		return time.UnixMicro(n).UTC(), nil
+	case UnixNano:
+		return time.Unix(0, n).UTC(), nil
+	}
+	nsec := 0
+	if m[2] != "" {
+		nsec, _ = strconv.Atoi((m[2] + "000000000")[:9])
+	}
+	if strings.HasPrefix(m[1], "-") {
+		nsec = -nsec
+	}
+	return time.Unix(n, int64(nsec)).UTC(), nil
+}
+
+func encodeUnix(t time.Time, format Format) (string, error) {
+	switch format {
+	case UnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10), nil
+	case UnixMicro:
+		return strconv.FormatInt(t.UnixMicro(), 10), nil
+	case UnixNano:
+		// int64 的纳秒只能表示 1678 到 2262 年
+		if t.Year() < 1678 || t.Year() > 2261 {
+			return "", fmt.Errorf("纳秒时间戳无法表示 %d 年", t.Year())
+		}
+		return strconv.FormatInt(t.UnixNano(), 10), nil
+	}
+	sec, ns := t.Unix(), int64(t.Nanosecond())
+	if ns == 0 {
+		return strconv.FormatInt(sec, 10), nil
+	}
+	sign := ""
+	if sec < 0 {
+		// Unix() 向下取整：-1.5 秒是 -2 秒加 0.5 秒
+		sign, sec, ns = "-", -(sec + 1), 1e9-ns
+	}
+	return fmt.Sprintf("%s%d.%s", sign, sec, strings.TrimRight(fmt.Sprintf("%09d", ns), "0")), nil
+}
+
+// parseUint parses a decimal or 0x hexadecimal number.
+func parseUint(s string) (uint64, error) {
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "0x") {
+		return strconv.ParseUint(lower[2:], 16, 64)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func decodeFileTime(s string) (time.Time, error) {
+	n, err := parseUint(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的 FILETIME: %s", s)
+	}
+	sec := int64(n/fileTimeTicks) - fileTimeUnixOffset
+	return time.Unix(sec, int64(n%fileTimeTicks)*100).UTC(), nil
+}
+
+func encodeFileTime(t time.Time) (string, error) {
+	sec := t.Unix() + fileTimeUnixOffset
+	if sec < 0 {
+		return "", fmt.Errorf("FILETIME 无法表示 1601 年之前的时间")
+	}
+	if sec >= math.MaxInt64/fileTimeTicks {
+		return "", fmt.Errorf("FILETIME 无法表示 %d 年", t.Year())
+	}
+	return strconv.FormatInt(sec*fileTimeTicks+int64(t.Nanosecond()/100), 10), nil
+}
@@ -1,6 +1,12 @@
 package datetime
 
-import "time"
+import (
+	"time"
+
+	"ltools/plugins/datetime/dates"
+	"ltools/plugins/datetime/timers"
+	"ltools/plugins/datetime/worldclock"
+)
 
 // DateTimeService exposes DateTime functionality to the frontend
 // This is a thin wrapper around the plugin that can be registered as a Wails service
@@ -46,11 +52,127 @@ func (s *DateTimeService) TimestampToDateTime(timestamp int64) string {
 
 // DateTimeToTimestamp converts a datetime string to Unix timestamp
 func (s *DateTimeService) DateTimeToTimestamp(datetimeStr string) (int64, error) {
-	t, err := time.Parse("2006-01-02 15:04:05", datetimeStr)
-	if err != nil {
-		return 0, err
-	}
-	return t.Unix(), nil
+	return s.plugin.DateTimeToTimestamp(datetimeStr)
+}
+
+// ParseDate parses a date in one of many forms and describes it
+func (s *DateTimeService) ParseDate(input string) (*dates.Info, error) {
+	return s.plugin.ParseDate(input)
+}
+
+// DateDiff returns the span between two dates
+func (s *DateTimeService) DateDiff(from, to string) (*dates.Span, error) {
+	return s.plugin.DateDiff(from, to)
+}
+
+// AddBusinessDays moves a date by a number of business days
+func (s *DateTimeService) AddBusinessDays(input string, days int) (*dates.Info, error) {
+	return s.plugin.AddBusinessDays(input, days)
+}
+
+// GetHolidayCalendar returns the holiday calendar
+func (s *DateTimeService) GetHolidayCalendar() *dates.Calendar {
+	return s.plugin.GetHolidayCalendar()
+}
+
+// SetHolidayCalendar replaces and saves the holiday calendar
+func (s *DateTimeService) SetHolidayCalendar(cal *dates.Calendar) error {
+	return s.plugin.SetHolidayCalendar(cal)
+}
+
+// GetWorldClocks returns the configured time zones
+func (s *DateTimeService) GetWorldClocks() []worldclock.Clock {
+	return s.plugin.GetWorldClocks()
+}
+
+// AddWorldClock adds a time zone to the world clocks
+func (s *DateTimeService) AddWorldClock(zone, label string) (worldclock.Clock, error) {
+	return s.plugin.AddWorldClock(zone, label)
+}
+
+// RemoveWorldClock removes a world clock
+func (s *DateTimeService) RemoveWorldClock(index int) error {
+	return s.plugin.RemoveWorldClock(index)
+}
+
+// MoveWorldClock moves a world clock to another position
+func (s *DateTimeService) MoveWorldClock(from, to int) error {
+	return s.plugin.MoveWorldClock(from, to)
+}
+
+// GetWorldTimes returns the current time in every world clock
+func (s *DateTimeService) GetWorldTimes() []worldclock.ZoneTime {
+	return s.plugin.GetWorldTimes()
+}
+
+// ConvertTime shows a time given in one zone in every world clock
+func (s *DateTimeService) ConvertTime(input, fromZone string) ([]worldclock.ZoneTime, error) {
+	return s.plugin.ConvertTime(input, fromZone)
+}
+
+// PlanMeeting finds overlapping working hours of several time zones
+func (s *DateTimeService) PlanMeeting(req worldclock.PlanRequest) ([]worldclock.Slot, error) {
+	return s.plugin.PlanMeeting(req)
+}
+
+// ListTimezones returns the known time zones
+func (s *DateTimeService) ListTimezones() []string {
+	return s.plugin.ListTimezones()
+}
+
+// GetTimers returns the timers and reminders
+func (s *DateTimeService) GetTimers() []timers.State {
+	return s.plugin.GetTimers()
+}
+
+// StartCountdown starts a countdown of the given number of seconds
+func (s *DateTimeService) StartCountdown(name string, seconds int) (timers.State, error) {
+	return s.plugin.StartCountdown(name, seconds)
+}
+
+// StartStopwatch starts a stopwatch
+func (s *DateTimeService) StartStopwatch(name string) (timers.State, error) {
+	return s.plugin.StartStopwatch(name)
+}
+
+// StartPomodoro starts a Pomodoro timer
+func (s *DateTimeService) StartPomodoro(name string, cfg timers.PomodoroConfig) (timers.State, error) {
+	return s.plugin.StartPomodoro(name, cfg)
+}
+
+// AddReminder adds a one-shot or repeating reminder
+func (s *DateTimeService) AddReminder(message, at, schedule string) (timers.State, error) {
+	return s.plugin.AddReminder(message, at, schedule)
+}
+
+// PauseTimer pauses a running timer
+func (s *DateTimeService) PauseTimer(id string) error {
+	return s.plugin.PauseTimer(id)
+}
+
+// ResumeTimer continues a paused timer
+func (s *DateTimeService) ResumeTimer(id string) error {
+	return s.plugin.ResumeTimer(id)
+}
+
+// ResetTimer sets a timer back to zero
+func (s *DateTimeService) ResetTimer(id string) error {
+	return s.plugin.ResetTimer(id)
+}
+
+// LapTimer records a lap of a stopwatch
+func (s *DateTimeService) LapTimer(id string) error {
+	return s.plugin.LapTimer(id)
+}
+
+// SkipPomodoroPhase ends the current phase of a Pomodoro timer
+func (s *DateTimeService) SkipPomodoroPhase(id string) error {
+	return s.plugin.SkipPomodoroPhase(id)
+}
+
+// RemoveTimer deletes a timer or reminder
+func (s *DateTimeService) RemoveTimer(id string) error {
+	return s.plugin.RemoveTimer(id)
 }
 
 // GetWeekday returns the weekday name for the current date
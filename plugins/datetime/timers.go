@@ -0,0 +1,160 @@
+package datetime
+
+import (
+	"fmt"
+	"time"
+
+	"ltools/internal/logging"
+	"ltools/internal/notify"
+	"ltools/plugins/datetime/dates"
+	"ltools/plugins/datetime/timers"
+)
+
+// SetNotifier sets how finished timers and reminders are shown to the user.
+func (p *DateTimePlugin) SetNotifier(notifier notify.Notifier) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notifier = notifier
+}
+
+// tickTimers fires the timers that became due and sends their state to the
+// frontend while any is running.
+func (p *DateTimePlugin) tickTimers(now time.Time) {
+	manager := p.getTimers()
+	alerts, err := manager.Tick(now)
+	if err != nil {
+		logging.For(PluginID).Warn("failed to save timers", "error", err)
+	}
+
+	p.mu.RLock()
+	notifier := p.notifier
+	p.mu.RUnlock()
+	for _, alert := range alerts {
+		p.app.Event.Emit("datetime:alert", alert)
+		if notifier == nil {
+			continue
+		}
+		n := notify.Notification{ID: "timer-" + alert.TimerID, Title: alert.Title, Body: alert.Body}
+		if err := notifier.Notify(n); err != nil {
+			logging.For(PluginID).Warn("failed to show timer notification", "error", err)
+		}
+	}
+
+	if len(alerts) > 0 || manager.Active() {
+		p.app.Event.Emit("datetime:timers", manager.List(now))
+	}
+}
+
+// GetTimers returns the countdowns, stopwatches, Pomodoro timers and
+// reminders.
+func (p *DateTimePlugin) GetTimers() []timers.State {
+	return p.getTimers().List(time.Now())
+}
+
+// StartCountdown starts a countdown of the given number of seconds.
+func (p *DateTimePlugin) StartCountdown(name string, seconds int) (timers.State, error) {
+	return p.getTimers().StartCountdown(name, time.Duration(seconds)*time.Second, time.Now())
+}
+
+// StartStopwatch starts a stopwatch.
+func (p *DateTimePlugin) StartStopwatch(name string) (timers.State, error) {
+	return p.getTimers().StartStopwatch(name, time.Now())
+}
+
+// StartPomodoro starts a Pomodoro timer. Zero fields of cfg take the
+// defaults: 25 minutes of work, 5 and 15 minute breaks, a long break after
+// every 4.
+func (p *DateTimePlugin) StartPomodoro(name string, cfg timers.PomodoroConfig) (timers.State, error) {
+	def := timers.DefaultPomodoro()
+	if cfg.Work == 0 {
+		cfg.Work = def.Work
+	}
+	if cfg.ShortBreak == 0 {
+		cfg.ShortBreak = def.ShortBreak
+	}
+	if cfg.LongBreak == 0 {
+		cfg.LongBreak = def.LongBreak
+	}
+	if cfg.LongEvery == 0 {
+		cfg.LongEvery = def.LongEvery
+	}
+	return p.getTimers().StartPomodoro(name, cfg, time.Now())
+}
+
+// AddReminder adds a reminder at a time ParseDate understands, such as
+// "15:00" or "tomorrow 9am". With a cron schedule ("0 9 * * mon-fri") the
+// reminder repeats and at may be empty.
+func (p *DateTimePlugin) AddReminder(message, at, schedule string) (timers.State, error) {
+	now := time.Now()
+	var t time.Time
+	if at != "" {
+		var err error
+		if t, err = dates.Parse(at, now); err != nil {
+			return timers.State{}, err
+		}
+	}
+	return p.getTimers().AddReminder(message, t, schedule, now)
+}
+
+// PauseTimer pauses a running timer.
+func (p *DateTimePlugin) PauseTimer(id string) error {
+	return p.getTimers().Pause(id, time.Now())
+}
+
+// ResumeTimer continues a paused timer.
+func (p *DateTimePlugin) ResumeTimer(id string) error {
+	return p.getTimers().Resume(id, time.Now())
+}
+
+// ResetTimer sets a timer back to zero.
+func (p *DateTimePlugin) ResetTimer(id string) error {
+	return p.getTimers().Reset(id)
+}
+
+// LapTimer records a lap of a stopwatch.
+func (p *DateTimePlugin) LapTimer(id string) error {
+	return p.getTimers().Lap(id, time.Now())
+}
+
+// SkipPomodoroPhase ends the current phase of a Pomodoro timer.
+func (p *DateTimePlugin) SkipPomodoroPhase(id string) error {
+	return p.getTimers().Skip(id, time.Now())
+}
+
+// RemoveTimer deletes a timer or reminder.
+func (p *DateTimePlugin) RemoveTimer(id string) error {
+	return p.getTimers().Remove(id)
+}
+
+// Command describes the timer command typed in the search window, such as
+// "timer 25m" or "remind 10m 喝水".
+func (p *DateTimePlugin) Command(query string) (title, description string, ok bool) {
+	cmd, ok, err := timers.ParseCommand(query, time.Now())
+	if !ok {
+		return "", "", false
+	}
+	if err != nil {
+		return err.Error(), "计时器命令", true
+	}
+	return cmd.Describe(), "回车开始，结束时通知", true
+}
+
+// RunCommand runs a timer command typed in the search window.
+func (p *DateTimePlugin) RunCommand(query string) error {
+	now := time.Now()
+	cmd, ok, err := timers.ParseCommand(query, now)
+	if !ok {
+		return fmt.Errorf("不是计时器命令: %s", query)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = cmd.Run(p.getTimers(), now)
+	return err
+}
+
+func (p *DateTimePlugin) getTimers() *timers.Manager {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.timers
+}
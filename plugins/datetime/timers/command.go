@@ -0,0 +1,214 @@
+package timers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ltools/plugins/datetime/dates"
+)
+
+var durationUnits = map[string]time.Duration{
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"小时": time.Hour, "个小时": time.Hour,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"分": time.Minute, "分钟": time.Minute,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"秒": time.Second, "秒钟": time.Second,
+}
+
+var durationPart = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([a-z\p{Han}]*)`)
+
+// ParseDuration parses a duration such as "25m", "1h30m", "90 seconds",
+// "1.5h" or "25分钟". A bare number is minutes.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("时长为空")
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		s = strconv.FormatFloat(n, 'f', -1, 64) + "m"
+	}
+
+	var total time.Duration
+	for rest := s; rest != ""; rest = strings.TrimSpace(rest) {
+		m := durationPart.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, fmt.Errorf("无效的时长: %s", s)
+		}
+		unit, ok := durationUnits[strings.ToLower(m[2])]
+		if !ok {
+			return 0, fmt.Errorf("无效的时长: %s", s)
+		}
+		n, _ := strconv.ParseFloat(m[1], 64)
+		if n*float64(unit) > float64(366*24*time.Hour) {
+			return 0, fmt.Errorf("时长太长: %s", s)
+		}
+		total += time.Duration(n * float64(unit))
+		rest = rest[len(m[0]):]
+	}
+	return total, nil
+}
+
+// FormatDuration formats d as hours, minutes and seconds, e.g. 1小时30分钟.
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h, m, s := int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second)
+	var sb strings.Builder
+	if h > 0 {
+		fmt.Fprintf(&sb, "%d小时", h)
+	}
+	if m > 0 {
+		fmt.Fprintf(&sb, "%d分钟", m)
+	}
+	if s > 0 || sb.Len() == 0 {
+		fmt.Fprintf(&sb, "%d秒", s)
+	}
+	return sb.String()
+}
+
+// Command is a timer command typed in the search window:
+//
+//	timer 25m [name]          倒计时 25m [名称]
+//	stopwatch [name]          秒表 [名称]
+//	pomodoro [work [break]]   番茄钟 [专注时长 [休息时长]]
+//	remind <when> <message>   提醒 <时间> <内容>
+//
+// The time of a reminder is a duration from now ("10m") or a date that
+// dates.Parse understands ("15:00", "tomorrow 9am").
+type Command struct {
+	Kind     Kind
+	Name     string
+	Duration time.Duration
+	Pomodoro PomodoroConfig
+	At       time.Time
+	Message  string
+}
+
+var commandWords = map[string]Kind{
+	"timer": Countdown, "countdown": Countdown, "计时": Countdown, "倒计时": Countdown,
+	"stopwatch": Stopwatch, "秒表": Stopwatch,
+	"pomodoro": Pomodoro, "番茄": Pomodoro, "番茄钟": Pomodoro,
+	"remind": Reminder, "reminder": Reminder, "提醒": Reminder,
+}
+
+// maxWhenWords is the most words tried as the time of a reminder.
+const maxWhenWords = 4
+
+// ParseCommand parses a timer command. ok is false if query is not a timer
+// command; err is set if it is one with invalid arguments.
+func ParseCommand(query string, now time.Time) (cmd *Command, ok bool, err error) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return nil, false, nil
+	}
+	kind, ok := commandWords[strings.ToLower(words[0])]
+	if !ok {
+		return nil, false, nil
+	}
+	args := words[1:]
+	cmd = &Command{Kind: kind}
+
+	switch kind {
+	case Countdown:
+		if len(args) == 0 {
+			return nil, true, fmt.Errorf("请输入时长，例如 timer 25m")
+		}
+		// 时长可以带空格，例如 "1h 30m"：取能解析的最长前缀
+		n := 0
+		for i := len(args); i > 0; i-- {
+			if d, err := ParseDuration(strings.Join(args[:i], " ")); err == nil {
+				cmd.Duration, n = d, i
+				break
+			}
+		}
+		if n == 0 {
+			return nil, true, fmt.Errorf("无效的时长: %s", args[0])
+		}
+		if cmd.Duration <= 0 {
+			return nil, true, fmt.Errorf("倒计时时长必须大于 0")
+		}
+		cmd.Name = strings.Join(args[n:], " ")
+
+	case Stopwatch:
+		cmd.Name = strings.Join(args, " ")
+
+	case Pomodoro:
+		cmd.Pomodoro = DefaultPomodoro()
+		for i, arg := range args {
+			d, err := ParseDuration(arg)
+			if err != nil || i > 1 || d < time.Minute {
+				return nil, true, fmt.Errorf("番茄钟参数应为专注和休息时长（分钟），例如 pomodoro 50 10")
+			}
+			if i == 0 {
+				cmd.Pomodoro.Work = int(d / time.Minute)
+			} else {
+				cmd.Pomodoro.ShortBreak = int(d / time.Minute)
+			}
+		}
+
+	case Reminder:
+		if len(args) == 0 {
+			return nil, true, fmt.Errorf("请输入提醒时间和内容，例如 remind 10m 喝水")
+		}
+		n := 0
+		for i := min(len(args), maxWhenWords); i > 0; i-- {
+			when := strings.Join(args[:i], " ")
+			if d, err := ParseDuration(when); err == nil {
+				cmd.At, n = now.Add(d), i
+				break
+			}
+			if t, err := dates.Parse(when, now); err == nil {
+				cmd.At, n = t, i
+				break
+			}
+		}
+		if n == 0 {
+			return nil, true, fmt.Errorf("无法识别提醒时间: %s", args[0])
+		}
+		if !cmd.At.After(now) {
+			return nil, true, fmt.Errorf("提醒时间 %s 已经过去", cmd.At.Format("2006-01-02 15:04"))
+		}
+		cmd.Message = strings.Join(args[n:], " ")
+	}
+	return cmd, true, nil
+}
+
+// Describe says what the command does, for the search window.
+func (c *Command) Describe() string {
+	switch c.Kind {
+	case Countdown:
+		if c.Name != "" {
+			return fmt.Sprintf("倒计时 %s：%s", FormatDuration(c.Duration), c.Name)
+		}
+		return fmt.Sprintf("倒计时 %s", FormatDuration(c.Duration))
+	case Stopwatch:
+		return strings.TrimSpace("开始秒表 " + c.Name)
+	case Pomodoro:
+		return fmt.Sprintf("番茄钟：专注 %d 分钟，休息 %d 分钟", c.Pomodoro.Work, c.Pomodoro.ShortBreak)
+	case Reminder:
+		message := c.Message
+		if message == "" {
+			message = "提醒"
+		}
+		return fmt.Sprintf("%s 提醒：%s", c.At.Format("01-02 15:04"), message)
+	}
+	return ""
+}
+
+// Run executes the command with m.
+func (c *Command) Run(m *Manager, now time.Time) (State, error) {
+	switch c.Kind {
+	case Countdown:
+		return m.StartCountdown(c.Name, c.Duration, now)
+	case Stopwatch:
+		return m.StartStopwatch(c.Name, now)
+	case Pomodoro:
+		return m.StartPomodoro("", c.Pomodoro, now)
+	case Reminder:
+		return m.AddReminder(c.Message, c.At, "", now)
+	}
+	return State{}, fmt.Errorf("未知的计时器类型: %s", c.Kind)
+}
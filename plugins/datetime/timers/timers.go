@@ -0,0 +1,433 @@
+// Package timers keeps countdowns, stopwatches, Pomodoro cycles and
+// reminders. Running timers are stored with the wall-clock time they
+// started, so they keep counting while the app is closed; Tick reports what
+// became due since the last call.
+package timers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"ltools/plugins/datetime/cron"
+)
+
+// Kind is the kind of a timer.
+type Kind string
+
+const (
+	Countdown Kind = "countdown"
+	Stopwatch Kind = "stopwatch"
+	Pomodoro  Kind = "pomodoro"
+	Reminder  Kind = "reminder"
+)
+
+// Phase is the current phase of a Pomodoro timer.
+type Phase string
+
+const (
+	PhaseWork       Phase = "work"
+	PhaseShortBreak Phase = "short_break"
+	PhaseLongBreak  Phase = "long_break"
+)
+
+// maxTimers limits the number of timers, including finished ones.
+const maxTimers = 100
+
+// PomodoroConfig sets the phase lengths of a Pomodoro timer in minutes.
+type PomodoroConfig struct {
+	Work       int `json:"work"`
+	ShortBreak int `json:"shortBreak"`
+	LongBreak  int `json:"longBreak"`
+	LongEvery  int `json:"longEvery"` // 每完成几个番茄进行一次长休息
+}
+
+// DefaultPomodoro is 25 minutes of work, 5 minutes of break and a 15 minute
+// break after every fourth Pomodoro.
+func DefaultPomodoro() PomodoroConfig {
+	return PomodoroConfig{Work: 25, ShortBreak: 5, LongBreak: 15, LongEvery: 4}
+}
+
+func (c PomodoroConfig) phase(p Phase) time.Duration {
+	switch p {
+	case PhaseShortBreak:
+		return time.Duration(c.ShortBreak) * time.Minute
+	case PhaseLongBreak:
+		return time.Duration(c.LongBreak) * time.Minute
+	}
+	return time.Duration(c.Work) * time.Minute
+}
+
+// Timer is a countdown, stopwatch, Pomodoro timer or reminder.
+type Timer struct {
+	ID      string    `json:"id"`
+	Kind    Kind      `json:"kind"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+
+	// A running timer has counted Elapsed before StartedAt and counts on
+	// from there; a paused timer has counted Elapsed in total.
+	Running   bool          `json:"running"`
+	StartedAt time.Time     `json:"startedAt"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Done      bool          `json:"done"`
+
+	// Duration is the length of a countdown or of the current Pomodoro phase.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Laps are the elapsed times of a stopwatch when each lap was taken.
+	Laps []time.Duration `json:"laps,omitempty"`
+
+	Pomodoro  *PomodoroConfig `json:"pomodoro,omitempty"`
+	Phase     Phase           `json:"phase,omitempty"`
+	Completed int             `json:"completed,omitempty"` // 已完成的番茄数
+
+	// A reminder shows Message at At. With a cron Schedule it repeats.
+	Message  string    `json:"message,omitempty"`
+	At       time.Time `json:"at"`
+	Schedule string    `json:"schedule,omitempty"`
+}
+
+// elapsed returns the time counted at now.
+func (t *Timer) elapsed(now time.Time) time.Duration {
+	if t.Running {
+		return t.Elapsed + now.Sub(t.StartedAt)
+	}
+	return t.Elapsed
+}
+
+// State is a timer as shown at a moment.
+type State struct {
+	Timer
+	ElapsedMs int64 `json:"elapsedMs"`
+
+	// RemainingMs is the time left of a countdown or Pomodoro phase, or until
+	// a reminder.
+	RemainingMs int64 `json:"remainingMs"`
+}
+
+func (t *Timer) state(now time.Time) State {
+	s := State{Timer: *t, ElapsedMs: t.elapsed(now).Milliseconds()}
+	s.Laps = append([]time.Duration(nil), t.Laps...)
+	switch t.Kind {
+	case Countdown, Pomodoro:
+		s.RemainingMs = max(t.Duration-t.elapsed(now), 0).Milliseconds()
+	case Reminder:
+		if !t.Done {
+			s.RemainingMs = max(t.At.Sub(now), 0).Milliseconds()
+		}
+	}
+	return s
+}
+
+// Alert is a timer that became due.
+type Alert struct {
+	TimerID string `json:"timerId"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+}
+
+// Manager keeps the timers and saves them as JSON.
+type Manager struct {
+	mu     sync.Mutex
+	path   string // 为空时只保存在内存中
+	timers []*Timer
+	lastID int64
+}
+
+// Load reads the timers from path. A missing file gives no timers; an empty
+// path keeps them in memory.
+func Load(path string) (*Manager, error) {
+	m := &Manager{path: path}
+	if path == "" {
+		return m, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m.timers); err != nil {
+		return nil, fmt.Errorf("解析计时器文件失败: %w", err)
+	}
+	return m, nil
+}
+
+// List returns the timers as shown at now, oldest first.
+func (m *Manager) List(now time.Time) []State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := make([]State, 0, len(m.timers))
+	for _, t := range m.timers {
+		states = append(states, t.state(now))
+	}
+	return states
+}
+
+// Active reports whether a timer is running or a reminder is pending, i.e.
+// whether the timers change from second to second.
+func (m *Manager) Active() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.timers {
+		if t.Running || (t.Kind == Reminder && !t.Done) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartCountdown starts a countdown of d.
+func (m *Manager) StartCountdown(name string, d time.Duration, now time.Time) (State, error) {
+	if d <= 0 {
+		return State{}, fmt.Errorf("倒计时时长必须大于 0")
+	}
+	if name == "" {
+		name = FormatDuration(d)
+	}
+	return m.add(&Timer{Kind: Countdown, Name: name, Duration: d, Running: true, StartedAt: now}, now)
+}
+
+// StartStopwatch starts a stopwatch.
+func (m *Manager) StartStopwatch(name string, now time.Time) (State, error) {
+	if name == "" {
+		name = "秒表"
+	}
+	return m.add(&Timer{Kind: Stopwatch, Name: name, Running: true, StartedAt: now}, now)
+}
+
+// StartPomodoro starts a Pomodoro timer with its first work phase.
+func (m *Manager) StartPomodoro(name string, cfg PomodoroConfig, now time.Time) (State, error) {
+	if cfg.Work < 1 || cfg.ShortBreak < 1 || cfg.LongBreak < 1 || cfg.LongEvery < 1 {
+		return State{}, fmt.Errorf("番茄钟的时长和长休息间隔必须大于 0")
+	}
+	if name == "" {
+		name = "番茄钟"
+	}
+	return m.add(&Timer{
+		Kind: Pomodoro, Name: name, Pomodoro: &cfg, Phase: PhaseWork,
+		Duration: cfg.phase(PhaseWork), Running: true, StartedAt: now,
+	}, now)
+}
+
+// AddReminder adds a reminder at at. With a cron schedule the reminder
+// repeats, and a zero at means the next time of the schedule.
+func (m *Manager) AddReminder(message string, at time.Time, schedule string, now time.Time) (State, error) {
+	if message == "" {
+		message = "提醒"
+	}
+	if schedule != "" {
+		sched, err := cron.Parse(schedule)
+		if err != nil {
+			return State{}, err
+		}
+		if at.IsZero() {
+			at = sched.Next(now)
+		}
+	}
+	if at.IsZero() {
+		return State{}, fmt.Errorf("需要提醒时间或重复规则")
+	}
+	if schedule == "" && !at.After(now) {
+		return State{}, fmt.Errorf("提醒时间 %s 已经过去", at.Format("2006-01-02 15:04"))
+	}
+	return m.add(&Timer{Kind: Reminder, Name: message, Message: message, At: at, Schedule: schedule}, now)
+}
+
+func (m *Manager) add(t *Timer, now time.Time) (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.timers) >= maxTimers {
+		return State{}, fmt.Errorf("计时器最多 %d 个，请先删除不用的", maxTimers)
+	}
+	// ID 取创建时间，同一纳秒内创建的依次加一
+	id := max(now.UnixNano(), m.lastID+1)
+	m.lastID = id
+	t.ID = strconv.FormatInt(id, 36)
+	t.Created = now
+	m.timers = append(m.timers, t)
+	return t.state(now), m.save()
+}
+
+// Pause stops a running countdown, stopwatch or Pomodoro timer.
+func (m *Manager) Pause(id string, now time.Time) error {
+	return m.update(id, func(t *Timer) error {
+		if t.Kind == Reminder {
+			return fmt.Errorf("提醒不能暂停")
+		}
+		if t.Running {
+			t.Elapsed = t.elapsed(now)
+			t.Running = false
+		}
+		return nil
+	})
+}
+
+// Resume continues a paused timer.
+func (m *Manager) Resume(id string, now time.Time) error {
+	return m.update(id, func(t *Timer) error {
+		if t.Kind == Reminder || t.Done {
+			return fmt.Errorf("计时已经结束，请重置")
+		}
+		if !t.Running {
+			t.Running = true
+			t.StartedAt = now
+		}
+		return nil
+	})
+}
+
+// Reset sets a timer back to zero and pauses it. A Pomodoro timer returns to
+// its first work phase.
+func (m *Manager) Reset(id string) error {
+	return m.update(id, func(t *Timer) error {
+		if t.Kind == Reminder {
+			return fmt.Errorf("提醒不能重置")
+		}
+		t.Running, t.Done, t.Elapsed, t.Laps = false, false, 0, nil
+		if t.Kind == Pomodoro {
+			t.Phase, t.Completed = PhaseWork, 0
+			t.Duration = t.Pomodoro.phase(PhaseWork)
+		}
+		return nil
+	})
+}
+
+// Lap records a lap of a stopwatch.
+func (m *Manager) Lap(id string, now time.Time) error {
+	return m.update(id, func(t *Timer) error {
+		if t.Kind != Stopwatch {
+			return fmt.Errorf("只有秒表可以计圈")
+		}
+		t.Laps = append(t.Laps, t.elapsed(now))
+		return nil
+	})
+}
+
+// Skip ends the current phase of a Pomodoro timer and starts the next one.
+func (m *Manager) Skip(id string, now time.Time) error {
+	return m.update(id, func(t *Timer) error {
+		if t.Kind != Pomodoro {
+			return fmt.Errorf("只有番茄钟可以跳过阶段")
+		}
+		t.nextPhase(now)
+		return nil
+	})
+}
+
+// Remove deletes a timer.
+func (m *Manager) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, t := range m.timers {
+		if t.ID == id {
+			m.timers = append(m.timers[:i], m.timers[i+1:]...)
+			return m.save()
+		}
+	}
+	return fmt.Errorf("计时器 %s 不存在", id)
+}
+
+func (m *Manager) update(id string, fn func(t *Timer) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.timers {
+		if t.ID == id {
+			if err := fn(t); err != nil {
+				return err
+			}
+			return m.save()
+		}
+	}
+	return fmt.Errorf("计时器 %s 不存在", id)
+}
+
+// Tick finishes the countdowns and Pomodoro phases that ran out and fires
+// the reminders that are due at now. Reminders missed while the app was
+// closed fire once; a repeating one then continues from now.
+func (m *Manager) Tick(now time.Time) ([]Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var alerts []Alert
+	for _, t := range m.timers {
+		if t.Done {
+			continue
+		}
+		switch t.Kind {
+		case Countdown:
+			if t.Running && t.elapsed(now) >= t.Duration {
+				t.Running, t.Done, t.Elapsed = false, true, t.Duration
+				alerts = append(alerts, Alert{TimerID: t.ID, Title: "倒计时结束", Body: t.Name})
+			}
+		case Pomodoro:
+			if t.Running && t.elapsed(now) >= t.Duration {
+				alerts = append(alerts, t.nextPhase(now))
+			}
+		case Reminder:
+			if now.Before(t.At) {
+				continue
+			}
+			alerts = append(alerts, Alert{TimerID: t.ID, Title: "提醒", Body: t.Message})
+			t.Done = true
+			if t.Schedule != "" {
+				if sched, err := cron.Parse(t.Schedule); err == nil {
+					if next := sched.Next(now); !next.IsZero() {
+						t.At, t.Done = next, false
+					}
+				}
+			}
+		}
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+	return alerts, m.save()
+}
+
+// nextPhase moves a Pomodoro timer to its next phase, starting at now, and
+// returns the alert for the phase that ended.
+func (t *Timer) nextPhase(now time.Time) Alert {
+	cfg := t.Pomodoro
+	alert := Alert{TimerID: t.ID}
+	if t.Phase == PhaseWork {
+		t.Completed++
+		t.Phase = PhaseShortBreak
+		if t.Completed%cfg.LongEvery == 0 {
+			t.Phase = PhaseLongBreak
+		}
+		alert.Title = fmt.Sprintf("%s：完成第 %d 个番茄", t.Name, t.Completed)
+		alert.Body = fmt.Sprintf("休息 %d 分钟", int(cfg.phase(t.Phase)/time.Minute))
+	} else {
+		t.Phase = PhaseWork
+		alert.Title = fmt.Sprintf("%s：休息结束", t.Name)
+		alert.Body = fmt.Sprintf("开始第 %d 个番茄，专注 %d 分钟", t.Completed+1, cfg.Work)
+	}
+	t.Duration = cfg.phase(t.Phase)
+	t.Elapsed, t.StartedAt = 0, now
+	return alert
+}
+
+func (m *Manager) save() error {
+	if m.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m.timers, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存计时器失败: %w", err)
+	}
+	return os.Rename(tmp, m.path)
+}
@@ -0,0 +1,137 @@
+package timers
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timers.json")
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	countdown, _ := m.StartCountdown("", 10*time.Minute, start)
+	stopwatch, _ := m.StartStopwatch("", start)
+	pomodoro, _ := m.StartPomodoro("", PomodoroConfig{Work: 25, ShortBreak: 5, LongBreak: 15, LongEvery: 2}, start)
+	reminder, err := m.AddReminder("喝水", time.Time{}, "*/30 * * * *", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reminder.At != at(30*time.Minute) {
+		t.Errorf("reminder at %v, want 09:30", reminder.At)
+	}
+	if countdown.Name != "10分钟" || countdown.ID == stopwatch.ID {
+		t.Errorf("countdown = %+v", countdown)
+	}
+
+	// 暂停 2 分钟：倒计时在 12 分钟时结束
+	m.Pause(countdown.ID, at(5*time.Minute))
+	m.Resume(countdown.ID, at(7*time.Minute))
+	m.Lap(stopwatch.ID, at(time.Minute))
+	if alerts, _ := m.Tick(at(11 * time.Minute)); len(alerts) != 0 {
+		t.Errorf("alerts at 09:11 = %+v", alerts)
+	}
+
+	// 重新加载后继续计时
+	m, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts, err := m.Tick(at(12 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || alerts[0].TimerID != countdown.ID {
+		t.Fatalf("alerts at 09:12 = %+v", alerts)
+	}
+
+	alerts, _ = m.Tick(at(30 * time.Minute))
+	if len(alerts) != 2 || alerts[0].Title != "番茄钟：完成第 1 个番茄" || alerts[1].Body != "喝水" {
+		t.Fatalf("alerts at 09:30 = %+v", alerts)
+	}
+
+	states := m.List(at(31 * time.Minute))
+	byID := make(map[string]State)
+	for _, s := range states {
+		byID[s.ID] = s
+	}
+	if s := byID[countdown.ID]; !s.Done || s.RemainingMs != 0 {
+		t.Errorf("countdown = %+v", s)
+	}
+	if s := byID[stopwatch.ID]; s.ElapsedMs != (31*time.Minute).Milliseconds() || len(s.Laps) != 1 {
+		t.Errorf("stopwatch = %+v", s)
+	}
+	if s := byID[pomodoro.ID]; s.Phase != PhaseShortBreak || s.RemainingMs != (4*time.Minute).Milliseconds() {
+		t.Errorf("pomodoro = %+v", s)
+	}
+	if s := byID[reminder.ID]; s.Done || s.At != at(time.Hour) {
+		t.Errorf("reminder = %+v", s)
+	}
+
+	// 第二个番茄之后是长休息
+	m.Skip(pomodoro.ID, at(31*time.Minute))
+	alerts, _ = m.Tick(at(56 * time.Minute))
+	if len(alerts) != 1 || alerts[0].Body != "休息 15 分钟" {
+		t.Errorf("alerts at 09:56 = %+v", alerts)
+	}
+
+	if err := m.Reset(countdown.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Remove(stopwatch.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Lap(pomodoro.ID, start); err == nil {
+		t.Error("took a lap of a Pomodoro timer")
+	}
+	if _, err := m.AddReminder("x", start.Add(-time.Minute), "", start); err == nil {
+		t.Error("added a reminder in the past")
+	}
+	if n := len(m.List(start)); n != 3 {
+		t.Errorf("%d timers, want 3", n)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"timer 25m", "倒计时 25分钟"},
+		{"timer 1h 30m 泡茶", "倒计时 1小时30分钟：泡茶"},
+		{"倒计时 90秒", "倒计时 1分钟30秒"},
+		{"timer 1.5", "倒计时 1分钟30秒"},
+		{"stopwatch", "开始秒表"},
+		{"pomodoro 50 10", "番茄钟：专注 50 分钟，休息 10 分钟"},
+		{"remind 10m 喝水", "10-18 09:10 提醒：喝水"},
+		{"remind tomorrow 9am 交周报", "10-19 09:00 提醒：交周报"},
+		{"提醒 15:00", "10-18 15:00 提醒：提醒"},
+	}
+	for _, tt := range tests {
+		cmd, ok, err := ParseCommand(tt.query, now)
+		if !ok || err != nil {
+			t.Errorf("ParseCommand(%q) = ok %v, error %v", tt.query, ok, err)
+			continue
+		}
+		if got := cmd.Describe(); got != tt.want {
+			t.Errorf("ParseCommand(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"1+1", "timers", "time 25m", ""} {
+		if _, ok, _ := ParseCommand(query, now); ok {
+			t.Errorf("ParseCommand(%q) is a command", query)
+		}
+	}
+	for _, query := range []string{"timer", "timer soon", "remind 8am 早会", "pomodoro 1 2 3"} {
+		if _, ok, err := ParseCommand(query, now); !ok || err == nil {
+			t.Errorf("ParseCommand(%q) = ok %v, error %v; want an error", query, ok, err)
+		}
+	}
+}
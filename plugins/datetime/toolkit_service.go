@@ -0,0 +1,131 @@
+package datetime
+
+import (
+	"fmt"
+	"time"
+
+	"ltools/internal/timezone"
+	"ltools/plugins/datetime/cron"
+	"ltools/plugins/datetime/dates"
+	"ltools/plugins/datetime/epoch"
+	"ltools/plugins/datetime/worldclock"
+)
+
+// maxCronTimes is the most fire times NextCronTimes lists.
+const maxCronTimes = 100
+
+// TimeToolkitService exposes the cron and timestamp tools to the frontend.
+// Unlike DateTimeService it needs no plugin: every method is a pure
+// conversion.
+type TimeToolkitService struct{}
+
+// NewTimeToolkitService creates a new time toolkit service
+func NewTimeToolkitService() *TimeToolkitService {
+	return &TimeToolkitService{}
+}
+
+// ExplainCron validates a cron expression and describes it. dialect is
+// "unix", "quartz" or empty to detect it.
+func (s *TimeToolkitService) ExplainCron(spec, dialect string) (*cron.Explanation, error) {
+	return cron.Explain(spec, cron.Dialect(dialect))
+}
+
+// ValidateCron checks a cron expression without explaining it.
+func (s *TimeToolkitService) ValidateCron(spec, dialect string) error {
+	_, err := cron.ParseDialect(spec, cron.Dialect(dialect))
+	return err
+}
+
+// NextCronTimes lists the next count times a cron expression fires after
+// from (now if empty), in zone (local if empty). A CRON_TZ prefix in the
+// expression takes precedence over zone.
+func (s *TimeToolkitService) NextCronTimes(spec, dialect, zone, from string, count int) ([]worldclock.ZoneTime, error) {
+	if count <= 0 || count > maxCronTimes {
+		return nil, fmt.Errorf("次数应在 1 到 %d 之间", maxCronTimes)
+	}
+	schedule, err := cron.ParseDialect(spec, cron.Dialect(dialect))
+	if err != nil {
+		return nil, err
+	}
+	loc, err := lookupZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now().In(loc)
+	if from != "" {
+		if start, err = dates.Parse(from, start); err != nil {
+			return nil, err
+		}
+	}
+
+	var times []worldclock.ZoneTime
+	for _, t := range cron.NextN(schedule, start.In(loc), count) {
+		zt, err := worldclock.Clock{Zone: t.Location().String()}.At(t, loc)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, zt)
+	}
+	return times, nil
+}
+
+// DecodeTimestamp reads the time in a timestamp or ID. With an empty format
+// every format that gives a plausible time is tried. zone is the time zone
+// of Excel serial dates (local if empty).
+func (s *TimeToolkitService) DecodeTimestamp(input, format, zone string, opts epoch.Options) ([]epoch.Decoded, error) {
+	loc, err := lookupZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	opts.Location = loc
+	if format == "" {
+		results := epoch.DecodeAny(input, opts)
+		if len(results) == 0 {
+			return nil, fmt.Errorf("无法识别的时间戳: %s", input)
+		}
+		return results, nil
+	}
+	d, err := epoch.Decode(input, epoch.Format(format), opts)
+	if err != nil {
+		return nil, err
+	}
+	return []epoch.Decoded{d}, nil
+}
+
+// EncodeTimestamp writes a time that ParseDate understands ("now",
+// "2026-10-18 09:00") in every timestamp format, in zone (local if empty).
+func (s *TimeToolkitService) EncodeTimestamp(input, zone string, opts epoch.Options) ([]epoch.Value, error) {
+	loc, err := lookupZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	opts.Location = loc
+	t, err := dates.Parse(input, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+	return epoch.EncodeAll(t, opts), nil
+}
+
+// ConvertTimestamp converts a timestamp from one format to another.
+func (s *TimeToolkitService) ConvertTimestamp(input, from, to, zone string, opts epoch.Options) (string, error) {
+	loc, err := lookupZone(zone)
+	if err != nil {
+		return "", err
+	}
+	opts.Location = loc
+	return epoch.Convert(input, epoch.Format(from), epoch.Format(to), opts)
+}
+
+// GetSnowflakeEpochs returns the epochs of well-known Snowflake IDs.
+func (s *TimeToolkitService) GetSnowflakeEpochs() map[string]int64 {
+	return epoch.SnowflakeEpochs
+}
+
+// lookupZone finds a time zone by name, or the local one if name is empty.
+func lookupZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return timezone.Lookup(name)
+}
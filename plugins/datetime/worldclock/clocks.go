@@ -0,0 +1,196 @@
+// Package worldclock keeps the user's list of time zones, shows an instant
+// in all of them and finds meeting times within everyone's working hours.
+package worldclock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ltools/internal/timezone"
+	"ltools/plugins/datetime/dates"
+)
+
+// Clock is a time zone in the user's list.
+type Clock struct {
+	Zone  string `json:"zone"`  // IANA 名称或固定偏移，如 Asia/Tokyo、UTC+05:30
+	Label string `json:"label"` // 显示名称，为空时用城市名
+}
+
+// name returns the label, or the city of the zone.
+func (c Clock) name() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	city := c.Zone[strings.LastIndex(c.Zone, "/")+1:]
+	return strings.ReplaceAll(city, "_", " ")
+}
+
+// ZoneTime is an instant as seen in one time zone.
+type ZoneTime struct {
+	Zone     string    `json:"zone"`
+	Label    string    `json:"label"`
+	Time     time.Time `json:"time"`
+	DateTime string    `json:"dateTime"` // 2006-01-02 15:04:05
+	Weekday  string    `json:"weekday"`
+	Abbrev   string    `json:"abbrev"` // JST、CEST
+	Offset   string    `json:"offset"` // UTC+09:00
+	DST      bool      `json:"dst"`
+
+	// DayDiff is the date in this zone minus the date in the reference zone:
+	// 1 for the next day, -1 for the previous one.
+	DayDiff int `json:"dayDiff"`
+}
+
+// At shows t in the zone of c. The date is compared with the date in ref.
+func (c Clock) At(t time.Time, ref *time.Location) (ZoneTime, error) {
+	loc, err := timezone.Lookup(c.Zone)
+	if err != nil {
+		return ZoneTime{}, err
+	}
+	return zoneTime(c, loc, t, ref), nil
+}
+
+func zoneTime(c Clock, loc *time.Location, t time.Time, ref *time.Location) ZoneTime {
+	lt := t.In(loc)
+	abbrev, offset := lt.Zone()
+	return ZoneTime{
+		Zone:     c.Zone,
+		Label:    c.name(),
+		Time:     lt,
+		DateTime: lt.Format("2006-01-02 15:04:05"),
+		Weekday:  dates.WeekdayName(lt.Weekday()),
+		Abbrev:   abbrev,
+		Offset:   timezone.FormatOffset(offset),
+		DST:      lt.IsDST(),
+		DayDiff:  dayNumber(lt) - dayNumber(t.In(ref)),
+	}
+}
+
+// dayNumber numbers the calendar date of t, ignoring its zone.
+func dayNumber(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// List is the user's list of clocks, saved as JSON.
+type List struct {
+	mu     sync.Mutex
+	path   string // 为空时只保存在内存中
+	clocks []Clock
+	locs   map[string]*time.Location // 每秒都要显示，缓存加载过的时区
+}
+
+// Load reads the list from path. A missing file gives an empty list; an
+// empty path keeps the list in memory.
+func Load(path string) (*List, error) {
+	l := &List{path: path, clocks: []Clock{}, locs: make(map[string]*time.Location)}
+	if path == "" {
+		return l, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.clocks); err != nil {
+		return nil, fmt.Errorf("解析世界时钟列表失败: %w", err)
+	}
+	return l, nil
+}
+
+// Clocks returns the clocks in display order.
+func (l *List) Clocks() []Clock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Clock{}, l.clocks...)
+}
+
+// Add appends a zone to the list. The zone is resolved with
+// timezone.Lookup, so "Tokyo", "PST" and "UTC+8" work; the list keeps the
+// resolved name.
+func (l *List) Add(zone, label string) (Clock, error) {
+	loc, err := timezone.Lookup(zone)
+	if err != nil {
+		return Clock{}, err
+	}
+	c := Clock{Zone: loc.String(), Label: strings.TrimSpace(label)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, existing := range l.clocks {
+		if existing.Zone == c.Zone && existing.Label == c.Label {
+			return Clock{}, fmt.Errorf("时区 %s 已在列表中", c.Zone)
+		}
+	}
+	l.clocks = append(l.clocks, c)
+	return c, l.save()
+}
+
+// Remove removes the clock at index.
+func (l *List) Remove(index int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if index < 0 || index >= len(l.clocks) {
+		return fmt.Errorf("时钟 %d 不存在", index)
+	}
+	l.clocks = append(l.clocks[:index], l.clocks[index+1:]...)
+	return l.save()
+}
+
+// Move moves the clock at from to index to.
+func (l *List) Move(from, to int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if from < 0 || from >= len(l.clocks) || to < 0 || to >= len(l.clocks) {
+		return fmt.Errorf("无效的位置")
+	}
+	c := l.clocks[from]
+	l.clocks = append(l.clocks[:from], l.clocks[from+1:]...)
+	l.clocks = append(l.clocks[:to], append([]Clock{c}, l.clocks[to:]...)...)
+	return l.save()
+}
+
+// At shows t in every clock of the list. Clocks whose zone can no longer be
+// loaded are skipped.
+func (l *List) At(t time.Time, ref *time.Location) []ZoneTime {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	times := make([]ZoneTime, 0, len(l.clocks))
+	for _, c := range l.clocks {
+		loc, ok := l.locs[c.Zone]
+		if !ok {
+			var err error
+			if loc, err = timezone.Lookup(c.Zone); err != nil {
+				continue
+			}
+			l.locs[c.Zone] = loc
+		}
+		times = append(times, zoneTime(c, loc, t, ref))
+	}
+	return times
+}
+
+func (l *List) save() error {
+	if l.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l.clocks, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存世界时钟列表失败: %w", err)
+	}
+	return os.Rename(tmp, l.path)
+}
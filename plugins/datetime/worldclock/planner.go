@@ -0,0 +1,165 @@
+package worldclock
+
+import (
+	"fmt"
+	"time"
+
+	"ltools/internal/timezone"
+)
+
+// step is the granularity of the planner. Every UTC offset in use is a
+// multiple of 15 minutes, so working hours on the quarter hour line up.
+const step = 15 * time.Minute
+
+const (
+	maxPlanDays  = 31
+	maxPlanZones = 20
+)
+
+// PlanRequest asks for meeting times that fall within the working hours of
+// every zone.
+type PlanRequest struct {
+	Zones []string `json:"zones"`
+	Days  int      `json:"days"` // 从今天起查找的天数，默认 5
+
+	// Duration is the meeting length in minutes, 30 by default.
+	Duration int `json:"duration"`
+
+	// WorkStart and WorkEnd are the local working hours in every zone, such
+	// as "09:00" and "18:00" (the default). An end before the start spans
+	// midnight.
+	WorkStart string `json:"workStart"`
+	WorkEnd   string `json:"workEnd"`
+
+	// Weekends allows Saturday and Sunday.
+	Weekends bool `json:"weekends"`
+}
+
+// Slot is a time when every zone is within working hours.
+type Slot struct {
+	Start   time.Time   `json:"start"`
+	End     time.Time   `json:"end"`
+	Minutes int         `json:"minutes"`
+	Zones   []SlotLocal `json:"zones"`
+}
+
+// SlotLocal is a slot in the local time of one zone.
+type SlotLocal struct {
+	Zone   string `json:"zone"`
+	Start  string `json:"start"` // 2006-01-02 15:04
+	End    string `json:"end"`
+	Offset string `json:"offset"`
+}
+
+// Plan finds the slots from now until the end of the requested number of
+// days in the zone of now. Each instant is checked in the local time of each
+// zone, so DST changes in any of the zones move the slots accordingly.
+func Plan(req PlanRequest, now time.Time) ([]Slot, error) {
+	if len(req.Zones) == 0 {
+		return nil, fmt.Errorf("至少需要一个时区")
+	}
+	if len(req.Zones) > maxPlanZones {
+		return nil, fmt.Errorf("最多支持 %d 个时区", maxPlanZones)
+	}
+	if req.Days == 0 {
+		req.Days = 5
+	}
+	if req.Days < 1 || req.Days > maxPlanDays {
+		return nil, fmt.Errorf("天数必须在 1 到 %d 之间", maxPlanDays)
+	}
+	if req.Duration == 0 {
+		req.Duration = 30
+	}
+	if req.Duration < 1 || req.Duration > 24*60 {
+		return nil, fmt.Errorf("会议时长必须在 1 到 1440 分钟之间")
+	}
+	if req.WorkStart == "" {
+		req.WorkStart = "09:00"
+	}
+	if req.WorkEnd == "" {
+		req.WorkEnd = "18:00"
+	}
+	workStart, err := parseHour(req.WorkStart)
+	if err != nil {
+		return nil, err
+	}
+	workEnd, err := parseHour(req.WorkEnd)
+	if err != nil {
+		return nil, err
+	}
+	if workStart == workEnd {
+		return nil, fmt.Errorf("工作时间的开始和结束不能相同")
+	}
+
+	locs := make([]*time.Location, len(req.Zones))
+	for i, zone := range req.Zones {
+		if locs[i], err = timezone.Lookup(zone); err != nil {
+			return nil, err
+		}
+	}
+
+	working := func(t time.Time) bool {
+		for _, loc := range locs {
+			lt := t.In(loc)
+			if !req.Weekends && (lt.Weekday() == time.Saturday || lt.Weekday() == time.Sunday) {
+				return false
+			}
+			m := lt.Hour()*60 + lt.Minute()
+			in := m >= workStart && m < workEnd
+			if workEnd < workStart {
+				in = m >= workStart || m < workEnd
+			}
+			if !in {
+				return false
+			}
+		}
+		return true
+	}
+
+	start := now.Truncate(step)
+	if start.Before(now) {
+		start = start.Add(step)
+	}
+	y, mo, d := now.Date()
+	end := time.Date(y, mo, d+req.Days, 0, 0, 0, 0, now.Location())
+	minLen := time.Duration(req.Duration) * time.Minute
+
+	slots := make([]Slot, 0)
+	var open time.Time // 当前区间的开始，零值表示不在区间内
+	for t := start; !t.After(end); t = t.Add(step) {
+		if t.Before(end) && working(t) {
+			if open.IsZero() {
+				open = t
+			}
+			continue
+		}
+		if !open.IsZero() && t.Sub(open) >= minLen {
+			slots = append(slots, newSlot(open, t, req.Zones, locs))
+		}
+		open = time.Time{}
+	}
+	return slots, nil
+}
+
+func newSlot(start, end time.Time, zones []string, locs []*time.Location) Slot {
+	s := Slot{Start: start, End: end, Minutes: int(end.Sub(start) / time.Minute)}
+	for i, loc := range locs {
+		_, offset := start.In(loc).Zone()
+		s.Zones = append(s.Zones, SlotLocal{
+			Zone:   zones[i],
+			Start:  start.In(loc).Format("2006-01-02 15:04"),
+			End:    end.In(loc).Format("2006-01-02 15:04"),
+			Offset: timezone.FormatOffset(offset),
+		})
+	}
+	return s
+}
+
+// parseHour parses "HH:MM" on the quarter hour into minutes after midnight.
+func parseHour(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil || t.Minute()%15 != 0 {
+		return 0, fmt.Errorf("工作时间应为整刻钟的 HH:MM 格式: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
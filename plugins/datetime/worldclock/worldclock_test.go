@@ -0,0 +1,113 @@
+package worldclock
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clocks.json")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, zone := range []string{"Tokyo", "new york", "UTC+5:30"} {
+		if _, err := l.Add(zone, ""); err != nil {
+			t.Fatalf("Add(%q): %v", zone, err)
+		}
+	}
+	if _, err := l.Add("Asia/Tokyo", ""); err == nil {
+		t.Error("added Tokyo twice")
+	}
+	if _, err := l.Add("Atlantis", ""); err == nil {
+		t.Error("added an unknown zone")
+	}
+	if err := l.Move(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-10-18 20:00 UTC：东京已是次日，纽约还是当天
+	at := time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC)
+	got := l.At(at, time.UTC)
+	want := []struct {
+		zone, dateTime, offset string
+		dayDiff                int
+		dst                    bool
+	}{
+		{"UTC+05:30", "2026-10-19 01:30:00", "UTC+05:30", 1, false},
+		{"America/New_York", "2026-10-18 16:00:00", "UTC-04:00", 0, true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("At = %+v", got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Zone != w.zone || g.DateTime != w.dateTime || g.Offset != w.offset || g.DayDiff != w.dayDiff || g.DST != w.dst {
+			t.Errorf("At[%d] = %+v, want %+v", i, g, w)
+		}
+	}
+	if got[1].Label != "New York" {
+		t.Errorf("label = %q, want New York", got[1].Label)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	// 2026-10-19 是周一。欧洲 10/25 结束夏令时，美国 11/1 结束，
+	// 中间一周伦敦和纽约只差 4 小时，重叠的工作时间更长。
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	req := PlanRequest{Zones: []string{"London", "New York"}, Days: 15, WorkStart: "09:00", WorkEnd: "17:00"}
+
+	slots, err := Plan(req, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 11 {
+		t.Fatalf("got %d slots, want 11: %+v", len(slots), slots)
+	}
+	for _, tt := range []struct {
+		i          int
+		start, end string // UTC
+	}{
+		{0, "10-19 13:00", "10-19 16:00"},
+		{5, "10-26 13:00", "10-26 17:00"},
+		{10, "11-02 14:00", "11-02 17:00"},
+	} {
+		s := slots[tt.i]
+		if s.Start.UTC().Format("01-02 15:04") != tt.start || s.End.UTC().Format("01-02 15:04") != tt.end {
+			t.Errorf("slot %d = %s – %s, want %s – %s", tt.i, s.Start.UTC(), s.End.UTC(), tt.start, tt.end)
+		}
+	}
+	if z := slots[10].Zones[1]; z.Start != "2026-11-02 09:00" || z.Offset != "UTC-05:00" {
+		t.Errorf("New York on 11-02 = %+v", z)
+	}
+
+	req.Duration = 200
+	if slots, _ := Plan(req, now); len(slots) != 5 {
+		t.Errorf("got %d slots of 200 minutes, want 5", len(slots))
+	}
+
+	// 东京和纽约的工作时间不重叠
+	req = PlanRequest{Zones: []string{"Tokyo", "New York"}, Days: 7}
+	if slots, _ := Plan(req, now); len(slots) != 0 {
+		t.Errorf("Tokyo and New York overlap: %+v", slots)
+	}
+
+	for _, bad := range []PlanRequest{
+		{},
+		{Zones: []string{"Atlantis"}},
+		{Zones: []string{"UTC"}, WorkStart: "09:10"},
+		{Zones: []string{"UTC"}, Days: 100},
+	} {
+		if _, err := Plan(bad, now); err == nil {
+			t.Errorf("Plan(%+v) succeeded", bad)
+		}
+	}
+}
@@ -33,6 +33,9 @@ func NewHostsPlugin() *HostsPlugin {
 			plugins.PermissionFileSystem,
 		},
 		Keywords:   []string{"hosts", "域名", "domain", "switch"},
+		DataPaths:  []string{"hosts.json", "backups/hosts/"},
+		// 当前激活的场景只对本机有效
+		LocalDataFields: []string{"hosts.json:currentScenario", "hosts.json:scenarios.*.isActive"},
 		// ShowInMenu 和 HasPage 将由 NewBasePlugin 设置为默认值 true
 	}
 
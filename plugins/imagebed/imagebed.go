@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"ltools/internal/plugins"
+	"ltools/internal/proxy"
 )
 
 const (
@@ -27,6 +30,7 @@ type ImageBedPlugin struct {
 	history  *UploadHistory
 	dataDir  string
 	uploader *Uploader
+	client   *http.Client // 经过插件代理策略的客户端，未设置时直连
 }
 
 // NewImageBedPlugin creates a new image bed plugin
@@ -43,9 +47,11 @@ func NewImageBedPlugin() *ImageBedPlugin {
 		Permissions: []plugins.Permission{
 			plugins.PermissionNetwork,
 		},
-		Keywords:   []string{"图床", "图片", "上传", "github", "jsdelivr", "image", "upload", "hosting"},
-		ShowInMenu: plugins.BoolPtr(true),
-		HasPage:    plugins.BoolPtr(true),
+		AllowedDomains: []string{"api.github.com"},
+		Keywords:       []string{"图床", "图片", "上传", "github", "jsdelivr", "image", "upload", "hosting"},
+		DataPaths:      []string{"imagebed/"},
+		ShowInMenu:     plugins.BoolPtr(true),
+		HasPage:        plugins.BoolPtr(true),
 	}
 
 	return &ImageBedPlugin{
@@ -71,6 +77,12 @@ func (p *ImageBedPlugin) Init(app *application.App) error {
 	return nil
 }
 
+// SetProxyService routes GitHub API requests through the plugin's proxy policy
+func (p *ImageBedPlugin) SetProxyService(svc *proxy.ProxyService) {
+	p.client = svc.Client(30 * time.Second)
+	p.uploader = nil
+}
+
 // SetDataDir sets the data directory for persistence
 func (p *ImageBedPlugin) SetDataDir(dataDir string) error {
 	p.dataDir = dataDir
@@ -113,7 +125,7 @@ func (p *ImageBedPlugin) GetConfig() *ImageBedConfig {
 // SetConfig updates the configuration
 func (p *ImageBedPlugin) SetConfig(config *ImageBedConfig) error {
 	p.config = config
-	p.uploader = NewUploader(config)
+	p.uploader = NewUploader(config, p.client)
 	return p.saveConfig()
 }
 
@@ -188,7 +200,7 @@ func (p *ImageBedPlugin) UploadImage(fileName string, base64Content string) (*Up
 
 	// Initialize uploader if needed
 	if p.uploader == nil {
-		p.uploader = NewUploader(p.config)
+		p.uploader = NewUploader(p.config, p.client)
 	}
 
 	// Upload to GitHub
@@ -202,7 +214,7 @@ func (p *ImageBedPlugin) UploadImage(fileName string, base64Content string) (*Up
 
 	// Add to history
 	if err := p.AddRecord(record, p.dataDir); err != nil {
-		fmt.Printf("[ImageBed] Failed to save history: %v\n", err)
+		log.Printf("[ImageBed] Failed to save history: %v", err)
 	}
 
 	// Emit event
@@ -230,12 +242,12 @@ func (p *ImageBedPlugin) DeleteImage(id string) (*UploadResult, error) {
 	// Delete from GitHub if we have the sha
 	if record.Sha != "" && p.config.GitHubToken != "" {
 		if p.uploader == nil {
-			p.uploader = NewUploader(p.config)
+			p.uploader = NewUploader(p.config, p.client)
 		}
 
 		if err := p.uploader.Delete(record.Path, record.Sha); err != nil {
 			// Log error but still remove from history
-			fmt.Printf("[ImageBed] Failed to delete from GitHub: %v\n", err)
+			log.Printf("[ImageBed] Failed to delete from GitHub: %v", err)
 		}
 	}
 
@@ -261,7 +273,7 @@ func (p *ImageBedPlugin) DeleteImage(id string) (*UploadResult, error) {
 // ValidateConfig validates the current configuration
 func (p *ImageBedPlugin) ValidateConfig() (*ConfigValidationResult, error) {
 	if p.uploader == nil {
-		p.uploader = NewUploader(p.config)
+		p.uploader = NewUploader(p.config, p.client)
 	}
 	return p.uploader.ValidateConfig()
 }
@@ -288,7 +300,7 @@ func (p *ImageBedPlugin) RenameImage(id string, newFileName string) (*UploadResu
 
 	// Initialize uploader if needed
 	if p.uploader == nil {
-		p.uploader = NewUploader(p.config)
+		p.uploader = NewUploader(p.config, p.client)
 	}
 
 	// Download existing image content
@@ -317,18 +329,18 @@ func (p *ImageBedPlugin) RenameImage(id string, newFileName string) (*UploadResu
 
 	// Delete old file
 	if err := p.uploader.Delete(record.Path, record.Sha); err != nil {
-		fmt.Printf("[ImageBed] Failed to delete old file: %v\n", err)
+		log.Printf("[ImageBed] Failed to delete old file: %v", err)
 		// Don't fail the operation, just log the error
 	}
 
 	// Update history record
 	if err := p.DeleteRecord(id, p.dataDir); err != nil {
-		fmt.Printf("[ImageBed] Failed to delete old record: %v\n", err)
+		log.Printf("[ImageBed] Failed to delete old record: %v", err)
 	}
 
 	// Add new record
 	if err := p.AddRecord(newRecord, p.dataDir); err != nil {
-		fmt.Printf("[ImageBed] Failed to save new record: %v\n", err)
+		log.Printf("[ImageBed] Failed to save new record: %v", err)
 	}
 
 	// Emit event
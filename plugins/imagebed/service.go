@@ -87,7 +87,7 @@ func (s *ImageBedService) ClearHistory() error {
 // SyncFromRepository syncs images from GitHub repository to local history
 func (s *ImageBedService) SyncFromRepository() ([]UploadRecord, error) {
 	if s.plugin.uploader == nil {
-		s.plugin.uploader = NewUploader(s.plugin.config)
+		s.plugin.uploader = NewUploader(s.plugin.config, s.plugin.client)
 	}
 
 	records, err := s.plugin.uploader.ListRepositoryImages()
@@ -11,6 +11,8 @@ import (
 	"path"
 	"strings"
 	"time"
+
+	"ltools/internal/network"
 )
 
 const (
@@ -24,13 +26,15 @@ type Uploader struct {
 	client *http.Client
 }
 
-// NewUploader creates a new uploader instance
-func NewUploader(config *ImageBedConfig) *Uploader {
+// NewUploader creates a new uploader instance.
+// A nil client falls back to a direct client with the app-wide network configuration.
+func NewUploader(config *ImageBedConfig, client *http.Client) *Uploader {
+	if client == nil {
+		client = network.NewClient(30 * time.Second)
+	}
 	return &Uploader{
 		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client: client,
 	}
 }
 
@@ -2,6 +2,7 @@ package ipinfo
 
 import (
 	"ltools/internal/plugins"
+	"ltools/internal/proxy"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
@@ -11,6 +12,7 @@ type Plugin struct {
 	*plugins.BasePlugin
 	app     *application.App
 	service *Service
+	proxy   *proxy.ProxyService
 }
 
 // NewPlugin 创建新的IP信息插件
@@ -26,9 +28,10 @@ func NewPlugin() *Plugin {
 		Permissions: []plugins.Permission{
 			plugins.PermissionNetwork,
 		},
-		Keywords:   []string{"IP", "地址", "位置", "网络", "公网"},
-		ShowInMenu: plugins.BoolPtr(true),
-		HasPage:    plugins.BoolPtr(true),
+		AllowedDomains: []string{"ip-api.com"},
+		Keywords:       []string{"IP", "地址", "位置", "网络", "公网"},
+		ShowInMenu:     plugins.BoolPtr(true),
+		HasPage:        plugins.BoolPtr(true),
 	}
 
 	return &Plugin{
@@ -36,6 +39,11 @@ func NewPlugin() *Plugin {
 	}
 }
 
+// SetProxyService 设置插件代理服务，需在创建 Service 之前调用
+func (p *Plugin) SetProxyService(svc *proxy.ProxyService) {
+	p.proxy = svc
+}
+
 // Metadata 返回插件元数据
 func (p *Plugin) Metadata() *plugins.PluginMetadata {
 	return p.BasePlugin.Metadata()
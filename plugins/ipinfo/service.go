@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"ltools/internal/network"
+
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
@@ -19,14 +21,21 @@ type Service struct {
 	plugin *Plugin
 	app    *application.App
 	cache  *IPInfo
+	client *http.Client
 	mu     sync.RWMutex
 }
 
 // NewService 创建新的IP信息服务
+// 插件设置了代理服务时请求经过插件代理策略（域名白名单、频率限制），否则直连
 func NewService(plugin *Plugin, app *application.App) *Service {
+	client := network.NewClient(10 * time.Second)
+	if plugin.proxy != nil {
+		client = plugin.proxy.Client(10 * time.Second)
+	}
 	return &Service{
 		plugin: plugin,
 		app:    app,
+		client: client,
 	}
 }
 
@@ -42,7 +51,7 @@ func (s *Service) GetIPInfo() (*IPInfo, error) {
 	s.mu.RUnlock()
 
 	// 使用 ip-api.com API（免费，每分钟45次请求限制）
-	resp, err := http.Get("http://ip-api.com/json/?lang=zh-CN")
+	resp, err := s.client.Get("http://ip-api.com/json/?lang=zh-CN")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch IP info: %w", err)
 	}
@@ -31,6 +31,7 @@ func NewKanbanPlugin() *KanbanPlugin {
 		Type:        plugins.PluginTypeBuiltIn,
 		State:       plugins.PluginStateInstalled,
 		Keywords:    []string{"看板", "kanban", "项目", "任务", "管理", "project", "task", "board"},
+		DataPaths:   []string{"kanban/"},
 	}
 
 	base := plugins.NewBasePlugin(metadata)
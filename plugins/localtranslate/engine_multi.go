@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"ltools/internal/network"
 )
 
 // LLMProvider defines the interface for LLM providers
@@ -62,13 +65,13 @@ func NewMultiProviderEngine(config *Config) (*MultiProviderEngine, error) {
 		}
 
 		if err != nil {
-			fmt.Printf("[MultiProvider] Failed to initialize %s provider: %v\n", pc.Type, err)
+			log.Printf("[MultiProvider] Failed to initialize %s provider: %v", pc.Type, err)
 			continue
 		}
 
 		if provider != nil && provider.IsAvailable() {
 			providers = append(providers, provider)
-			fmt.Printf("[MultiProvider] ✅ Initialized %s provider\n", pc.Type)
+			log.Printf("[MultiProvider] ✅ Initialized %s provider", pc.Type)
 		}
 	}
 
@@ -98,16 +101,16 @@ func (e *MultiProviderEngine) Translate(text, sourceLang, targetLang string) (st
 			continue
 		}
 
-		fmt.Printf("[MultiProvider] Trying provider %d/%d: %s\n", i+1, len(e.providers), provider.GetType())
+		log.Printf("[MultiProvider] Trying provider %d/%d: %s", i+1, len(e.providers), provider.GetType())
 
 		result, err := provider.Translate(text, sourceLang, targetLang)
 		if err != nil {
-			fmt.Printf("[MultiProvider] Provider %s failed: %v\n", provider.GetType(), err)
+			log.Printf("[MultiProvider] Provider %s failed: %v", provider.GetType(), err)
 			lastError = err
 			continue
 		}
 
-		fmt.Printf("[MultiProvider] ✅ Translation succeeded with %s\n", provider.GetType())
+		log.Printf("[MultiProvider] ✅ Translation succeeded with %s", provider.GetType())
 		return result, nil
 	}
 
@@ -152,7 +155,7 @@ func NewOpenAIProvider(config *ProviderConfig) (*OpenAIProvider, error) {
 
 	return &OpenAIProvider{
 		config: config,
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: network.NewClient(30 * time.Second),
 		available: true,
 	}, nil
 }
@@ -247,7 +250,7 @@ func NewAnthropicProvider(config *ProviderConfig) (*AnthropicProvider, error) {
 
 	return &AnthropicProvider{
 		config: config,
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: network.NewClient(30 * time.Second),
 		available: true,
 	}, nil
 }
@@ -338,7 +341,7 @@ func NewDeepSeekProvider(config *ProviderConfig) (*DeepSeekProvider, error) {
 
 	return &DeepSeekProvider{
 		config: config,
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: network.NewClient(30 * time.Second),
 		available: true,
 	}, nil
 }
@@ -438,7 +441,7 @@ func NewOllamaProvider(config *ProviderConfig) (*OllamaProvider, error) {
 
 	provider := &OllamaProvider{
 		config: config,
-		client: &http.Client{Timeout: 60 * time.Second},
+		client: network.NewClient(60 * time.Second),
 	}
 
 	// Check if Ollama is running
@@ -2,6 +2,7 @@ package localtranslate
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
@@ -27,10 +28,10 @@ func (s *LocalTranslateService) ServiceStartup(app *application.App) error {
 	if s.plugin.config != nil {
 		multiEngine, err := NewMultiProviderEngine(s.plugin.config)
 		if err != nil {
-			fmt.Printf("[LocalTranslateService] Failed to initialize multi-provider engine: %v\n", err)
+			log.Printf("[LocalTranslateService] Failed to initialize multi-provider engine: %v", err)
 		} else {
 			s.multiEngine = multiEngine
-			fmt.Printf("[LocalTranslateService] ✅ Multi-provider engine initialized\n")
+			log.Printf("[LocalTranslateService] ✅ Multi-provider engine initialized")
 		}
 	}
 
@@ -135,7 +136,7 @@ func (s *LocalTranslateService) SetProviderEnabled(providerType ProviderType, en
 
 			// Save configuration to file
 			if err := s.plugin.config.Save(); err != nil {
-				fmt.Printf("[LocalTranslateService] Failed to save config: %v\n", err)
+				log.Printf("[LocalTranslateService] Failed to save config: %v", err)
 			}
 
 			// Re-initialize multi-provider engine
@@ -187,7 +188,7 @@ func (s *LocalTranslateService) ConfigureProvider(providerType ProviderType, inp
 
 			// Save configuration to file
 			if err := s.plugin.config.Save(); err != nil {
-				fmt.Printf("[LocalTranslateService] Failed to save config: %v\n", err)
+				log.Printf("[LocalTranslateService] Failed to save config: %v", err)
 				return fmt.Errorf("failed to save configuration: %w", err)
 			}
 
@@ -13,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"ltools/internal/network"
+
 	application "github.com/wailsapp/wails/v3/pkg/application"
 )
 
@@ -732,7 +734,7 @@ func (s *ServiceLX) DownloadSong(song Song, savePath string) error {
 	}
 
 	// 3. 下载文件
-	resp, err := http.Get(audioURL)
+	resp, err := network.NewClient(0).Get(audioURL)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
@@ -49,7 +49,8 @@ func NewScreenshot2Plugin() *Screenshot2Plugin {
 			plugins.PermissionClipboard,
 			plugins.PermissionFileSystem,
 		},
-		Keywords: []string{"截图", "屏幕", "捕获", "screenshot", "screen", "capture"},
+		Keywords:  []string{"截图", "屏幕", "捕获", "screenshot", "screen", "capture"},
+		DataPaths: []string{"screenshots/"},
 	}
 
 	base := plugins.NewBasePlugin(metadata)
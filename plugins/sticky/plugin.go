@@ -35,6 +35,9 @@ func NewStickyPlugin() *StickyPlugin {
 		Type:        plugins.PluginTypeBuiltIn,
 		State:       plugins.PluginStateInstalled,
 		Keywords:    []string{"便利贴", "便签", "便条", "note", "sticky"},
+		DataPaths:   []string{"sticky.json"},
+		// 便签窗口的位置和尺寸只对本机有效
+		LocalDataFields: []string{"sticky.json:notes.*.x", "sticky.json:notes.*.y", "sticky.json:notes.*.width", "sticky.json:notes.*.height"},
 	}
 
 	base := plugins.NewBasePlugin(metadata)
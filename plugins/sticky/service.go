@@ -219,7 +219,7 @@ func (s *StickyService) HandleShortcut(pluginID string) {
 		_, err := s.CreateNote()
 		if err != nil {
 			// Log error but don't return it (shortcut handlers typically don't return errors)
-			fmt.Printf("[Sticky] Failed to create note from shortcut: %v\n", err)
+			log.Printf("[Sticky] Failed to create note from shortcut: %v", err)
 		}
 	}
 }
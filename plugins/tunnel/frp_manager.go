@@ -60,14 +60,6 @@ func NewFRPProcessManager(config *TunnelConfig, emitEvent func(eventName string,
 func findFRPCExecutable(app *application.App) string {
 	logPrefix := "[FRPManager]"
 
-	// 创建调试日志文件
-	debugLogPath := filepath.Join(os.TempDir(), "ltools_frp_debug.log")
-	debugLog, err := os.OpenFile(debugLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err == nil {
-		defer debugLog.Close()
-		log.SetOutput(debugLog)
-	}
-
 	log.Printf("%s ========== Finding frpc executable ==========", logPrefix)
 	log.Printf("%s GOOS: %s, GOARCH: %s", logPrefix, runtime.GOOS, runtime.GOARCH)
 	log.Printf("%s Environment: HOME=%s, USER=%s, PATH=%s", logPrefix, os.Getenv("HOME"), os.Getenv("USER"), os.Getenv("PATH"))
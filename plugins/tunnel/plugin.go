@@ -37,6 +37,7 @@ func NewTunnelPlugin() *TunnelPlugin {
 			plugins.PermissionNetwork,
 		},
 		Keywords:   []string{"tunnel", "ngrok", "frp", "公网", "端口映射"},
+		DataPaths:  []string{"tunnel.json"},
 		ShowInMenu: &trueValue,
 	}
 
@@ -27,6 +27,7 @@ func NewVaultPlugin(dataDir string) *VaultPlugin {
 			plugins.PermissionFileSystem,
 		},
 		Keywords:    []string{"密码", "password", "保险库", "vault", "1password", "管理"},
+		DataPaths:  []string{"vault.json"},
 		ShowInMenu: plugins.BoolPtr(true),
 		HasPage:    plugins.BoolPtr(true),
 	}
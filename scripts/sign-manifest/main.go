@@ -0,0 +1,87 @@
+// sign-manifest 为 update.json 生成签名文件 update.json.sig
+//
+// 用法:
+//
+//	go run ./scripts/sign-manifest -gen                          # 生成新密钥对
+//	UPDATE_SIGNING_KEY=<seed> go run ./scripts/sign-manifest -key-id ltools-2026-1 release/update.json
+//	UPDATE_SIGNING_KEY=<seed> go run ./scripts/sign-manifest -key-id ltools-2027-1 -append release/update.json
+//
+// 私钥以 base64 编码的 32 字节种子通过环境变量传入；-append 用于密钥轮换期间追加第二个签名。
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"ltools/internal/update/signing"
+)
+
+func main() {
+	gen := flag.Bool("gen", false, "generate a new key pair")
+	keyID := flag.String("key-id", "", "ID of the signing key (must match the key embedded in the app)")
+	keyEnv := flag.String("key-env", "UPDATE_SIGNING_KEY", "environment variable holding the base64 private key seed")
+	appendSig := flag.Bool("append", false, "keep existing signatures from other keys")
+	flag.Parse()
+
+	if *gen {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("public key:  %s\n", base64.StdEncoding.EncodeToString(pub))
+		fmt.Printf("private key: %s\n", base64.StdEncoding.EncodeToString(priv.Seed()))
+		return
+	}
+
+	if *keyID == "" || flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: sign-manifest -key-id <id> [-append] <update.json>")
+		os.Exit(2)
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(os.Getenv(*keyEnv))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		fail(fmt.Errorf("%s must contain a base64 encoded %d-byte seed", *keyEnv, ed25519.SeedSize))
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+
+	manifestPath := flag.Arg(0)
+	manifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		fail(err)
+	}
+
+	sigPath := manifestPath + ".sig"
+	envelope := &signing.Envelope{}
+	if *appendSig {
+		if data, err := os.ReadFile(sigPath); err == nil {
+			if envelope, err = signing.ParseEnvelope(data); err != nil {
+				fail(err)
+			}
+		}
+	}
+	envelope.Sign(manifest, *keyID, key)
+
+	// 签名后立即自检，防止签出无法验证的文件
+	if _, err := envelope.Verify(manifest, signing.KeyRing{*keyID: key.Public().(ed25519.PublicKey)}); err != nil {
+		fail(err)
+	}
+
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(sigPath, append(data, '\n'), 0644); err != nil {
+		fail(err)
+	}
+	fmt.Printf("✓ %s signed with %s -> %s\n", manifestPath, *keyID, sigPath)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "错误:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ltools/internal/network"
+	"ltools/internal/plugins"
+	"ltools/internal/settings"
+	"ltools/internal/sync"
+)
+
+// portableShortcut 配置包中的快捷键
+type portableShortcut struct {
+	KeyCombo string `json:"keyCombo"`
+	PluginID string `json:"pluginId"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// registerSettingsSections 将分散在各服务中的配置加入设置导入导出
+func registerSettingsSections(
+	settingsService *settings.Service,
+	shortcutService *plugins.ShortcutService,
+	pluginManager *plugins.Manager,
+	syncService *sync.SyncService,
+	networkService *network.Service,
+) {
+	// 快捷键：导入时以配置包为准，本机多余的绑定会被移除
+	settingsService.RegisterSection("shortcuts", settings.SectionFuncs{
+		ExportFunc: func(bool) (any, error) {
+			var shortcuts []portableShortcut
+			for _, info := range shortcutService.GetAllShortcuts() {
+				shortcuts = append(shortcuts, portableShortcut{KeyCombo: info.KeyCombo, PluginID: info.PluginID, Enabled: info.Enabled})
+			}
+			return shortcuts, nil
+		},
+		ImportFunc: func(data json.RawMessage) error {
+			var shortcuts []portableShortcut
+			if err := json.Unmarshal(data, &shortcuts); err != nil {
+				return err
+			}
+
+			for _, info := range shortcutService.GetAllShortcuts() {
+				if err := shortcutService.RemoveShortcut(info.KeyCombo); err != nil {
+					return err
+				}
+			}
+
+			var errs []error
+			for _, sc := range shortcuts {
+				if err := shortcutService.SetShortcut(sc.KeyCombo, sc.PluginID); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", sc.KeyCombo, err))
+					continue
+				}
+				if !sc.Enabled {
+					if err := shortcutService.DisableShortcut(sc.KeyCombo); err != nil {
+						errs = append(errs, fmt.Errorf("%s: %w", sc.KeyCombo, err))
+					}
+				}
+			}
+			return errors.Join(errs...)
+		},
+	})
+
+	// 插件启用状态：本机未安装的插件忽略
+	settingsService.RegisterSection("plugins", settings.SectionFuncs{
+		ExportFunc: func(bool) (any, error) {
+			enabled := make(map[string]bool)
+			for _, meta := range pluginManager.ListMetadata() {
+				enabled[meta.ID] = meta.State == plugins.PluginStateEnabled
+			}
+			return enabled, nil
+		},
+		ImportFunc: func(data json.RawMessage) error {
+			var enabled map[string]bool
+			if err := json.Unmarshal(data, &enabled); err != nil {
+				return err
+			}
+
+			var errs []error
+			for id, on := range enabled {
+				if _, ok := pluginManager.Get(id); !ok {
+					continue
+				}
+				var err error
+				if on {
+					err = pluginManager.Enable(id)
+				} else {
+					err = pluginManager.Disable(id)
+				}
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		},
+	})
+
+	// 同步配置：访问令牌仅在导出密钥时包含
+	settingsService.RegisterSection("sync", settings.SectionFuncs{
+		ExportFunc: func(includeSecrets bool) (any, error) {
+			return sync.ExportPortable(syncService, includeSecrets)
+		},
+		ImportFunc: func(data json.RawMessage) error {
+			var portable sync.PortableConfig
+			if err := json.Unmarshal(data, &portable); err != nil {
+				return err
+			}
+			return sync.ImportPortable(syncService, &portable)
+		},
+	})
+
+	// 网络配置：代理地址中的账号密码仅在导出密钥时包含
+	settingsService.RegisterSection("network", settings.SectionFuncs{
+		ExportFunc: func(includeSecrets bool) (any, error) {
+			cfg := networkService.GetConfig()
+			if !includeSecrets {
+				cfg = cfg.WithoutCredentials()
+			}
+			return cfg, nil
+		},
+		ImportFunc: func(data json.RawMessage) error {
+			cfg := network.DefaultConfig()
+			if err := json.Unmarshal(data, cfg); err != nil {
+				return err
+			}
+			// 配置包不含密码时保留本机同一代理的账号密码
+			if current := networkService.GetConfig(); cfg.ProxyURL == current.WithoutCredentials().ProxyURL {
+				cfg.ProxyURL = current.ProxyURL
+			}
+			return networkService.SetConfig(cfg)
+		},
+	})
+}